@@ -0,0 +1 @@
+DROP TABLE IF EXISTS board_columns;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS board_columns (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `linkedProjectID` CHAR(36) NOT NULL,
+  `title` VARCHAR(255) NOT NULL,
+  `position` INT NOT NULL DEFAULT 0,
+  `isDone` CHAR(5) NOT NULL DEFAULT "False",
+
+  PRIMARY KEY (id),
+  FOREIGN KEY (linkedProjectID) REFERENCES projects(id)
+);
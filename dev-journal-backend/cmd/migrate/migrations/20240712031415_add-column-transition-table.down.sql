@@ -0,0 +1 @@
+DROP TABLE IF EXISTS column_transitions;
\ No newline at end of file
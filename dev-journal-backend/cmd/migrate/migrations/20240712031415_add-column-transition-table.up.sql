@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS column_transitions (
+  `fromColumnID` CHAR(36) NOT NULL,
+  `toColumnID` CHAR(36) NOT NULL,
+
+  PRIMARY KEY (fromColumnID, toColumnID),
+  FOREIGN KEY (fromColumnID) REFERENCES board_columns(id) ON DELETE CASCADE,
+  FOREIGN KEY (toColumnID) REFERENCES board_columns(id) ON DELETE CASCADE
+);
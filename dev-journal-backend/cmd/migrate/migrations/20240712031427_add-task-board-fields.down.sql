@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+  DROP FOREIGN KEY fk_tasks_columnID,
+  DROP COLUMN `columnID`,
+  DROP COLUMN `position`;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+  ADD COLUMN `columnID` CHAR(36) NULL,
+  ADD COLUMN `position` INT NOT NULL DEFAULT 0,
+  ADD CONSTRAINT fk_tasks_columnID FOREIGN KEY (columnID) REFERENCES board_columns(id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  DROP FOREIGN KEY fk_tasks_parentTaskID,
+  DROP COLUMN `parentTaskID`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  ADD COLUMN `parentTaskID` CHAR(36) NULL,
+  ADD CONSTRAINT fk_tasks_parentTaskID FOREIGN KEY (parentTaskID) REFERENCES tasks(id) ON DELETE CASCADE;
\ No newline at end of file
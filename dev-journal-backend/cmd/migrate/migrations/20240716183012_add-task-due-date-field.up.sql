@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+  ADD COLUMN `dueDate` DATE NULL;
\ No newline at end of file
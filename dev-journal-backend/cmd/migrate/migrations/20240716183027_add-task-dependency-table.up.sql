@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS task_dependencies (
+  `taskID` CHAR(36) NOT NULL,
+  `blockedByTaskID` CHAR(36) NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (taskID, blockedByTaskID),
+  FOREIGN KEY (taskID) REFERENCES tasks(id) ON DELETE CASCADE,
+  FOREIGN KEY (blockedByTaskID) REFERENCES tasks(id) ON DELETE CASCADE
+);
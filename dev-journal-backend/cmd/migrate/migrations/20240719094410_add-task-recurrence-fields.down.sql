@@ -0,0 +1,7 @@
+ALTER TABLE tasks
+  DROP INDEX `recurrenceSeriesID`,
+  DROP COLUMN `recurrenceRule`,
+  DROP COLUMN `recurrenceMode`,
+  DROP COLUMN `recurrenceStart`,
+  DROP COLUMN `recurrenceExceptions`,
+  DROP COLUMN `recurrenceSeriesID`;
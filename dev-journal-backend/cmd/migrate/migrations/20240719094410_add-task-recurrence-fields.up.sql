@@ -0,0 +1,7 @@
+ALTER TABLE tasks
+  ADD COLUMN `recurrenceRule` VARCHAR(255) NULL,
+  ADD COLUMN `recurrenceMode` ENUM('ON_COMPLETION', 'SCHEDULED') NOT NULL DEFAULT 'ON_COMPLETION',
+  ADD COLUMN `recurrenceStart` DATE NULL,
+  ADD COLUMN `recurrenceExceptions` JSON NULL,
+  ADD COLUMN `recurrenceSeriesID` CHAR(36) NULL,
+  ADD INDEX (recurrenceSeriesID);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS time_entries (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `taskID` CHAR(36) NOT NULL,
+  `description` TEXT NOT NULL,
+  `startedAt` DATETIME NOT NULL,
+  `endedAt` DATETIME NULL,
+  `runningUserID` CHAR(36) AS (IF(endedAt IS NULL, userID, NULL)) STORED,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (runningUserID),
+  INDEX (userID, startedAt),
+  FOREIGN KEY (userID) REFERENCES users(id),
+  FOREIGN KEY (taskID) REFERENCES tasks(id) ON DELETE CASCADE
+);
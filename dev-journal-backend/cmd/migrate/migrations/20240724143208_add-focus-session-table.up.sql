@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS focus_sessions (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `taskID` CHAR(36) NULL,
+  `linkedProjectID` CHAR(36) NULL,
+  `reflectionNoteID` CHAR(36) NULL,
+  `status` ENUM('RUNNING', 'PAUSED', 'FINISHED') NOT NULL DEFAULT 'RUNNING',
+  `plannedLengthInSeconds` INT NOT NULL,
+  `pausedSeconds` INT NOT NULL DEFAULT 0,
+  `interruptions` INT NOT NULL DEFAULT 0,
+  `startedAt` DATETIME NOT NULL,
+  `pausedAt` DATETIME NULL,
+  `endedAt` DATETIME NULL,
+  `activeUserID` CHAR(36) AS (IF(status = 'FINISHED', NULL, userID)) STORED,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (activeUserID),
+  INDEX (userID, startedAt),
+  FOREIGN KEY (userID) REFERENCES users(id),
+  FOREIGN KEY (taskID) REFERENCES tasks(id) ON DELETE SET NULL,
+  FOREIGN KEY (linkedProjectID) REFERENCES projects(id) ON DELETE SET NULL,
+  FOREIGN KEY (reflectionNoteID) REFERENCES notes(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS daily_entries;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS daily_entries (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `entryDate` DATE NOT NULL,
+  `content` TEXT NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (userID, entryDate),
+  FOREIGN KEY (userID) REFERENCES users(id)
+);
\ No newline at end of file
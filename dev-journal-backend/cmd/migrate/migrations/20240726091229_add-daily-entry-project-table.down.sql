@@ -0,0 +1 @@
+DROP TABLE IF EXISTS daily_entry_projects;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS daily_entry_projects (
+  `dailyEntryID` CHAR(36) NOT NULL,
+  `projectID` CHAR(36) NOT NULL,
+
+  PRIMARY KEY (dailyEntryID, projectID),
+  FOREIGN KEY (dailyEntryID) REFERENCES daily_entries(id) ON DELETE CASCADE,
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE
+);
\ No newline at end of file
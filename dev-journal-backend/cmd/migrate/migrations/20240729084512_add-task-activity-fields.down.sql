@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+  DROP COLUMN `lastMoved`,
+  DROP COLUMN `dateCompleted`,
+  DROP COLUMN `dateCreated`;
\ No newline at end of file
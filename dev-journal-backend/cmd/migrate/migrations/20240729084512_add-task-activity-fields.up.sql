@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+  ADD COLUMN `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD COLUMN `dateCompleted` DATETIME NULL,
+  ADD COLUMN `lastMoved` DATETIME NULL;
\ No newline at end of file
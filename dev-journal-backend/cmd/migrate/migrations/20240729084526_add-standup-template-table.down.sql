@@ -0,0 +1 @@
+DROP TABLE IF EXISTS standup_templates;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS standup_templates (
+  `userID` CHAR(36) NOT NULL,
+  `template` TEXT NOT NULL,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (userID),
+  FOREIGN KEY (userID) REFERENCES users(id)
+);
\ No newline at end of file
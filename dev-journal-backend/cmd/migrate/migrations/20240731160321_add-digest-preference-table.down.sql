@@ -0,0 +1 @@
+DROP TABLE IF EXISTS digest_preferences;
\ No newline at end of file
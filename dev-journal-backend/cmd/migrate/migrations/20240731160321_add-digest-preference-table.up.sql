@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS digest_preferences (
+  `userID` CHAR(36) NOT NULL,
+  `weeklyDigest` CHAR(5) NOT NULL DEFAULT "False",
+  `lastDigestSentAt` DATETIME NULL,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (userID),
+  FOREIGN KEY (userID) REFERENCES users(id)
+);
\ No newline at end of file
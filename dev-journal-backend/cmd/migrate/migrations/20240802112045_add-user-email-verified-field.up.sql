@@ -0,0 +1,2 @@
+ALTER TABLE users
+  ADD COLUMN `emailVerified` CHAR(5) NOT NULL DEFAULT "False";
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_tokens (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `purpose` ENUM('EMAIL_VERIFICATION', 'PASSWORD_RESET') NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `expiresAt` DATETIME NOT NULL,
+  `usedAt` DATETIME NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (tokenHash),
+  INDEX (userID, purpose, dateCreated),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
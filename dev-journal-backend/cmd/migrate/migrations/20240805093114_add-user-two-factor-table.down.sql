@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_two_factor;
\ No newline at end of file
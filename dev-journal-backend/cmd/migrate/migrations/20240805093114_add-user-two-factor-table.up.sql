@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS user_two_factor (
+  `userID` CHAR(36) NOT NULL,
+  `secret` VARCHAR(255) NOT NULL,
+  `enabled` CHAR(5) NOT NULL DEFAULT 'False',
+  `lastUsedStep` BIGINT NOT NULL DEFAULT 0,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (userID),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `codeHash` CHAR(64) NOT NULL,
+  `usedAt` DATETIME NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (userID, codeHash),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
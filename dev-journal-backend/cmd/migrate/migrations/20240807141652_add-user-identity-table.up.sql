@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `issuer` VARCHAR(255) NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (issuer, subject),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
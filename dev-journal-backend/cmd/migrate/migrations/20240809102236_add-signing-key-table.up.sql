@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+  `id` VARCHAR(64) NOT NULL,
+  `algorithm` VARCHAR(16) NOT NULL,
+  `privateKey` TEXT NOT NULL,
+  `dateCreated` DATETIME NOT NULL,
+  `retiredAt` DATETIME NULL,
+
+  PRIMARY KEY (id),
+  INDEX (retiredAt)
+);
\ No newline at end of file
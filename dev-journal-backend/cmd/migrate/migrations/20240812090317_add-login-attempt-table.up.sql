@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+  `attemptKey` VARCHAR(320) NOT NULL,
+  `failures` INT NOT NULL DEFAULT 0,
+  `lastFailureAt` DATETIME NOT NULL,
+  `lockedUntil` DATETIME NULL,
+
+  PRIMARY KEY (attemptKey)
+);
\ No newline at end of file
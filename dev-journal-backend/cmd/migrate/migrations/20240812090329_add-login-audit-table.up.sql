@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_audit_events (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `ipAddress` VARCHAR(45) NOT NULL,
+  `event` ENUM('LOGIN_SUCCEEDED', 'LOGIN_FAILED', 'LOGIN_BLOCKED', 'ACCOUNT_LOCKED', 'ACCOUNT_UNLOCKED') NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  INDEX (userID, dateCreated),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
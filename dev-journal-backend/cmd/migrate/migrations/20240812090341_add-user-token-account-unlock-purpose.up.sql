@@ -0,0 +1,2 @@
+ALTER TABLE user_tokens
+  MODIFY COLUMN `purpose` ENUM('EMAIL_VERIFICATION', 'PASSWORD_RESET', 'ACCOUNT_UNLOCK') NOT NULL;
\ No newline at end of file
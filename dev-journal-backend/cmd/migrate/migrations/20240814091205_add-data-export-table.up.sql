@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `userID` CHAR(36) NOT NULL,
+  `status` ENUM('PENDING', 'RUNNING', 'COMPLETED', 'FAILED', 'EXPIRED') NOT NULL DEFAULT 'PENDING',
+  `fileSize` BIGINT NOT NULL DEFAULT 0,
+  `error` TEXT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `startedAt` TIMESTAMP NULL,
+  `dateCompleted` TIMESTAMP NULL,
+  `expiresAt` TIMESTAMP NULL,
+
+  PRIMARY KEY (id),
+  INDEX (status, dateCreated),
+  INDEX (userID, dateCreated),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
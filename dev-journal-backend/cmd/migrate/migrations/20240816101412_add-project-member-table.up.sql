@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS project_members (
+  `projectID` CHAR(36) NOT NULL,
+  `userID` CHAR(36) NOT NULL,
+  `role` ENUM('OWNER', 'EDITOR', 'VIEWER') NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (projectID, userID),
+  INDEX (userID),
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE,
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM project_members WHERE role = 'OWNER';
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT IGNORE INTO project_members (projectID, userID, role)
+  SELECT id, userID, 'OWNER' FROM projects;
\ No newline at end of file
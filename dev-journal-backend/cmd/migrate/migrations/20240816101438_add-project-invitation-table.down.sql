@@ -0,0 +1 @@
+DROP TABLE IF EXISTS project_invitations;
\ No newline at end of file
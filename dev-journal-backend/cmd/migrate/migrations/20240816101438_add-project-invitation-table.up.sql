@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS project_invitations (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `projectID` CHAR(36) NOT NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `role` ENUM('OWNER', 'EDITOR', 'VIEWER') NOT NULL,
+  `invitedBy` CHAR(36) NULL,
+  `status` ENUM('PENDING', 'ACCEPTED', 'DECLINED', 'REVOKED') NOT NULL DEFAULT 'PENDING',
+  `expiresAt` DATETIME NOT NULL,
+  `respondedAt` DATETIME NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  INDEX (projectID, status),
+  INDEX (email, status),
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE,
+  FOREIGN KEY (invitedBy) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS workspaces;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+  `id` CHAR(36) NOT NULL DEFAULT (UUID()),
+  `name` VARCHAR(255) NOT NULL,
+  `allowMemberProjectCreation` CHAR(5) NOT NULL DEFAULT 'True',
+  `allowExternalInvitations` CHAR(5) NOT NULL DEFAULT 'True',
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS workspace_members;
\ No newline at end of file
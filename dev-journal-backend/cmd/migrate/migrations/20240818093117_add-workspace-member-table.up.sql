@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS workspace_members (
+  `workspaceID` CHAR(36) NOT NULL,
+  `userID` CHAR(36) NOT NULL,
+  `role` ENUM('ADMIN', 'MEMBER') NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (workspaceID, userID),
+  INDEX (userID),
+  FOREIGN KEY (workspaceID) REFERENCES workspaces(id) ON DELETE CASCADE,
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM workspaces WHERE id IN (SELECT id FROM users);
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT IGNORE INTO workspaces (id, name)
+  SELECT id, CONCAT(firstName, '''s workspace') FROM users;
\ No newline at end of file
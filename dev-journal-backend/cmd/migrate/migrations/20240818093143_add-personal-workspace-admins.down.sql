@@ -0,0 +1 @@
+DELETE FROM workspace_members WHERE workspaceID = userID;
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT IGNORE INTO workspace_members (workspaceID, userID, role)
+  SELECT id, id, 'ADMIN' FROM users;
\ No newline at end of file
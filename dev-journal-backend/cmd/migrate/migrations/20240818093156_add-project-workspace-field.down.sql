@@ -0,0 +1,3 @@
+ALTER TABLE projects
+  DROP FOREIGN KEY fk_projects_workspaceID,
+  DROP COLUMN `workspaceID`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE projects
+  ADD COLUMN `workspaceID` CHAR(36) NULL,
+  ADD CONSTRAINT fk_projects_workspaceID FOREIGN KEY (workspaceID) REFERENCES workspaces(id);
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE projects SET workspaceID = NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE projects SET workspaceID = userID WHERE workspaceID IS NULL;
\ No newline at end of file
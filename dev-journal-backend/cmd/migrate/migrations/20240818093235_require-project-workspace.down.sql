@@ -0,0 +1,2 @@
+ALTER TABLE projects
+  MODIFY `workspaceID` CHAR(36) NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS share_links;
\ No newline at end of file
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS share_links (
+  `id` CHAR(36) NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `projectID` CHAR(36) NOT NULL,
+  `noteID` CHAR(36) NULL,
+  `createdBy` CHAR(36) NULL,
+  `passwordHash` VARCHAR(255) NULL,
+  `expiresAt` TIMESTAMP NULL,
+  `revokedAt` TIMESTAMP NULL,
+  `viewCount` INT NOT NULL DEFAULT 0,
+  `lastViewedAt` TIMESTAMP NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY (tokenHash),
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE,
+  FOREIGN KEY (noteID) REFERENCES notes(id) ON DELETE CASCADE,
+  FOREIGN KEY (createdBy) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS comments (
+  `id` CHAR(36) NOT NULL,
+  `projectID` CHAR(36) NOT NULL,
+  `noteID` CHAR(36) NULL,
+  `taskID` CHAR(36) NULL,
+  `parentCommentID` CHAR(36) NULL,
+  `userID` CHAR(36) NULL,
+  `content` TEXT NOT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastEdited` TIMESTAMP NULL,
+  `dateDeleted` TIMESTAMP NULL,
+
+  PRIMARY KEY (id),
+  INDEX (projectID, dateCreated),
+  INDEX (noteID, dateCreated),
+  INDEX (taskID, dateCreated),
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE,
+  FOREIGN KEY (noteID) REFERENCES notes(id) ON DELETE CASCADE,
+  FOREIGN KEY (taskID) REFERENCES tasks(id) ON DELETE CASCADE,
+  FOREIGN KEY (parentCommentID) REFERENCES comments(id) ON DELETE CASCADE,
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS comment_mentions;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS comment_mentions (
+  `commentID` CHAR(36) NOT NULL,
+  `userID` CHAR(36) NOT NULL,
+
+  PRIMARY KEY (commentID, userID),
+  INDEX (userID),
+  FOREIGN KEY (commentID) REFERENCES comments(id) ON DELETE CASCADE,
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  `id` CHAR(36) NOT NULL,
+  `actorID` CHAR(36) NULL,
+  `projectID` CHAR(36) NULL,
+  `entityType` ENUM('USER', 'PROJECT', 'PROJECT_MEMBER', 'PROJECT_INVITATION', 'NOTE', 'TASK', 'COMMENT') NOT NULL,
+  `entityID` CHAR(36) NOT NULL,
+  `action` ENUM('CREATE', 'UPDATE', 'DELETE', 'TOKEN_CREATED', 'PASSWORD_CHANGED', 'TWO_FACTOR_ENABLED', 'TWO_FACTOR_DISABLED') NOT NULL,
+  `changes` JSON NULL,
+  `ipAddress` VARCHAR(45) NOT NULL,
+  `userAgent` VARCHAR(512) NOT NULL,
+  `dateCreated` TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+  PRIMARY KEY (id),
+  INDEX (projectID, dateCreated),
+  INDEX (entityType, entityID, dateCreated),
+  INDEX (actorID, dateCreated)
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  DROP FOREIGN KEY fk_tasks_assigneeID,
+  DROP COLUMN `assigneeID`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  ADD COLUMN `assigneeID` CHAR(36) NULL,
+  ADD CONSTRAINT fk_tasks_assigneeID FOREIGN KEY (assigneeID) REFERENCES users(id) ON DELETE SET NULL;
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS notifications (
+  `id` CHAR(36) NOT NULL,
+  `userID` CHAR(36) NOT NULL,
+  `type` ENUM('MENTION', 'TASK_ASSIGNED', 'DEADLINE_APPROACHING', 'PROJECT_INVITATION') NOT NULL,
+  `actorID` CHAR(36) NULL,
+  `projectID` CHAR(36) NULL,
+  `entityType` ENUM('PROJECT', 'PROJECT_INVITATION', 'NOTE', 'TASK', 'COMMENT') NOT NULL,
+  `entityID` CHAR(36) NOT NULL,
+  `message` VARCHAR(512) NOT NULL,
+  `readAt` TIMESTAMP NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (id),
+  INDEX (userID, readAt, dateCreated),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (actorID) REFERENCES users(id) ON DELETE SET NULL,
+  FOREIGN KEY (projectID) REFERENCES projects(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  `userID` CHAR(36) NOT NULL,
+  `type` ENUM('MENTION', 'TASK_ASSIGNED', 'DEADLINE_APPROACHING', 'PROJECT_INVITATION') NOT NULL,
+  `inApp` CHAR(5) NOT NULL DEFAULT "True",
+  `email` CHAR(5) NOT NULL DEFAULT "False",
+  `lastEdited` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (userID, type),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
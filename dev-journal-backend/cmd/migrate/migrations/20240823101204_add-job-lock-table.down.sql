@@ -0,0 +1 @@
+DROP TABLE IF EXISTS job_locks;
\ No newline at end of file
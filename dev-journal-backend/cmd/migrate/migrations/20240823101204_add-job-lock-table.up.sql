@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS job_locks (
+  `name` VARCHAR(64) NOT NULL,
+  `holder` CHAR(36) NOT NULL,
+  `lockedUntil` DATETIME(3) NOT NULL,
+
+  PRIMARY KEY (name)
+);
\ No newline at end of file
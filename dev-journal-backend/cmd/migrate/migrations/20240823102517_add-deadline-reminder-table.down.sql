@@ -0,0 +1 @@
+DROP TABLE IF EXISTS deadline_reminders;
\ No newline at end of file
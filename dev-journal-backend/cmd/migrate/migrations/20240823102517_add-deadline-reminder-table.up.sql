@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS deadline_reminders (
+  `entityType` ENUM('PROJECT', 'TASK') NOT NULL,
+  `entityID` CHAR(36) NOT NULL,
+  `userID` CHAR(36) NOT NULL,
+  `dueDate` DATE NOT NULL,
+  `windowInHours` INT NOT NULL,
+  `dateSent` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (entityType, entityID, userID, dueDate, windowInHours),
+  INDEX (dueDate),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS jobs (
+  `id` CHAR(36) NOT NULL,
+  `type` VARCHAR(64) NOT NULL,
+  `payload` JSON NOT NULL,
+  `status` ENUM('PENDING', 'RUNNING', 'SUCCEEDED', 'DEAD') NOT NULL DEFAULT "PENDING",
+  `attempts` INT NOT NULL DEFAULT 0,
+  `maxAttempts` INT NOT NULL,
+  `runAt` DATETIME(3) NOT NULL,
+  `lockedBy` CHAR(36) NULL,
+  `lockedUntil` DATETIME(3) NULL,
+  `lastError` TEXT NULL,
+  `dateCreated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `dateCompleted` DATETIME(3) NULL,
+
+  PRIMARY KEY (id),
+  INDEX (status, runAt),
+  INDEX (status, lockedUntil),
+  INDEX (type, status)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS weekly_digests;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS weekly_digests (
+  `userID` CHAR(36) NOT NULL,
+  `weekStart` DATE NOT NULL,
+  `dateSent` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (userID, weekStart),
+  INDEX (weekStart),
+  FOREIGN KEY (userID) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE users
+  ADD COLUMN `passwordChangedAt` DATETIME NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE data_exports
+  DROP COLUMN `archive`,
+  DROP COLUMN `leaseUntil`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE data_exports
+  ADD COLUMN `archive` LONGBLOB NULL,
+  ADD COLUMN `leaseUntil` TIMESTAMP NULL;
\ No newline at end of file
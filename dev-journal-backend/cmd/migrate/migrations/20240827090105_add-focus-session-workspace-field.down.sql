@@ -0,0 +1,3 @@
+ALTER TABLE focus_sessions
+  DROP FOREIGN KEY fk_focus_sessions_workspaceID,
+  DROP COLUMN `workspaceID`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE focus_sessions
+  ADD COLUMN `workspaceID` CHAR(36) NULL AFTER `userID`,
+  ADD CONSTRAINT fk_focus_sessions_workspaceID FOREIGN KEY (workspaceID) REFERENCES workspaces(id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE focus_sessions SET workspaceID = NULL;
\ No newline at end of file
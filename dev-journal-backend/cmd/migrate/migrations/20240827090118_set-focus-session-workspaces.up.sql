@@ -0,0 +1,4 @@
+UPDATE focus_sessions SET workspaceID = COALESCE(
+  (SELECT projects.workspaceID FROM projects WHERE projects.id = focus_sessions.linkedProjectID),
+  (SELECT members.workspaceID FROM workspace_members members WHERE members.userID = focus_sessions.userID ORDER BY members.dateCreated, members.workspaceID LIMIT 1)
+) WHERE workspaceID IS NULL;
\ No newline at end of file
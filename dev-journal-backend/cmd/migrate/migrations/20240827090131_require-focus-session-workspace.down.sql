@@ -0,0 +1,2 @@
+ALTER TABLE focus_sessions
+  MODIFY `workspaceID` CHAR(36) NULL;
\ No newline at end of file
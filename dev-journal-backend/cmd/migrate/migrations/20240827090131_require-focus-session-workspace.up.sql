@@ -0,0 +1,2 @@
+ALTER TABLE focus_sessions
+  MODIFY `workspaceID` CHAR(36) NOT NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE daily_entries
+  DROP FOREIGN KEY fk_daily_entries_workspaceID,
+  DROP COLUMN `workspaceID`;
\ No newline at end of file
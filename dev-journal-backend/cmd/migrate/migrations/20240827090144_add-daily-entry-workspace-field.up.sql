@@ -0,0 +1,3 @@
+ALTER TABLE daily_entries
+  ADD COLUMN `workspaceID` CHAR(36) NULL AFTER `userID`,
+  ADD CONSTRAINT fk_daily_entries_workspaceID FOREIGN KEY (workspaceID) REFERENCES workspaces(id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE daily_entries SET workspaceID = NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE daily_entries SET workspaceID = (SELECT members.workspaceID FROM workspace_members members WHERE members.userID = daily_entries.userID ORDER BY members.dateCreated, members.workspaceID LIMIT 1) WHERE workspaceID IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE daily_entries
+  ADD UNIQUE KEY userID (userID, entryDate),
+  DROP INDEX daily_entries_workspace_date,
+  MODIFY `workspaceID` CHAR(36) NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE daily_entries
+  MODIFY `workspaceID` CHAR(36) NOT NULL,
+  ADD UNIQUE KEY daily_entries_workspace_date (userID, workspaceID, entryDate),
+  DROP INDEX userID;
\ No newline at end of file
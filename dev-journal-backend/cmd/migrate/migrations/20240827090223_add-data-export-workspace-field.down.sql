@@ -0,0 +1,3 @@
+ALTER TABLE data_exports
+  DROP FOREIGN KEY fk_data_exports_workspaceID,
+  DROP COLUMN `workspaceID`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE data_exports
+  ADD COLUMN `workspaceID` CHAR(36) NULL AFTER `userID`,
+  ADD CONSTRAINT fk_data_exports_workspaceID FOREIGN KEY (workspaceID) REFERENCES workspaces(id) ON DELETE CASCADE;
\ No newline at end of file
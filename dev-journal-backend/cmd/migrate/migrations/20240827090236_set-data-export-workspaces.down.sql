@@ -0,0 +1 @@
+UPDATE data_exports SET workspaceID = NULL;
\ No newline at end of file
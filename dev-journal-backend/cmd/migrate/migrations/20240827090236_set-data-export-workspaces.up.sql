@@ -0,0 +1 @@
+UPDATE data_exports SET workspaceID = (SELECT members.workspaceID FROM workspace_members members WHERE members.userID = data_exports.userID ORDER BY members.dateCreated, members.workspaceID LIMIT 1) WHERE workspaceID IS NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE board_columns JOIN (SELECT id, ROW_NUMBER() OVER (PARTITION BY linkedProjectID ORDER BY position, id) - 1 AS newPosition FROM board_columns) ordered ON ordered.id = board_columns.id SET board_columns.position = ordered.newPosition WHERE board_columns.position != ordered.newPosition;
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO board_columns (id, linkedProjectID, title, position, isDone) SELECT UUID(), projects.id, defaultColumns.title, defaultColumns.position, defaultColumns.isDone FROM projects CROSS JOIN (SELECT 'To Do' AS title, 0 AS position, 'False' AS isDone UNION ALL SELECT 'In Progress', 1, 'False' UNION ALL SELECT 'Done', 2, 'True') defaultColumns WHERE NOT EXISTS (SELECT 1 FROM board_columns boardColumns WHERE boardColumns.linkedProjectID = projects.id);
\ No newline at end of file
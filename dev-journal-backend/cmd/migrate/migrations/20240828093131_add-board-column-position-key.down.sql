@@ -0,0 +1,3 @@
+ALTER TABLE board_columns
+  ADD INDEX (linkedProjectID),
+  DROP INDEX `linkedProjectPosition`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE board_columns
+  ADD UNIQUE KEY `linkedProjectPosition` (linkedProjectID, position);
\ No newline at end of file
@@ -16,19 +16,61 @@ type DatabaseConfigs struct {
 }
 
 type ServerConfigs struct {
-	PublicHost string
-	Port       string
+	PublicHost     string
+	Port           string
+	ClientURL      string // links in mails point to the client
+	TrustedProxies string // comma separated IPs or CIDRs of the reverse proxies whose X-Forwarded-For is trusted
+}
+
+type MailConfigs struct {
+	Mailer       string // smtp or log
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	Directory    string // where the log mailer writes messages, they are only logged when empty
+}
+
+type OIDCConfigs struct {
+	IssuerURL          string // single sign-on is disabled when empty
+	ClientID           string
+	ClientSecret       string
+	RedirectURL        string // client page the provider redirects back to with the code
+	Scopes             string
+	StateEncryptionKey string // encrypts the code verifier and nonce of pending logins, single sign-on cannot be started without it
 }
 
 type GlobalConfigs struct {
-	JWTExpirationInSeconds int64
-	JWTSecret              string
+	JWTExpirationInSeconds      int64
+	JWTSecret                   string
+	JWTSigningAlgorithm         string // HS256 signs with JWTSecret, RS256 and EdDSA with rotated keys published as JWKS
+	JWTKeyRotationInSeconds     int64
+	JWTKeyEncryptionKey         string
+	RecurrenceIntervalInSeconds int64
+	DigestIntervalInSeconds     int64
+	TwoFactorEncryptionKey      string
+	LoginAttemptTracker         string // database or memory
+	ExportIntervalInSeconds     int64
+	ExportExpirationInSeconds   int64
+	ReminderIntervalInSeconds   int64
+	ReminderWindowsInHours      string // comma separated hours before a deadline to remind of it
+	QueueWorkers                int64
+	QueuePollIntervalInSeconds  int64
+	QueueVisibilityInSeconds    int64 // how long a running job stays locked without a sign of life from its worker
+	JobRetentionInSeconds       int64 // how long succeeded jobs are kept
+	ShutdownTimeoutInSeconds    int64
+	AdminEmails                 string // comma separated emails of the users allowed to inspect the job queue
 }
 
 var DatabaseEnvironmentVariables = initializeDatabaseConfigs()
 
 var ServerEnvironmentVariables = initializeServerConfigs()
 
+var MailEnvironmentVariables = initializeMailConfigs()
+
+var OIDCEnvironmentVariables = initializeOIDCConfigs()
+
 var GlobalEnvironmentVariables = initializeGlobalConfigs()
 
 // return environment variables for MySQL
@@ -48,8 +90,39 @@ func initializeServerConfigs() ServerConfigs {
 	godotenv.Load()
 
 	return ServerConfigs{
-		PublicHost: getEnvironmentVariable("PUBLIC_HOST", "http://localhost"),
-		Port:       getEnvironmentVariable("PORT", "8080"),
+		PublicHost:     getEnvironmentVariable("PUBLIC_HOST", "http://localhost"),
+		Port:           getEnvironmentVariable("PORT", "8080"),
+		ClientURL:      getEnvironmentVariable("CLIENT_URL", "http://localhost:3000"),
+		TrustedProxies: getEnvironmentVariable("TRUSTED_PROXIES", ""),
+	}
+}
+
+// return environment variables for sending mails
+func initializeMailConfigs() MailConfigs {
+	godotenv.Load()
+
+	return MailConfigs{
+		Mailer:       getEnvironmentVariable("MAILER", "log"),
+		SMTPHost:     getEnvironmentVariable("SMTP_HOST", "127.0.0.1"),
+		SMTPPort:     getEnvironmentVariable("SMTP_PORT", "587"),
+		SMTPUsername: getEnvironmentVariable("SMTP_USERNAME", ""),
+		SMTPPassword: getEnvironmentVariable("SMTP_PASSWORD", ""),
+		From:         getEnvironmentVariable("MAIL_FROM", "dev-journal@localhost"),
+		Directory:    getEnvironmentVariable("MAIL_DIRECTORY", ""),
+	}
+}
+
+// return environment variables for single sign-on
+func initializeOIDCConfigs() OIDCConfigs {
+	godotenv.Load()
+
+	return OIDCConfigs{
+		IssuerURL:          getEnvironmentVariable("OIDC_ISSUER_URL", ""),
+		ClientID:           getEnvironmentVariable("OIDC_CLIENT_ID", ""),
+		ClientSecret:       getEnvironmentVariable("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:        getEnvironmentVariable("OIDC_REDIRECT_URL", "http://localhost:3000/oidc/callback"),
+		Scopes:             getEnvironmentVariable("OIDC_SCOPES", "openid email profile"),
+		StateEncryptionKey: getEnvironmentVariable("OIDC_STATE_ENCRYPTION_KEY", ""),
 	}
 }
 
@@ -57,8 +130,25 @@ func initializeServerConfigs() ServerConfigs {
 func initializeGlobalConfigs() GlobalConfigs {
 	godotenv.Load()
 	return GlobalConfigs{
-		JWTExpirationInSeconds: getEnvironmentVariableAsInt("JWT_EXPIRATION_IN_SECONDS", 3600*24*7),
-		JWTSecret:              getEnvironmentVariable("JWT_SECRET", "not-so-secret-anymore?"),
+		JWTExpirationInSeconds:      getEnvironmentVariableAsInt("JWT_EXPIRATION_IN_SECONDS", 3600*24*7),
+		JWTSecret:                   getEnvironmentVariable("JWT_SECRET", "not-so-secret-anymore?"),
+		JWTSigningAlgorithm:         getEnvironmentVariable("JWT_SIGNING_ALGORITHM", "HS256"),
+		JWTKeyRotationInSeconds:     getEnvironmentVariableAsInt("JWT_KEY_ROTATION_IN_SECONDS", 3600*24*30),
+		JWTKeyEncryptionKey:         getEnvironmentVariable("JWT_KEY_ENCRYPTION_KEY", ""),
+		RecurrenceIntervalInSeconds: getEnvironmentVariableAsInt("RECURRENCE_INTERVAL_IN_SECONDS", 3600),
+		DigestIntervalInSeconds:     getEnvironmentVariableAsInt("DIGEST_INTERVAL_IN_SECONDS", 3600),
+		TwoFactorEncryptionKey:      getEnvironmentVariable("TWO_FACTOR_ENCRYPTION_KEY", ""),
+		LoginAttemptTracker:         getEnvironmentVariable("LOGIN_ATTEMPT_TRACKER", "database"),
+		ExportIntervalInSeconds:     getEnvironmentVariableAsInt("EXPORT_INTERVAL_IN_SECONDS", 60),
+		ExportExpirationInSeconds:   getEnvironmentVariableAsInt("EXPORT_EXPIRATION_IN_SECONDS", 3600*24*7),
+		ReminderIntervalInSeconds:   getEnvironmentVariableAsInt("REMINDER_INTERVAL_IN_SECONDS", 900),
+		ReminderWindowsInHours:      getEnvironmentVariable("REMINDER_WINDOWS_IN_HOURS", "72,24"),
+		QueueWorkers:                getEnvironmentVariableAsInt("QUEUE_WORKERS", 4),
+		QueuePollIntervalInSeconds:  getEnvironmentVariableAsInt("QUEUE_POLL_INTERVAL_IN_SECONDS", 5),
+		QueueVisibilityInSeconds:    getEnvironmentVariableAsInt("QUEUE_VISIBILITY_IN_SECONDS", 300),
+		JobRetentionInSeconds:       getEnvironmentVariableAsInt("JOB_RETENTION_IN_SECONDS", 3600*24*7),
+		ShutdownTimeoutInSeconds:    getEnvironmentVariableAsInt("SHUTDOWN_TIMEOUT_IN_SECONDS", 30),
+		AdminEmails:                 getEnvironmentVariable("ADMIN_EMAILS", ""),
 	}
 }
 
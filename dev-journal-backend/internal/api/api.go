@@ -1,19 +1,68 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/audit"
+	columnRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/column"
+	commentRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/comment"
+	dailyEntryRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/dailyEntry"
+	dataExportRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/dataExport"
+	digestRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/digest"
+	focusSessionRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/focusSession"
+	jobRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/job"
+	jobLockRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/jobLock"
+	loginAttemptRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/loginAttempt"
 	noteRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/note"
+	notificationRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/notification"
 	projectRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/project"
+	projectMemberRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/projectMember"
+	reminderRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/reminder"
+	reportRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/report"
+	shareLinkRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/shareLink"
+	signingKeyRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/signingKey"
 	taskRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/task"
+	timeEntryRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/timeEntry"
+	twoFactorRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/twoFactor"
 	userRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/user"
+	userIdentityRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/userIdentity"
+	userTokenRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/userToken"
+	workspaceRepository "github.com/hwaengfan/dev-journal-backend/internal/database/repositories/workspace"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	boardService "github.com/hwaengfan/dev-journal-backend/internal/services/board"
+	commentService "github.com/hwaengfan/dev-journal-backend/internal/services/comment"
+	dailyEntryService "github.com/hwaengfan/dev-journal-backend/internal/services/dailyEntry"
+	dataExportService "github.com/hwaengfan/dev-journal-backend/internal/services/dataExport"
+	digestService "github.com/hwaengfan/dev-journal-backend/internal/services/digest"
+	focusSessionService "github.com/hwaengfan/dev-journal-backend/internal/services/focusSession"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	markdownServices "github.com/hwaengfan/dev-journal-backend/internal/services/markdown"
 	noteService "github.com/hwaengfan/dev-journal-backend/internal/services/note"
+	notificationService "github.com/hwaengfan/dev-journal-backend/internal/services/notification"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
 	projectService "github.com/hwaengfan/dev-journal-backend/internal/services/project"
+	queueService "github.com/hwaengfan/dev-journal-backend/internal/services/queue"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+	reminderServices "github.com/hwaengfan/dev-journal-backend/internal/services/reminder"
+	reportService "github.com/hwaengfan/dev-journal-backend/internal/services/report"
+	runnerServices "github.com/hwaengfan/dev-journal-backend/internal/services/runner"
+	shareLinkService "github.com/hwaengfan/dev-journal-backend/internal/services/shareLink"
 	taskService "github.com/hwaengfan/dev-journal-backend/internal/services/task"
+	timeEntryService "github.com/hwaengfan/dev-journal-backend/internal/services/timeEntry"
 	userService "github.com/hwaengfan/dev-journal-backend/internal/services/user"
+	workspaceService "github.com/hwaengfan/dev-journal-backend/internal/services/workspace"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
 type Server struct {
@@ -26,6 +75,11 @@ func NewServer(address string, database *sql.DB) *Server {
 }
 
 func (server *Server) Run() error {
+	// Read client IPs from X-Forwarded-For behind the trusted proxies only
+	if error := utils.SetTrustedProxies(configs.ServerEnvironmentVariables.TrustedProxies); error != nil {
+		return error
+	}
+
 	// Set up router
 	router := mux.NewRouter()
 	subrouter := router.PathPrefix("/api/v1").Subrouter()
@@ -35,24 +89,213 @@ func (server *Server) Run() error {
 	projectStore := projectRepository.NewStore(server.database)
 	noteStore := noteRepository.NewStore(server.database)
 	taskStore := taskRepository.NewStore(server.database)
+	columnStore := columnRepository.NewStore(server.database)
+	timeEntryStore := timeEntryRepository.NewStore(server.database)
+	focusSessionStore := focusSessionRepository.NewStore(server.database)
+	dailyEntryStore := dailyEntryRepository.NewStore(server.database)
+	reportStore := reportRepository.NewStore(server.database)
+	digestStore := digestRepository.NewStore(server.database)
+	userTokenStore := userTokenRepository.NewStore(server.database)
+	twoFactorStore := twoFactorRepository.NewStore(server.database)
+	userIdentityStore := userIdentityRepository.NewStore(server.database)
+	signingKeyStore := signingKeyRepository.NewStore(server.database)
+	loginAttemptStore := loginAttemptRepository.NewStore(server.database)
+	dataExportStore := dataExportRepository.NewStore(server.database)
+	projectMemberStore := projectMemberRepository.NewStore(server.database)
+	workspaceStore := workspaceRepository.NewStore(server.database)
+	shareLinkStore := shareLinkRepository.NewStore(server.database)
+	commentStore := commentRepository.NewStore(server.database)
+	auditStore := auditRepository.NewStore(server.database)
+	notificationStore := notificationRepository.NewStore(server.database)
+	jobLockStore := jobLockRepository.NewStore(server.database)
+	reminderStore := reminderRepository.NewStore(server.database)
+	jobStore := jobRepository.NewStore(server.database)
+
+	// Set up JWT signing keys, the first rotation creates a key when there is none
+	keyManager, error := authenticationServices.NewKeyManager(
+		signingKeyStore,
+		configs.GlobalEnvironmentVariables.JWTSigningAlgorithm,
+		time.Second*time.Duration(configs.GlobalEnvironmentVariables.JWTKeyRotationInSeconds),
+		time.Second*time.Duration(configs.GlobalEnvironmentVariables.JWTExpirationInSeconds),
+		configs.GlobalEnvironmentVariables.JWTKeyEncryptionKey,
+	)
+	if error != nil {
+		return error
+	}
+
+	if error := keyManager.Start(); error != nil {
+		return error
+	}
+
+	authenticationServices.UseKeyManager(keyManager)
+	router.HandleFunc("/.well-known/jwks.json", keyManager.HandleJWKS).Methods(http.MethodGet)
+
+	// two-factor cannot be set up or used without the key its secrets are encrypted with
+	if configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey == "" {
+		log.Println("TWO_FACTOR_ENCRYPTION_KEY is not set, two-factor authentication is disabled")
+	}
+
+	// Scope every session to a workspace the user is a member of
+	authenticationServices.UseWorkspaceStore(workspaceStore)
+
+	// Set up mailer
+	mailer, error := mailServices.NewMailer(configs.MailEnvironmentVariables)
+	if error != nil {
+		return error
+	}
+
+	// Set up the job queue, mails sent through it are retried while the mail server is unavailable
+	jobQueue := queueService.NewQueue(jobStore, time.Second*time.Duration(configs.GlobalEnvironmentVariables.QueueVisibilityInSeconds), time.Second*time.Duration(configs.GlobalEnvironmentVariables.QueuePollIntervalInSeconds), time.Second*time.Duration(configs.GlobalEnvironmentVariables.JobRetentionInSeconds))
+	queuedMailer := queueService.NewQueuedMailer(jobQueue, mailer)
+
+	// Set up failed login tracking, memory counters are not shared between servers
+	var attemptTracker loginAttemptModel.AttemptTracker = loginAttemptStore
+	if configs.GlobalEnvironmentVariables.LoginAttemptTracker == "memory" {
+		attemptTracker = authenticationServices.NewMemoryAttemptTracker()
+	}
+	loginGuard := authenticationServices.NewLoginGuard(attemptTracker)
+
+	// Set up Markdown rendering, the latest rendering of each note is kept until it changes
+	markdownRenderer := markdownServices.NewRenderer(1000)
+
+	// Set up audit recording, shared by every handler that changes something
+	auditRecorder := auditServices.NewRecorder(auditStore)
+
+	// Set up notifications, mailed to the users who opted in to email for their type
+	notifier := notificationService.NewNotifier(notificationStore, userStore, notificationService.NewEmailDeliverer(queuedMailer))
+
+	// Set up single sign-on provider
+	oidcProvider := oidcServices.NewProvider(configs.OIDCEnvironmentVariables, nil)
+	if oidcProvider.Enabled() && configs.OIDCEnvironmentVariables.StateEncryptionKey == "" {
+		log.Println("OIDC_STATE_ENCRYPTION_KEY is not set, single sign-on logins cannot be started")
+	}
+
+	// Set up background schedulers
+	recurrenceScheduler := recurrenceServices.NewScheduler(taskStore, columnStore)
+
+	digestScheduler := digestService.NewScheduler(digestStore, workspaceStore, projectStore, taskStore, noteStore, queuedMailer)
+
+	dataExportWorker := dataExportService.NewWorker(dataExportStore, userStore, projectStore, noteStore, taskStore, queuedMailer, time.Second*time.Duration(configs.GlobalEnvironmentVariables.ExportExpirationInSeconds))
+	dataExportWorker.Start(time.Second * time.Duration(configs.GlobalEnvironmentVariables.ExportIntervalInSeconds))
+
+	// Set up background jobs, each runs on one server at a time
+	reminderWindows, error := reminderServices.ParseWindows(configs.GlobalEnvironmentVariables.ReminderWindowsInHours)
+	if error != nil {
+		return error
+	}
+
+	deadlineReminder := reminderServices.NewDeadlineReminder(reminderStore, notifier, reminderWindows)
+	jobRunner := runnerServices.NewRunner(jobLockStore)
+	jobRunner.Register("recurring-tasks", time.Second*time.Duration(configs.GlobalEnvironmentVariables.RecurrenceIntervalInSeconds), recurrenceScheduler.GenerateScheduledOccurrences)
+	jobRunner.Register("weekly-digests", time.Second*time.Duration(configs.GlobalEnvironmentVariables.DigestIntervalInSeconds), digestScheduler.SendDueDigests)
+	jobRunner.Register("deadline-reminders", time.Second*time.Duration(configs.GlobalEnvironmentVariables.ReminderIntervalInSeconds), deadlineReminder.SendDueReminders)
+	jobRunner.Register("job-purge", time.Hour, jobQueue.PurgeSucceededJobs)
+	jobRunner.Register("signing-key-rotation", time.Hour, keyManager.Rotate)
+	go jobRunner.Run()
+
+	jobQueue.Start(int(configs.GlobalEnvironmentVariables.QueueWorkers))
 
 	// Set up user routes
-	userHandler := userService.NewHandler(userStore)
+	userHandler := userService.NewHandler(userStore, userTokenStore, twoFactorStore, userIdentityStore, loginAttemptStore, auditStore, queuedMailer, oidcProvider, loginGuard, auditRecorder)
 	userHandler.RegisterRoutes(subrouter)
 
+	// Set up workspace routes
+	workspaceHandler := workspaceService.NewHandler(workspaceStore, userStore, auditRecorder)
+	workspaceHandler.RegisterRoutes(subrouter)
+
 	// Set up project routes
-	projectHandler := projectService.NewHandler(projectStore, userStore, noteStore, taskStore)
+	projectHandler := projectService.NewHandler(projectStore, userStore, noteStore, taskStore, columnStore, projectMemberStore, workspaceStore, auditStore, queuedMailer, auditRecorder, notifier)
 	projectHandler.RegisterRoutes(subrouter)
 
 	// Set up note routes
-	noteHandler := noteService.NewHandler(noteStore, userStore, projectStore)
+	noteHandler := noteService.NewHandler(noteStore, userStore, projectMemberStore, markdownRenderer, auditRecorder)
 	noteHandler.RegisterRoutes(subrouter)
 
 	// Set up task routes
-	taskHandler := taskService.NewHandler(taskStore, userStore, projectStore)
+	taskHandler := taskService.NewHandler(taskStore, userStore, projectMemberStore, columnStore, recurrenceScheduler, auditRecorder, notifier)
 	taskHandler.RegisterRoutes(subrouter)
 
-	// Start server
+	// Set up board routes
+	boardHandler := boardService.NewHandler(columnStore, userStore, projectMemberStore, taskStore, recurrenceScheduler)
+	boardHandler.RegisterRoutes(subrouter)
+
+	// Set up comment routes
+	commentHandler := commentService.NewHandler(commentStore, userStore, projectMemberStore, noteStore, taskStore, markdownRenderer, auditRecorder, notifier)
+	commentHandler.RegisterRoutes(subrouter)
+
+	// Set up notification routes
+	notificationHandler := notificationService.NewHandler(notificationStore, userStore)
+	notificationHandler.RegisterRoutes(subrouter)
+
+	// Set up share link routes
+	shareLinkHandler := shareLinkService.NewHandler(shareLinkStore, userStore, projectMemberStore, projectStore, noteStore, taskStore, markdownRenderer, auditRecorder)
+	shareLinkHandler.RegisterRoutes(subrouter)
+
+	// Set up time entry routes
+	timeEntryHandler := timeEntryService.NewHandler(timeEntryStore, userStore, projectMemberStore, taskStore)
+	timeEntryHandler.RegisterRoutes(subrouter)
+
+	// Set up focus session routes
+	focusSessionHandler := focusSessionService.NewHandler(focusSessionStore, userStore, projectMemberStore, taskStore, noteStore)
+	focusSessionHandler.RegisterRoutes(subrouter)
+
+	// Set up daily entry routes
+	dailyEntryHandler := dailyEntryService.NewHandler(dailyEntryStore, userStore, projectMemberStore)
+	dailyEntryHandler.RegisterRoutes(subrouter)
+
+	// Set up report routes
+	reportHandler := reportService.NewHandler(reportStore, userStore, projectStore, taskStore, noteStore, timeEntryStore, focusSessionStore)
+	reportHandler.RegisterRoutes(subrouter)
+
+	// Set up digest routes
+	digestHandler := digestService.NewHandler(digestStore, userStore, digestScheduler)
+	digestHandler.RegisterRoutes(subrouter)
+
+	// Set up data export routes
+	dataExportHandler := dataExportService.NewHandler(dataExportStore, userStore, dataExportWorker)
+	dataExportHandler.RegisterRoutes(subrouter)
+
+	// Set up job queue routes
+	queueHandler := queueService.NewHandler(jobStore, userStore, jobQueue)
+	queueHandler.RegisterRoutes(subrouter)
+
+	// Start server, it stops on an interrupt once the running requests and jobs are done
+	httpServer := &http.Server{Addr: server.address, Handler: router}
+	stopped := make(chan struct{})
+	go shutdownOnInterrupt(httpServer, jobRunner, dataExportWorker, jobQueue, stopped)
+
 	log.Println("Starting HTTP server on address", server.address)
-	return http.ListenAndServe(server.address, router)
+	if error := httpServer.ListenAndServe(); error != http.ErrServerClosed {
+		return error
+	}
+
+	<-stopped
+	return nil
+}
+
+// shutdownOnInterrupt waits for an interrupt, then stops taking requests and background work and closes stopped once everything running is done
+func shutdownOnInterrupt(httpServer *http.Server, jobRunner *runnerServices.Runner, dataExportWorker *dataExportService.Worker, jobQueue *queueService.Queue, stopped chan struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	log.Println("Shutting down HTTP server")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(configs.GlobalEnvironmentVariables.ShutdownTimeoutInSeconds))
+	defer cancel()
+
+	if error := httpServer.Shutdown(ctx); error != nil {
+		log.Printf("failed to finish running requests: %v", error)
+	}
+
+	jobRunner.Stop()
+	if error := dataExportWorker.Stop(ctx); error != nil {
+		log.Printf("failed to finish running data export: %v", error)
+	}
+
+	// the queue stops last, the mails of the jobs and exports finishing above are queued by then
+	if error := jobQueue.Stop(ctx); error != nil {
+		log.Printf("failed to finish running jobs: %v", error)
+	}
+
+	close(stopped)
 }
@@ -0,0 +1,109 @@
+package auditRepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+)
+
+const auditEventQuery = "SELECT audit_events.id, audit_events.actorID, COALESCE(users.firstName, ''), COALESCE(users.lastName, ''), audit_events.projectID, audit_events.entityType, audit_events.entityID, audit_events.action, audit_events.changes, audit_events.ipAddress, audit_events.userAgent, audit_events.dateCreated FROM audit_events LEFT JOIN users ON users.id = audit_events.actorID"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateAuditEvent records an audit event, events are never updated or deleted
+func (store *Store) CreateAuditEvent(event auditModel.AuditEvent) error {
+	var changes []byte
+	if len(event.Changes) > 0 {
+		changesJSON, error := json.Marshal(event.Changes)
+		if error != nil {
+			return fmt.Errorf("failed to marshal audit event changes: %v", error)
+		}
+		changes = changesJSON
+	}
+
+	query := "INSERT INTO audit_events (id, actorID, projectID, entityType, entityID, action, changes, ipAddress, userAgent) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, uuid.New(), event.ActorID, event.ProjectID, event.EntityType, event.EntityID, event.Action, changes, event.IPAddress, event.UserAgent)
+	if error != nil {
+		return fmt.Errorf("failed to create audit event: %v", error)
+	}
+
+	return nil
+}
+
+// GetAuditEventsByProjectID retrieves the latest audit events of a project and everything in it, newest first
+func (store *Store) GetAuditEventsByProjectID(projectID uuid.UUID, filter auditModel.AuditEventFilter) ([]*auditModel.AuditEvent, error) {
+	query := auditEventQuery + " WHERE audit_events.projectID = ?"
+	args := []interface{}{projectID}
+
+	// conditionally add filters
+	if filter.EntityType != "" {
+		query += " AND audit_events.entityType = ?"
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != uuid.Nil {
+		query += " AND audit_events.entityID = ?"
+		args = append(args, filter.EntityID)
+	}
+	if filter.ActorID != uuid.Nil {
+		query += " AND audit_events.actorID = ?"
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		query += " AND audit_events.action = ?"
+		args = append(args, filter.Action)
+	}
+	if filter.From != nil {
+		query += " AND audit_events.dateCreated >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		query += " AND audit_events.dateCreated < ?"
+		args = append(args, filter.To.UTC())
+	}
+
+	return store.getAuditEvents(query+" ORDER BY audit_events.dateCreated DESC LIMIT ?", append(args, filter.Limit)...)
+}
+
+// GetSecurityEventsByUserID retrieves the latest events about the account of a user, newest first
+func (store *Store) GetSecurityEventsByUserID(userID uuid.UUID, limit int) ([]*auditModel.AuditEvent, error) {
+	query := auditEventQuery + " WHERE audit_events.entityType = ? AND audit_events.entityID = ? ORDER BY audit_events.dateCreated DESC LIMIT ?"
+	return store.getAuditEvents(query, auditModel.EntityUser, userID, limit)
+}
+
+// getAuditEvents scans the audit events a query returns
+func (store *Store) getAuditEvents(query string, args ...any) ([]*auditModel.AuditEvent, error) {
+	rows, error := store.database.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get audit events: %v", error)
+	}
+	defer rows.Close()
+
+	events := make([]*auditModel.AuditEvent, 0)
+	for rows.Next() {
+		event := new(auditModel.AuditEvent)
+		var changes sql.NullString
+		error := rows.Scan(&event.ID, &event.ActorID, &event.FirstName, &event.LastName, &event.ProjectID, &event.EntityType, &event.EntityID, &event.Action, &changes, &event.IPAddress, &event.UserAgent, &event.DateCreated)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan audit event from rows: %v", error)
+		}
+
+		if changes.Valid {
+			if error := json.Unmarshal([]byte(changes.String), &event.Changes); error != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit event changes: %v", error)
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,316 @@
+package columnRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	columnModel "github.com/hwaengfan/dev-journal-backend/internal/models/column"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateColumn creates a new board column
+func (store *Store) CreateColumn(column columnModel.Column) (uuid.UUID, error) {
+	columnID := uuid.New()
+
+	query := "INSERT INTO board_columns (id, linkedProjectID, title, position, isDone) VALUES (?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, columnID, column.LinkedProjectID, column.Title, column.Position, column.IsDone)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create column: %v", error)
+	}
+
+	return columnID, nil
+}
+
+// CreateDefaultColumns creates the default board columns for a project
+func (store *Store) CreateDefaultColumns(linkedProjectID uuid.UUID) error {
+	for _, column := range columnModel.DefaultColumns {
+		column.LinkedProjectID = linkedProjectID
+		if _, error := store.CreateColumn(column); error != nil {
+			return error
+		}
+	}
+
+	return nil
+}
+
+// GetColumnsByLinkedProjectID retrieves all board columns of a project in display order
+func (store *Store) GetColumnsByLinkedProjectID(linkedProjectID uuid.UUID) ([]*columnModel.Column, error) {
+	// query columns by linked project ID
+	query := "SELECT id, linkedProjectID, title, position, isDone FROM board_columns WHERE linkedProjectID = ? ORDER BY position"
+	rows, error := store.database.Query(query, linkedProjectID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get columns by linked project ID: %v", error)
+	}
+	defer rows.Close()
+
+	// scan columns from rows
+	columns, error := scanColumnsFromRows(rows)
+	if error != nil {
+		return nil, error
+	}
+
+	return columns, nil
+}
+
+// GetColumnByID retrieves a board column by its ID
+func (store *Store) GetColumnByID(id uuid.UUID) (*columnModel.Column, error) {
+	// query column by ID
+	query := "SELECT id, linkedProjectID, title, position, isDone FROM board_columns WHERE id = ?"
+	row := store.database.QueryRow(query, id)
+
+	// scan column from row
+	column, error := scanColumnFromRow(row)
+	if error != nil {
+		return nil, fmt.Errorf("failed to scan column from row: %v", error)
+	}
+
+	return column, nil
+}
+
+// UpdateColumnByID updates a board column by its ID
+func (store *Store) UpdateColumnByID(column columnModel.Column, id uuid.UUID) error {
+	// base query
+	query := "UPDATE board_columns SET"
+	var updates []string
+	var args []interface{}
+
+	// conditionally add fields to update
+	if column.Title != "" {
+		updates = append(updates, "title = ?")
+		args = append(args, column.Title)
+	}
+	if column.IsDone != "" {
+		updates = append(updates, "isDone = ?")
+		args = append(args, column.IsDone)
+	}
+
+	// check if there are fields to update
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	// finalize query
+	query += " " + strings.Join(updates, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	// execute the query
+	_, error := store.database.Exec(query, args...)
+	if error != nil {
+		return fmt.Errorf("failed to update column: %v", error)
+	}
+
+	return nil
+}
+
+// MoveColumnByID moves a board column to the given position, shifting the other columns of the project
+func (store *Store) MoveColumnByID(id uuid.UUID, position int) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// get the current place of the column
+	var linkedProjectID uuid.UUID
+	var currentPosition int
+	query := "SELECT linkedProjectID, position FROM board_columns WHERE id = ? FOR UPDATE"
+	if error := transaction.QueryRow(query, id).Scan(&linkedProjectID, &currentPosition); error != nil {
+		if error == sql.ErrNoRows {
+			return fmt.Errorf("column not found")
+		}
+		return fmt.Errorf("failed to get column position: %v", error)
+	}
+
+	// clamp the position to the end of the board
+	var count int
+	query = "SELECT COUNT(*) FROM board_columns WHERE linkedProjectID = ?"
+	if error := transaction.QueryRow(query, linkedProjectID).Scan(&count); error != nil {
+		return fmt.Errorf("failed to count columns: %v", error)
+	}
+	if position > count-1 {
+		position = count - 1
+	}
+
+	// positions are unique per board, so the column steps aside while the others shift one at a time towards the gap
+	query = "UPDATE board_columns SET position = -1 WHERE id = ?"
+	if _, error := transaction.Exec(query, id); error != nil {
+		return fmt.Errorf("failed to move column: %v", error)
+	}
+
+	// shift the columns between the old and the new position
+	if position < currentPosition {
+		query = "UPDATE board_columns SET position = position + 1 WHERE linkedProjectID = ? AND position >= ? AND position < ? ORDER BY position DESC"
+		_, error = transaction.Exec(query, linkedProjectID, position, currentPosition)
+	} else {
+		query = "UPDATE board_columns SET position = position - 1 WHERE linkedProjectID = ? AND position > ? AND position <= ? ORDER BY position"
+		_, error = transaction.Exec(query, linkedProjectID, currentPosition, position)
+	}
+	if error != nil {
+		return fmt.Errorf("failed to reorder columns: %v", error)
+	}
+
+	query = "UPDATE board_columns SET position = ? WHERE id = ?"
+	if _, error := transaction.Exec(query, position, id); error != nil {
+		return fmt.Errorf("failed to move column: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit column move: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteColumnByID deletes an empty board column by its ID and closes the gap it leaves
+func (store *Store) DeleteColumnByID(id uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// lock the column so no task is moved into it while it is deleted
+	var linkedProjectID uuid.UUID
+	var position int
+	query := "SELECT linkedProjectID, position FROM board_columns WHERE id = ? FOR UPDATE"
+	if error := transaction.QueryRow(query, id).Scan(&linkedProjectID, &position); error != nil {
+		if error == sql.ErrNoRows {
+			return fmt.Errorf("column not found")
+		}
+		return fmt.Errorf("failed to get column position: %v", error)
+	}
+
+	var count int
+	query = "SELECT COUNT(*) FROM tasks WHERE columnID = ?"
+	if error := transaction.QueryRow(query, id).Scan(&count); error != nil {
+		return fmt.Errorf("failed to count tasks in column: %v", error)
+	}
+	if count > 0 {
+		return columnModel.ErrColumnNotEmpty
+	}
+
+	// tasks without a column are shown in the first one, so a board keeps at least one
+	query = "SELECT COUNT(*) FROM board_columns WHERE linkedProjectID = ? FOR UPDATE"
+	if error := transaction.QueryRow(query, linkedProjectID).Scan(&count); error != nil {
+		return fmt.Errorf("failed to count columns: %v", error)
+	}
+	if count <= 1 {
+		return columnModel.ErrLastColumn
+	}
+
+	query = "DELETE FROM board_columns WHERE id = ?"
+	if _, error := transaction.Exec(query, id); error != nil {
+		return fmt.Errorf("failed to delete column: %v", error)
+	}
+
+	query = "UPDATE board_columns SET position = position - 1 WHERE linkedProjectID = ? AND position > ? ORDER BY position"
+	if _, error := transaction.Exec(query, linkedProjectID, position); error != nil {
+		return fmt.Errorf("failed to reorder columns: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit column deletion: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteColumnsByLinkedProjectID deletes all board columns of a project
+func (store *Store) DeleteColumnsByLinkedProjectID(linkedProjectID uuid.UUID) error {
+	query := "DELETE FROM board_columns WHERE linkedProjectID = ?"
+	_, error := store.database.Exec(query, linkedProjectID)
+	if error != nil {
+		return fmt.Errorf("failed to delete all columns by linked project ID: %v", error)
+	}
+
+	return nil
+}
+
+// GetTransitionsByLinkedProjectID retrieves the allowed column transitions of a project
+func (store *Store) GetTransitionsByLinkedProjectID(linkedProjectID uuid.UUID) ([]*columnModel.Transition, error) {
+	query := "SELECT transitions.fromColumnID, transitions.toColumnID FROM column_transitions transitions JOIN board_columns boardColumns ON boardColumns.id = transitions.fromColumnID WHERE boardColumns.linkedProjectID = ?"
+	rows, error := store.database.Query(query, linkedProjectID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get transitions by linked project ID: %v", error)
+	}
+	defer rows.Close()
+
+	transitions := make([]*columnModel.Transition, 0)
+	for rows.Next() {
+		transition := new(columnModel.Transition)
+		if error := rows.Scan(&transition.FromColumnID, &transition.ToColumnID); error != nil {
+			return nil, fmt.Errorf("failed to scan transition from rows: %v", error)
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, nil
+}
+
+// SetTransitionsByLinkedProjectID replaces the allowed column transitions of a project
+func (store *Store) SetTransitionsByLinkedProjectID(linkedProjectID uuid.UUID, transitions []columnModel.Transition) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	query := "DELETE transitions FROM column_transitions transitions JOIN board_columns boardColumns ON boardColumns.id = transitions.fromColumnID WHERE boardColumns.linkedProjectID = ?"
+	if _, error := transaction.Exec(query, linkedProjectID); error != nil {
+		return fmt.Errorf("failed to clear transitions: %v", error)
+	}
+
+	query = "INSERT INTO column_transitions (fromColumnID, toColumnID) VALUES (?, ?)"
+	for _, transition := range transitions {
+		if _, error := transaction.Exec(query, transition.FromColumnID, transition.ToColumnID); error != nil {
+			return fmt.Errorf("failed to create transition: %v", error)
+		}
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transitions: %v", error)
+	}
+
+	return nil
+}
+
+// scanColumnsFromRows scans MySQL rows into a slice of column objects
+func scanColumnsFromRows(rows *sql.Rows) ([]*columnModel.Column, error) {
+	columns := make([]*columnModel.Column, 0)
+	for rows.Next() {
+		column := new(columnModel.Column)
+
+		error := rows.Scan(&column.ID, &column.LinkedProjectID, &column.Title, &column.Position, &column.IsDone)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan column from rows: %v", error)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// scanColumnFromRow scans a MySQL row into a new column object
+func scanColumnFromRow(row *sql.Row) (*columnModel.Column, error) {
+	column := new(columnModel.Column)
+
+	error := row.Scan(&column.ID, &column.LinkedProjectID, &column.Title, &column.Position, &column.IsDone)
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("column not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan column from row: %v", error)
+	}
+
+	return column, nil
+}
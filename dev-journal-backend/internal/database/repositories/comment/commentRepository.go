@@ -0,0 +1,205 @@
+package commentRepository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	commentModel "github.com/hwaengfan/dev-journal-backend/internal/models/comment"
+)
+
+const commentColumns = "comments.id, comments.projectID, comments.noteID, comments.taskID, comments.parentCommentID, comments.userID, COALESCE(users.firstName, ''), COALESCE(users.lastName, ''), comments.content, comments.dateCreated, comments.lastEdited, comments.dateDeleted"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateComment stores a new comment along with the users it mentions
+func (store *Store) CreateComment(comment commentModel.Comment, mentionedUserIDs []uuid.UUID) (uuid.UUID, error) {
+	id := uuid.New()
+
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	query := "INSERT INTO comments (id, projectID, noteID, taskID, parentCommentID, userID, content) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	if _, error := transaction.Exec(query, id, comment.ProjectID, comment.NoteID, comment.TaskID, comment.ParentCommentID, comment.UserID, comment.Content); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create comment: %v", error)
+	}
+
+	if error := insertMentions(transaction, id, mentionedUserIDs); error != nil {
+		return uuid.Nil, error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return id, nil
+}
+
+// GetCommentByID retrieves a comment by its ID, nil when it does not exist
+func (store *Store) GetCommentByID(id uuid.UUID) (*commentModel.Comment, error) {
+	comments, error := store.getComments("comments.id = ?", "", id)
+	if error != nil {
+		return nil, error
+	}
+	if len(comments) == 0 {
+		return nil, nil
+	}
+
+	return comments[0], nil
+}
+
+// GetCommentsByNoteID retrieves the comments on a note, oldest first
+func (store *Store) GetCommentsByNoteID(noteID uuid.UUID) ([]*commentModel.Comment, error) {
+	return store.getComments("comments.noteID = ?", " ORDER BY comments.dateCreated, comments.id", noteID)
+}
+
+// GetCommentsByTaskID retrieves the comments on a task, oldest first
+func (store *Store) GetCommentsByTaskID(taskID uuid.UUID) ([]*commentModel.Comment, error) {
+	return store.getComments("comments.taskID = ?", " ORDER BY comments.dateCreated, comments.id", taskID)
+}
+
+// GetRecentCommentsByProjectID retrieves the latest comments on the notes and tasks of a project, newest first
+func (store *Store) GetRecentCommentsByProjectID(projectID uuid.UUID, limit int) ([]*commentModel.Comment, error) {
+	filter := "comments.id IN (SELECT id FROM (SELECT id FROM comments WHERE projectID = ? AND dateDeleted IS NULL ORDER BY dateCreated DESC LIMIT ?) AS recent)"
+	return store.getComments(filter, " ORDER BY comments.dateCreated DESC, comments.id", projectID, limit)
+}
+
+// UpdateCommentByID replaces the content of a comment and the users it mentions
+func (store *Store) UpdateCommentByID(id uuid.UUID, content string, mentionedUserIDs []uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec("UPDATE comments SET content = ?, lastEdited = CURRENT_TIMESTAMP WHERE id = ? AND dateDeleted IS NULL", content, id); error != nil {
+		return fmt.Errorf("failed to update comment: %v", error)
+	}
+
+	if _, error := transaction.Exec("DELETE FROM comment_mentions WHERE commentID = ?", id); error != nil {
+		return fmt.Errorf("failed to delete comment mentions: %v", error)
+	}
+
+	if error := insertMentions(transaction, id, mentionedUserIDs); error != nil {
+		return error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteCommentByID deletes a comment, comments with replies only lose their content so the thread stays readable
+func (store *Store) DeleteCommentByID(id uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	var parentCommentID uuid.NullUUID
+	var replies int
+	query := "SELECT parentCommentID, (SELECT COUNT(*) FROM comments replies WHERE replies.parentCommentID = comments.id) FROM comments WHERE id = ? FOR UPDATE"
+	if error := transaction.QueryRow(query, id).Scan(&parentCommentID, &replies); error == sql.ErrNoRows {
+		return nil
+	} else if error != nil {
+		return fmt.Errorf("failed to get comment replies: %v", error)
+	}
+
+	if replies > 0 {
+		if _, error := transaction.Exec("UPDATE comments SET content = '', dateDeleted = CURRENT_TIMESTAMP WHERE id = ?", id); error != nil {
+			return fmt.Errorf("failed to delete comment: %v", error)
+		}
+		if _, error := transaction.Exec("DELETE FROM comment_mentions WHERE commentID = ?", id); error != nil {
+			return fmt.Errorf("failed to delete comment mentions: %v", error)
+		}
+	} else {
+		if _, error := transaction.Exec("DELETE FROM comments WHERE id = ?", id); error != nil {
+			return fmt.Errorf("failed to delete comment: %v", error)
+		}
+
+		// a deleted thread start is removed with its last reply
+		if parentCommentID.Valid {
+			query := "DELETE FROM comments WHERE id = ? AND dateDeleted IS NOT NULL AND NOT EXISTS (SELECT 1 FROM (SELECT id FROM comments WHERE parentCommentID = ?) AS replies)"
+			if _, error := transaction.Exec(query, parentCommentID.UUID, parentCommentID.UUID); error != nil {
+				return fmt.Errorf("failed to delete comment thread: %v", error)
+			}
+		}
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// getComments retrieves the comments matching a filter along with their mentions
+func (store *Store) getComments(filter string, order string, args ...any) ([]*commentModel.Comment, error) {
+	rows, error := store.database.Query("SELECT "+commentColumns+" FROM comments LEFT JOIN users ON users.id = comments.userID WHERE "+filter+order, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get comments: %v", error)
+	}
+	defer rows.Close()
+
+	comments := make([]*commentModel.Comment, 0)
+	commentsByID := make(map[uuid.UUID]*commentModel.Comment)
+	for rows.Next() {
+		comment := &commentModel.Comment{Mentions: make([]*commentModel.Mention, 0)}
+		error := rows.Scan(&comment.ID, &comment.ProjectID, &comment.NoteID, &comment.TaskID, &comment.ParentCommentID, &comment.UserID, &comment.FirstName, &comment.LastName, &comment.Content, &comment.DateCreated, &comment.LastEdited, &comment.DateDeleted)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan comment from rows: %v", error)
+		}
+
+		comments = append(comments, comment)
+		commentsByID[comment.ID] = comment
+	}
+
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	// mentions of the same comments, selected with the same filter
+	query := "SELECT comment_mentions.commentID, users.id, users.firstName, users.lastName FROM comment_mentions JOIN comments ON comments.id = comment_mentions.commentID JOIN users ON users.id = comment_mentions.userID WHERE " + filter + " ORDER BY users.firstName, users.lastName"
+	mentionRows, error := store.database.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get comment mentions: %v", error)
+	}
+	defer mentionRows.Close()
+
+	for mentionRows.Next() {
+		var commentID uuid.UUID
+		mention := new(commentModel.Mention)
+		if error := mentionRows.Scan(&commentID, &mention.UserID, &mention.FirstName, &mention.LastName); error != nil {
+			return nil, fmt.Errorf("failed to scan comment mention from rows: %v", error)
+		}
+
+		if comment, exists := commentsByID[commentID]; exists {
+			comment.Mentions = append(comment.Mentions, mention)
+		}
+	}
+
+	return comments, nil
+}
+
+// insertMentions stores the users a comment mentions
+func insertMentions(transaction *sql.Tx, commentID uuid.UUID, userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		if _, error := transaction.Exec("INSERT IGNORE INTO comment_mentions (commentID, userID) VALUES (?, ?)", commentID, userID); error != nil {
+			return fmt.Errorf("failed to create comment mention: %v", error)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package dailyEntryRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	dailyEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/dailyEntry"
+)
+
+const dailyEntryQuery = "SELECT id, userID, workspaceID, entryDate, content, dateCreated, lastEdited FROM daily_entries"
+
+// an entry counts as written once it has content or linked projects, empty entries are skipped when navigating
+const writtenCondition = "(content <> '' OR EXISTS (SELECT 1 FROM daily_entry_projects WHERE dailyEntryID = daily_entries.id))"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetOrCreateDailyEntry retrieves the entry of a user in a workspace for a date, creating an empty one if it does not exist yet
+func (store *Store) GetOrCreateDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*dailyEntryModel.DailyEntry, error) {
+	query := "INSERT IGNORE INTO daily_entries (id, userID, workspaceID, entryDate, content) VALUES (?, ?, ?, ?, '')"
+	_, error := store.database.Exec(query, uuid.New(), userID, workspaceID, entryDate)
+	if error != nil {
+		return nil, fmt.Errorf("failed to create daily entry: %v", error)
+	}
+
+	return store.getDailyEntry(dailyEntryQuery+" WHERE userID = ? AND workspaceID = ? AND entryDate = ?", userID, workspaceID, entryDate)
+}
+
+// GetDailyEntryByID retrieves a daily entry by its ID
+func (store *Store) GetDailyEntryByID(id uuid.UUID) (*dailyEntryModel.DailyEntry, error) {
+	return store.getDailyEntry(dailyEntryQuery+" WHERE id = ?", id)
+}
+
+// GetPreviousDailyEntry retrieves the latest written entry of a user in a workspace before a date
+func (store *Store) GetPreviousDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*dailyEntryModel.DailyEntry, error) {
+	return store.getDailyEntry(dailyEntryQuery+" WHERE userID = ? AND workspaceID = ? AND entryDate < ? AND "+writtenCondition+" ORDER BY entryDate DESC LIMIT 1", userID, workspaceID, entryDate)
+}
+
+// GetNextDailyEntry retrieves the earliest written entry of a user in a workspace after a date
+func (store *Store) GetNextDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*dailyEntryModel.DailyEntry, error) {
+	return store.getDailyEntry(dailyEntryQuery+" WHERE userID = ? AND workspaceID = ? AND entryDate > ? AND "+writtenCondition+" ORDER BY entryDate ASC LIMIT 1", userID, workspaceID, entryDate)
+}
+
+// GetDailyEntryDatesByUserID retrieves the dates between from and to, both inclusive, on which a user wrote an entry in a workspace
+func (store *Store) GetDailyEntryDatesByUserID(userID uuid.UUID, workspaceID uuid.UUID, from string, to string) ([]string, error) {
+	query := "SELECT entryDate FROM daily_entries WHERE userID = ? AND workspaceID = ? AND entryDate BETWEEN ? AND ? AND " + writtenCondition + " ORDER BY entryDate"
+	rows, error := store.database.Query(query, userID, workspaceID, from, to)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get daily entry dates: %v", error)
+	}
+	defer rows.Close()
+
+	dates := make([]string, 0)
+	for rows.Next() {
+		var entryDate time.Time
+		if error := rows.Scan(&entryDate); error != nil {
+			return nil, fmt.Errorf("failed to scan daily entry date from rows: %v", error)
+		}
+
+		dates = append(dates, entryDate.Format(time.DateOnly))
+	}
+
+	return dates, nil
+}
+
+// UpdateDailyEntryByID updates the content and replaces the linked projects of a daily entry, nil leaves a field unchanged
+func (store *Store) UpdateDailyEntryByID(id uuid.UUID, content *string, linkedProjectIDs *[]uuid.UUID) error {
+	if content == nil && linkedProjectIDs == nil {
+		return fmt.Errorf("no fields to update")
+	}
+
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if content != nil {
+		if _, error := transaction.Exec("UPDATE daily_entries SET content = ? WHERE id = ?", *content, id); error != nil {
+			return fmt.Errorf("failed to update daily entry: %v", error)
+		}
+	}
+
+	if linkedProjectIDs != nil {
+		if _, error := transaction.Exec("DELETE FROM daily_entry_projects WHERE dailyEntryID = ?", id); error != nil {
+			return fmt.Errorf("failed to unlink projects from daily entry: %v", error)
+		}
+
+		for _, projectID := range *linkedProjectIDs {
+			if _, error := transaction.Exec("INSERT IGNORE INTO daily_entry_projects (dailyEntryID, projectID) VALUES (?, ?)", id, projectID); error != nil {
+				return fmt.Errorf("failed to link project to daily entry: %v", error)
+			}
+		}
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteDailyEntryByID deletes a daily entry by its ID, its project links are deleted with it
+func (store *Store) DeleteDailyEntryByID(id uuid.UUID) error {
+	query := "DELETE FROM daily_entries WHERE id = ?"
+	_, error := store.database.Exec(query, id)
+	if error != nil {
+		return fmt.Errorf("failed to delete daily entry: %v", error)
+	}
+
+	return nil
+}
+
+// getDailyEntry retrieves a single daily entry together with its linked projects
+func (store *Store) getDailyEntry(query string, args ...interface{}) (*dailyEntryModel.DailyEntry, error) {
+	dailyEntry := new(dailyEntryModel.DailyEntry)
+	var entryDate time.Time
+
+	error := store.database.QueryRow(query, args...).Scan(&dailyEntry.ID, &dailyEntry.UserID, &dailyEntry.WorkspaceID, &entryDate, &dailyEntry.Content, &dailyEntry.DateCreated, &dailyEntry.LastEdited)
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("daily entry not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan daily entry from row: %v", error)
+	}
+	dailyEntry.EntryDate = entryDate.Format(time.DateOnly)
+
+	rows, error := store.database.Query("SELECT projectID FROM daily_entry_projects WHERE dailyEntryID = ?", dailyEntry.ID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get linked projects of daily entry: %v", error)
+	}
+	defer rows.Close()
+
+	dailyEntry.LinkedProjectIDs = make([]uuid.UUID, 0)
+	for rows.Next() {
+		var projectID uuid.UUID
+		if error := rows.Scan(&projectID); error != nil {
+			return nil, fmt.Errorf("failed to scan linked project from rows: %v", error)
+		}
+
+		dailyEntry.LinkedProjectIDs = append(dailyEntry.LinkedProjectIDs, projectID)
+	}
+
+	return dailyEntry, nil
+}
@@ -0,0 +1,201 @@
+package dataExportRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	dataExportModel "github.com/hwaengfan/dev-journal-backend/internal/models/dataExport"
+)
+
+const dataExportColumns = "id, userID, workspaceID, status, fileSize, error, dateCreated, startedAt, dateCompleted, expiresAt"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateDataExport queues a new export of the data of a user in a workspace
+func (store *Store) CreateDataExport(userID uuid.UUID, workspaceID uuid.UUID) (uuid.UUID, error) {
+	id := uuid.New()
+	_, error := store.database.Exec("INSERT INTO data_exports (id, userID, workspaceID) VALUES (?, ?, ?)", id, userID, workspaceID)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create data export: %v", error)
+	}
+
+	return id, nil
+}
+
+// GetDataExportByID retrieves an export by its ID, nil when it does not exist
+func (store *Store) GetDataExportByID(id uuid.UUID) (*dataExportModel.DataExport, error) {
+	row := store.database.QueryRow("SELECT "+dataExportColumns+" FROM data_exports WHERE id = ?", id)
+	return scanDataExportFromRow(row)
+}
+
+// GetDataExportsByUserID retrieves the latest exports of a user, in every workspace when workspaceID is not valid, newest first
+func (store *Store) GetDataExportsByUserID(userID uuid.UUID, workspaceID uuid.NullUUID, limit int) ([]*dataExportModel.DataExport, error) {
+	query := "SELECT " + dataExportColumns + " FROM data_exports WHERE userID = ?"
+	args := []interface{}{userID}
+	if workspaceID.Valid {
+		query += " AND workspaceID = ?"
+		args = append(args, workspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY dateCreated DESC LIMIT ?", append(args, limit)...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get data exports by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	return scanDataExportsFromRows(rows)
+}
+
+// GetActiveDataExportByUserID retrieves the pending or running export of a user, nil when there is none
+func (store *Store) GetActiveDataExportByUserID(userID uuid.UUID) (*dataExportModel.DataExport, error) {
+	query := "SELECT " + dataExportColumns + " FROM data_exports WHERE userID = ? AND status IN ('PENDING', 'RUNNING') ORDER BY dateCreated DESC LIMIT 1"
+	row := store.database.QueryRow(query, userID)
+	return scanDataExportFromRow(row)
+}
+
+// ClaimNextDataExport marks the oldest pending export as running for the lease and returns it, nil when there is none
+// Running exports whose lease ran out are claimed again, their server stopped while building them
+func (store *Store) ClaimNextDataExport(now time.Time, lease time.Duration) (*dataExportModel.DataExport, error) {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// skip exports other servers are claiming at the same time
+	query := "SELECT " + dataExportColumns + " FROM data_exports WHERE status = 'PENDING' OR (status = 'RUNNING' AND leaseUntil < ?) ORDER BY dateCreated LIMIT 1 FOR UPDATE SKIP LOCKED"
+	dataExport, error := scanDataExportFromRow(transaction.QueryRow(query, now.UTC()))
+	if error != nil || dataExport == nil {
+		return nil, error
+	}
+
+	if _, error := transaction.Exec("UPDATE data_exports SET status = 'RUNNING', startedAt = ?, leaseUntil = ? WHERE id = ?", now.UTC(), now.Add(lease).UTC(), dataExport.ID); error != nil {
+		return nil, fmt.Errorf("failed to claim data export: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	dataExport.Status, dataExport.StartedAt = dataExportModel.StatusRunning, &now
+	return dataExport, nil
+}
+
+// ExtendDataExportLease keeps a running export claimed until leaseUntil
+func (store *Store) ExtendDataExportLease(id uuid.UUID, leaseUntil time.Time) error {
+	_, error := store.database.Exec("UPDATE data_exports SET leaseUntil = ? WHERE id = ? AND status = 'RUNNING'", leaseUntil.UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to extend data export lease: %v", error)
+	}
+
+	return nil
+}
+
+// CompleteDataExport stores the archive of an export and marks it as completed, it can be downloaded until expiresAt
+func (store *Store) CompleteDataExport(id uuid.UUID, archive []byte, completedAt time.Time, expiresAt time.Time) error {
+	query := "UPDATE data_exports SET status = 'COMPLETED', archive = ?, fileSize = ?, error = NULL, dateCompleted = ?, expiresAt = ?, leaseUntil = NULL WHERE id = ?"
+	_, error := store.database.Exec(query, archive, len(archive), completedAt.UTC(), expiresAt.UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to complete data export: %v", error)
+	}
+
+	return nil
+}
+
+// GetDataExportArchive retrieves the archive of a completed export, nil when there is none
+func (store *Store) GetDataExportArchive(id uuid.UUID) ([]byte, error) {
+	var archive []byte
+	error := store.database.QueryRow("SELECT archive FROM data_exports WHERE id = ? AND status = 'COMPLETED'", id).Scan(&archive)
+	if error != nil && error != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get data export archive: %v", error)
+	}
+
+	return archive, nil
+}
+
+// FailDataExport marks an export as failed with the reason
+func (store *Store) FailDataExport(id uuid.UUID, message string) error {
+	_, error := store.database.Exec("UPDATE data_exports SET status = 'FAILED', error = ?, leaseUntil = NULL WHERE id = ?", message, id)
+	if error != nil {
+		return fmt.Errorf("failed to fail data export: %v", error)
+	}
+
+	return nil
+}
+
+// ExpireDataExports marks the completed exports past their expiration as expired, deletes their archives and returns their IDs
+func (store *Store) ExpireDataExports(now time.Time) ([]uuid.UUID, error) {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	rows, error := transaction.Query("SELECT id FROM data_exports WHERE status = 'COMPLETED' AND expiresAt <= ? FOR UPDATE", now.UTC())
+	if error != nil {
+		return nil, fmt.Errorf("failed to get expired data exports: %v", error)
+	}
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if error := rows.Scan(&id); error != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan data export ID from rows: %v", error)
+		}
+
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, error := transaction.Exec("UPDATE data_exports SET status = 'EXPIRED', archive = NULL WHERE id = ?", id); error != nil {
+			return nil, fmt.Errorf("failed to expire data export: %v", error)
+		}
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return ids, nil
+}
+
+// scanDataExportsFromRows scans MySQL rows into a slice of data export objects
+func scanDataExportsFromRows(rows *sql.Rows) ([]*dataExportModel.DataExport, error) {
+	dataExports := make([]*dataExportModel.DataExport, 0)
+	for rows.Next() {
+		dataExport := new(dataExportModel.DataExport)
+
+		error := rows.Scan(&dataExport.ID, &dataExport.UserID, &dataExport.WorkspaceID, &dataExport.Status, &dataExport.FileSize, &dataExport.Error, &dataExport.DateCreated, &dataExport.StartedAt, &dataExport.DateCompleted, &dataExport.ExpiresAt)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan data export from rows: %v", error)
+		}
+
+		dataExports = append(dataExports, dataExport)
+	}
+
+	return dataExports, nil
+}
+
+// scanDataExportFromRow scans a MySQL row into a new data export object, nil when there is no row
+func scanDataExportFromRow(row *sql.Row) (*dataExportModel.DataExport, error) {
+	dataExport := new(dataExportModel.DataExport)
+
+	error := row.Scan(&dataExport.ID, &dataExport.UserID, &dataExport.WorkspaceID, &dataExport.Status, &dataExport.FileSize, &dataExport.Error, &dataExport.DateCreated, &dataExport.StartedAt, &dataExport.DateCompleted, &dataExport.ExpiresAt)
+	if error == sql.ErrNoRows {
+		return nil, nil
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan data export from row: %v", error)
+	}
+
+	return dataExport, nil
+}
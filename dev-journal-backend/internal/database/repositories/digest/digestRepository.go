@@ -0,0 +1,113 @@
+package digestRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	digestModel "github.com/hwaengfan/dev-journal-backend/internal/models/digest"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetDigestPreferenceByUserID retrieves the digest preference of a user, users who never chose are opted out
+func (store *Store) GetDigestPreferenceByUserID(userID uuid.UUID) (*digestModel.DigestPreference, error) {
+	preference := &digestModel.DigestPreference{UserID: userID, WeeklyDigest: "False"}
+
+	query := "SELECT weeklyDigest, lastDigestSentAt FROM digest_preferences WHERE userID = ?"
+	error := store.database.QueryRow(query, userID).Scan(&preference.WeeklyDigest, &preference.LastDigestSentAt)
+	if error != nil && error != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest preference: %v", error)
+	}
+
+	return preference, nil
+}
+
+// SetWeeklyDigestByUserID opts a user in or out of the weekly digest
+func (store *Store) SetWeeklyDigestByUserID(userID uuid.UUID, weeklyDigest string) error {
+	query := "INSERT INTO digest_preferences (userID, weeklyDigest) VALUES (?, ?) ON DUPLICATE KEY UPDATE weeklyDigest = VALUES(weeklyDigest)"
+	_, error := store.database.Exec(query, userID, weeklyDigest)
+	if error != nil {
+		return fmt.Errorf("failed to set weekly digest preference: %v", error)
+	}
+
+	return nil
+}
+
+// GetWeeklyDigestRecipients retrieves the users with a verified email opted in to the weekly digest
+func (store *Store) GetWeeklyDigestRecipients() ([]*digestModel.DigestRecipient, error) {
+	query := "SELECT users.id, users.firstName, users.email, users.timezone, digest_preferences.lastDigestSentAt FROM digest_preferences JOIN users ON users.id = digest_preferences.userID WHERE digest_preferences.weeklyDigest = 'True' AND users.emailVerified = 'True'"
+	rows, error := store.database.Query(query)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get weekly digest recipients: %v", error)
+	}
+	defer rows.Close()
+
+	recipients := make([]*digestModel.DigestRecipient, 0)
+	for rows.Next() {
+		recipient := new(digestModel.DigestRecipient)
+		if error := rows.Scan(&recipient.UserID, &recipient.FirstName, &recipient.Email, &recipient.Timezone, &recipient.LastDigestSentAt); error != nil {
+			return nil, fmt.Errorf("failed to scan weekly digest recipient from rows: %v", error)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// MarkDigestSentByUserID records when the last digest was sent to a user
+func (store *Store) MarkDigestSentByUserID(userID uuid.UUID, sentAt time.Time) error {
+	query := "UPDATE digest_preferences SET lastDigestSentAt = ? WHERE userID = ?"
+	_, error := store.database.Exec(query, sentAt.UTC(), userID)
+	if error != nil {
+		return fmt.Errorf("failed to mark digest as sent: %v", error)
+	}
+
+	return nil
+}
+
+// ClaimWeeklyDigest records the digest of a week as sent to a user, false when it was sent before
+func (store *Store) ClaimWeeklyDigest(userID uuid.UUID, weekStart string) (bool, error) {
+	query := "INSERT IGNORE INTO weekly_digests (userID, weekStart) VALUES (?, ?)"
+	result, error := store.database.Exec(query, userID, weekStart)
+	if error != nil {
+		return false, fmt.Errorf("failed to claim weekly digest: %v", error)
+	}
+
+	claimed, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to count claimed weekly digests: %v", error)
+	}
+
+	return claimed == 1, nil
+}
+
+// ReleaseWeeklyDigest forgets a claimed digest that could not be sent so it is tried again
+func (store *Store) ReleaseWeeklyDigest(userID uuid.UUID, weekStart string) error {
+	query := "DELETE FROM weekly_digests WHERE userID = ? AND weekStart = ?"
+	_, error := store.database.Exec(query, userID, weekStart)
+	if error != nil {
+		return fmt.Errorf("failed to release weekly digest: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteWeeklyDigestsBefore forgets the digests of the weeks before weekStart
+func (store *Store) DeleteWeeklyDigestsBefore(weekStart string) error {
+	query := "DELETE FROM weekly_digests WHERE weekStart < ?"
+	_, error := store.database.Exec(query, weekStart)
+	if error != nil {
+		return fmt.Errorf("failed to delete past weekly digests: %v", error)
+	}
+
+	return nil
+}
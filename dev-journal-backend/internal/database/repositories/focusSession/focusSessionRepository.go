@@ -0,0 +1,155 @@
+package focusSessionRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	focusSessionModel "github.com/hwaengfan/dev-journal-backend/internal/models/focusSession"
+)
+
+const focusSessionQuery = "SELECT id, userID, workspaceID, taskID, linkedProjectID, reflectionNoteID, status, plannedLengthInSeconds, pausedSeconds, interruptions, startedAt, pausedAt, endedAt FROM focus_sessions"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateFocusSession creates a new running focus session
+func (store *Store) CreateFocusSession(focusSession focusSessionModel.FocusSession) (uuid.UUID, error) {
+	focusSessionID := uuid.New()
+
+	query := "INSERT INTO focus_sessions (id, userID, workspaceID, taskID, linkedProjectID, status, plannedLengthInSeconds, startedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, focusSessionID, focusSession.UserID, focusSession.WorkspaceID, focusSession.TaskID, focusSession.LinkedProjectID, focusSessionModel.FocusSessionRunning, focusSession.PlannedLengthInSeconds, focusSession.StartedAt.UTC())
+	if error != nil {
+		if strings.Contains(error.Error(), "Duplicate entry") {
+			return uuid.Nil, fmt.Errorf("a focus session is already active")
+		}
+		return uuid.Nil, fmt.Errorf("failed to create focus session: %v", error)
+	}
+
+	return focusSessionID, nil
+}
+
+// GetFocusSessionsByUserID retrieves a user's focus sessions started within the filter's range, newest first
+func (store *Store) GetFocusSessionsByUserID(userID uuid.UUID, filter focusSessionModel.FocusSessionFilter) ([]*focusSessionModel.FocusSession, error) {
+	query := focusSessionQuery + " WHERE userID = ?"
+	args := []interface{}{userID}
+
+	// conditionally add filters
+	if filter.From != nil {
+		query += " AND startedAt >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		query += " AND startedAt < ?"
+		args = append(args, filter.To.UTC())
+	}
+	if filter.LinkedProjectID != uuid.Nil {
+		query += " AND linkedProjectID = ?"
+		args = append(args, filter.LinkedProjectID)
+	}
+	if filter.WorkspaceID.Valid {
+		query += " AND workspaceID = ?"
+		args = append(args, filter.WorkspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY startedAt DESC", args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get focus sessions by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	// scan focus sessions from rows
+	focusSessions := make([]*focusSessionModel.FocusSession, 0)
+	for rows.Next() {
+		focusSession, error := scanFocusSession(rows)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan focus session from rows: %v", error)
+		}
+
+		focusSessions = append(focusSessions, focusSession)
+	}
+
+	return focusSessions, nil
+}
+
+// GetFocusSessionByID retrieves a focus session by its ID
+func (store *Store) GetFocusSessionByID(id uuid.UUID) (*focusSessionModel.FocusSession, error) {
+	focusSession, error := scanFocusSession(store.database.QueryRow(focusSessionQuery+" WHERE id = ?", id))
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("focus session not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan focus session from row: %v", error)
+	}
+
+	return focusSession, nil
+}
+
+// GetActiveFocusSessionByUserID retrieves the running or paused focus session of a user
+func (store *Store) GetActiveFocusSessionByUserID(userID uuid.UUID) (*focusSessionModel.FocusSession, error) {
+	focusSession, error := scanFocusSession(store.database.QueryRow(focusSessionQuery+" WHERE activeUserID = ?", userID))
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("focus session not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan focus session from row: %v", error)
+	}
+
+	return focusSession, nil
+}
+
+// UpdateFocusSessionStateByID stores the status, pause bookkeeping, interruptions, end and reflection of a focus session
+func (store *Store) UpdateFocusSessionStateByID(focusSession focusSessionModel.FocusSession, id uuid.UUID) error {
+	query := "UPDATE focus_sessions SET status = ?, pausedSeconds = ?, interruptions = ?, pausedAt = ?, endedAt = ?, reflectionNoteID = ? WHERE id = ?"
+	_, error := store.database.Exec(query, focusSession.Status, focusSession.PausedSeconds, focusSession.Interruptions, utcOrNil(focusSession.PausedAt), utcOrNil(focusSession.EndedAt), focusSession.ReflectionNoteID, id)
+	if error != nil {
+		return fmt.Errorf("failed to update focus session: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteFocusSessionByID deletes a focus session by its ID
+func (store *Store) DeleteFocusSessionByID(id uuid.UUID) error {
+	query := "DELETE FROM focus_sessions WHERE id = ?"
+	_, error := store.database.Exec(query, id)
+	if error != nil {
+		return fmt.Errorf("failed to delete focus session: %v", error)
+	}
+
+	return nil
+}
+
+// scanFocusSession scans a MySQL row into a new focus session object and computes its actual length
+func scanFocusSession(row interface{ Scan(...any) error }) (*focusSessionModel.FocusSession, error) {
+	focusSession := new(focusSessionModel.FocusSession)
+
+	error := row.Scan(&focusSession.ID, &focusSession.UserID, &focusSession.WorkspaceID, &focusSession.TaskID, &focusSession.LinkedProjectID, &focusSession.ReflectionNoteID, &focusSession.Status, &focusSession.PlannedLengthInSeconds, &focusSession.PausedSeconds, &focusSession.Interruptions, &focusSession.StartedAt, &focusSession.PausedAt, &focusSession.EndedAt)
+	if error != nil {
+		return nil, error
+	}
+
+	// paused time does not count towards the session, neither the past pauses nor the current one
+	endedAt := time.Now()
+	if focusSession.EndedAt != nil {
+		endedAt = *focusSession.EndedAt
+	} else if focusSession.PausedAt != nil {
+		endedAt = *focusSession.PausedAt
+	}
+	focusSession.ActualLengthInSeconds = max(int64(endedAt.Sub(focusSession.StartedAt).Seconds())-focusSession.PausedSeconds, 0)
+
+	return focusSession, nil
+}
+
+func utcOrNil(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return value.UTC()
+}
@@ -0,0 +1,251 @@
+package jobRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	jobModel "github.com/hwaengfan/dev-journal-backend/internal/models/job"
+)
+
+// columns selected for every job
+const jobColumns = "id, type, payload, status, attempts, maxAttempts, runAt, lockedBy, lockedUntil, lastError, dateCreated, dateCompleted"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateJob adds a pending job to the queue
+func (store *Store) CreateJob(job jobModel.Job) (uuid.UUID, error) {
+	jobID := uuid.New()
+
+	query := "INSERT INTO jobs (id, type, payload, maxAttempts, runAt) VALUES (?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, jobID, job.Type, []byte(job.Payload), job.MaxAttempts, job.RunAt.UTC())
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create job: %v", error)
+	}
+
+	return jobID, nil
+}
+
+// GetJobByID retrieves a job, nil when it does not exist
+func (store *Store) GetJobByID(id uuid.UUID) (*jobModel.Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id = ?"
+	return scanJobFromRow(store.database.QueryRow(query, id))
+}
+
+// GetJobs retrieves the latest jobs, newest first
+func (store *Store) GetJobs(filter jobModel.JobFilter) ([]*jobModel.Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs"
+	var conditions []string
+	var args []interface{}
+
+	// conditionally add filters
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY dateCreated DESC LIMIT ?", append(args, filter.Limit)...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get jobs: %v", error)
+	}
+	defer rows.Close()
+
+	jobs := make([]*jobModel.Job, 0)
+	for rows.Next() {
+		job := new(jobModel.Job)
+		if error := rows.Scan(&job.ID, &job.Type, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LockedBy, &job.LockedUntil, &job.LastError, &job.DateCreated, &job.DateCompleted); error != nil {
+			return nil, fmt.Errorf("failed to scan job from rows: %v", error)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// CountJobsByStatus counts the jobs in each status, statuses without jobs are counted as zero
+func (store *Store) CountJobsByStatus() (map[string]int, error) {
+	counts := make(map[string]int, len(jobModel.Statuses))
+	for _, status := range jobModel.Statuses {
+		counts[status] = 0
+	}
+
+	rows, error := store.database.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if error != nil {
+		return nil, fmt.Errorf("failed to count jobs: %v", error)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if error := rows.Scan(&status, &count); error != nil {
+			return nil, fmt.Errorf("failed to scan job count from rows: %v", error)
+		}
+
+		counts[status] = count
+	}
+
+	return counts, nil
+}
+
+// ClaimNextJob locks the next due job of the given types for a worker until lockedUntil, running jobs whose lock ran out are taken over
+func (store *Store) ClaimNextJob(types []string, workerID uuid.UUID, now time.Time, lockedUntil time.Time) (*jobModel.Job, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// skip jobs other workers are claiming at the same time
+	query := "SELECT " + jobColumns + " FROM jobs WHERE type IN (?" + strings.Repeat(", ?", len(types)-1) + ") AND ((status = 'PENDING' AND runAt <= ?) OR (status = 'RUNNING' AND lockedUntil < ?)) ORDER BY runAt LIMIT 1 FOR UPDATE SKIP LOCKED"
+	args := make([]interface{}, 0, len(types)+2)
+	for _, jobType := range types {
+		args = append(args, jobType)
+	}
+	args = append(args, now.UTC(), now.UTC())
+
+	job, error := scanJobFromRow(transaction.QueryRow(query, args...))
+	if error != nil || job == nil {
+		return nil, error
+	}
+
+	query = "UPDATE jobs SET status = 'RUNNING', attempts = attempts + 1, lockedBy = ?, lockedUntil = ? WHERE id = ?"
+	if _, error := transaction.Exec(query, workerID, lockedUntil.UTC(), job.ID); error != nil {
+		return nil, fmt.Errorf("failed to claim job: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	job.Status, job.Attempts = jobModel.StatusRunning, job.Attempts+1
+	job.LockedBy, job.LockedUntil = uuid.NullUUID{UUID: workerID, Valid: true}, &lockedUntil
+	return job, nil
+}
+
+// ExtendJobLock keeps a running job locked for its worker until lockedUntil, false once another worker took it over
+func (store *Store) ExtendJobLock(id uuid.UUID, workerID uuid.UUID, lockedUntil time.Time) (bool, error) {
+	query := "UPDATE jobs SET lockedUntil = ? WHERE id = ? AND status = 'RUNNING' AND lockedBy = ?"
+	result, error := store.database.Exec(query, lockedUntil.UTC(), id, workerID)
+	if error != nil {
+		return false, fmt.Errorf("failed to extend job lock: %v", error)
+	}
+
+	// the lock always moves forward so an unchanged row means the job was taken over or finished
+	extended, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to count extended job locks: %v", error)
+	}
+
+	return extended == 1, nil
+}
+
+// CompleteJob marks a job its worker still holds as succeeded
+func (store *Store) CompleteJob(id uuid.UUID, workerID uuid.UUID, completedAt time.Time) error {
+	query := "UPDATE jobs SET status = 'SUCCEEDED', lockedBy = NULL, lockedUntil = NULL, dateCompleted = ? WHERE id = ? AND lockedBy = ?"
+	_, error := store.database.Exec(query, completedAt.UTC(), id, workerID)
+	if error != nil {
+		return fmt.Errorf("failed to complete job: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteJob deletes a job its worker still holds, for jobs that are not kept once they succeeded
+func (store *Store) DeleteJob(id uuid.UUID, workerID uuid.UUID) error {
+	_, error := store.database.Exec("DELETE FROM jobs WHERE id = ? AND lockedBy = ?", id, workerID)
+	if error != nil {
+		return fmt.Errorf("failed to delete job: %v", error)
+	}
+
+	return nil
+}
+
+// RetryJob puts a failed job its worker still holds back in the queue to run again at runAt
+func (store *Store) RetryJob(id uuid.UUID, workerID uuid.UUID, runAt time.Time, message string) error {
+	query := "UPDATE jobs SET status = 'PENDING', lockedBy = NULL, lockedUntil = NULL, runAt = ?, lastError = ? WHERE id = ? AND lockedBy = ?"
+	_, error := store.database.Exec(query, runAt.UTC(), message, id, workerID)
+	if error != nil {
+		return fmt.Errorf("failed to retry job: %v", error)
+	}
+
+	return nil
+}
+
+// KillJob moves a job its worker still holds to the dead letters
+func (store *Store) KillJob(id uuid.UUID, workerID uuid.UUID, message string) error {
+	query := "UPDATE jobs SET status = 'DEAD', lockedBy = NULL, lockedUntil = NULL, lastError = ? WHERE id = ? AND lockedBy = ?"
+	_, error := store.database.Exec(query, message, id, workerID)
+	if error != nil {
+		return fmt.Errorf("failed to kill job: %v", error)
+	}
+
+	return nil
+}
+
+// RequeueDeadJob gives a dead job a fresh set of attempts starting at runAt, false when the job is not dead
+func (store *Store) RequeueDeadJob(id uuid.UUID, runAt time.Time) (bool, error) {
+	query := "UPDATE jobs SET status = 'PENDING', attempts = 0, runAt = ? WHERE id = ? AND status = 'DEAD'"
+	result, error := store.database.Exec(query, runAt.UTC(), id)
+	if error != nil {
+		return false, fmt.Errorf("failed to requeue job: %v", error)
+	}
+
+	requeued, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to count requeued jobs: %v", error)
+	}
+
+	return requeued == 1, nil
+}
+
+// DeleteSucceededJobs purges the jobs that succeeded before completedBefore
+func (store *Store) DeleteSucceededJobs(completedBefore time.Time) (int64, error) {
+	query := "DELETE FROM jobs WHERE status = 'SUCCEEDED' AND dateCompleted < ?"
+	result, error := store.database.Exec(query, completedBefore.UTC())
+	if error != nil {
+		return 0, fmt.Errorf("failed to delete succeeded jobs: %v", error)
+	}
+
+	deleted, error := result.RowsAffected()
+	if error != nil {
+		return 0, fmt.Errorf("failed to count deleted jobs: %v", error)
+	}
+
+	return deleted, nil
+}
+
+// scanJobFromRow scans a MySQL row into a job object, nil when there is none
+func scanJobFromRow(row *sql.Row) (*jobModel.Job, error) {
+	job := new(jobModel.Job)
+
+	error := row.Scan(&job.ID, &job.Type, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LockedBy, &job.LockedUntil, &job.LastError, &job.DateCreated, &job.DateCompleted)
+	if error == sql.ErrNoRows {
+		return nil, nil
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan job from row: %v", error)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,45 @@
+package jobLockRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// AcquireJobLock takes or extends the lock of a job for the lease, it fails while another holder's lease has not run out
+func (store *Store) AcquireJobLock(name string, holder uuid.UUID, lease time.Duration) (bool, error) {
+	// leases are compared against the clock of the database so servers with drifting clocks agree on them,
+	// the holder is updated first so the new lease is only set once the lock changed hands
+	query := "INSERT INTO job_locks (name, holder, lockedUntil) VALUES (?, ?, UTC_TIMESTAMP(3) + INTERVAL ? MICROSECOND) ON DUPLICATE KEY UPDATE holder = IF(holder = VALUES(holder) OR lockedUntil < UTC_TIMESTAMP(3), VALUES(holder), holder), lockedUntil = IF(holder = VALUES(holder), VALUES(lockedUntil), lockedUntil)"
+	if _, error := store.database.Exec(query, name, holder, lease.Microseconds()); error != nil {
+		return false, fmt.Errorf("failed to acquire job lock: %v", error)
+	}
+
+	var currentHolder uuid.UUID
+	query = "SELECT holder FROM job_locks WHERE name = ?"
+	if error := store.database.QueryRow(query, name).Scan(&currentHolder); error != nil {
+		return false, fmt.Errorf("failed to get job lock holder: %v", error)
+	}
+
+	return currentHolder == holder, nil
+}
+
+// ReleaseJobLock lets another server take the lock of a job right away, locks of other holders are left alone
+func (store *Store) ReleaseJobLock(name string, holder uuid.UUID) error {
+	query := "UPDATE job_locks SET lockedUntil = UTC_TIMESTAMP(3) WHERE name = ? AND holder = ?"
+	if _, error := store.database.Exec(query, name, holder); error != nil {
+		return fmt.Errorf("failed to release job lock: %v", error)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package loginAttemptRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetLockedUntil retrieves until when a key is locked, nil when it is not
+func (store *Store) GetLockedUntil(key string) (*time.Time, error) {
+	var lockedUntil *time.Time
+	error := store.database.QueryRow("SELECT lockedUntil FROM login_attempts WHERE attemptKey = ?", key).Scan(&lockedUntil)
+	if error != nil && error != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get login attempts: %v", error)
+	}
+
+	return lockedUntil, nil
+}
+
+// IncrementFailures counts a failed attempt for a key and returns the failures so far, counting starts over when the last failure is older than resetAfter
+func (store *Store) IncrementFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	// failures is assigned first so it still sees the previous lastFailureAt
+	query := "INSERT INTO login_attempts (attemptKey, failures, lastFailureAt) VALUES (?, 1, ?) ON DUPLICATE KEY UPDATE failures = IF(lastFailureAt < ?, 1, failures + 1), lastFailureAt = VALUES(lastFailureAt)"
+	if _, error := store.database.Exec(query, key, now.UTC(), now.Add(-resetAfter).UTC()); error != nil {
+		return 0, fmt.Errorf("failed to record failed login: %v", error)
+	}
+
+	var failures int
+	if error := store.database.QueryRow("SELECT failures FROM login_attempts WHERE attemptKey = ?", key).Scan(&failures); error != nil {
+		return 0, fmt.Errorf("failed to get login attempts: %v", error)
+	}
+
+	return failures, nil
+}
+
+// SetLockedUntil locks a key until a time
+func (store *Store) SetLockedUntil(key string, lockedUntil time.Time) error {
+	_, error := store.database.Exec("UPDATE login_attempts SET lockedUntil = ? WHERE attemptKey = ?", lockedUntil.UTC(), key)
+	if error != nil {
+		return fmt.Errorf("failed to lock login: %v", error)
+	}
+
+	return nil
+}
+
+// ResetAttempts clears the failures and lock of a key
+func (store *Store) ResetAttempts(key string) error {
+	_, error := store.database.Exec("DELETE FROM login_attempts WHERE attemptKey = ?", key)
+	if error != nil {
+		return fmt.Errorf("failed to reset login attempts: %v", error)
+	}
+
+	return nil
+}
+
+// CreateLoginAuditEvent records a login event
+func (store *Store) CreateLoginAuditEvent(event loginAttemptModel.LoginAuditEvent) error {
+	query := "INSERT INTO login_audit_events (id, userID, email, ipAddress, event) VALUES (?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, uuid.New(), event.UserID, event.Email, event.IPAddress, event.Event)
+	if error != nil {
+		return fmt.Errorf("failed to create login audit event: %v", error)
+	}
+
+	return nil
+}
+
+// GetLoginAuditEventsByUserID retrieves the latest login events of a user, newest first
+func (store *Store) GetLoginAuditEventsByUserID(userID uuid.UUID, limit int) ([]*loginAttemptModel.LoginAuditEvent, error) {
+	query := "SELECT id, userID, email, ipAddress, event, dateCreated FROM login_audit_events WHERE userID = ? ORDER BY dateCreated DESC LIMIT ?"
+	rows, error := store.database.Query(query, userID, limit)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get login audit events: %v", error)
+	}
+	defer rows.Close()
+
+	events := make([]*loginAttemptModel.LoginAuditEvent, 0)
+	for rows.Next() {
+		event := new(loginAttemptModel.LoginAuditEvent)
+		if error := rows.Scan(&event.ID, &event.UserID, &event.Email, &event.IPAddress, &event.Event, &event.DateCreated); error != nil {
+			return nil, fmt.Errorf("failed to scan login audit event from rows: %v", error)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
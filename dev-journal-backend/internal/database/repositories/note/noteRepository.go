@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
@@ -40,7 +41,7 @@ func (store *Store) CreateNote(note noteModel.Note) (uuid.UUID, error) {
 // GetNotesByLinkedProjectID retrieves all notes by a linked project's ID
 func (store *Store) GetNotesByLinkedProjectID(linkedProjectID uuid.UUID) ([]*noteModel.Note, error) {
 	// query notes by linked project ID
-	query := "SELECT id, linkedProjectID, title, content, favorited, tags, dateCreated, lastEdited FROM notes WHERE linkedProjectID = ?"
+	query := "SELECT id, userID, linkedProjectID, title, content, favorited, tags, dateCreated, lastEdited FROM notes WHERE linkedProjectID = ?"
 	rows, error := store.database.Query(query, linkedProjectID)
 	if error != nil {
 		return nil, fmt.Errorf("failed to get notes by linked project ID: %v", error)
@@ -56,10 +57,34 @@ func (store *Store) GetNotesByLinkedProjectID(linkedProjectID uuid.UUID) ([]*not
 	return notes, nil
 }
 
+// GetNotesEditedByUserID retrieves the notes of a user last edited within a range, most recent first, only those in workspaceID when it is valid
+func (store *Store) GetNotesEditedByUserID(userID uuid.UUID, workspaceID uuid.NullUUID, from time.Time, to time.Time) ([]*noteModel.Note, error) {
+	query := "SELECT notes.id, notes.userID, notes.linkedProjectID, notes.title, notes.content, notes.favorited, notes.tags, notes.dateCreated, notes.lastEdited FROM notes JOIN projects ON projects.id = notes.linkedProjectID WHERE notes.userID = ? AND notes.lastEdited >= ? AND notes.lastEdited < ?"
+	args := []interface{}{userID, from.UTC(), to.UTC()}
+	if workspaceID.Valid {
+		query += " AND projects.workspaceID = ?"
+		args = append(args, workspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY notes.lastEdited DESC", args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get notes edited by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	// scan notes from rows
+	notes, error := scanNotesFromRows(rows)
+	if error != nil {
+		return nil, error
+	}
+
+	return notes, nil
+}
+
 // GetNoteByID retrieves a note by its ID
 func (store *Store) GetNoteByID(id uuid.UUID) (*noteModel.Note, error) {
 	// query note by ID
-	query := "SELECT id, linkedProjectID, title, content, favorited, tags, dateCreated, lastEdited FROM notes WHERE id = ?"
+	query := "SELECT id, userID, linkedProjectID, title, content, favorited, tags, dateCreated, lastEdited FROM notes WHERE id = ?"
 	row := store.database.QueryRow(query, id)
 
 	// scan note from row
@@ -153,7 +178,7 @@ func scanNotesFromRows(rows *sql.Rows) ([]*noteModel.Note, error) {
 		note := new(noteModel.Note)
 		var tagsJSONString string
 
-		error := rows.Scan(&note.ID, &note.LinkedProjectID, &note.Title, &note.Content, &note.Favorited, &tagsJSONString, &note.DateCreated, &note.LastEdited)
+		error := rows.Scan(&note.ID, &note.UserID, &note.LinkedProjectID, &note.Title, &note.Content, &note.Favorited, &tagsJSONString, &note.DateCreated, &note.LastEdited)
 		if error != nil {
 			return nil, fmt.Errorf("failed to scan note from rows: %v", error)
 		}
@@ -173,7 +198,7 @@ func scanNotesFromRows(rows *sql.Rows) ([]*noteModel.Note, error) {
 func scanNoteFromRow(row *sql.Row) (*noteModel.Note, error) {
 	note := new(noteModel.Note)
 	var tagsJSONString string
-	error := row.Scan(&note.ID, &note.LinkedProjectID, &note.Title, &note.Content, &note.Favorited, &tagsJSONString, &note.DateCreated, &note.LastEdited)
+	error := row.Scan(&note.ID, &note.UserID, &note.LinkedProjectID, &note.Title, &note.Content, &note.Favorited, &tagsJSONString, &note.DateCreated, &note.LastEdited)
 
 	if error == sql.ErrNoRows {
 		return nil, fmt.Errorf("note not found")
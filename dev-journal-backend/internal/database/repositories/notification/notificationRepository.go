@@ -0,0 +1,184 @@
+package notificationRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+)
+
+const notificationQuery = "SELECT notifications.id, notifications.userID, notifications.type, notifications.actorID, COALESCE(users.firstName, ''), COALESCE(users.lastName, ''), notifications.projectID, notifications.entityType, notifications.entityID, notifications.message, notifications.readAt, notifications.dateCreated FROM notifications LEFT JOIN users ON users.id = notifications.actorID"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateNotification adds a notification to the inbox of a user
+func (store *Store) CreateNotification(notification notificationModel.Notification) (uuid.UUID, error) {
+	notificationID := uuid.New()
+
+	query := "INSERT INTO notifications (id, userID, type, actorID, projectID, entityType, entityID, message) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, notificationID, notification.UserID, notification.Type, notification.ActorID, notification.ProjectID, notification.EntityType, notification.EntityID, notification.Message)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create notification: %v", error)
+	}
+
+	return notificationID, nil
+}
+
+// GetNotificationByID retrieves a notification, nil when it does not exist
+func (store *Store) GetNotificationByID(id uuid.UUID) (*notificationModel.Notification, error) {
+	notifications, error := store.getNotifications(notificationQuery+" WHERE notifications.id = ?", id)
+	if error != nil {
+		return nil, error
+	}
+
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+
+	return notifications[0], nil
+}
+
+// GetNotificationsByUserID retrieves the latest notifications in the inbox of a user, newest first
+func (store *Store) GetNotificationsByUserID(userID uuid.UUID, filter notificationModel.NotificationFilter) ([]*notificationModel.Notification, error) {
+	query := notificationQuery + " WHERE notifications.userID = ?"
+	args := []interface{}{userID}
+
+	// conditionally add filters
+	if filter.Unread {
+		query += " AND notifications.readAt IS NULL"
+	}
+	if filter.Type != "" {
+		query += " AND notifications.type = ?"
+		args = append(args, filter.Type)
+	}
+
+	return store.getNotifications(query+" ORDER BY notifications.dateCreated DESC LIMIT ?", append(args, filter.Limit)...)
+}
+
+// CountUnreadNotificationsByUserID counts the notifications a user has not read yet
+func (store *Store) CountUnreadNotificationsByUserID(userID uuid.UUID) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM notifications WHERE userID = ? AND readAt IS NULL"
+	if error := store.database.QueryRow(query, userID).Scan(&count); error != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %v", error)
+	}
+
+	return count, nil
+}
+
+// SetNotificationReadByID marks a notification as read, or as unread again
+func (store *Store) SetNotificationReadByID(id uuid.UUID, read bool) error {
+	var readAt *time.Time
+	if read {
+		now := time.Now().UTC()
+		readAt = &now
+	}
+
+	// a notification read before keeps the time it was first read
+	query := "UPDATE notifications SET readAt = IF(? IS NULL, NULL, COALESCE(readAt, ?)) WHERE id = ?"
+	_, error := store.database.Exec(query, readAt, readAt, id)
+	if error != nil {
+		return fmt.Errorf("failed to set notification read: %v", error)
+	}
+
+	return nil
+}
+
+// MarkNotificationsReadByUserID marks the given unread notifications of a user as read, or all of them when no IDs are given
+func (store *Store) MarkNotificationsReadByUserID(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	query := "UPDATE notifications SET readAt = ? WHERE userID = ? AND readAt IS NULL"
+	args := []interface{}{time.Now().UTC(), userID}
+
+	if len(ids) > 0 {
+		query += " AND id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	result, error := store.database.Exec(query, args...)
+	if error != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %v", error)
+	}
+
+	marked, error := result.RowsAffected()
+	if error != nil {
+		return 0, fmt.Errorf("failed to count notifications marked read: %v", error)
+	}
+
+	return marked, nil
+}
+
+// GetNotificationPreferencesByUserID retrieves the preference of a user for every notification type, types never chosen get the defaults
+func (store *Store) GetNotificationPreferencesByUserID(userID uuid.UUID) ([]*notificationModel.NotificationPreference, error) {
+	preferences := make([]*notificationModel.NotificationPreference, 0, len(notificationModel.Types))
+	preferencesByType := make(map[string]*notificationModel.NotificationPreference, len(notificationModel.Types))
+	for _, notificationType := range notificationModel.Types {
+		preference := &notificationModel.NotificationPreference{Type: notificationType, InApp: "True", Email: "False"}
+		preferences = append(preferences, preference)
+		preferencesByType[notificationType] = preference
+	}
+
+	query := "SELECT type, inApp, email FROM notification_preferences WHERE userID = ?"
+	rows, error := store.database.Query(query, userID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", error)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType, inApp, email string
+		if error := rows.Scan(&notificationType, &inApp, &email); error != nil {
+			return nil, fmt.Errorf("failed to scan notification preference from rows: %v", error)
+		}
+
+		if preference, exists := preferencesByType[notificationType]; exists {
+			preference.InApp = inApp
+			preference.Email = email
+		}
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreferenceByUserID sets how a user receives one type of notification
+func (store *Store) SetNotificationPreferenceByUserID(userID uuid.UUID, preference notificationModel.NotificationPreference) error {
+	query := "INSERT INTO notification_preferences (userID, type, inApp, email) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE inApp = VALUES(inApp), email = VALUES(email)"
+	_, error := store.database.Exec(query, userID, preference.Type, preference.InApp, preference.Email)
+	if error != nil {
+		return fmt.Errorf("failed to set notification preference: %v", error)
+	}
+
+	return nil
+}
+
+// getNotifications scans the notifications a query returns
+func (store *Store) getNotifications(query string, args ...any) ([]*notificationModel.Notification, error) {
+	rows, error := store.database.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get notifications: %v", error)
+	}
+	defer rows.Close()
+
+	notifications := make([]*notificationModel.Notification, 0)
+	for rows.Next() {
+		notification := new(notificationModel.Notification)
+		error := rows.Scan(&notification.ID, &notification.UserID, &notification.Type, &notification.ActorID, &notification.FirstName, &notification.LastName, &notification.ProjectID, &notification.EntityType, &notification.EntityID, &notification.Message, &notification.ReadAt, &notification.DateCreated)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan notification from rows: %v", error)
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
@@ -17,33 +17,60 @@ func NewStore(database *sql.DB) *Store {
 	return &Store{database: database}
 }
 
-// CreateProject creates a new project
+// CreateProject creates a new project in a workspace owned by its creator
 func (store *Store) CreateProject(project projectModel.Project) (uuid.UUID, error) {
 	projectID := uuid.New()
 
-	query := "INSERT INTO projects (id, userID, title, description, priority, deadline) VALUES (?, ?, ?, ?, ?, ?)"
-	_, error := store.database.Exec(query, projectID, project.UserID, project.Title, project.Description, project.Priority, project.Deadline)
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	query := "INSERT INTO projects (id, userID, workspaceID, title, description, priority, deadline) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, error = transaction.Exec(query, projectID, project.UserID, project.WorkspaceID, project.Title, project.Description, project.Priority, project.Deadline)
 	if error != nil {
 		return uuid.Nil, fmt.Errorf("failed to create project: %v", error)
 	}
 
+	if _, error := transaction.Exec("INSERT INTO project_members (projectID, userID, role) VALUES (?, ?, 'OWNER')", projectID, project.UserID); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to add project owner: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
 	return projectID, nil
 }
 
-// GetProjectsByUserID retrieves all projects by a user's ID
-func (store *Store) GetProjectsByUserID(userID uuid.UUID) ([]*projectModel.Project, error) {
-	// query projects by user ID
-	query := "SELECT id, title, description, priority, deadline, dateCreated, lastEdited FROM projects WHERE userID = ?"
-	rows, error := store.database.Query(query, userID)
+// GetProjectsByUserID retrieves the projects a user owns or was invited to, along with their role in each, only those in workspaceID when it is valid
+func (store *Store) GetProjectsByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*projectModel.Project, error) {
+	// query projects by membership
+	query := "SELECT projects.id, projects.userID, projects.workspaceID, projects.title, projects.description, projects.priority, projects.deadline, projects.dateCreated, projects.lastEdited, members.role FROM projects JOIN project_members members ON members.projectID = projects.id WHERE members.userID = ?"
+	args := []interface{}{userID}
+	if workspaceID.Valid {
+		query += " AND projects.workspaceID = ?"
+		args = append(args, workspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query, args...)
 	if error != nil {
 		return nil, fmt.Errorf("failed to get projects by user ID: %v", error)
 	}
 	defer rows.Close()
 
-	// scan projects from rows
-	projects, error := scanProjectsFromRows(rows)
-	if error != nil {
-		return nil, error
+	// scan projects with roles from rows
+	projects := make([]*projectModel.Project, 0)
+	for rows.Next() {
+		project := new(projectModel.Project)
+
+		error := rows.Scan(&project.ID, &project.UserID, &project.WorkspaceID, &project.Title, &project.Description, &project.Priority, &project.Deadline, &project.DateCreated, &project.LastEdited, &project.Role)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan project from rows: %v", error)
+		}
+
+		projects = append(projects, project)
 	}
 
 	return projects, nil
@@ -52,7 +79,7 @@ func (store *Store) GetProjectsByUserID(userID uuid.UUID) ([]*projectModel.Proje
 // GetProjectByID retrieves a project by its ID
 func (store *Store) GetProjectByID(id uuid.UUID) (*projectModel.Project, error) {
 	// query project by ID
-	query := "SELECT id, title, description, priority, deadline, dateCreated, lastEdited FROM projects WHERE id = ?"
+	query := "SELECT id, userID, workspaceID, title, description, priority, deadline, dateCreated, lastEdited FROM projects WHERE id = ?"
 	row := store.database.QueryRow(query, id)
 
 	// scan project from row
@@ -118,27 +145,10 @@ func (store *Store) DeleteProjectByID(id uuid.UUID) error {
 	return nil
 }
 
-// scanProjectsFromRows scans MySQL rows into a slice of project objects
-func scanProjectsFromRows(rows *sql.Rows) ([]*projectModel.Project, error) {
-	projects := make([]*projectModel.Project, 0)
-	for rows.Next() {
-		project := new(projectModel.Project)
-
-		error := rows.Scan(&project.ID, &project.Title, &project.Description, &project.Priority, &project.Deadline, &project.DateCreated, &project.LastEdited)
-		if error != nil {
-			return nil, fmt.Errorf("failed to scan project from rows: %v", error)
-		}
-
-		projects = append(projects, project)
-	}
-
-	return projects, nil
-}
-
 // scanProjectFromRow scans a MySQL row into a new project object
 func scanProjectFromRow(row *sql.Row) (*projectModel.Project, error) {
 	project := new(projectModel.Project)
-	error := row.Scan(&project.ID, &project.Title, &project.Description, &project.Priority, &project.Deadline, &project.DateCreated, &project.LastEdited)
+	error := row.Scan(&project.ID, &project.UserID, &project.WorkspaceID, &project.Title, &project.Description, &project.Priority, &project.Deadline, &project.DateCreated, &project.LastEdited)
 
 	if error == sql.ErrNoRows {
 		return nil, fmt.Errorf("project not found")
@@ -0,0 +1,279 @@
+package projectMemberRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+)
+
+// invitations are selected together with the title of their project
+const invitationQuery = "SELECT invitations.id, invitations.projectID, projects.title, invitations.email, invitations.role, invitations.invitedBy, invitations.status, invitations.expiresAt, invitations.respondedAt, invitations.dateCreated FROM project_invitations invitations JOIN projects ON projects.id = invitations.projectID"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetProjectRole retrieves the role of a user in a project, empty when the user is not a member or the project is in another workspace
+func (store *Store) GetProjectRole(projectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID) (string, error) {
+	var role string
+	query := "SELECT members.role FROM project_members members JOIN projects ON projects.id = members.projectID WHERE members.projectID = ? AND members.userID = ? AND projects.workspaceID = ?"
+	error := store.database.QueryRow(query, projectID, userID, workspaceID).Scan(&role)
+	if error == sql.ErrNoRows {
+		return "", nil
+	} else if error != nil {
+		return "", fmt.Errorf("failed to get project role: %v", error)
+	}
+
+	return role, nil
+}
+
+// GetProjectMembers retrieves the members of a project with their names, owners first
+func (store *Store) GetProjectMembers(projectID uuid.UUID) ([]*projectMemberModel.ProjectMember, error) {
+	query := "SELECT members.projectID, members.userID, users.firstName, users.lastName, users.email, members.role, members.dateCreated FROM project_members members JOIN users ON users.id = members.userID WHERE members.projectID = ? ORDER BY FIELD(members.role, 'OWNER', 'EDITOR', 'VIEWER'), members.dateCreated"
+	rows, error := store.database.Query(query, projectID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get project members: %v", error)
+	}
+	defer rows.Close()
+
+	members := make([]*projectMemberModel.ProjectMember, 0)
+	for rows.Next() {
+		member := new(projectMemberModel.ProjectMember)
+		if error := rows.Scan(&member.ProjectID, &member.UserID, &member.FirstName, &member.LastName, &member.Email, &member.Role, &member.DateCreated); error != nil {
+			return nil, fmt.Errorf("failed to scan project member from rows: %v", error)
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// UpdateProjectMemberRole changes the role of a member, the last owner cannot be demoted
+func (store *Store) UpdateProjectMemberRole(projectID uuid.UUID, userID uuid.UUID, role string) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if role != projectMemberModel.RoleOwner {
+		if error := ensureAnotherOwner(transaction, projectID, userID); error != nil {
+			return error
+		}
+	}
+
+	if _, error := transaction.Exec("UPDATE project_members SET role = ? WHERE projectID = ? AND userID = ?", role, projectID, userID); error != nil {
+		return fmt.Errorf("failed to update project member role: %v", error)
+	}
+
+	if error := handOverProject(transaction, projectID); error != nil {
+		return error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// RemoveProjectMember removes a member from a project, the last owner cannot be removed
+func (store *Store) RemoveProjectMember(projectID uuid.UUID, userID uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if error := ensureAnotherOwner(transaction, projectID, userID); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec("DELETE FROM project_members WHERE projectID = ? AND userID = ?", projectID, userID); error != nil {
+		return fmt.Errorf("failed to remove project member: %v", error)
+	}
+
+	if error := handOverProject(transaction, projectID); error != nil {
+		return error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// CreateProjectInvitation stores an invitation to join a project
+func (store *Store) CreateProjectInvitation(invitation projectMemberModel.ProjectInvitation) (uuid.UUID, error) {
+	id := uuid.New()
+
+	query := "INSERT INTO project_invitations (id, projectID, email, role, invitedBy, expiresAt) VALUES (?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, id, invitation.ProjectID, strings.ToLower(invitation.Email), invitation.Role, invitation.InvitedBy, invitation.ExpiresAt.UTC())
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create project invitation: %v", error)
+	}
+
+	return id, nil
+}
+
+// GetProjectInvitationByID retrieves an invitation by its ID, nil when it does not exist
+func (store *Store) GetProjectInvitationByID(id uuid.UUID) (*projectMemberModel.ProjectInvitation, error) {
+	rows, error := store.database.Query(invitationQuery+" WHERE invitations.id = ?", id)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get project invitation: %v", error)
+	}
+	defer rows.Close()
+
+	invitations, error := scanInvitationsFromRows(rows)
+	if error != nil || len(invitations) == 0 {
+		return nil, error
+	}
+
+	return invitations[0], nil
+}
+
+// GetPendingProjectInvitationsByProjectID retrieves the unanswered, unexpired invitations of a project
+func (store *Store) GetPendingProjectInvitationsByProjectID(projectID uuid.UUID) ([]*projectMemberModel.ProjectInvitation, error) {
+	query := invitationQuery + " WHERE invitations.projectID = ? AND invitations.status = 'PENDING' AND invitations.expiresAt > ? ORDER BY invitations.dateCreated DESC"
+	rows, error := store.database.Query(query, projectID, time.Now().UTC())
+	if error != nil {
+		return nil, fmt.Errorf("failed to get project invitations: %v", error)
+	}
+	defer rows.Close()
+
+	return scanInvitationsFromRows(rows)
+}
+
+// GetPendingProjectInvitationsByEmail retrieves the unanswered, unexpired invitations sent to an email
+func (store *Store) GetPendingProjectInvitationsByEmail(email string) ([]*projectMemberModel.ProjectInvitation, error) {
+	query := invitationQuery + " WHERE invitations.email = ? AND invitations.status = 'PENDING' AND invitations.expiresAt > ? ORDER BY invitations.dateCreated DESC"
+	rows, error := store.database.Query(query, strings.ToLower(email), time.Now().UTC())
+	if error != nil {
+		return nil, fmt.Errorf("failed to get project invitations: %v", error)
+	}
+	defer rows.Close()
+
+	return scanInvitationsFromRows(rows)
+}
+
+// AcceptProjectInvitation adds the user to the project of a pending invitation with the invited role, and to its workspace if needed
+func (store *Store) AcceptProjectInvitation(id uuid.UUID, userID uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	var projectID uuid.UUID
+	var role string
+	query := "SELECT projectID, role FROM project_invitations WHERE id = ? AND status = 'PENDING' AND expiresAt > ? FOR UPDATE"
+	error = transaction.QueryRow(query, id, time.Now().UTC()).Scan(&projectID, &role)
+	if error == sql.ErrNoRows {
+		return projectMemberModel.ErrInvitationNotPending
+	} else if error != nil {
+		return fmt.Errorf("failed to get project invitation: %v", error)
+	}
+
+	var count int
+	if error := transaction.QueryRow("SELECT COUNT(*) FROM project_members WHERE projectID = ? AND userID = ?", projectID, userID).Scan(&count); error != nil {
+		return fmt.Errorf("failed to get project member: %v", error)
+	}
+	if count > 0 {
+		return projectMemberModel.ErrAlreadyProjectMember
+	}
+
+	if _, error := transaction.Exec("INSERT INTO project_members (projectID, userID, role) VALUES (?, ?, ?)", projectID, userID, role); error != nil {
+		return fmt.Errorf("failed to add project member: %v", error)
+	}
+
+	if _, error := transaction.Exec("UPDATE project_invitations SET status = 'ACCEPTED', respondedAt = ? WHERE id = ?", time.Now().UTC(), id); error != nil {
+		return fmt.Errorf("failed to accept project invitation: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// SetProjectInvitationStatus declines or revokes a pending invitation
+func (store *Store) SetProjectInvitationStatus(id uuid.UUID, status string) error {
+	result, error := store.database.Exec("UPDATE project_invitations SET status = ?, respondedAt = ? WHERE id = ? AND status = 'PENDING'", status, time.Now().UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to update project invitation: %v", error)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return projectMemberModel.ErrInvitationNotPending
+	}
+
+	return nil
+}
+
+// ensureAnotherOwner fails when a user is the only owner of a project, so a project is never left without one
+func ensureAnotherOwner(transaction *sql.Tx, projectID uuid.UUID, userID uuid.UUID) error {
+	// lock the owners so two owners cannot demote each other at the same time
+	rows, error := transaction.Query("SELECT userID FROM project_members WHERE projectID = ? AND role = 'OWNER' FOR UPDATE", projectID)
+	if error != nil {
+		return fmt.Errorf("failed to get project owners: %v", error)
+	}
+	defer rows.Close()
+
+	isOwner, otherOwners := false, 0
+	for rows.Next() {
+		var ownerID uuid.UUID
+		if error := rows.Scan(&ownerID); error != nil {
+			return fmt.Errorf("failed to scan project owner from rows: %v", error)
+		}
+
+		if ownerID == userID {
+			isOwner = true
+		} else {
+			otherOwners++
+		}
+	}
+
+	if isOwner && otherOwners == 0 {
+		return projectMemberModel.ErrLastOwner
+	}
+
+	return nil
+}
+
+// handOverProject moves projects.userID to the longest standing owner once the user it points to is no longer an owner
+func handOverProject(transaction *sql.Tx, projectID uuid.UUID) error {
+	query := "UPDATE projects SET userID = (SELECT userID FROM project_members WHERE projectID = ? AND role = 'OWNER' ORDER BY dateCreated LIMIT 1) WHERE id = ? AND userID NOT IN (SELECT userID FROM project_members WHERE projectID = ? AND role = 'OWNER')"
+	if _, error := transaction.Exec(query, projectID, projectID, projectID); error != nil {
+		return fmt.Errorf("failed to hand over project: %v", error)
+	}
+
+	return nil
+}
+
+// scanInvitationsFromRows scans MySQL rows into a slice of invitation objects
+func scanInvitationsFromRows(rows *sql.Rows) ([]*projectMemberModel.ProjectInvitation, error) {
+	invitations := make([]*projectMemberModel.ProjectInvitation, 0)
+	for rows.Next() {
+		invitation := new(projectMemberModel.ProjectInvitation)
+
+		error := rows.Scan(&invitation.ID, &invitation.ProjectID, &invitation.ProjectTitle, &invitation.Email, &invitation.Role, &invitation.InvitedBy, &invitation.Status, &invitation.ExpiresAt, &invitation.RespondedAt, &invitation.DateCreated)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan project invitation from rows: %v", error)
+		}
+
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,88 @@
+package reminderRepository
+
+import (
+	"database/sql"
+	"fmt"
+
+	reminderModel "github.com/hwaengfan/dev-journal-backend/internal/models/reminder"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetProjectDeadlines retrieves the projects with open tasks due between from and to, once for each of their members
+func (store *Store) GetProjectDeadlines(from string, to string) ([]*reminderModel.Deadline, error) {
+	query := "SELECT 'PROJECT', projects.id, projects.id, projects.title, projects.deadline, users.id, users.timezone FROM projects JOIN project_members members ON members.projectID = projects.id JOIN users ON users.id = members.userID WHERE projects.deadline BETWEEN ? AND ? AND EXISTS (SELECT 1 FROM tasks WHERE tasks.linkedProjectID = projects.id AND tasks.completed = 'False')"
+	return store.getDeadlines(query, from, to)
+}
+
+// GetTaskDeadlines retrieves the open tasks due between from and to, once for their assignee or for each owner of their project when unassigned
+func (store *Store) GetTaskDeadlines(from string, to string) ([]*reminderModel.Deadline, error) {
+	query := "SELECT 'TASK', tasks.id, tasks.linkedProjectID, tasks.description, tasks.dueDate, users.id, users.timezone FROM tasks JOIN project_members members ON members.projectID = tasks.linkedProjectID AND (members.userID = tasks.assigneeID OR (tasks.assigneeID IS NULL AND members.role = 'OWNER')) JOIN users ON users.id = members.userID WHERE tasks.completed = 'False' AND tasks.dueDate BETWEEN ? AND ?"
+	return store.getDeadlines(query, from, to)
+}
+
+// ClaimReminder records a reminder as sent, false when it was sent before
+func (store *Store) ClaimReminder(reminder reminderModel.Reminder) (bool, error) {
+	query := "INSERT IGNORE INTO deadline_reminders (entityType, entityID, userID, dueDate, windowInHours) VALUES (?, ?, ?, ?, ?)"
+	result, error := store.database.Exec(query, reminder.EntityType, reminder.EntityID, reminder.UserID, reminder.DueDate, reminder.WindowInHours)
+	if error != nil {
+		return false, fmt.Errorf("failed to claim reminder: %v", error)
+	}
+
+	claimed, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to count claimed reminders: %v", error)
+	}
+
+	return claimed == 1, nil
+}
+
+// ReleaseReminder forgets a claimed reminder that could not be sent so it is tried again
+func (store *Store) ReleaseReminder(reminder reminderModel.Reminder) error {
+	query := "DELETE FROM deadline_reminders WHERE entityType = ? AND entityID = ? AND userID = ? AND dueDate = ? AND windowInHours = ?"
+	_, error := store.database.Exec(query, reminder.EntityType, reminder.EntityID, reminder.UserID, reminder.DueDate, reminder.WindowInHours)
+	if error != nil {
+		return fmt.Errorf("failed to release reminder: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteRemindersDueBefore forgets the reminders of deadlines that have passed
+func (store *Store) DeleteRemindersDueBefore(date string) error {
+	query := "DELETE FROM deadline_reminders WHERE dueDate < ?"
+	_, error := store.database.Exec(query, date)
+	if error != nil {
+		return fmt.Errorf("failed to delete past reminders: %v", error)
+	}
+
+	return nil
+}
+
+// getDeadlines scans the deadlines a query returns
+func (store *Store) getDeadlines(query string, args ...any) ([]*reminderModel.Deadline, error) {
+	rows, error := store.database.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get deadlines: %v", error)
+	}
+	defer rows.Close()
+
+	deadlines := make([]*reminderModel.Deadline, 0)
+	for rows.Next() {
+		deadline := new(reminderModel.Deadline)
+		error := rows.Scan(&deadline.EntityType, &deadline.EntityID, &deadline.ProjectID, &deadline.Title, &deadline.DueDate, &deadline.UserID, &deadline.Timezone)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan deadline from rows: %v", error)
+		}
+
+		deadlines = append(deadlines, deadline)
+	}
+
+	return deadlines, nil
+}
@@ -0,0 +1,52 @@
+package reportRepository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetStandupTemplateByUserID retrieves the standup template a user configured
+func (store *Store) GetStandupTemplateByUserID(userID uuid.UUID) (string, error) {
+	var template string
+	query := "SELECT template FROM standup_templates WHERE userID = ?"
+	error := store.database.QueryRow(query, userID).Scan(&template)
+	if error == sql.ErrNoRows {
+		return "", fmt.Errorf("standup template not found")
+	} else if error != nil {
+		return "", fmt.Errorf("failed to get standup template: %v", error)
+	}
+
+	return template, nil
+}
+
+// SetStandupTemplateByUserID creates or replaces the standup template of a user
+func (store *Store) SetStandupTemplateByUserID(userID uuid.UUID, template string) error {
+	query := "INSERT INTO standup_templates (userID, template) VALUES (?, ?) ON DUPLICATE KEY UPDATE template = VALUES(template)"
+	_, error := store.database.Exec(query, userID, template)
+	if error != nil {
+		return fmt.Errorf("failed to set standup template: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteStandupTemplateByUserID deletes the standup template of a user, reports fall back to the default template
+func (store *Store) DeleteStandupTemplateByUserID(userID uuid.UUID) error {
+	query := "DELETE FROM standup_templates WHERE userID = ?"
+	_, error := store.database.Exec(query, userID)
+	if error != nil {
+		return fmt.Errorf("failed to delete standup template: %v", error)
+	}
+
+	return nil
+}
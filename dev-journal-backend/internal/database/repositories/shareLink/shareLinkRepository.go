@@ -0,0 +1,114 @@
+package shareLinkRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	shareLinkModel "github.com/hwaengfan/dev-journal-backend/internal/models/shareLink"
+)
+
+const shareLinkColumns = "id, tokenHash, projectID, noteID, createdBy, passwordHash, expiresAt, revokedAt, viewCount, lastViewedAt, dateCreated"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateShareLink stores a new share link
+func (store *Store) CreateShareLink(shareLink shareLinkModel.ShareLink) (uuid.UUID, error) {
+	id := uuid.New()
+
+	var expiresAt *time.Time
+	if shareLink.ExpiresAt != nil {
+		utc := shareLink.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	query := "INSERT INTO share_links (id, tokenHash, projectID, noteID, createdBy, passwordHash, expiresAt) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, id, shareLink.TokenHash, shareLink.ProjectID, shareLink.NoteID, shareLink.CreatedBy, shareLink.PasswordHash, expiresAt)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create share link: %v", error)
+	}
+
+	return id, nil
+}
+
+// GetShareLinkByID retrieves a share link by its ID, nil when it does not exist
+func (store *Store) GetShareLinkByID(id uuid.UUID) (*shareLinkModel.ShareLink, error) {
+	return scanShareLinkFromRow(store.database.QueryRow("SELECT "+shareLinkColumns+" FROM share_links WHERE id = ?", id))
+}
+
+// GetShareLinkByTokenHash retrieves the share link of a token, nil when it does not exist
+func (store *Store) GetShareLinkByTokenHash(tokenHash string) (*shareLinkModel.ShareLink, error) {
+	return scanShareLinkFromRow(store.database.QueryRow("SELECT "+shareLinkColumns+" FROM share_links WHERE tokenHash = ?", tokenHash))
+}
+
+// GetShareLinksByProjectID retrieves the share links of a project and its notes, newest first
+func (store *Store) GetShareLinksByProjectID(projectID uuid.UUID) ([]*shareLinkModel.ShareLink, error) {
+	rows, error := store.database.Query("SELECT "+shareLinkColumns+" FROM share_links WHERE projectID = ? ORDER BY dateCreated DESC", projectID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get share links by project ID: %v", error)
+	}
+	defer rows.Close()
+
+	shareLinks := make([]*shareLinkModel.ShareLink, 0)
+	for rows.Next() {
+		shareLink, error := scanShareLink(rows)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan share link from rows: %v", error)
+		}
+
+		shareLinks = append(shareLinks, shareLink)
+	}
+
+	return shareLinks, nil
+}
+
+// RevokeShareLink stops a share link from working, revoking it again keeps the first revocation time
+func (store *Store) RevokeShareLink(id uuid.UUID, revokedAt time.Time) error {
+	_, error := store.database.Exec("UPDATE share_links SET revokedAt = ? WHERE id = ? AND revokedAt IS NULL", revokedAt.UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to revoke share link: %v", error)
+	}
+
+	return nil
+}
+
+// RecordShareLinkView counts a view of a share link
+func (store *Store) RecordShareLinkView(id uuid.UUID, viewedAt time.Time) error {
+	_, error := store.database.Exec("UPDATE share_links SET viewCount = viewCount + 1, lastViewedAt = ? WHERE id = ?", viewedAt.UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to record share link view: %v", error)
+	}
+
+	return nil
+}
+
+// scanShareLinkFromRow scans a MySQL row into a new share link object, nil when there is no row
+func scanShareLinkFromRow(row *sql.Row) (*shareLinkModel.ShareLink, error) {
+	shareLink, error := scanShareLink(row)
+	if error == sql.ErrNoRows {
+		return nil, nil
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan share link from row: %v", error)
+	}
+
+	return shareLink, nil
+}
+
+// scanShareLink scans the columns of a share link from a row or rows
+func scanShareLink(scanner interface{ Scan(...any) error }) (*shareLinkModel.ShareLink, error) {
+	shareLink := new(shareLinkModel.ShareLink)
+	error := scanner.Scan(&shareLink.ID, &shareLink.TokenHash, &shareLink.ProjectID, &shareLink.NoteID, &shareLink.CreatedBy, &shareLink.PasswordHash, &shareLink.ExpiresAt, &shareLink.RevokedAt, &shareLink.ViewCount, &shareLink.LastViewedAt, &shareLink.DateCreated)
+	if error != nil {
+		return nil, error
+	}
+
+	shareLink.HasPassword = shareLink.PasswordHash != nil
+	return shareLink, nil
+}
@@ -0,0 +1,109 @@
+package signingKeyRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	signingKeyModel "github.com/hwaengfan/dev-journal-backend/internal/models/signingKey"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetSigningKeys retrieves every signing key, oldest first
+func (store *Store) GetSigningKeys() ([]*signingKeyModel.SigningKey, error) {
+	rows, error := store.database.Query("SELECT id, algorithm, privateKey, dateCreated, retiredAt FROM signing_keys ORDER BY dateCreated, id")
+	if error != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %v", error)
+	}
+	defer rows.Close()
+
+	signingKeys := make([]*signingKeyModel.SigningKey, 0)
+	for rows.Next() {
+		signingKey := new(signingKeyModel.SigningKey)
+		if error := rows.Scan(&signingKey.ID, &signingKey.Algorithm, &signingKey.PrivateKey, &signingKey.DateCreated, &signingKey.RetiredAt); error != nil {
+			return nil, fmt.Errorf("failed to scan signing key from rows: %v", error)
+		}
+
+		signingKeys = append(signingKeys, signingKey)
+	}
+
+	return signingKeys, nil
+}
+
+// RotateSigningKey stores a new active key and retires the previous ones in one transaction,
+// nothing changes when the active key already uses the algorithm and was created after rotateBefore, another server rotated first
+func (store *Store) RotateSigningKey(signingKey signingKeyModel.SigningKey, rotateBefore time.Time) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// lock the active keys so concurrent rotations wait for each other and the later one sees the key of the first
+	query := "SELECT algorithm, dateCreated FROM signing_keys WHERE retiredAt IS NULL FOR UPDATE"
+	rows, error := transaction.Query(query)
+	if error != nil {
+		return fmt.Errorf("failed to lock active signing keys: %v", error)
+	}
+
+	hasActiveKey, upToDate := false, false
+	for rows.Next() {
+		var algorithm string
+		var dateCreated time.Time
+		if error := rows.Scan(&algorithm, &dateCreated); error != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan signing key from rows: %v", error)
+		}
+
+		hasActiveKey = true
+		if algorithm == signingKey.Algorithm && !dateCreated.Before(rotateBefore.UTC().Truncate(time.Second)) {
+			upToDate = true
+		}
+	}
+	rows.Close()
+
+	if upToDate {
+		return nil
+	}
+
+	// the first key replaces JWT_SECRET, which keeps verifying the tokens it signed until it is deleted with the retired keys
+	if !hasActiveKey {
+		query = "INSERT INTO signing_keys (id, algorithm, privateKey, dateCreated, retiredAt) VALUES (?, 'HS256', '', ?, ?) ON DUPLICATE KEY UPDATE dateCreated = VALUES(dateCreated), retiredAt = VALUES(retiredAt)"
+		if _, error := transaction.Exec(query, signingKeyModel.SecretKeyID, signingKey.DateCreated.UTC(), signingKey.DateCreated.UTC()); error != nil {
+			return fmt.Errorf("failed to retire JWT secret: %v", error)
+		}
+	}
+
+	query = "UPDATE signing_keys SET retiredAt = ? WHERE retiredAt IS NULL"
+	if _, error := transaction.Exec(query, signingKey.DateCreated.UTC()); error != nil {
+		return fmt.Errorf("failed to retire signing keys: %v", error)
+	}
+
+	query = "INSERT INTO signing_keys (id, algorithm, privateKey, dateCreated) VALUES (?, ?, ?, ?)"
+	if _, error := transaction.Exec(query, signingKey.ID, signingKey.Algorithm, signingKey.PrivateKey, signingKey.DateCreated.UTC()); error != nil {
+		return fmt.Errorf("failed to create signing key: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit signing key rotation: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteSigningKeysRetiredBefore deletes the keys retired before a time
+func (store *Store) DeleteSigningKeysRetiredBefore(before time.Time) error {
+	_, error := store.database.Exec("DELETE FROM signing_keys WHERE retiredAt < ?", before.UTC())
+	if error != nil {
+		return fmt.Errorf("failed to delete retired signing keys: %v", error)
+	}
+
+	return nil
+}
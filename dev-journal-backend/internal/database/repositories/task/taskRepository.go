@@ -2,13 +2,21 @@ package taskRepository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
 )
 
+// columns selected for every task
+const taskColumns = "id, linkedProjectID, parentTaskID, columnID, position, description, assigneeID, completed, dueDate, recurrenceRule, recurrenceMode, recurrenceStart, recurrenceExceptions, recurrenceSeriesID, dateCreated, dateCompleted, lastMoved"
+
+// keeps the first completion time while a task stays completed and clears it once reopened
+const dateCompletedExpression = "IF(? = 'True', COALESCE(dateCompleted, ?), NULL)"
+
 type Store struct {
 	database *sql.DB
 }
@@ -21,8 +29,19 @@ func NewStore(database *sql.DB) *Store {
 func (store *Store) CreateTask(task taskModel.Task) (uuid.UUID, error) {
 	taskID := uuid.New()
 
-	query := "INSERT INTO tasks (id, linkedProjectID, description, completed) VALUES (?, ?, ?, ?)"
-	_, error := store.database.Exec(query, taskID, task.LinkedProjectID, task.Description, task.Completed)
+	// convert []string to JSON
+	exceptionsJSON, error := json.Marshal(task.RecurrenceExceptions)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to convert recurrence exceptions to JSON: %v", error)
+	}
+
+	recurrenceMode := task.RecurrenceMode
+	if recurrenceMode == "" {
+		recurrenceMode = taskModel.RecurrenceOnCompletion
+	}
+
+	query := "INSERT INTO tasks (id, linkedProjectID, parentTaskID, columnID, position, description, assigneeID, completed, dueDate, recurrenceRule, recurrenceMode, recurrenceStart, recurrenceExceptions, recurrenceSeriesID) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, error = store.database.Exec(query, taskID, task.LinkedProjectID, task.ParentTaskID, task.ColumnID, task.Position, task.Description, task.AssigneeID, task.Completed, task.DueDate, task.RecurrenceRule, recurrenceMode, task.RecurrenceStart, exceptionsJSON, task.RecurrenceSeriesID)
 	if error != nil {
 		return uuid.Nil, fmt.Errorf("failed to create task: %v", error)
 	}
@@ -33,7 +52,7 @@ func (store *Store) CreateTask(task taskModel.Task) (uuid.UUID, error) {
 // GetTasksByLinkedProjectID gets tasks by linked project ID
 func (store *Store) GetTasksByLinkedProjectID(linkedProjectID uuid.UUID) ([]*taskModel.Task, error) {
 	// query tasks by project ID
-	query := "SELECT id, linkedProjectID, description, completed FROM tasks WHERE linkedProjectID = ?"
+	query := "SELECT " + taskColumns + " FROM tasks WHERE linkedProjectID = ? ORDER BY position"
 	rows, error := store.database.Query(query, linkedProjectID)
 	if error != nil {
 		return nil, fmt.Errorf("failed to get tasks by linked project ID: %v", error)
@@ -52,7 +71,7 @@ func (store *Store) GetTasksByLinkedProjectID(linkedProjectID uuid.UUID) ([]*tas
 // GetTaskByID gets a task by its ID
 func (store *Store) GetTaskByID(id uuid.UUID) (*taskModel.Task, error) {
 	// query task by ID
-	query := "SELECT id, linkedProjectID, description, completed FROM tasks WHERE id = ?"
+	query := "SELECT " + taskColumns + " FROM tasks WHERE id = ?"
 	row := store.database.QueryRow(query, id)
 
 	// scan task from row
@@ -64,6 +83,41 @@ func (store *Store) GetTaskByID(id uuid.UUID) (*taskModel.Task, error) {
 	return task, nil
 }
 
+// GetTasksByUserID gets the tasks of all projects of a user, only those in workspaceID when it is valid
+func (store *Store) GetTasksByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*taskModel.Task, error) {
+	query := "SELECT tasks." + strings.ReplaceAll(taskColumns, ", ", ", tasks.") + " FROM tasks JOIN project_members members ON members.projectID = tasks.linkedProjectID JOIN projects ON projects.id = tasks.linkedProjectID WHERE members.userID = ?"
+	args := []interface{}{userID}
+	if workspaceID.Valid {
+		query += " AND projects.workspaceID = ?"
+		args = append(args, workspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY tasks.position", args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get tasks by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	// scan tasks from rows
+	tasks, error := scanTasksFromRows(rows)
+	if error != nil {
+		return nil, error
+	}
+
+	return tasks, nil
+}
+
+// CountTasksByColumnID counts the tasks in a board column
+func (store *Store) CountTasksByColumnID(columnID uuid.UUID) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM tasks WHERE columnID = ?"
+	if error := store.database.QueryRow(query, columnID).Scan(&count); error != nil {
+		return 0, fmt.Errorf("failed to count tasks by column ID: %v", error)
+	}
+
+	return count, nil
+}
+
 // UpdateTaskByID updates a task by its ID
 func (store *Store) UpdateTaskByID(task taskModel.Task, id uuid.UUID) error {
 	// base query
@@ -81,8 +135,12 @@ func (store *Store) UpdateTaskByID(task taskModel.Task, id uuid.UUID) error {
 		args = append(args, task.Description)
 	}
 	if task.Completed != "" {
-		updates = append(updates, "completed = ?")
-		args = append(args, task.Completed)
+		updates = append(updates, "completed = ?", "dateCompleted = "+dateCompletedExpression)
+		args = append(args, task.Completed, task.Completed, time.Now().UTC())
+	}
+	if task.DueDate != nil {
+		updates = append(updates, "dueDate = ?")
+		args = append(args, *task.DueDate)
 	}
 
 	// check if there are fields to update
@@ -103,6 +161,97 @@ func (store *Store) UpdateTaskByID(task taskModel.Task, id uuid.UUID) error {
 	return nil
 }
 
+// SetParentTaskByID attaches a task to a parent task, or detaches it when the parent is null
+func (store *Store) SetParentTaskByID(id uuid.UUID, parentTaskID uuid.NullUUID) error {
+	query := "UPDATE tasks SET parentTaskID = ? WHERE id = ?"
+	_, error := store.database.Exec(query, parentTaskID, id)
+	if error != nil {
+		return fmt.Errorf("failed to set parent task: %v", error)
+	}
+
+	return nil
+}
+
+// SetAssigneeByID assigns a task to a user, or unassigns it when the assignee is null
+func (store *Store) SetAssigneeByID(id uuid.UUID, assigneeID uuid.NullUUID) error {
+	query := "UPDATE tasks SET assigneeID = ? WHERE id = ?"
+	_, error := store.database.Exec(query, assigneeID, id)
+	if error != nil {
+		return fmt.Errorf("failed to set task assignee: %v", error)
+	}
+
+	return nil
+}
+
+// SetDueDateByID sets the due date of a task, or removes it when the due date is nil
+func (store *Store) SetDueDateByID(id uuid.UUID, dueDate *string) error {
+	query := "UPDATE tasks SET dueDate = ? WHERE id = ?"
+	_, error := store.database.Exec(query, dueDate, id)
+	if error != nil {
+		return fmt.Errorf("failed to set task due date: %v", error)
+	}
+
+	return nil
+}
+
+// MoveTaskByID moves a task into a column at the given position, shifting the other tasks to keep the order contiguous
+func (store *Store) MoveTaskByID(id uuid.UUID, columnID uuid.UUID, position int, completed string) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// get the current place of the task
+	var currentColumnID uuid.NullUUID
+	var currentPosition int
+	query := "SELECT columnID, position FROM tasks WHERE id = ? FOR UPDATE"
+	if error := transaction.QueryRow(query, id).Scan(&currentColumnID, &currentPosition); error != nil {
+		if error == sql.ErrNoRows {
+			return fmt.Errorf("task not found")
+		}
+		return fmt.Errorf("failed to get task position: %v", error)
+	}
+
+	// close the gap left in the source column
+	if currentColumnID.Valid {
+		query = "UPDATE tasks SET position = position - 1 WHERE columnID = ? AND position > ? AND id != ?"
+		if _, error := transaction.Exec(query, currentColumnID.UUID, currentPosition, id); error != nil {
+			return fmt.Errorf("failed to reorder source column: %v", error)
+		}
+	}
+
+	// clamp the position to the end of the target column
+	var count int
+	query = "SELECT COUNT(*) FROM tasks WHERE columnID = ? AND id != ?"
+	if error := transaction.QueryRow(query, columnID, id).Scan(&count); error != nil {
+		return fmt.Errorf("failed to count tasks in target column: %v", error)
+	}
+	if position > count {
+		position = count
+	}
+
+	// open a gap in the target column
+	query = "UPDATE tasks SET position = position + 1 WHERE columnID = ? AND position >= ? AND id != ?"
+	if _, error := transaction.Exec(query, columnID, position, id); error != nil {
+		return fmt.Errorf("failed to reorder target column: %v", error)
+	}
+
+	// place the task, moving to another column is recorded as board activity
+	now := time.Now().UTC()
+	query = "UPDATE tasks SET columnID = ?, position = ?, completed = ?, dateCompleted = " + dateCompletedExpression + ", lastMoved = IF(?, ?, lastMoved) WHERE id = ?"
+	moved := !currentColumnID.Valid || currentColumnID.UUID != columnID
+	if _, error := transaction.Exec(query, columnID, position, completed, completed, now, moved, now, id); error != nil {
+		return fmt.Errorf("failed to move task: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit task move: %v", error)
+	}
+
+	return nil
+}
+
 // DeleteTaskByID deletes a task by its ID
 func (store *Store) DeleteTaskByID(id uuid.UUID) error {
 	query := "DELETE FROM tasks WHERE id = ?"
@@ -125,17 +274,256 @@ func (store *Store) DeleteTasksByLinkedProjectID(linkedProjectID uuid.UUID) erro
 	return nil
 }
 
+// SetRecurrenceByID sets or, with a nil rule, clears the recurrence of a task
+func (store *Store) SetRecurrenceByID(task taskModel.Task, id uuid.UUID) error {
+	recurrenceMode := task.RecurrenceMode
+	if recurrenceMode == "" {
+		recurrenceMode = taskModel.RecurrenceOnCompletion
+	}
+
+	query := "UPDATE tasks SET recurrenceRule = ?, recurrenceMode = ?, recurrenceStart = ?, recurrenceSeriesID = ?, dueDate = COALESCE(?, dueDate) WHERE id = ?"
+	_, error := store.database.Exec(query, task.RecurrenceRule, recurrenceMode, task.RecurrenceStart, task.RecurrenceSeriesID, task.DueDate, id)
+	if error != nil {
+		return fmt.Errorf("failed to set task recurrence: %v", error)
+	}
+
+	return nil
+}
+
+// SetRecurrenceExceptionsBySeriesID sets the skipped dates shared by all occurrences of a recurring task
+func (store *Store) SetRecurrenceExceptionsBySeriesID(exceptions []string, recurrenceSeriesID uuid.UUID) error {
+	// convert []string to JSON
+	exceptionsJSON, error := json.Marshal(exceptions)
+	if error != nil {
+		return fmt.Errorf("failed to convert recurrence exceptions to JSON: %v", error)
+	}
+
+	query := "UPDATE tasks SET recurrenceExceptions = ? WHERE recurrenceSeriesID = ?"
+	_, error = store.database.Exec(query, exceptionsJSON, recurrenceSeriesID)
+	if error != nil {
+		return fmt.Errorf("failed to set recurrence exceptions: %v", error)
+	}
+
+	return nil
+}
+
+// GetTasksByRecurrenceSeriesID gets all occurrences of a recurring task
+func (store *Store) GetTasksByRecurrenceSeriesID(recurrenceSeriesID uuid.UUID) ([]*taskModel.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE recurrenceSeriesID = ? ORDER BY dueDate"
+	rows, error := store.database.Query(query, recurrenceSeriesID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get tasks by recurrence series ID: %v", error)
+	}
+	defer rows.Close()
+
+	return scanTasksFromRows(rows)
+}
+
+// GetLatestScheduledRecurringTasks gets the latest occurrence of every recurring task that is generated on a schedule
+func (store *Store) GetLatestScheduledRecurringTasks() ([]*taskModel.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks latest WHERE recurrenceRule IS NOT NULL AND recurrenceMode = ? AND NOT EXISTS (SELECT 1 FROM tasks later WHERE later.recurrenceSeriesID = latest.recurrenceSeriesID AND later.dueDate > latest.dueDate)"
+	rows, error := store.database.Query(query, taskModel.RecurrenceScheduled)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get scheduled recurring tasks: %v", error)
+	}
+	defer rows.Close()
+
+	return scanTasksFromRows(rows)
+}
+
+// CreateTaskDependency records that a task is blocked by another task, it fails with ErrDependencyCycle when the blocker already waits on the task
+func (store *Store) CreateTaskDependency(taskID uuid.UUID, blockedByTaskID uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// lock the task, a concurrent dependency closing a loop through it walks past this row and waits for the commit
+	var locked uuid.UUID
+	if error := transaction.QueryRow("SELECT id FROM tasks WHERE id = ? FOR UPDATE", taskID).Scan(&locked); error != nil {
+		if error == sql.ErrNoRows {
+			return fmt.Errorf("task not found")
+		}
+		return fmt.Errorf("failed to lock task: %v", error)
+	}
+
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM task_dependencies WHERE taskID = ? AND blockedByTaskID = ?)"
+	if error := transaction.QueryRow(query, taskID, blockedByTaskID).Scan(&exists); error != nil {
+		return fmt.Errorf("failed to check task dependency: %v", error)
+	}
+	if exists {
+		return taskModel.ErrDependencyExists
+	}
+
+	// walk every blocker of the blocker, across all projects and users
+	cycle, error := createsCycle(taskID, blockedByTaskID, lockingBlockers(transaction))
+	if error != nil {
+		return error
+	}
+	if cycle {
+		return taskModel.ErrDependencyCycle
+	}
+
+	query = "INSERT INTO task_dependencies (taskID, blockedByTaskID) VALUES (?, ?)"
+	if _, error := transaction.Exec(query, taskID, blockedByTaskID); error != nil {
+		return fmt.Errorf("failed to create task dependency: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit task dependency: %v", error)
+	}
+
+	return nil
+}
+
+// blockerLookup gets the tasks blocking each of the given tasks
+type blockerLookup func(taskIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+
+// lockingBlockers looks up blockers within a transaction, locking the tasks so no dependency is added to them until it ends
+func lockingBlockers(transaction *sql.Tx) blockerLookup {
+	return func(taskIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+		return lockBlockers(transaction, taskIDs)
+	}
+}
+
+// lockBlockers locks tasks and gets the tasks blocking them
+func lockBlockers(transaction *sql.Tx, taskIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	args := make([]interface{}, len(taskIDs))
+	for index, taskID := range taskIDs {
+		args[index] = taskID
+	}
+
+	query := "SELECT tasks.id, dependencies.blockedByTaskID FROM tasks LEFT JOIN task_dependencies dependencies ON dependencies.taskID = tasks.id WHERE tasks.id IN (?" + strings.Repeat(", ?", len(taskIDs)-1) + ") FOR UPDATE"
+	rows, error := transaction.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get blocking tasks: %v", error)
+	}
+	defer rows.Close()
+
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var taskID uuid.UUID
+		var blockedByTaskID uuid.NullUUID
+		if error := rows.Scan(&taskID, &blockedByTaskID); error != nil {
+			return nil, fmt.Errorf("failed to scan blocking task from rows: %v", error)
+		}
+
+		if blockedByTaskID.Valid {
+			blockers[taskID] = append(blockers[taskID], blockedByTaskID.UUID)
+		}
+	}
+
+	return blockers, rows.Err()
+}
+
+// createsCycle checks whether blocking a task by another would close a loop, i.e. the blocker already waits on the task,
+// it walks the blockers one level at a time so each level is read with a single query
+func createsCycle(taskID uuid.UUID, blockedByTaskID uuid.UUID, getBlockers blockerLookup) (bool, error) {
+	visited := map[uuid.UUID]bool{blockedByTaskID: true}
+	level := []uuid.UUID{blockedByTaskID}
+
+	for len(level) > 0 {
+		blockers, error := getBlockers(level)
+		if error != nil {
+			return false, error
+		}
+
+		next := make([]uuid.UUID, 0)
+		for _, current := range level {
+			for _, blockerID := range blockers[current] {
+				if blockerID == taskID {
+					return true, nil
+				}
+				if !visited[blockerID] {
+					visited[blockerID] = true
+					next = append(next, blockerID)
+				}
+			}
+		}
+		level = next
+	}
+
+	return false, nil
+}
+
+// GetTaskDependenciesByLinkedProjectID gets the dependencies of all tasks in a project, blockers may live in other projects
+func (store *Store) GetTaskDependenciesByLinkedProjectID(linkedProjectID uuid.UUID) ([]*taskModel.TaskDependency, error) {
+	query := "SELECT dependencies.taskID, dependencies.blockedByTaskID, blockers.completed FROM task_dependencies dependencies JOIN tasks dependents ON dependents.id = dependencies.taskID JOIN tasks blockers ON blockers.id = dependencies.blockedByTaskID WHERE dependents.linkedProjectID = ?"
+	rows, error := store.database.Query(query, linkedProjectID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get task dependencies by linked project ID: %v", error)
+	}
+	defer rows.Close()
+
+	return scanTaskDependenciesFromRows(rows)
+}
+
+// GetTaskDependenciesByUserID gets the dependencies of all tasks across a user's projects, only those in workspaceID when it is valid
+func (store *Store) GetTaskDependenciesByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*taskModel.TaskDependency, error) {
+	query := "SELECT dependencies.taskID, dependencies.blockedByTaskID, blockers.completed FROM task_dependencies dependencies JOIN tasks dependents ON dependents.id = dependencies.taskID JOIN tasks blockers ON blockers.id = dependencies.blockedByTaskID JOIN project_members members ON members.projectID = dependents.linkedProjectID JOIN projects ON projects.id = dependents.linkedProjectID WHERE members.userID = ?"
+	args := []interface{}{userID}
+	if workspaceID.Valid {
+		query += " AND projects.workspaceID = ?"
+		args = append(args, workspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get task dependencies by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	return scanTaskDependenciesFromRows(rows)
+}
+
+// DeleteTaskDependency removes a blocking task from a task
+func (store *Store) DeleteTaskDependency(taskID uuid.UUID, blockedByTaskID uuid.UUID) error {
+	query := "DELETE FROM task_dependencies WHERE taskID = ? AND blockedByTaskID = ?"
+	_, error := store.database.Exec(query, taskID, blockedByTaskID)
+	if error != nil {
+		return fmt.Errorf("failed to delete task dependency: %v", error)
+	}
+
+	return nil
+}
+
+// scanTaskDependenciesFromRows scans MySQL rows into a slice of task dependency objects
+func scanTaskDependenciesFromRows(rows *sql.Rows) ([]*taskModel.TaskDependency, error) {
+	dependencies := make([]*taskModel.TaskDependency, 0)
+	for rows.Next() {
+		dependency := new(taskModel.TaskDependency)
+
+		error := rows.Scan(&dependency.TaskID, &dependency.BlockedByTaskID, &dependency.BlockerCompleted)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan task dependency from rows: %v", error)
+		}
+
+		dependencies = append(dependencies, dependency)
+	}
+
+	return dependencies, nil
+}
+
 // scanTaskFromRows scans MySQL rows into a slice of task objects
 func scanTasksFromRows(rows *sql.Rows) ([]*taskModel.Task, error) {
 	tasks := make([]*taskModel.Task, 0)
 	for rows.Next() {
 		task := new(taskModel.Task)
 
-		error := rows.Scan(&task.ID, &task.LinkedProjectID, &task.Description, &task.Completed)
+		var exceptionsJSONString sql.NullString
+
+		error := rows.Scan(&task.ID, &task.LinkedProjectID, &task.ParentTaskID, &task.ColumnID, &task.Position, &task.Description, &task.AssigneeID, &task.Completed, &task.DueDate, &task.RecurrenceRule, &task.RecurrenceMode, &task.RecurrenceStart, &exceptionsJSONString, &task.RecurrenceSeriesID, &task.DateCreated, &task.DateCompleted, &task.LastMoved)
 		if error != nil {
 			return nil, fmt.Errorf("failed to scan project from rows: %v", error)
 		}
 
+		// convert JSON to []string
+		if error := unmarshalRecurrenceExceptions(exceptionsJSONString, task); error != nil {
+			return nil, error
+		}
+
 		tasks = append(tasks, task)
 	}
 
@@ -146,12 +534,33 @@ func scanTasksFromRows(rows *sql.Rows) ([]*taskModel.Task, error) {
 func scanTaskFromRow(row *sql.Row) (*taskModel.Task, error) {
 	task := new(taskModel.Task)
 
-	error := row.Scan(&task.ID, &task.LinkedProjectID, &task.Description, &task.Completed)
+	var exceptionsJSONString sql.NullString
+
+	error := row.Scan(&task.ID, &task.LinkedProjectID, &task.ParentTaskID, &task.ColumnID, &task.Position, &task.Description, &task.AssigneeID, &task.Completed, &task.DueDate, &task.RecurrenceRule, &task.RecurrenceMode, &task.RecurrenceStart, &exceptionsJSONString, &task.RecurrenceSeriesID, &task.DateCreated, &task.DateCompleted, &task.LastMoved)
 	if error == sql.ErrNoRows {
 		return nil, fmt.Errorf("task not found")
 	} else if error != nil {
 		return nil, fmt.Errorf("failed to scan project from row: %v", error)
 	}
 
+	// convert JSON to []string
+	if error := unmarshalRecurrenceExceptions(exceptionsJSONString, task); error != nil {
+		return nil, error
+	}
+
 	return task, nil
 }
+
+// unmarshalRecurrenceExceptions converts the stored exception dates of a task, tasks without any get an empty list
+func unmarshalRecurrenceExceptions(exceptionsJSONString sql.NullString, task *taskModel.Task) error {
+	task.RecurrenceExceptions = make([]string, 0)
+	if !exceptionsJSONString.Valid || exceptionsJSONString.String == "null" {
+		return nil
+	}
+
+	if error := json.Unmarshal([]byte(exceptionsJSONString.String), &task.RecurrenceExceptions); error != nil {
+		return fmt.Errorf("failed to convert recurrence exceptions from JSON: %v", error)
+	}
+
+	return nil
+}
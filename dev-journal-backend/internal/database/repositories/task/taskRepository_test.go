@@ -0,0 +1,63 @@
+package taskRepository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreatesCycle(t *testing.T) {
+	a, b, c, d, e := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name            string
+		blockers        map[uuid.UUID][]uuid.UUID
+		taskID          uuid.UUID
+		blockedByTaskID uuid.UUID
+		expected        bool
+	}{
+		{name: "no dependencies", blockers: map[uuid.UUID][]uuid.UUID{}, taskID: a, blockedByTaskID: b},
+		{name: "blocker waits on the task", blockers: map[uuid.UUID][]uuid.UUID{b: {a}}, taskID: a, blockedByTaskID: b, expected: true},
+		{name: "blocker waits on the task through a chain", blockers: map[uuid.UUID][]uuid.UUID{b: {c}, c: {d}, d: {a}}, taskID: a, blockedByTaskID: b, expected: true},
+		{name: "task waits on the blocker already", blockers: map[uuid.UUID][]uuid.UUID{a: {c}, c: {b}}, taskID: a, blockedByTaskID: b},
+		{name: "diamond of blockers", blockers: map[uuid.UUID][]uuid.UUID{b: {c, d}, c: {e}, d: {e}}, taskID: a, blockedByTaskID: b},
+		{name: "loop among the blockers", blockers: map[uuid.UUID][]uuid.UUID{b: {c}, c: {d}, d: {b}}, taskID: a, blockedByTaskID: b},
+		{name: "one of several blockers waits on the task", blockers: map[uuid.UUID][]uuid.UUID{b: {c, d}, d: {e}, e: {a}}, taskID: a, blockedByTaskID: b, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookedUp := make(map[uuid.UUID]bool)
+			lookup := func(taskIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+				blockers := make(map[uuid.UUID][]uuid.UUID)
+				for _, taskID := range taskIDs {
+					if lookedUp[taskID] {
+						t.Fatalf("blockers of %s were looked up twice", taskID)
+					}
+					lookedUp[taskID] = true
+					blockers[taskID] = test.blockers[taskID]
+				}
+				return blockers, nil
+			}
+
+			cycle, error := createsCycle(test.taskID, test.blockedByTaskID, lookup)
+			if error != nil {
+				t.Fatal(error)
+			}
+			if cycle != test.expected {
+				t.Errorf("cycle is %v, expected %v", cycle, test.expected)
+			}
+		})
+	}
+}
+
+func TestCreatesCycleReturnsLookupErrors(t *testing.T) {
+	lookupError := fmt.Errorf("lookup failed")
+	_, error := createsCycle(uuid.New(), uuid.New(), func(taskIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+		return nil, lookupError
+	})
+	if error != lookupError {
+		t.Fatalf("returned %v, expected the lookup error", error)
+	}
+}
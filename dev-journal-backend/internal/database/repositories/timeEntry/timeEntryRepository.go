@@ -0,0 +1,218 @@
+package timeEntryRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	timeEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/timeEntry"
+)
+
+// time entries are selected together with their task and project
+const timeEntryQuery = "SELECT timeEntries.id, timeEntries.userID, timeEntries.taskID, tasks.description, tasks.linkedProjectID, projects.workspaceID, projects.title, timeEntries.description, timeEntries.startedAt, timeEntries.endedAt FROM time_entries timeEntries JOIN tasks ON tasks.id = timeEntries.taskID JOIN projects ON projects.id = tasks.linkedProjectID"
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateTimeEntry creates a new time entry, an entry without an end is a running timer
+func (store *Store) CreateTimeEntry(timeEntry timeEntryModel.TimeEntry) (uuid.UUID, error) {
+	timeEntryID := uuid.New()
+
+	query := "INSERT INTO time_entries (id, userID, taskID, description, startedAt, endedAt) VALUES (?, ?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, timeEntryID, timeEntry.UserID, timeEntry.TaskID, timeEntry.Description, timeEntry.StartedAt.UTC(), utcOrNil(timeEntry.EndedAt))
+	if error != nil {
+		if strings.Contains(error.Error(), "Duplicate entry") {
+			return uuid.Nil, fmt.Errorf("a timer is already running")
+		}
+		return uuid.Nil, fmt.Errorf("failed to create time entry: %v", error)
+	}
+
+	return timeEntryID, nil
+}
+
+// GetTimeEntriesByUserID retrieves a user's time entries overlapping the filter's range, newest first
+func (store *Store) GetTimeEntriesByUserID(userID uuid.UUID, filter timeEntryModel.TimeEntryFilter) ([]*timeEntryModel.TimeEntry, error) {
+	query := timeEntryQuery + " WHERE timeEntries.userID = ?"
+	args := []interface{}{userID}
+
+	// conditionally add filters
+	if filter.From != nil {
+		query += " AND (timeEntries.endedAt IS NULL OR timeEntries.endedAt > ?)"
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		query += " AND timeEntries.startedAt < ?"
+		args = append(args, filter.To.UTC())
+	}
+	if filter.LinkedProjectID != uuid.Nil {
+		query += " AND tasks.linkedProjectID = ?"
+		args = append(args, filter.LinkedProjectID)
+	}
+	if filter.WorkspaceID.Valid {
+		query += " AND projects.workspaceID = ?"
+		args = append(args, filter.WorkspaceID.UUID)
+	}
+
+	rows, error := store.database.Query(query+" ORDER BY timeEntries.startedAt DESC", args...)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get time entries by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	// scan time entries from rows
+	timeEntries, error := scanTimeEntriesFromRows(rows)
+	if error != nil {
+		return nil, error
+	}
+
+	return timeEntries, nil
+}
+
+// GetTimeEntryByID retrieves a time entry by its ID
+func (store *Store) GetTimeEntryByID(id uuid.UUID) (*timeEntryModel.TimeEntry, error) {
+	row := store.database.QueryRow(timeEntryQuery+" WHERE timeEntries.id = ?", id)
+
+	// scan time entry from row
+	timeEntry, error := scanTimeEntryFromRow(row)
+	if error != nil {
+		return nil, fmt.Errorf("failed to scan time entry from row: %v", error)
+	}
+
+	return timeEntry, nil
+}
+
+// GetRunningTimeEntryByUserID retrieves the running timer of a user
+func (store *Store) GetRunningTimeEntryByUserID(userID uuid.UUID) (*timeEntryModel.TimeEntry, error) {
+	row := store.database.QueryRow(timeEntryQuery+" WHERE timeEntries.userID = ? AND timeEntries.endedAt IS NULL", userID)
+
+	// scan time entry from row
+	timeEntry, error := scanTimeEntryFromRow(row)
+	if error != nil {
+		return nil, error
+	}
+
+	return timeEntry, nil
+}
+
+// UpdateTimeEntryByID updates a time entry by its ID
+func (store *Store) UpdateTimeEntryByID(timeEntry timeEntryModel.TimeEntry, id uuid.UUID) error {
+	// base query
+	query := "UPDATE time_entries SET"
+	var updates []string
+	var args []interface{}
+
+	// conditionally add fields to update
+	if timeEntry.TaskID != uuid.Nil {
+		updates = append(updates, "taskID = ?")
+		args = append(args, timeEntry.TaskID)
+	}
+	if timeEntry.Description != "" {
+		updates = append(updates, "description = ?")
+		args = append(args, timeEntry.Description)
+	}
+	if !timeEntry.StartedAt.IsZero() {
+		updates = append(updates, "startedAt = ?")
+		args = append(args, timeEntry.StartedAt.UTC())
+	}
+	if timeEntry.EndedAt != nil {
+		updates = append(updates, "endedAt = ?")
+		args = append(args, timeEntry.EndedAt.UTC())
+	}
+
+	// check if there are fields to update
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	// finalize query
+	query += " " + strings.Join(updates, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	// execute the query
+	_, error := store.database.Exec(query, args...)
+	if error != nil {
+		return fmt.Errorf("failed to update time entry: %v", error)
+	}
+
+	return nil
+}
+
+// StopTimeEntryByID stops a running timer
+func (store *Store) StopTimeEntryByID(id uuid.UUID, endedAt time.Time) error {
+	query := "UPDATE time_entries SET endedAt = ? WHERE id = ? AND endedAt IS NULL"
+	_, error := store.database.Exec(query, endedAt.UTC(), id)
+	if error != nil {
+		return fmt.Errorf("failed to stop time entry: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteTimeEntryByID deletes a time entry by its ID
+func (store *Store) DeleteTimeEntryByID(id uuid.UUID) error {
+	query := "DELETE FROM time_entries WHERE id = ?"
+	_, error := store.database.Exec(query, id)
+	if error != nil {
+		return fmt.Errorf("failed to delete time entry: %v", error)
+	}
+
+	return nil
+}
+
+// scanTimeEntriesFromRows scans MySQL rows into a slice of time entry objects
+func scanTimeEntriesFromRows(rows *sql.Rows) ([]*timeEntryModel.TimeEntry, error) {
+	timeEntries := make([]*timeEntryModel.TimeEntry, 0)
+	for rows.Next() {
+		timeEntry := new(timeEntryModel.TimeEntry)
+
+		error := rows.Scan(&timeEntry.ID, &timeEntry.UserID, &timeEntry.TaskID, &timeEntry.TaskDescription, &timeEntry.LinkedProjectID, &timeEntry.WorkspaceID, &timeEntry.ProjectTitle, &timeEntry.Description, &timeEntry.StartedAt, &timeEntry.EndedAt)
+		if error != nil {
+			return nil, fmt.Errorf("failed to scan time entry from rows: %v", error)
+		}
+
+		setDuration(timeEntry)
+		timeEntries = append(timeEntries, timeEntry)
+	}
+
+	return timeEntries, nil
+}
+
+// scanTimeEntryFromRow scans a MySQL row into a new time entry object
+func scanTimeEntryFromRow(row *sql.Row) (*timeEntryModel.TimeEntry, error) {
+	timeEntry := new(timeEntryModel.TimeEntry)
+
+	error := row.Scan(&timeEntry.ID, &timeEntry.UserID, &timeEntry.TaskID, &timeEntry.TaskDescription, &timeEntry.LinkedProjectID, &timeEntry.WorkspaceID, &timeEntry.ProjectTitle, &timeEntry.Description, &timeEntry.StartedAt, &timeEntry.EndedAt)
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("time entry not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan time entry from row: %v", error)
+	}
+
+	setDuration(timeEntry)
+	return timeEntry, nil
+}
+
+// setDuration computes the length of a time entry, running timers count up to now
+func setDuration(timeEntry *timeEntryModel.TimeEntry) {
+	endedAt := time.Now()
+	if timeEntry.EndedAt != nil {
+		endedAt = *timeEntry.EndedAt
+	}
+
+	timeEntry.DurationInSeconds = int64(endedAt.Sub(timeEntry.StartedAt).Seconds())
+}
+
+func utcOrNil(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return value.UTC()
+}
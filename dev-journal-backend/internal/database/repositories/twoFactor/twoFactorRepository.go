@@ -0,0 +1,170 @@
+package twoFactorRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	twoFactorModel "github.com/hwaengfan/dev-journal-backend/internal/models/twoFactor"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetTwoFactorByUserID retrieves the two-factor settings of a user, nil when the user never set it up
+func (store *Store) GetTwoFactorByUserID(userID uuid.UUID) (*twoFactorModel.TwoFactor, error) {
+	twoFactor := new(twoFactorModel.TwoFactor)
+
+	query := "SELECT userID, secret, enabled, lastUsedStep, dateCreated FROM user_two_factor WHERE userID = ?"
+	error := store.database.QueryRow(query, userID).Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastUsedStep, &twoFactor.DateCreated)
+	if error == sql.ErrNoRows {
+		return nil, nil
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %v", error)
+	}
+
+	return twoFactor, nil
+}
+
+// SaveTwoFactorSecret stores a new secret for a user, two-factor stays disabled until a code of it is confirmed
+func (store *Store) SaveTwoFactorSecret(userID uuid.UUID, secret string) error {
+	query := "INSERT INTO user_two_factor (userID, secret) VALUES (?, ?) ON DUPLICATE KEY UPDATE secret = VALUES(secret), enabled = 'False', lastUsedStep = 0"
+	_, error := store.database.Exec(query, userID, secret)
+	if error != nil {
+		return fmt.Errorf("failed to save two-factor secret: %v", error)
+	}
+
+	return nil
+}
+
+// EnableTwoFactor turns two-factor on for a user along with a fresh set of recovery codes
+func (store *Store) EnableTwoFactor(userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	result, error := transaction.Exec("UPDATE user_two_factor SET enabled = 'True', lastUsedStep = ? WHERE userID = ? AND enabled = 'False'", step, userID)
+	if error != nil {
+		return fmt.Errorf("failed to enable two-factor: %v", error)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("two-factor is already enabled")
+	}
+
+	if error := replaceRecoveryCodes(transaction, userID, recoveryCodeHashes); error != nil {
+		return error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// UseTwoFactorStep records the time step of an accepted code, it reports false when the step or a later one was already used
+func (store *Store) UseTwoFactorStep(userID uuid.UUID, step int64) (bool, error) {
+	result, error := store.database.Exec("UPDATE user_two_factor SET lastUsedStep = ? WHERE userID = ? AND lastUsedStep < ?", step, userID, step)
+	if error != nil {
+		return false, fmt.Errorf("failed to use two-factor code: %v", error)
+	}
+
+	rows, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to use two-factor code: %v", error)
+	}
+
+	return rows == 1, nil
+}
+
+// ReplaceRecoveryCodes discards the recovery codes of a user and stores new ones
+func (store *Store) ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if error := replaceRecoveryCodes(transaction, userID, recoveryCodeHashes); error != nil {
+		return error
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used, it reports false when no such code exists
+func (store *Store) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	query := "UPDATE two_factor_recovery_codes SET usedAt = ? WHERE userID = ? AND codeHash = ? AND usedAt IS NULL"
+	result, error := store.database.Exec(query, time.Now().UTC(), userID, codeHash)
+	if error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", error)
+	}
+
+	rows, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", error)
+	}
+
+	return rows == 1, nil
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes a user has left
+func (store *Store) CountUnusedRecoveryCodes(userID uuid.UUID) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM two_factor_recovery_codes WHERE userID = ? AND usedAt IS NULL"
+	if error := store.database.QueryRow(query, userID).Scan(&count); error != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %v", error)
+	}
+
+	return count, nil
+}
+
+// DeleteTwoFactor removes the two-factor settings and recovery codes of a user
+func (store *Store) DeleteTwoFactor(userID uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec("DELETE FROM two_factor_recovery_codes WHERE userID = ?", userID); error != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", error)
+	}
+
+	if _, error := transaction.Exec("DELETE FROM user_two_factor WHERE userID = ?", userID); error != nil {
+		return fmt.Errorf("failed to delete two-factor settings: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes deletes and inserts the recovery codes of a user within a transaction
+func replaceRecoveryCodes(transaction *sql.Tx, userID uuid.UUID, recoveryCodeHashes []string) error {
+	if _, error := transaction.Exec("DELETE FROM two_factor_recovery_codes WHERE userID = ?", userID); error != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", error)
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		if _, error := transaction.Exec("INSERT INTO two_factor_recovery_codes (id, userID, codeHash) VALUES (?, ?, ?)", uuid.New(), userID, codeHash); error != nil {
+			return fmt.Errorf("failed to create recovery code: %v", error)
+		}
+	}
+
+	return nil
+}
@@ -3,11 +3,15 @@ package userRepository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
 )
 
+const userColumns = "id, firstName, lastName, email, password, timezone, emailVerified, passwordChangedAt"
+
 type Store struct {
 	database *sql.DB
 }
@@ -16,21 +20,44 @@ func NewStore(database *sql.DB) *Store {
 	return &Store{database: database}
 }
 
-// CreateUser creates a new user
+// CreateUser creates a new user along with a personal workspace they administer
 func (store *Store) CreateUser(user userModel.User) error {
-	query := "INSERT INTO users (firstName, lastName, email, password) VALUES (?, ?, ?, ?)"
-	_, error := store.database.Exec(query, user.FirstName, user.LastName, user.Email, user.Password)
+	if user.Timezone == "" {
+		user.Timezone = "UTC"
+	}
+
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	userID, workspaceID := uuid.New(), uuid.New()
+	query := "INSERT INTO users (id, firstName, lastName, email, password, timezone) VALUES (?, ?, ?, ?, ?, ?)"
+	_, error = transaction.Exec(query, userID, user.FirstName, user.LastName, user.Email, user.Password, user.Timezone)
 	if error != nil {
 		return error
 	}
 
+	if _, error := transaction.Exec("INSERT INTO workspaces (id, name) VALUES (?, ?)", workspaceID, user.FirstName+"'s workspace"); error != nil {
+		return fmt.Errorf("failed to create personal workspace: %v", error)
+	}
+
+	if _, error := transaction.Exec("INSERT INTO workspace_members (workspaceID, userID, role) VALUES (?, ?, 'ADMIN')", workspaceID, userID); error != nil {
+		return fmt.Errorf("failed to add personal workspace admin: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
 	return nil
 }
 
 // GetUserByID retrieves a user by ID
 func (store *Store) GetUserByID(id uuid.UUID) (*userModel.User, error) {
 	// query user by ID
-	query := "SELECT * FROM users WHERE id = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 	row := store.database.QueryRow(query, id)
 
 	// scan user from row
@@ -45,7 +72,7 @@ func (store *Store) GetUserByID(id uuid.UUID) (*userModel.User, error) {
 // GetUserByEmail retrieves a user by email
 func (store *Store) GetUserByEmail(email string) (*userModel.User, error) {
 	// query user by email
-	query := "SELECT * FROM users WHERE email = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE email = ?"
 	row := store.database.QueryRow(query, email)
 
 	// scan user from row
@@ -57,10 +84,132 @@ func (store *Store) GetUserByEmail(email string) (*userModel.User, error) {
 	return user, nil
 }
 
+// UpdateTimezoneByID updates the timezone a user's days are counted in
+func (store *Store) UpdateTimezoneByID(id uuid.UUID, timezone string) error {
+	query := "UPDATE users SET timezone = ? WHERE id = ?"
+	_, error := store.database.Exec(query, timezone, id)
+	if error != nil {
+		return fmt.Errorf("failed to update timezone: %v", error)
+	}
+
+	return nil
+}
+
+// UpdatePasswordByID replaces the hashed password of a user and revokes the sessions issued before
+func (store *Store) UpdatePasswordByID(id uuid.UUID, password string) error {
+	// sessions carry their issue time in whole seconds
+	changedAt := time.Now().UTC().Truncate(time.Second)
+
+	query := "UPDATE users SET password = ?, passwordChangedAt = ? WHERE id = ?"
+	_, error := store.database.Exec(query, password, changedAt, id)
+	if error != nil {
+		return fmt.Errorf("failed to update password: %v", error)
+	}
+
+	return nil
+}
+
+// SetEmailVerifiedByID marks the email of a user as verified
+func (store *Store) SetEmailVerifiedByID(id uuid.UUID) error {
+	query := "UPDATE users SET emailVerified = 'True' WHERE id = ?"
+	_, error := store.database.Exec(query, id)
+	if error != nil {
+		return fmt.Errorf("failed to verify email: %v", error)
+	}
+
+	return nil
+}
+
+// UpdateUserByID updates the name and email of a user, a changed email has to be verified again
+func (store *Store) UpdateUserByID(user userModel.User, id uuid.UUID) error {
+	// base query
+	query := "UPDATE users SET"
+	var updates []string
+	var args []interface{}
+
+	// conditionally add fields to update
+	if user.FirstName != "" {
+		updates = append(updates, "firstName = ?")
+		args = append(args, user.FirstName)
+	}
+	if user.LastName != "" {
+		updates = append(updates, "lastName = ?")
+		args = append(args, user.LastName)
+	}
+	if user.Email != "" {
+		updates = append(updates, "email = ?", "emailVerified = 'False'")
+		args = append(args, user.Email)
+	}
+
+	// check if there are fields to update
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	// finalize query
+	query += " " + strings.Join(updates, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	_, error := store.database.Exec(query, args...)
+	if error != nil {
+		return fmt.Errorf("failed to update user: %v", error)
+	}
+
+	return nil
+}
+
+// DeleteUserByID deletes a user with everything they own in one transaction, rows of tables that cascade on users are removed by MySQL
+func (store *Store) DeleteUserByID(id uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	// children before parents, tasks reference board columns and everything references projects
+	// projects shared with another owner are handed over to them instead of being deleted
+	ownedProjects := "SELECT id FROM projects WHERE userID = ?"
+	queries := []string{
+		"UPDATE projects SET userID = (SELECT members.userID FROM project_members members WHERE members.projectID = projects.id AND members.role = 'OWNER' AND members.userID <> ? ORDER BY members.dateCreated LIMIT 1) WHERE userID = ? AND id IN (SELECT projectID FROM project_members WHERE role = 'OWNER' AND userID <> ?)",
+		"DELETE FROM time_entries WHERE userID = ?",
+		"DELETE FROM focus_sessions WHERE userID = ?",
+		"DELETE FROM daily_entries WHERE userID = ?",
+		"DELETE FROM standup_templates WHERE userID = ?",
+		"DELETE FROM digest_preferences WHERE userID = ?",
+		// notes the user wrote in projects that stay with other owners are kept and handed to the project owner
+		"UPDATE notes SET userID = (SELECT projects.userID FROM projects WHERE projects.id = notes.linkedProjectID) WHERE userID = ? AND linkedProjectID NOT IN (" + ownedProjects + ")",
+		"DELETE FROM notes WHERE linkedProjectID IN (" + ownedProjects + ")",
+		"DELETE FROM tasks WHERE linkedProjectID IN (" + ownedProjects + ")",
+		"DELETE FROM board_columns WHERE linkedProjectID IN (" + ownedProjects + ")",
+		"DELETE FROM projects WHERE userID = ?",
+		"DELETE FROM users WHERE id = ?",
+		// workspaces the user was the only admin of are handed to their longest standing member
+		"UPDATE workspace_members SET role = 'ADMIN' WHERE (workspaceID, userID) IN (SELECT workspaceID, userID FROM (SELECT members.workspaceID, members.userID, ROW_NUMBER() OVER (PARTITION BY members.workspaceID ORDER BY members.dateCreated) AS position FROM workspace_members members WHERE NOT EXISTS (SELECT 1 FROM workspace_members admins WHERE admins.workspaceID = members.workspaceID AND admins.role = 'ADMIN')) AS successors WHERE position = 1)",
+		// workspaces left without members and projects, like the personal workspace of the user
+		"DELETE FROM workspaces WHERE NOT EXISTS (SELECT 1 FROM workspace_members members WHERE members.workspaceID = workspaces.id) AND NOT EXISTS (SELECT 1 FROM projects WHERE projects.workspaceID = workspaces.id)",
+	}
+	for _, query := range queries {
+		args := make([]interface{}, strings.Count(query, "?"))
+		for index := range args {
+			args[index] = id
+		}
+
+		if _, error := transaction.Exec(query, args...); error != nil {
+			return fmt.Errorf("failed to delete user: %v", error)
+		}
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
 // scanUserFromRow scans a MySQL row into a new user object
 func scanUserFromRow(row *sql.Row) (*userModel.User, error) {
 	user := new(userModel.User)
-	error := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password)
+	error := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.Timezone, &user.EmailVerified, &user.PasswordChangedAt)
 
 	if error == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
@@ -0,0 +1,43 @@
+package userIdentityRepository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	userIdentityModel "github.com/hwaengfan/dev-journal-backend/internal/models/userIdentity"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// GetUserIdentity retrieves the identity of a provider account, nil when the account is not linked to a user
+func (store *Store) GetUserIdentity(issuer string, subject string) (*userIdentityModel.UserIdentity, error) {
+	userIdentity := new(userIdentityModel.UserIdentity)
+
+	query := "SELECT id, userID, issuer, subject, dateCreated FROM user_identities WHERE issuer = ? AND subject = ?"
+	error := store.database.QueryRow(query, issuer, subject).Scan(&userIdentity.ID, &userIdentity.UserID, &userIdentity.Issuer, &userIdentity.Subject, &userIdentity.DateCreated)
+	if error == sql.ErrNoRows {
+		return nil, nil
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to get user identity: %v", error)
+	}
+
+	return userIdentity, nil
+}
+
+// CreateUserIdentity links a provider account to a user
+func (store *Store) CreateUserIdentity(userIdentity userIdentityModel.UserIdentity) error {
+	query := "INSERT INTO user_identities (id, userID, issuer, subject) VALUES (?, ?, ?, ?)"
+	_, error := store.database.Exec(query, uuid.New(), userIdentity.UserID, userIdentity.Issuer, userIdentity.Subject)
+	if error != nil {
+		return fmt.Errorf("failed to create user identity: %v", error)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package userTokenRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	userTokenModel "github.com/hwaengfan/dev-journal-backend/internal/models/userToken"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateUserToken stores the hash of a newly issued token
+func (store *Store) CreateUserToken(userToken userTokenModel.UserToken) (uuid.UUID, error) {
+	tokenID := uuid.New()
+	query := "INSERT INTO user_tokens (id, userID, purpose, tokenHash, expiresAt) VALUES (?, ?, ?, ?, ?)"
+	_, error := store.database.Exec(query, tokenID, userToken.UserID, userToken.Purpose, userToken.TokenHash, userToken.ExpiresAt.UTC())
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create user token: %v", error)
+	}
+
+	return tokenID, nil
+}
+
+// ReissueUserToken replaces the hash of an unused, unexpired token, false when the token cannot be used anymore
+func (store *Store) ReissueUserToken(id uuid.UUID, tokenHash string) (bool, error) {
+	query := "UPDATE user_tokens SET tokenHash = ? WHERE id = ? AND usedAt IS NULL AND expiresAt > ?"
+	result, error := store.database.Exec(query, tokenHash, id, time.Now().UTC())
+	if error != nil {
+		return false, fmt.Errorf("failed to reissue user token: %v", error)
+	}
+
+	reissued, error := result.RowsAffected()
+	if error != nil {
+		return false, fmt.Errorf("failed to count reissued user tokens: %v", error)
+	}
+
+	return reissued == 1, nil
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns its user, a token can only be consumed once
+func (store *Store) ConsumeUserToken(purpose string, tokenHash string) (uuid.UUID, error) {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	var id, userID uuid.UUID
+	query := "SELECT id, userID FROM user_tokens WHERE tokenHash = ? AND purpose = ? AND usedAt IS NULL AND expiresAt > ? FOR UPDATE"
+	error = transaction.QueryRow(query, tokenHash, purpose, time.Now().UTC()).Scan(&id, &userID)
+	if error == sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("invalid or expired token")
+	} else if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to get user token: %v", error)
+	}
+
+	if _, error := transaction.Exec("UPDATE user_tokens SET usedAt = ? WHERE id = ?", time.Now().UTC(), id); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to consume user token: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return userID, nil
+}
+
+// CountUserTokensSince counts the tokens issued to a user for a purpose since a time
+func (store *Store) CountUserTokensSince(userID uuid.UUID, purpose string, since time.Time) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM user_tokens WHERE userID = ? AND purpose = ? AND dateCreated >= ?"
+	if error := store.database.QueryRow(query, userID, purpose, since.UTC()).Scan(&count); error != nil {
+		return 0, fmt.Errorf("failed to count user tokens: %v", error)
+	}
+
+	return count, nil
+}
+
+// InvalidateUserTokens marks every unused token of a user for a purpose as used
+func (store *Store) InvalidateUserTokens(userID uuid.UUID, purpose string) error {
+	query := "UPDATE user_tokens SET usedAt = ? WHERE userID = ? AND purpose = ? AND usedAt IS NULL"
+	_, error := store.database.Exec(query, time.Now().UTC(), userID, purpose)
+	if error != nil {
+		return fmt.Errorf("failed to invalidate user tokens: %v", error)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package workspaceRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+)
+
+type Store struct {
+	database *sql.DB
+}
+
+func NewStore(database *sql.DB) *Store {
+	return &Store{database: database}
+}
+
+// CreateWorkspace creates a new workspace with its creator as admin
+func (store *Store) CreateWorkspace(workspace workspaceModel.Workspace, adminID uuid.UUID) (uuid.UUID, error) {
+	workspaceID := uuid.New()
+
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec("INSERT INTO workspaces (id, name) VALUES (?, ?)", workspaceID, workspace.Name); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to create workspace: %v", error)
+	}
+
+	if _, error := transaction.Exec("INSERT INTO workspace_members (workspaceID, userID, role) VALUES (?, ?, 'ADMIN')", workspaceID, adminID); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to add workspace admin: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return workspaceID, nil
+}
+
+// GetWorkspacesByUserID retrieves the workspaces a user is a member or a guest of, along with their role in each
+func (store *Store) GetWorkspacesByUserID(userID uuid.UUID) ([]*workspaceModel.Workspace, error) {
+	query := "SELECT workspaces.id, workspaces.name, workspaces.allowMemberProjectCreation, workspaces.allowExternalInvitations, workspaces.dateCreated, workspaces.lastEdited, members.role, members.dateCreated AS joinedAt FROM workspaces JOIN workspace_members members ON members.workspaceID = workspaces.id WHERE members.userID = ? " +
+		"UNION ALL SELECT workspaces.id, workspaces.name, workspaces.allowMemberProjectCreation, workspaces.allowExternalInvitations, workspaces.dateCreated, workspaces.lastEdited, 'GUEST', MIN(guests.dateCreated) FROM workspaces JOIN projects ON projects.workspaceID = workspaces.id JOIN project_members guests ON guests.projectID = projects.id WHERE guests.userID = ? AND NOT EXISTS (SELECT 1 FROM workspace_members members WHERE members.workspaceID = workspaces.id AND members.userID = ?) GROUP BY workspaces.id " +
+		"ORDER BY joinedAt"
+	rows, error := store.database.Query(query, userID, userID, userID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get workspaces by user ID: %v", error)
+	}
+	defer rows.Close()
+
+	workspaces := make([]*workspaceModel.Workspace, 0)
+	for rows.Next() {
+		workspace := new(workspaceModel.Workspace)
+		var joinedAt time.Time
+		if error := rows.Scan(&workspace.ID, &workspace.Name, &workspace.AllowMemberProjectCreation, &workspace.AllowExternalInvitations, &workspace.DateCreated, &workspace.LastEdited, &workspace.Role, &joinedAt); error != nil {
+			return nil, fmt.Errorf("failed to scan workspace from rows: %v", error)
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
+// GetWorkspaceByID retrieves a workspace by its ID
+func (store *Store) GetWorkspaceByID(id uuid.UUID) (*workspaceModel.Workspace, error) {
+	query := "SELECT id, name, allowMemberProjectCreation, allowExternalInvitations, dateCreated, lastEdited FROM workspaces WHERE id = ?"
+
+	workspace := new(workspaceModel.Workspace)
+	error := store.database.QueryRow(query, id).Scan(&workspace.ID, &workspace.Name, &workspace.AllowMemberProjectCreation, &workspace.AllowExternalInvitations, &workspace.DateCreated, &workspace.LastEdited)
+	if error == sql.ErrNoRows {
+		return nil, fmt.Errorf("workspace not found")
+	} else if error != nil {
+		return nil, fmt.Errorf("failed to scan workspace from row: %v", error)
+	}
+
+	return workspace, nil
+}
+
+// UpdateWorkspaceByID updates the name and settings of a workspace
+func (store *Store) UpdateWorkspaceByID(workspace workspaceModel.Workspace, id uuid.UUID) error {
+	// base query
+	query := "UPDATE workspaces SET"
+	var updates []string
+	var args []interface{}
+
+	// conditionally add fields to update
+	if workspace.Name != "" {
+		updates = append(updates, "name = ?")
+		args = append(args, workspace.Name)
+	}
+	if workspace.AllowMemberProjectCreation != "" {
+		updates = append(updates, "allowMemberProjectCreation = ?")
+		args = append(args, workspace.AllowMemberProjectCreation)
+	}
+	if workspace.AllowExternalInvitations != "" {
+		updates = append(updates, "allowExternalInvitations = ?")
+		args = append(args, workspace.AllowExternalInvitations)
+	}
+
+	// check if there are fields to update
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	// finalize query
+	query += " " + strings.Join(updates, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	if _, error := store.database.Exec(query, args...); error != nil {
+		return fmt.Errorf("failed to update workspace: %v", error)
+	}
+
+	return nil
+}
+
+// GetWorkspaceRole retrieves the role of a user in a workspace, RoleGuest for members of its projects only and empty for everyone else
+func (store *Store) GetWorkspaceRole(workspaceID uuid.UUID, userID uuid.UUID) (string, error) {
+	var role string
+	error := store.database.QueryRow("SELECT role FROM workspace_members WHERE workspaceID = ? AND userID = ?", workspaceID, userID).Scan(&role)
+	if error == nil {
+		return role, nil
+	} else if error != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get workspace role: %v", error)
+	}
+
+	// people invited to a project stay guests of its workspace while they are members of the project
+	var projects int
+	query := "SELECT COUNT(*) FROM project_members members JOIN projects ON projects.id = members.projectID WHERE projects.workspaceID = ? AND members.userID = ?"
+	if error := store.database.QueryRow(query, workspaceID, userID).Scan(&projects); error != nil {
+		return "", fmt.Errorf("failed to get workspace projects of user: %v", error)
+	}
+
+	if projects > 0 {
+		return workspaceModel.RoleGuest, nil
+	}
+
+	return "", nil
+}
+
+// GetDefaultWorkspaceID retrieves the workspace a user joined first, uuid.Nil when they are in none
+func (store *Store) GetDefaultWorkspaceID(userID uuid.UUID) (uuid.UUID, error) {
+	var workspaceID uuid.UUID
+	error := store.database.QueryRow("SELECT workspaceID FROM workspace_members WHERE userID = ? ORDER BY dateCreated, workspaceID LIMIT 1", userID).Scan(&workspaceID)
+	if error == sql.ErrNoRows {
+		return uuid.Nil, nil
+	} else if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to get default workspace: %v", error)
+	}
+
+	return workspaceID, nil
+}
+
+// GetWorkspaceMembers retrieves the members of a workspace with their names, admins first
+func (store *Store) GetWorkspaceMembers(workspaceID uuid.UUID) ([]*workspaceModel.WorkspaceMember, error) {
+	query := "SELECT members.workspaceID, members.userID, users.firstName, users.lastName, users.email, members.role, members.dateCreated FROM workspace_members members JOIN users ON users.id = members.userID WHERE members.workspaceID = ? ORDER BY FIELD(members.role, 'ADMIN', 'MEMBER'), members.dateCreated"
+	rows, error := store.database.Query(query, workspaceID)
+	if error != nil {
+		return nil, fmt.Errorf("failed to get workspace members: %v", error)
+	}
+	defer rows.Close()
+
+	members := make([]*workspaceModel.WorkspaceMember, 0)
+	for rows.Next() {
+		member := new(workspaceModel.WorkspaceMember)
+		if error := rows.Scan(&member.WorkspaceID, &member.UserID, &member.FirstName, &member.LastName, &member.Email, &member.Role, &member.DateCreated); error != nil {
+			return nil, fmt.Errorf("failed to scan workspace member from rows: %v", error)
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// AddWorkspaceMember adds a user to a workspace
+func (store *Store) AddWorkspaceMember(workspaceID uuid.UUID, userID uuid.UUID, role string) error {
+	result, error := store.database.Exec("INSERT IGNORE INTO workspace_members (workspaceID, userID, role) VALUES (?, ?, ?)", workspaceID, userID, role)
+	if error != nil {
+		return fmt.Errorf("failed to add workspace member: %v", error)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return workspaceModel.ErrAlreadyWorkspaceMember
+	}
+
+	return nil
+}
+
+// UpdateWorkspaceMemberRole changes the role of a member, the last admin cannot be demoted
+func (store *Store) UpdateWorkspaceMemberRole(workspaceID uuid.UUID, userID uuid.UUID, role string) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if role != workspaceModel.RoleAdmin {
+		if error := ensureAnotherAdmin(transaction, workspaceID, userID); error != nil {
+			return error
+		}
+	}
+
+	if _, error := transaction.Exec("UPDATE workspace_members SET role = ? WHERE workspaceID = ? AND userID = ?", role, workspaceID, userID); error != nil {
+		return fmt.Errorf("failed to update workspace member role: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// RemoveWorkspaceMember removes a member from a workspace and from its projects
+func (store *Store) RemoveWorkspaceMember(workspaceID uuid.UUID, userID uuid.UUID) error {
+	transaction, error := store.database.Begin()
+	if error != nil {
+		return fmt.Errorf("failed to begin transaction: %v", error)
+	}
+	defer transaction.Rollback()
+
+	if error := ensureAnotherAdmin(transaction, workspaceID, userID); error != nil {
+		return error
+	}
+
+	// projects would be left without an owner
+	var soleOwnerships int
+	query := "SELECT COUNT(*) FROM project_members members JOIN projects ON projects.id = members.projectID WHERE projects.workspaceID = ? AND members.userID = ? AND members.role = 'OWNER' AND NOT EXISTS (SELECT 1 FROM project_members others WHERE others.projectID = members.projectID AND others.role = 'OWNER' AND others.userID <> members.userID)"
+	if error := transaction.QueryRow(query, workspaceID, userID).Scan(&soleOwnerships); error != nil {
+		return fmt.Errorf("failed to get project owners: %v", error)
+	}
+	if soleOwnerships > 0 {
+		return workspaceModel.ErrOnlyProjectOwner
+	}
+
+	if _, error := transaction.Exec("DELETE members FROM project_members members JOIN projects ON projects.id = members.projectID WHERE projects.workspaceID = ? AND members.userID = ?", workspaceID, userID); error != nil {
+		return fmt.Errorf("failed to remove project memberships: %v", error)
+	}
+
+	// hand the projects the member created over to their longest standing owner
+	query = "UPDATE projects SET userID = (SELECT members.userID FROM project_members members WHERE members.projectID = projects.id AND members.role = 'OWNER' ORDER BY members.dateCreated LIMIT 1) WHERE workspaceID = ? AND userID = ?"
+	if _, error := transaction.Exec(query, workspaceID, userID); error != nil {
+		return fmt.Errorf("failed to hand over projects: %v", error)
+	}
+
+	if _, error := transaction.Exec("DELETE FROM workspace_members WHERE workspaceID = ? AND userID = ?", workspaceID, userID); error != nil {
+		return fmt.Errorf("failed to remove workspace member: %v", error)
+	}
+
+	if error := transaction.Commit(); error != nil {
+		return fmt.Errorf("failed to commit transaction: %v", error)
+	}
+
+	return nil
+}
+
+// ensureAnotherAdmin fails when a user is the only admin of a workspace, so a workspace is never left without one
+func ensureAnotherAdmin(transaction *sql.Tx, workspaceID uuid.UUID, userID uuid.UUID) error {
+	// lock the admins so two admins cannot demote each other at the same time
+	rows, error := transaction.Query("SELECT userID FROM workspace_members WHERE workspaceID = ? AND role = 'ADMIN' FOR UPDATE", workspaceID)
+	if error != nil {
+		return fmt.Errorf("failed to get workspace admins: %v", error)
+	}
+	defer rows.Close()
+
+	isAdmin, otherAdmins := false, 0
+	for rows.Next() {
+		var adminID uuid.UUID
+		if error := rows.Scan(&adminID); error != nil {
+			return fmt.Errorf("failed to scan workspace admin from rows: %v", error)
+		}
+
+		if adminID == userID {
+			isAdmin = true
+		} else {
+			otherAdmins++
+		}
+	}
+
+	if isAdmin && otherAdmins == 0 {
+		return workspaceModel.ErrLastAdmin
+	}
+
+	return nil
+}
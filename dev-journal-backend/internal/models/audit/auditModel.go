@@ -0,0 +1,74 @@
+package auditModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of entities audit events describe
+const (
+	EntityUser              = "USER"
+	EntityProject           = "PROJECT"
+	EntityProjectMember     = "PROJECT_MEMBER" // identified by the ID of the member
+	EntityProjectInvitation = "PROJECT_INVITATION"
+	EntityNote              = "NOTE"
+	EntityTask              = "TASK"
+	EntityComment           = "COMMENT"
+)
+
+// Actions recorded in the audit, the security log also lists the events of the login audit
+const (
+	ActionCreate            = "CREATE"
+	ActionUpdate            = "UPDATE"
+	ActionDelete            = "DELETE"
+	ActionTokenCreated      = "TOKEN_CREATED" // the purpose of the token is recorded as a change
+	ActionPasswordChanged   = "PASSWORD_CHANGED"
+	ActionTwoFactorEnabled  = "TWO_FACTOR_ENABLED"
+	ActionTwoFactorDisabled = "TWO_FACTOR_DISABLED"
+)
+
+// Purposes of the tokens recorded with TOKEN_CREATED besides the ones mailed to users
+const (
+	TokenSession       = "SESSION"
+	TokenRecoveryCodes = "RECOVERY_CODES"
+	TokenShareLink     = "SHARE_LINK"
+)
+
+// Audit events are append-only and outlive the users and projects they describe
+type AuditEvent struct {
+	ID          uuid.UUID          `json:"id"`
+	ActorID     uuid.NullUUID      `json:"actorID"` // not set for events without a logged in user, like a password reset
+	FirstName   string             `json:"firstName"`
+	LastName    string             `json:"lastName"`
+	ProjectID   uuid.NullUUID      `json:"projectID"`
+	EntityType  string             `json:"entityType"`
+	EntityID    uuid.UUID          `json:"entityID"`
+	Action      string             `json:"action"`
+	Changes     map[string]*Change `json:"changes"`
+	IPAddress   string             `json:"ipAddress"`
+	UserAgent   string             `json:"userAgent"`
+	DateCreated time.Time          `json:"dateCreated"`
+}
+
+// A change holds the values of a field before and after an event, nil on the side where the entity does not exist
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditEventFilter struct {
+	EntityType string
+	EntityID   uuid.UUID
+	ActorID    uuid.UUID
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+type AuditEventStore interface {
+	CreateAuditEvent(event AuditEvent) error
+	GetAuditEventsByProjectID(projectID uuid.UUID, filter AuditEventFilter) ([]*AuditEvent, error)
+	GetSecurityEventsByUserID(userID uuid.UUID, limit int) ([]*AuditEvent, error)
+}
@@ -0,0 +1,80 @@
+package columnModel
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+type Column struct {
+	ID              uuid.UUID `json:"id"`
+	LinkedProjectID uuid.UUID `json:"linkedProjectID"`
+	Title           string    `json:"title"`
+	Position        int       `json:"position"`
+	IsDone          string    `json:"isDone"`
+}
+
+type Transition struct {
+	FromColumnID uuid.UUID `json:"fromColumnID" validate:"required"`
+	ToColumnID   uuid.UUID `json:"toColumnID" validate:"required"`
+}
+
+// Returned when deleting a column that still holds tasks, or the only column of a board
+var (
+	ErrColumnNotEmpty = fmt.Errorf("column still contains tasks")
+	ErrLastColumn     = fmt.Errorf("a board needs at least one column")
+)
+
+// Columns every project starts with
+var DefaultColumns = []Column{
+	{Title: "To Do", Position: 0, IsDone: "False"},
+	{Title: "In Progress", Position: 1, IsDone: "False"},
+	{Title: "Done", Position: 2, IsDone: "True"},
+}
+
+type ColumnStore interface {
+	CreateColumn(column Column) (uuid.UUID, error)
+	CreateDefaultColumns(linkedProjectID uuid.UUID) error
+	GetColumnsByLinkedProjectID(linkedProjectID uuid.UUID) ([]*Column, error)
+	GetColumnByID(id uuid.UUID) (*Column, error)
+	UpdateColumnByID(column Column, id uuid.UUID) error
+	MoveColumnByID(id uuid.UUID, position int) error
+	DeleteColumnByID(id uuid.UUID) error
+	DeleteColumnsByLinkedProjectID(linkedProjectID uuid.UUID) error
+	GetTransitionsByLinkedProjectID(linkedProjectID uuid.UUID) ([]*Transition, error)
+	SetTransitionsByLinkedProjectID(linkedProjectID uuid.UUID, transitions []Transition) error
+}
+
+type BoardColumn struct {
+	Column
+	Tasks []*taskModel.Task `json:"tasks"`
+}
+
+type Board struct {
+	LinkedProjectID uuid.UUID      `json:"linkedProjectID"`
+	Columns         []*BoardColumn `json:"columns"`
+	Transitions     []*Transition  `json:"transitions"`
+}
+
+type CreateColumnPayload struct {
+	LinkedProjectID uuid.UUID `json:"linkedProjectID" validate:"required"`
+	Title           string    `json:"title" validate:"required"`
+	Position        *int      `json:"position"` // appended to the end of the board when omitted
+	IsDone          string    `json:"isDone"`
+}
+
+type UpdateColumnPayload struct {
+	Title    string `json:"title"`
+	Position *int   `json:"position"`
+	IsDone   string `json:"isDone"`
+}
+
+type SetTransitionsPayload struct {
+	Transitions []Transition `json:"transitions" validate:"dive"`
+}
+
+type MoveTaskPayload struct {
+	ColumnID uuid.UUID `json:"columnID" validate:"required"`
+	Position int       `json:"position" validate:"min=0"`
+}
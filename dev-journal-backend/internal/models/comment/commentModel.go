@@ -0,0 +1,55 @@
+package commentModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	markdownModel "github.com/hwaengfan/dev-journal-backend/internal/models/markdown"
+)
+
+// Comments belong to a note or a task, replies belong to the first comment of their thread
+type Comment struct {
+	ID              uuid.UUID                       `json:"id"`
+	ProjectID       uuid.UUID                       `json:"projectID"`
+	NoteID          uuid.NullUUID                   `json:"noteID"`
+	TaskID          uuid.NullUUID                   `json:"taskID"`
+	ParentCommentID uuid.NullUUID                   `json:"parentCommentID"`
+	UserID          uuid.NullUUID                   `json:"userID"` // author, not set once their account is deleted
+	FirstName       string                          `json:"firstName"`
+	LastName        string                          `json:"lastName"`
+	Content         string                          `json:"content"`
+	Rendered        *markdownModel.RenderedMarkdown `json:"rendered"`
+	Mentions        []*Mention                      `json:"mentions"`
+	DateCreated     time.Time                       `json:"dateCreated"`
+	LastEdited      *time.Time                      `json:"lastEdited"`
+	DateDeleted     *time.Time                      `json:"dateDeleted"` // deleted comments with replies keep their place in the thread without content
+	Replies         []*Comment                      `json:"replies,omitempty"`
+}
+
+// Mentions are written as @ followed by the email of a project member
+type Mention struct {
+	UserID    uuid.UUID `json:"userID"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+}
+
+type CommentStore interface {
+	CreateComment(comment Comment, mentionedUserIDs []uuid.UUID) (uuid.UUID, error)
+	GetCommentByID(id uuid.UUID) (*Comment, error)
+	GetCommentsByNoteID(noteID uuid.UUID) ([]*Comment, error)
+	GetCommentsByTaskID(taskID uuid.UUID) ([]*Comment, error)
+	GetRecentCommentsByProjectID(projectID uuid.UUID, limit int) ([]*Comment, error)
+	UpdateCommentByID(id uuid.UUID, content string, mentionedUserIDs []uuid.UUID) error
+	DeleteCommentByID(id uuid.UUID) error
+}
+
+type CreateCommentPayload struct {
+	NoteID          uuid.UUID `json:"noteID"`
+	TaskID          uuid.UUID `json:"taskID"`
+	ParentCommentID uuid.UUID `json:"parentCommentID"`
+	Content         string    `json:"content" validate:"required,max=10000"`
+}
+
+type UpdateCommentPayload struct {
+	Content string `json:"content" validate:"required,max=10000"`
+}
@@ -0,0 +1,31 @@
+package dailyEntryModel
+
+import (
+	"github.com/google/uuid"
+)
+
+type DailyEntry struct {
+	ID               uuid.UUID   `json:"id"`
+	UserID           uuid.UUID   `json:"userID"`
+	WorkspaceID      uuid.UUID   `json:"workspaceID"` // a user keeps one entry per day in every workspace
+	EntryDate        string      `json:"entryDate"` // calendar day in the user's timezone
+	Content          string      `json:"content"`
+	LinkedProjectIDs []uuid.UUID `json:"linkedProjectIDs"`
+	DateCreated      string      `json:"dateCreated"`
+	LastEdited       string      `json:"lastEdited"`
+}
+
+type DailyEntryStore interface {
+	GetOrCreateDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*DailyEntry, error)
+	GetDailyEntryByID(id uuid.UUID) (*DailyEntry, error)
+	GetPreviousDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*DailyEntry, error)
+	GetNextDailyEntry(userID uuid.UUID, workspaceID uuid.UUID, entryDate string) (*DailyEntry, error)
+	GetDailyEntryDatesByUserID(userID uuid.UUID, workspaceID uuid.UUID, from string, to string) ([]string, error)
+	UpdateDailyEntryByID(id uuid.UUID, content *string, linkedProjectIDs *[]uuid.UUID) error
+	DeleteDailyEntryByID(id uuid.UUID) error
+}
+
+type UpdateDailyEntryPayload struct {
+	Content          *string      `json:"content"`
+	LinkedProjectIDs *[]uuid.UUID `json:"linkedProjectIDs"` // replaces the linked projects when set
+}
@@ -0,0 +1,43 @@
+package dataExportModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses an export goes through, the archive is deleted once it is expired
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+	StatusExpired   = "EXPIRED"
+)
+
+type DataExport struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"userID"`
+	WorkspaceID   uuid.UUID  `json:"workspaceID"` // the archive holds the projects of this workspace
+	Status        string     `json:"status"`
+	FileSize      int64      `json:"fileSize"`
+	Error         *string    `json:"error"`
+	DateCreated   time.Time  `json:"dateCreated"`
+	StartedAt     *time.Time `json:"startedAt"`
+	DateCompleted *time.Time `json:"dateCompleted"`
+	ExpiresAt     *time.Time `json:"expiresAt"` // when the archive is deleted, it is stored with the export so any server can serve it
+	DownloadURL   string     `json:"downloadURL,omitempty"`
+}
+
+type DataExportStore interface {
+	CreateDataExport(userID uuid.UUID, workspaceID uuid.UUID) (uuid.UUID, error)
+	GetDataExportByID(id uuid.UUID) (*DataExport, error)
+	GetDataExportsByUserID(userID uuid.UUID, workspaceID uuid.NullUUID, limit int) ([]*DataExport, error)
+	GetActiveDataExportByUserID(userID uuid.UUID) (*DataExport, error)
+	ClaimNextDataExport(now time.Time, lease time.Duration) (*DataExport, error)
+	ExtendDataExportLease(id uuid.UUID, leaseUntil time.Time) error
+	CompleteDataExport(id uuid.UUID, archive []byte, completedAt time.Time, expiresAt time.Time) error
+	GetDataExportArchive(id uuid.UUID) ([]byte, error)
+	FailDataExport(id uuid.UUID, message string) error
+	ExpireDataExports(now time.Time) ([]uuid.UUID, error)
+}
@@ -0,0 +1,64 @@
+package digestModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+type DigestPreference struct {
+	UserID           uuid.UUID  `json:"userID"`
+	WeeklyDigest     string     `json:"weeklyDigest"`
+	LastDigestSentAt *time.Time `json:"lastDigestSentAt"`
+}
+
+type DigestRecipient struct {
+	UserID           uuid.UUID
+	FirstName        string
+	Email            string
+	Timezone         string
+	LastDigestSentAt *time.Time
+}
+
+type OverdueProject struct {
+	Project   *projectModel.Project `json:"project"`
+	OpenTasks int                   `json:"openTasks"`
+}
+
+type ProjectTasks struct {
+	ProjectTitle string            `json:"projectTitle"`
+	Tasks        []*taskModel.Task `json:"tasks"`
+}
+
+// Part of a digest covering the projects of one workspace, workspaces are never mixed
+type WorkspaceDigest struct {
+	WorkspaceID     uuid.UUID         `json:"workspaceID"`
+	WorkspaceName   string            `json:"workspaceName"`
+	OverdueProjects []*OverdueProject `json:"overdueProjects"`
+	OpenTaskCount   int               `json:"openTaskCount"`
+	OpenTasks       []*ProjectTasks   `json:"openTasks"`
+	RecentNotes     []*noteModel.Note `json:"recentNotes"`
+}
+
+type Digest struct {
+	FirstName  string             `json:"firstName"`
+	WeekStart  string             `json:"weekStart"`
+	Workspaces []*WorkspaceDigest `json:"workspaces"`
+}
+
+type DigestStore interface {
+	GetDigestPreferenceByUserID(userID uuid.UUID) (*DigestPreference, error)
+	SetWeeklyDigestByUserID(userID uuid.UUID, weeklyDigest string) error
+	GetWeeklyDigestRecipients() ([]*DigestRecipient, error)
+	MarkDigestSentByUserID(userID uuid.UUID, sentAt time.Time) error
+	ClaimWeeklyDigest(userID uuid.UUID, weekStart string) (bool, error)
+	ReleaseWeeklyDigest(userID uuid.UUID, weekStart string) error
+	DeleteWeeklyDigestsBefore(weekStart string) error
+}
+
+type UpdateDigestPreferencePayload struct {
+	WeeklyDigest string `json:"weeklyDigest" validate:"required,oneof=True False"`
+}
@@ -0,0 +1,82 @@
+package focusSessionModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Focus session statuses, a user has at most one session that is not finished
+const (
+	FocusSessionRunning  = "RUNNING"
+	FocusSessionPaused   = "PAUSED"
+	FocusSessionFinished = "FINISHED"
+)
+
+type FocusSession struct {
+	ID                     uuid.UUID     `json:"id"`
+	UserID                 uuid.UUID     `json:"userID"`
+	WorkspaceID            uuid.UUID     `json:"workspaceID"` // workspace the session was started in, it is listed in it only
+	TaskID                 uuid.NullUUID `json:"taskID"`
+	LinkedProjectID        uuid.NullUUID `json:"linkedProjectID"`
+	ReflectionNoteID       uuid.NullUUID `json:"reflectionNoteID"`
+	Status                 string        `json:"status"`
+	PlannedLengthInSeconds int64         `json:"plannedLengthInSeconds"`
+	ActualLengthInSeconds  int64         `json:"actualLengthInSeconds"`
+	PausedSeconds          int64         `json:"pausedSeconds"`
+	Interruptions          int           `json:"interruptions"`
+	StartedAt              time.Time     `json:"startedAt"`
+	PausedAt               *time.Time    `json:"pausedAt"`
+	EndedAt                *time.Time    `json:"endedAt"`
+}
+
+type FocusSessionFilter struct {
+	From            *time.Time
+	To              *time.Time
+	LinkedProjectID uuid.UUID
+	WorkspaceID     uuid.NullUUID // sessions of every workspace when not valid
+}
+
+type FocusSessionStore interface {
+	CreateFocusSession(focusSession FocusSession) (uuid.UUID, error)
+	GetFocusSessionsByUserID(userID uuid.UUID, filter FocusSessionFilter) ([]*FocusSession, error)
+	GetFocusSessionByID(id uuid.UUID) (*FocusSession, error)
+	GetActiveFocusSessionByUserID(userID uuid.UUID) (*FocusSession, error)
+	UpdateFocusSessionStateByID(focusSession FocusSession, id uuid.UUID) error
+	DeleteFocusSessionByID(id uuid.UUID) error
+}
+
+type FocusStatistics struct {
+	Sessions               int   `json:"sessions"`
+	CompletedSessions      int   `json:"completedSessions"` // sessions that reached their planned length
+	PlannedLengthInSeconds int64 `json:"plannedLengthInSeconds"`
+	ActualLengthInSeconds  int64 `json:"actualLengthInSeconds"`
+	Interruptions          int   `json:"interruptions"`
+}
+
+type PeriodStatistics struct {
+	PeriodStart string `json:"periodStart"`
+	FocusStatistics
+}
+
+type ProjectStatistics struct {
+	LinkedProjectID uuid.NullUUID `json:"linkedProjectID"` // null groups the sessions without a project
+	FocusStatistics
+}
+
+type FocusSessionStatistics struct {
+	Period   string               `json:"period"`
+	Total    FocusStatistics      `json:"total"`
+	Periods  []*PeriodStatistics  `json:"periods"`
+	Projects []*ProjectStatistics `json:"projects"`
+}
+
+type StartFocusSessionPayload struct {
+	TaskID                 uuid.UUID `json:"taskID"`
+	PlannedLengthInMinutes int       `json:"plannedLengthInMinutes" validate:"required,min=1,max=480"`
+}
+
+type FinishFocusSessionPayload struct {
+	ReflectionNoteID uuid.UUID `json:"reflectionNoteID"`
+	Reflection       string    `json:"reflection"`
+}
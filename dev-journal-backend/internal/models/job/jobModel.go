@@ -0,0 +1,55 @@
+package jobModel
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses a job goes through, a failed job is pending again until it runs out of attempts
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusDead      = "DEAD" // dead letter, kept for inspection until it is retried by hand
+)
+
+// Statuses lists every job status
+var Statuses = []string{StatusPending, StatusRunning, StatusSucceeded, StatusDead}
+
+type Job struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"-"` // never returned, payloads can hold mails and other personal data
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"maxAttempts"`
+	RunAt         time.Time       `json:"runAt"`       // pending jobs are not run before
+	LockedBy      uuid.NullUUID   `json:"lockedBy"`    // worker running the job
+	LockedUntil   *time.Time      `json:"lockedUntil"` // other workers take over a running job once it passes
+	LastError     *string         `json:"lastError"`
+	DateCreated   time.Time       `json:"dateCreated"`
+	DateCompleted *time.Time      `json:"dateCompleted"`
+}
+
+type JobFilter struct {
+	Status string
+	Type   string
+	Limit  int
+}
+
+type JobStore interface {
+	CreateJob(job Job) (uuid.UUID, error)
+	GetJobByID(id uuid.UUID) (*Job, error)
+	GetJobs(filter JobFilter) ([]*Job, error)
+	CountJobsByStatus() (map[string]int, error)
+	ClaimNextJob(types []string, workerID uuid.UUID, now time.Time, lockedUntil time.Time) (*Job, error)
+	ExtendJobLock(id uuid.UUID, workerID uuid.UUID, lockedUntil time.Time) (bool, error)
+	CompleteJob(id uuid.UUID, workerID uuid.UUID, completedAt time.Time) error
+	DeleteJob(id uuid.UUID, workerID uuid.UUID) error
+	RetryJob(id uuid.UUID, workerID uuid.UUID, runAt time.Time, message string) error
+	KillJob(id uuid.UUID, workerID uuid.UUID, message string) error
+	RequeueDeadJob(id uuid.UUID, runAt time.Time) (bool, error)
+	DeleteSucceededJobs(completedBefore time.Time) (int64, error)
+}
@@ -0,0 +1,13 @@
+package jobLockModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Background jobs take a lock before running so only one server runs each of them at a time
+type JobLockStore interface {
+	AcquireJobLock(name string, holder uuid.UUID, lease time.Duration) (bool, error)
+	ReleaseJobLock(name string, holder uuid.UUID) error
+}
@@ -0,0 +1,42 @@
+package loginAttemptModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Events recorded in the login audit
+const (
+	EventLoginSucceeded  = "LOGIN_SUCCEEDED"
+	EventLoginFailed     = "LOGIN_FAILED"
+	EventLoginBlocked    = "LOGIN_BLOCKED" // attempted while locked out, the password was not checked
+	EventAccountLocked   = "ACCOUNT_LOCKED"
+	EventAccountUnlocked = "ACCOUNT_UNLOCKED"
+)
+
+// AttemptTracker keeps the failed login counters of accounts and IP addresses, keys are prefixed with what they count
+type AttemptTracker interface {
+	GetLockedUntil(key string) (*time.Time, error)
+	IncrementFailures(key string, now time.Time, resetAfter time.Duration) (int, error)
+	SetLockedUntil(key string, lockedUntil time.Time) error
+	ResetAttempts(key string) error
+}
+
+type LoginAuditEvent struct {
+	ID          uuid.UUID     `json:"id"`
+	UserID      uuid.NullUUID `json:"userID"`
+	Email       string        `json:"email"`
+	IPAddress   string        `json:"ipAddress"`
+	Event       string        `json:"event"`
+	DateCreated time.Time     `json:"dateCreated"`
+}
+
+type LoginAuditStore interface {
+	CreateLoginAuditEvent(event LoginAuditEvent) error
+	GetLoginAuditEventsByUserID(userID uuid.UUID, limit int) ([]*LoginAuditEvent, error)
+}
+
+type UnlockAccountPayload struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,13 @@
+package markdownModel
+
+// Markdown rendered to HTML, raw HTML in the source is escaped and only safe link targets are kept
+type RenderedMarkdown struct {
+	HTML            string     `json:"html"`
+	TableOfContents []*Heading `json:"tableOfContents"`
+}
+
+type Heading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"` // ID of the heading element, links to it use #anchor
+}
@@ -1,25 +1,30 @@
 package noteModel
 
 import (
+	"time"
+
 	"github.com/google/uuid"
+	markdownModel "github.com/hwaengfan/dev-journal-backend/internal/models/markdown"
 )
 
 type Note struct {
-	ID              uuid.UUID `json:"id"`
-	UserID          uuid.UUID `json:"userID"`
-	LinkedProjectID uuid.UUID `json:"linkedProjectID"`
-	Title           string    `json:"title"`
-	Content         string    `json:"content"`
-	Favorited       string    `json:"favorited"`
-	Tags            []string  `json:"tags"`
-	DateCreated     string    `json:"dateCreated"`
-	LastEdited      string    `json:"lastEdited"`
+	ID              uuid.UUID                       `json:"id"`
+	UserID          uuid.UUID                       `json:"userID"`
+	LinkedProjectID uuid.UUID                       `json:"linkedProjectID"`
+	Title           string                          `json:"title"`
+	Content         string                          `json:"content"`
+	Favorited       string                          `json:"favorited"`
+	Tags            []string                        `json:"tags"`
+	DateCreated     string                          `json:"dateCreated"`
+	LastEdited      string                          `json:"lastEdited"`
+	Rendered        *markdownModel.RenderedMarkdown `json:"rendered,omitempty"` // only set when requested with ?render=html
 }
 
 type NoteStore interface {
 	CreateNote(note Note) (uuid.UUID, error)
 	GetNotesByLinkedProjectID(linkedProjectID uuid.UUID) ([]*Note, error)
 	GetNoteByID(id uuid.UUID) (*Note, error)
+	GetNotesEditedByUserID(userID uuid.UUID, workspaceID uuid.NullUUID, from time.Time, to time.Time) ([]*Note, error)
 	UpdateNoteByID(note Note, id uuid.UUID) error
 	DeleteNoteByID(id uuid.UUID) error
 	DeleteNotesByLinkedProjectID(linkedProjectID uuid.UUID) error
@@ -0,0 +1,74 @@
+package notificationModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of notifications, users choose per type how they want to receive them
+const (
+	TypeMention             = "MENTION"              // mentioned in a comment
+	TypeTaskAssigned        = "TASK_ASSIGNED"        // assigned to a task by someone else
+	TypeDeadlineApproaching = "DEADLINE_APPROACHING" // a project or task is due soon
+	TypeProjectInvitation   = "PROJECT_INVITATION"   // invited to a project
+)
+
+// Types lists every notification type in the order preferences are shown
+var Types = []string{TypeMention, TypeTaskAssigned, TypeDeadlineApproaching, TypeProjectInvitation}
+
+// Types of entities a notification links to
+const (
+	EntityProject           = "PROJECT"
+	EntityProjectInvitation = "PROJECT_INVITATION"
+	EntityNote              = "NOTE"
+	EntityTask              = "TASK"
+	EntityComment           = "COMMENT"
+)
+
+type Notification struct {
+	ID          uuid.UUID     `json:"id"`
+	UserID      uuid.UUID     `json:"userID"`
+	Type        string        `json:"type"`
+	ActorID     uuid.NullUUID `json:"actorID"` // not set for notifications the server sends by itself
+	FirstName   string        `json:"firstName"`
+	LastName    string        `json:"lastName"`
+	ProjectID   uuid.NullUUID `json:"projectID"`
+	EntityType  string        `json:"entityType"`
+	EntityID    uuid.UUID     `json:"entityID"`
+	Message     string        `json:"message"`
+	ReadAt      *time.Time    `json:"readAt"`
+	DateCreated time.Time     `json:"dateCreated"`
+}
+
+// Users without a preference for a type get it in their inbox only
+type NotificationPreference struct {
+	Type  string `json:"type" validate:"required,oneof=MENTION TASK_ASSIGNED DEADLINE_APPROACHING PROJECT_INVITATION"`
+	InApp string `json:"inApp" validate:"required,oneof=True False"`
+	Email string `json:"email" validate:"required,oneof=True False"`
+}
+
+type NotificationFilter struct {
+	Unread bool
+	Type   string
+	Limit  int
+}
+
+type NotificationStore interface {
+	CreateNotification(notification Notification) (uuid.UUID, error)
+	GetNotificationByID(id uuid.UUID) (*Notification, error)
+	GetNotificationsByUserID(userID uuid.UUID, filter NotificationFilter) ([]*Notification, error)
+	CountUnreadNotificationsByUserID(userID uuid.UUID) (int, error)
+	SetNotificationReadByID(id uuid.UUID, read bool) error
+	MarkNotificationsReadByUserID(userID uuid.UUID, ids []uuid.UUID) (int64, error)
+	GetNotificationPreferencesByUserID(userID uuid.UUID) ([]*NotificationPreference, error)
+	SetNotificationPreferenceByUserID(userID uuid.UUID, preference NotificationPreference) error
+}
+
+type MarkNotificationsReadPayload struct {
+	NotificationIDs []uuid.UUID `json:"notificationIDs"` // every unread notification is marked when empty
+}
+
+type UpdateNotificationPreferencesPayload struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,min=1,dive"`
+}
@@ -4,18 +4,20 @@ import "github.com/google/uuid"
 
 type Project struct {
 	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"userID"`
+	UserID      uuid.UUID `json:"userID"` // creator, handed over to another owner when they stop owning the project
+	WorkspaceID uuid.UUID `json:"workspaceID"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Priority    string    `json:"priority"`
 	Deadline    string    `json:"deadline"`
 	DateCreated string    `json:"dateCreated"`
 	LastEdited  string    `json:"lastEdited"`
+	Role        string    `json:"role,omitempty"` // role of the requesting user in the project
 }
 
 type ProjectStore interface {
 	CreateProject(project Project) (uuid.UUID, error)
-	GetProjectsByUserID(userID uuid.UUID) ([]*Project, error)
+	GetProjectsByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*Project, error)
 	GetProjectByID(id uuid.UUID) (*Project, error)
 	UpdateProjectByID(project Project, id uuid.UUID) error
 	DeleteProjectByID(id uuid.UUID) error
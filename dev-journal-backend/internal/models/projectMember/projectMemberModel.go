@@ -0,0 +1,76 @@
+package projectMemberModel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of a project member, each role can do everything the roles below it can
+const (
+	RoleOwner  = "OWNER"  // manages members and can delete the project
+	RoleEditor = "EDITOR" // edits the project, its notes, tasks and board
+	RoleViewer = "VIEWER" // reads the project, its notes, tasks and board
+)
+
+// Statuses of an invitation, only pending invitations can be answered
+const (
+	InvitationPending  = "PENDING"
+	InvitationAccepted = "ACCEPTED"
+	InvitationDeclined = "DECLINED"
+	InvitationRevoked  = "REVOKED"
+)
+
+// Returned by the member store when a change conflicts with the current members or invitations
+var (
+	ErrLastOwner            = fmt.Errorf("a project needs at least one owner")
+	ErrInvitationNotPending = fmt.Errorf("invitation is no longer pending")
+	ErrAlreadyProjectMember = fmt.Errorf("already a member of the project")
+)
+
+type ProjectMember struct {
+	ProjectID   uuid.UUID `json:"projectID"`
+	UserID      uuid.UUID `json:"userID"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type ProjectInvitation struct {
+	ID           uuid.UUID     `json:"id"`
+	ProjectID    uuid.UUID     `json:"projectID"`
+	ProjectTitle string        `json:"projectTitle"`
+	Email        string        `json:"email"`
+	Role         string        `json:"role"`
+	InvitedBy    uuid.NullUUID `json:"invitedBy"`
+	Status       string        `json:"status"`
+	ExpiresAt    time.Time     `json:"expiresAt"`
+	RespondedAt  *time.Time    `json:"respondedAt"`
+	DateCreated  time.Time     `json:"dateCreated"`
+}
+
+type ProjectMemberStore interface {
+	GetProjectRole(projectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID) (string, error)
+	GetProjectMembers(projectID uuid.UUID) ([]*ProjectMember, error)
+	UpdateProjectMemberRole(projectID uuid.UUID, userID uuid.UUID, role string) error
+	RemoveProjectMember(projectID uuid.UUID, userID uuid.UUID) error
+	CreateProjectInvitation(invitation ProjectInvitation) (uuid.UUID, error)
+	GetProjectInvitationByID(id uuid.UUID) (*ProjectInvitation, error)
+	GetPendingProjectInvitationsByProjectID(projectID uuid.UUID) ([]*ProjectInvitation, error)
+	GetPendingProjectInvitationsByEmail(email string) ([]*ProjectInvitation, error)
+	AcceptProjectInvitation(id uuid.UUID, userID uuid.UUID) error
+	SetProjectInvitationStatus(id uuid.UUID, status string) error
+}
+
+// Ownership is only granted to members, by changing their role
+type InviteProjectMemberPayload struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=EDITOR VIEWER"`
+}
+
+type UpdateProjectMemberRolePayload struct {
+	Role string `json:"role" validate:"required,oneof=OWNER EDITOR VIEWER"`
+}
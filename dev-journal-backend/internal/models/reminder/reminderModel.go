@@ -0,0 +1,37 @@
+package reminderModel
+
+import "github.com/google/uuid"
+
+// Types of entities with a deadline to be reminded of
+const (
+	EntityProject = "PROJECT"
+	EntityTask    = "TASK"
+)
+
+// A deadline along with one of the users to remind of it
+type Deadline struct {
+	EntityType string
+	EntityID   uuid.UUID
+	ProjectID  uuid.UUID
+	Title      string
+	DueDate    string
+	UserID     uuid.UUID
+	Timezone   string
+}
+
+// A reminder is sent once per deadline, user and window, a deadline moved to another date is reminded of again
+type Reminder struct {
+	EntityType    string
+	EntityID      uuid.UUID
+	UserID        uuid.UUID
+	DueDate       string
+	WindowInHours int
+}
+
+type ReminderStore interface {
+	GetProjectDeadlines(from string, to string) ([]*Deadline, error)
+	GetTaskDeadlines(from string, to string) ([]*Deadline, error)
+	ClaimReminder(reminder Reminder) (bool, error)
+	ReleaseReminder(reminder Reminder) error
+	DeleteRemindersDueBefore(date string) error
+}
@@ -0,0 +1,43 @@
+package reportModel
+
+import (
+	"github.com/google/uuid"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+type Blocker struct {
+	Task      *taskModel.Task   `json:"task"`
+	BlockedBy []*taskModel.Task `json:"blockedBy"` // incomplete tasks the task waits on
+}
+
+type ProjectReport struct {
+	LinkedProjectID uuid.UUID         `json:"linkedProjectID"`
+	ProjectTitle    string            `json:"projectTitle"`
+	CompletedTasks  []*taskModel.Task `json:"completedTasks"`
+	CreatedTasks    []*taskModel.Task `json:"createdTasks"`
+	MovedTasks      []*taskModel.Task `json:"movedTasks"`
+	EditedNotes     []*noteModel.Note `json:"editedNotes"`
+	TrackedSeconds  int64             `json:"trackedSeconds"`
+	FocusedSeconds  int64             `json:"focusedSeconds"`
+	FocusSessions   int               `json:"focusSessions"`
+	PlannedTasks    []*taskModel.Task `json:"plannedTasks"` // open tasks that are due or were worked on
+	Blockers        []*Blocker        `json:"blockers"`
+}
+
+type StandupReport struct {
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	Timezone string           `json:"timezone"`
+	Projects []*ProjectReport `json:"projects"`
+}
+
+type ReportStore interface {
+	GetStandupTemplateByUserID(userID uuid.UUID) (string, error)
+	SetStandupTemplateByUserID(userID uuid.UUID, template string) error
+	DeleteStandupTemplateByUserID(userID uuid.UUID) error
+}
+
+type UpdateStandupTemplatePayload struct {
+	Template string `json:"template" validate:"required,max=10000"`
+}
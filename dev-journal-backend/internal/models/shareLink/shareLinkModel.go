@@ -0,0 +1,72 @@
+package shareLinkModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	markdownModel "github.com/hwaengfan/dev-journal-backend/internal/models/markdown"
+)
+
+// A share link shows a note, or a whole project when NoteID is not set, to anyone holding its token
+type ShareLink struct {
+	ID           uuid.UUID     `json:"id"`
+	TokenHash    string        `json:"-"`
+	ProjectID    uuid.UUID     `json:"projectID"`
+	NoteID       uuid.NullUUID `json:"noteID"`
+	CreatedBy    uuid.NullUUID `json:"createdBy"`
+	PasswordHash *string       `json:"-"`
+	HasPassword  bool          `json:"hasPassword"`
+	ExpiresAt    *time.Time    `json:"expiresAt"`
+	RevokedAt    *time.Time    `json:"revokedAt"`
+	ViewCount    int           `json:"viewCount"`
+	LastViewedAt *time.Time    `json:"lastViewedAt"`
+	DateCreated  time.Time     `json:"dateCreated"`
+	URL          string        `json:"url,omitempty"` // only returned when the link is created, the token is not stored
+}
+
+type ShareLinkStore interface {
+	CreateShareLink(shareLink ShareLink) (uuid.UUID, error)
+	GetShareLinkByID(id uuid.UUID) (*ShareLink, error)
+	GetShareLinkByTokenHash(tokenHash string) (*ShareLink, error)
+	GetShareLinksByProjectID(projectID uuid.UUID) ([]*ShareLink, error)
+	RevokeShareLink(id uuid.UUID, revokedAt time.Time) error
+	RecordShareLinkView(id uuid.UUID, viewedAt time.Time) error
+}
+
+// Shared content only holds what a reader without an account may see
+type SharedNote struct {
+	Title      string                          `json:"title"`
+	Content    string                          `json:"content"`
+	Rendered   *markdownModel.RenderedMarkdown `json:"rendered"`
+	Tags       []string                        `json:"tags"`
+	LastEdited string                          `json:"lastEdited"`
+}
+
+type SharedTask struct {
+	Description string        `json:"description"`
+	Completed   string        `json:"completed"`
+	DueDate     *string       `json:"dueDate"`
+	Subtasks    []*SharedTask `json:"subtasks,omitempty"`
+}
+
+type SharedProject struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Priority    string        `json:"priority"`
+	Deadline    string        `json:"deadline"`
+	Tasks       []*SharedTask `json:"tasks"`
+	Notes       []*SharedNote `json:"notes"`
+}
+
+type SharedContent struct {
+	Note      *SharedNote    `json:"note,omitempty"`
+	Project   *SharedProject `json:"project,omitempty"`
+	ExpiresAt *time.Time     `json:"expiresAt"`
+}
+
+type CreateShareLinkPayload struct {
+	ProjectID uuid.UUID  `json:"projectID"`
+	NoteID    uuid.UUID  `json:"noteID"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Password  string     `json:"password" validate:"omitempty,min=8,max=72"`
+}
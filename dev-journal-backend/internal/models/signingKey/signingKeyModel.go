@@ -0,0 +1,20 @@
+package signingKeyModel
+
+import "time"
+
+// ID of the row standing for JWT_SECRET, it is stored retired when the first key replaces HS256 so tokens signed with the secret verify until they expire
+const SecretKeyID = "jwt-secret"
+
+type SigningKey struct {
+	ID          string // kid header of the tokens signed with the key
+	Algorithm   string // RS256 or EdDSA, HS256 for the JWT_SECRET row
+	PrivateKey  string // PKCS #8 PEM encrypted with JWT_KEY_ENCRYPTION_KEY
+	DateCreated time.Time
+	RetiredAt   *time.Time // retired keys no longer sign but keep verifying until their tokens expire
+}
+
+type SigningKeyStore interface {
+	GetSigningKeys() ([]*SigningKey, error)
+	RotateSigningKey(signingKey SigningKey, rotateBefore time.Time) error
+	DeleteSigningKeysRetiredBefore(before time.Time) error
+}
@@ -1,31 +1,128 @@
 package taskModel
 
-import "github.com/google/uuid"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Deepest level a subtask can be nested at, root tasks are at depth 1
+const MaxTaskDepth = 5
 
 type Task struct {
-	ID              uuid.UUID `json:"id"`
-	LinkedProjectID uuid.UUID `json:"linkedProjectID"`
-	Description     string    `json:"description"`
-	Completed       string    `json:"completed"`
+	ID                   uuid.UUID     `json:"id"`
+	LinkedProjectID      uuid.UUID     `json:"linkedProjectID"`
+	ParentTaskID         uuid.NullUUID `json:"parentTaskID"`
+	ColumnID             uuid.NullUUID `json:"columnID"`
+	Position             int           `json:"position"`
+	Description          string        `json:"description"`
+	AssigneeID           uuid.NullUUID `json:"assigneeID"` // a member of the project, not set once their account is deleted
+	Completed            string        `json:"completed"`
+	DueDate              *string       `json:"dueDate"`
+	CompletionPercentage float64       `json:"completionPercentage"`
+	BlockedByTaskIDs     []uuid.UUID   `json:"blockedByTaskIDs"`
+	Blocked              bool          `json:"blocked"` // at least one blocking task is incomplete
+	RecurrenceRule       *string       `json:"recurrenceRule"`
+	RecurrenceMode       string        `json:"recurrenceMode"`
+	RecurrenceStart      *string       `json:"recurrenceStart"`
+	RecurrenceExceptions []string      `json:"recurrenceExceptions"`
+	RecurrenceSeriesID   uuid.NullUUID `json:"recurrenceSeriesID"`
+	UpcomingOccurrences  []string      `json:"upcomingOccurrences,omitempty"`
+	DateCreated          time.Time     `json:"dateCreated"`
+	DateCompleted        *time.Time    `json:"dateCompleted"`
+	LastMoved            *time.Time    `json:"lastMoved"` // last time the task changed board column
+	Subtasks             []*Task       `json:"subtasks,omitempty"`
+}
+
+// Recurring tasks either get their next occurrence once completed or once the current occurrence is due
+const (
+	RecurrenceOnCompletion = "ON_COMPLETION"
+	RecurrenceScheduled    = "SCHEDULED"
+)
+
+// Returned by the task store when a dependency cannot be added
+var (
+	ErrDependencyExists = fmt.Errorf("dependency already exists")
+	ErrDependencyCycle  = fmt.Errorf("dependency would create a cycle")
+)
+
+type TaskDependency struct {
+	TaskID           uuid.UUID `json:"taskID"`
+	BlockedByTaskID  uuid.UUID `json:"blockedByTaskID"`
+	BlockerCompleted string    `json:"blockerCompleted"`
+}
+
+type ScheduleConflict struct {
+	TaskID          uuid.UUID `json:"taskID"`
+	BlockedByTaskID uuid.UUID `json:"blockedByTaskID"`
+	Reason          string    `json:"reason"`
+}
+
+type CriticalPath struct {
+	LinkedProjectID uuid.UUID           `json:"linkedProjectID"`
+	Tasks           []*Task             `json:"tasks"`
+	StartDate       *string             `json:"startDate"`
+	EndDate         *string             `json:"endDate"`
+	DurationInDays  int                 `json:"durationInDays"`
+	Conflicts       []*ScheduleConflict `json:"conflicts"`
 }
 
 type TaskStore interface {
 	CreateTask(task Task) (uuid.UUID, error)
 	GetTasksByLinkedProjectID(linkedProjectID uuid.UUID) ([]*Task, error)
 	GetTaskByID(id uuid.UUID) (*Task, error)
+	GetTasksByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*Task, error)
+	CountTasksByColumnID(columnID uuid.UUID) (int, error)
 	UpdateTaskByID(task Task, id uuid.UUID) error
+	SetParentTaskByID(id uuid.UUID, parentTaskID uuid.NullUUID) error
+	SetAssigneeByID(id uuid.UUID, assigneeID uuid.NullUUID) error
+	SetDueDateByID(id uuid.UUID, dueDate *string) error
+	MoveTaskByID(id uuid.UUID, columnID uuid.UUID, position int, completed string) error
 	DeleteTaskByID(id uuid.UUID) error
 	DeleteTasksByLinkedProjectID(linkedProjectID uuid.UUID) error
+	CreateTaskDependency(taskID uuid.UUID, blockedByTaskID uuid.UUID) error
+	GetTaskDependenciesByLinkedProjectID(linkedProjectID uuid.UUID) ([]*TaskDependency, error)
+	GetTaskDependenciesByUserID(userID uuid.UUID, workspaceID uuid.NullUUID) ([]*TaskDependency, error)
+	DeleteTaskDependency(taskID uuid.UUID, blockedByTaskID uuid.UUID) error
+	SetRecurrenceByID(task Task, id uuid.UUID) error
+	SetRecurrenceExceptionsBySeriesID(exceptions []string, recurrenceSeriesID uuid.UUID) error
+	GetTasksByRecurrenceSeriesID(recurrenceSeriesID uuid.UUID) ([]*Task, error)
+	GetLatestScheduledRecurringTasks() ([]*Task, error)
 }
 
 type CreateTaskPayload struct {
 	LinkedProjectID uuid.UUID `json:"linkedProjectID" validate:"required"`
+	ParentTaskID    uuid.UUID `json:"parentTaskID"`
+	ColumnID        uuid.UUID `json:"columnID"` // first column of the board when omitted
 	Description     string    `json:"description" validate:"required"`
+	AssigneeID      uuid.UUID `json:"assigneeID"`
 	Completed       string    `json:"completed"` // default is false so no need to require it
+	DueDate         string    `json:"dueDate" validate:"omitempty,datetime=2006-01-02"`
+	RecurrenceRule  string    `json:"recurrenceRule"`
+	RecurrenceMode  string    `json:"recurrenceMode" validate:"omitempty,oneof=ON_COMPLETION SCHEDULED"`
 }
 
 type UpdateTaskPayload struct {
-	LinkedProjectID uuid.UUID `json:"linkedProjectID"`
-	Description     string    `json:"description"`
-	Completed       string    `json:"completed"`
+	LinkedProjectID   uuid.UUID  `json:"linkedProjectID"`
+	ParentTaskID      *uuid.UUID `json:"parentTaskID"` // a nil UUID detaches the task from its parent
+	Description       string     `json:"description"`
+	AssigneeID        *uuid.UUID `json:"assigneeID"` // a nil UUID unassigns the task
+	Completed         string     `json:"completed"`
+	DueDate           string     `json:"dueDate" validate:"omitempty,datetime=2006-01-02"`
+	ClearDueDate      bool       `json:"clearDueDate"`      // remove the due date, cannot be combined with dueDate
+	CascadeCompletion bool       `json:"cascadeCompletion"` // apply completed to all subtasks as well
+}
+
+type SetRecurrencePayload struct {
+	RecurrenceRule string `json:"recurrenceRule"` // an empty rule stops the recurrence
+	RecurrenceMode string `json:"recurrenceMode" validate:"omitempty,oneof=ON_COMPLETION SCHEDULED"`
+}
+
+type AddRecurrenceExceptionPayload struct {
+	Date string `json:"date" validate:"required,datetime=2006-01-02"`
+}
+
+type AddTaskDependencyPayload struct {
+	BlockedByTaskID uuid.UUID `json:"blockedByTaskID" validate:"required"`
 }
@@ -0,0 +1,74 @@
+package timeEntryModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TimeEntry struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            uuid.UUID  `json:"userID"`
+	TaskID            uuid.UUID  `json:"taskID"`
+	TaskDescription   string     `json:"taskDescription"`
+	LinkedProjectID   uuid.UUID  `json:"linkedProjectID"`
+	WorkspaceID       uuid.UUID  `json:"workspaceID"` // workspace of the project, entries are listed in it only
+	ProjectTitle      string     `json:"projectTitle"`
+	Description       string     `json:"description"`
+	StartedAt         time.Time  `json:"startedAt"`
+	EndedAt           *time.Time `json:"endedAt"` // nil while the timer is running
+	DurationInSeconds int64      `json:"durationInSeconds"`
+}
+
+type TimeEntryFilter struct {
+	From            *time.Time
+	To              *time.Time
+	LinkedProjectID uuid.UUID
+	WorkspaceID     uuid.NullUUID // entries of every workspace when not valid
+}
+
+type TimeEntryStore interface {
+	CreateTimeEntry(timeEntry TimeEntry) (uuid.UUID, error)
+	GetTimeEntriesByUserID(userID uuid.UUID, filter TimeEntryFilter) ([]*TimeEntry, error)
+	GetTimeEntryByID(id uuid.UUID) (*TimeEntry, error)
+	GetRunningTimeEntryByUserID(userID uuid.UUID) (*TimeEntry, error)
+	UpdateTimeEntryByID(timeEntry TimeEntry, id uuid.UUID) error
+	StopTimeEntryByID(id uuid.UUID, endedAt time.Time) error
+	DeleteTimeEntryByID(id uuid.UUID) error
+}
+
+type ProjectTotal struct {
+	LinkedProjectID   uuid.UUID `json:"linkedProjectID"`
+	ProjectTitle      string    `json:"projectTitle"`
+	DurationInSeconds int64     `json:"durationInSeconds"`
+}
+
+type DayTotal struct {
+	Date              string `json:"date"`
+	DurationInSeconds int64  `json:"durationInSeconds"`
+}
+
+type TimeTotals struct {
+	DurationInSeconds int64           `json:"durationInSeconds"`
+	Projects          []*ProjectTotal `json:"projects"`
+	Days              []*DayTotal     `json:"days"`
+}
+
+type StartTimerPayload struct {
+	TaskID      uuid.UUID `json:"taskID" validate:"required"`
+	Description string    `json:"description"`
+}
+
+type CreateTimeEntryPayload struct {
+	TaskID      uuid.UUID `json:"taskID" validate:"required"`
+	Description string    `json:"description"`
+	StartedAt   time.Time `json:"startedAt" validate:"required"`
+	EndedAt     time.Time `json:"endedAt" validate:"required,gtfield=StartedAt"`
+}
+
+type UpdateTimeEntryPayload struct {
+	TaskID      uuid.UUID  `json:"taskID"`
+	Description string     `json:"description"`
+	StartedAt   *time.Time `json:"startedAt"`
+	EndedAt     *time.Time `json:"endedAt"`
+}
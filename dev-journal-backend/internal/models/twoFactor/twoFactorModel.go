@@ -0,0 +1,49 @@
+package twoFactorModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactor struct {
+	UserID       uuid.UUID
+	Secret       string // TOTP secret encrypted with TWO_FACTOR_ENCRYPTION_KEY
+	Enabled      string // True, False until the first code is confirmed
+	LastUsedStep int64  // time step of the last accepted code, so a code cannot be replayed
+	DateCreated  time.Time
+}
+
+type TwoFactorStatus struct {
+	Enabled                string `json:"enabled"`
+	RemainingRecoveryCodes int    `json:"remainingRecoveryCodes"`
+}
+
+type TwoFactorStore interface {
+	GetTwoFactorByUserID(userID uuid.UUID) (*TwoFactor, error)
+	SaveTwoFactorSecret(userID uuid.UUID, secret string) error
+	EnableTwoFactor(userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTwoFactorStep(userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID uuid.UUID) (int, error)
+	DeleteTwoFactor(userID uuid.UUID) error
+}
+
+type EnableTwoFactorPayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTwoFactorPayload struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RegenerateRecoveryCodesPayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorLoginPayload struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"` // a TOTP code or an unused recovery code
+}
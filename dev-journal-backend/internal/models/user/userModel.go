@@ -1,19 +1,31 @@
 package userModel
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
+	ID                uuid.UUID  `json:"id"`
+	FirstName         string     `json:"firstName"`
+	LastName          string     `json:"lastName"`
+	Email             string     `json:"email"`
+	Password          string     `json:"-"`
+	Timezone          string     `json:"timezone"`
+	EmailVerified     string     `json:"emailVerified"`
+	PasswordChangedAt *time.Time `json:"-"` // sessions issued before are revoked
 }
 
 type UserStore interface {
 	GetUserByEmail(email string) (*User, error)
 	GetUserByID(id uuid.UUID) (*User, error)
 	CreateUser(user User) error
+	UpdateTimezoneByID(id uuid.UUID, timezone string) error
+	UpdatePasswordByID(id uuid.UUID, password string) error
+	SetEmailVerifiedByID(id uuid.UUID) error
+	UpdateUserByID(user User, id uuid.UUID) error
+	DeleteUserByID(id uuid.UUID) error
 }
 
 type RegisterUserPayload struct {
@@ -21,9 +33,42 @@ type RegisterUserPayload struct {
 	LastName  string `json:"lastName" validate:"required"`
 	Email     string `json:"email" validate:"required,email"`
 	Password  string `json:"password" validate:"required,min=8,max=32"`
+	Timezone  string `json:"timezone" validate:"omitempty,timezone"`
 }
 
 type LoginUserPayload struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
+
+type VerifyEmailPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=32"`
+}
+
+type UpdateTimezonePayload struct {
+	Timezone string `json:"timezone" validate:"required,timezone"`
+}
+
+type UpdateProfilePayload struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email" validate:"omitempty,email"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=32"`
+}
+
+type DeleteAccountPayload struct {
+	Password string `json:"password" validate:"required"`
+}
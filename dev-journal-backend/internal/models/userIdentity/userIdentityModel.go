@@ -0,0 +1,27 @@
+package userIdentityModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an account at an OpenID Connect provider
+type UserIdentity struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Issuer      string
+	Subject     string
+	DateCreated time.Time
+}
+
+type UserIdentityStore interface {
+	GetUserIdentity(issuer string, subject string) (*UserIdentity, error)
+	CreateUserIdentity(userIdentity UserIdentity) error
+}
+
+type OIDCLoginPayload struct {
+	Code       string `json:"code" validate:"required"`
+	State      string `json:"state" validate:"required"`
+	LoginToken string `json:"loginToken" validate:"required"`
+}
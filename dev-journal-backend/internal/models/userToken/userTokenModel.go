@@ -0,0 +1,31 @@
+package userTokenModel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purposes a token can be issued for, a token only works for its own purpose
+const (
+	PurposeEmailVerification = "EMAIL_VERIFICATION"
+	PurposePasswordReset     = "PASSWORD_RESET"
+	PurposeAccountUnlock     = "ACCOUNT_UNLOCK"
+)
+
+type UserToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash string // SHA-256 of the token sent to the user, the token itself is never stored
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type UserTokenStore interface {
+	CreateUserToken(userToken UserToken) (uuid.UUID, error)
+	ReissueUserToken(id uuid.UUID, tokenHash string) (bool, error)
+	ConsumeUserToken(purpose string, tokenHash string) (uuid.UUID, error)
+	CountUserTokensSince(userID uuid.UUID, purpose string, since time.Time) (int, error)
+	InvalidateUserTokens(userID uuid.UUID, purpose string) error
+}
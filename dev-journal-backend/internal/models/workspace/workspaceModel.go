@@ -0,0 +1,78 @@
+package workspaceModel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of a workspace member, admins manage the workspace, its settings and its members
+const (
+	RoleAdmin  = "ADMIN"
+	RoleMember = "MEMBER"
+)
+
+// Role of people invited to projects of a workspace they are not a member of, they only reach those projects
+const RoleGuest = "GUEST"
+
+// Returned by the workspace store when a change conflicts with the current members
+var (
+	ErrLastAdmin              = fmt.Errorf("a workspace needs at least one admin")
+	ErrOnlyProjectOwner       = fmt.Errorf("member is the only owner of a project in the workspace")
+	ErrAlreadyWorkspaceMember = fmt.Errorf("already a member of the workspace")
+)
+
+// Workspaces own projects and everything in them, time entries, focus sessions and daily entries are kept per workspace
+type Workspace struct {
+	ID                         uuid.UUID `json:"id"`
+	Name                       string    `json:"name"`
+	AllowMemberProjectCreation string    `json:"allowMemberProjectCreation"` // members who are not admins can create projects
+	AllowExternalInvitations   string    `json:"allowExternalInvitations"`   // projects can invite people outside the workspace
+	DateCreated                time.Time `json:"dateCreated"`
+	LastEdited                 time.Time `json:"lastEdited"`
+	Role                       string    `json:"role,omitempty"` // role of the requesting user in the workspace
+	Active                     bool      `json:"active"`         // the workspace the request was made in
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspaceID"`
+	UserID      uuid.UUID `json:"userID"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type WorkspaceStore interface {
+	CreateWorkspace(workspace Workspace, adminID uuid.UUID) (uuid.UUID, error)
+	GetWorkspacesByUserID(userID uuid.UUID) ([]*Workspace, error)
+	GetWorkspaceByID(id uuid.UUID) (*Workspace, error)
+	UpdateWorkspaceByID(workspace Workspace, id uuid.UUID) error
+	GetWorkspaceRole(workspaceID uuid.UUID, userID uuid.UUID) (string, error)
+	GetDefaultWorkspaceID(userID uuid.UUID) (uuid.UUID, error)
+	GetWorkspaceMembers(workspaceID uuid.UUID) ([]*WorkspaceMember, error)
+	AddWorkspaceMember(workspaceID uuid.UUID, userID uuid.UUID, role string) error
+	UpdateWorkspaceMemberRole(workspaceID uuid.UUID, userID uuid.UUID, role string) error
+	RemoveWorkspaceMember(workspaceID uuid.UUID, userID uuid.UUID) error
+}
+
+type CreateWorkspacePayload struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type UpdateWorkspacePayload struct {
+	Name                       string `json:"name" validate:"max=255"`
+	AllowMemberProjectCreation string `json:"allowMemberProjectCreation" validate:"omitempty,oneof=True False"`
+	AllowExternalInvitations   string `json:"allowExternalInvitations" validate:"omitempty,oneof=True False"`
+}
+
+type AddWorkspaceMemberPayload struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=ADMIN MEMBER"`
+}
+
+type UpdateWorkspaceMemberRolePayload struct {
+	Role string `json:"role" validate:"required,oneof=ADMIN MEMBER"`
+}
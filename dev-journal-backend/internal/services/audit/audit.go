@@ -0,0 +1,126 @@
+package auditServices
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Longest user agent kept, longer ones are cut
+const maxUserAgentLength = 512
+
+// Fields left out of diffs, they are either the entity ID, derived from other fields or change on every update
+var ignoredFields = map[string]bool{
+	"id":                   true,
+	"dateCreated":          true,
+	"lastEdited":           true,
+	"rendered":             true,
+	"blocked":              true,
+	"completionPercentage": true,
+	"upcomingOccurrences":  true,
+	"subtasks":             true,
+	"replies":              true,
+}
+
+type Recorder struct {
+	store auditModel.AuditEventStore
+}
+
+func NewRecorder(store auditModel.AuditEventStore) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Record stores an audit event for a request with the fields that differ between the before and after versions of the entity, nil for a version that does not exist
+// The change already happened when it is recorded, so failures are logged instead of failing the request
+func (recorder *Recorder) Record(request *http.Request, event auditModel.AuditEvent, before any, after any) {
+	changes, error := Diff(before, after)
+	if error != nil {
+		log.Printf("failed to record %s of %s %s: %v", event.Action, event.EntityType, event.EntityID, error)
+		return
+	}
+
+	// nothing to record when an update did not change anything
+	if event.Action == auditModel.ActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	event.Changes = changes
+	if !event.ActorID.Valid {
+		event.ActorID = authenticationServices.GetUserIDFromContext(request.Context())
+	}
+	event.IPAddress = utils.GetClientIP(request)
+	event.UserAgent = request.UserAgent()
+	if len(event.UserAgent) > maxUserAgentLength {
+		event.UserAgent = event.UserAgent[:maxUserAgentLength]
+	}
+
+	if error := recorder.store.CreateAuditEvent(event); error != nil {
+		log.Printf("failed to record %s of %s %s: %v", event.Action, event.EntityType, event.EntityID, error)
+	}
+}
+
+// RecordTokenCreated records a token issued to a user in their security log
+func (recorder *Recorder) RecordTokenCreated(request *http.Request, userID uuid.UUID, purpose string) {
+	recorder.Record(request, auditModel.AuditEvent{
+		EntityType: auditModel.EntityUser,
+		EntityID:   userID,
+		Action:     auditModel.ActionTokenCreated,
+	}, nil, map[string]string{"purpose": purpose})
+}
+
+// Diff returns the JSON fields that differ between two versions of an entity, nil stands for a version that does not exist
+func Diff(before any, after any) (map[string]*auditModel.Change, error) {
+	beforeFields, error := fields(before)
+	if error != nil {
+		return nil, error
+	}
+
+	afterFields, error := fields(after)
+	if error != nil {
+		return nil, error
+	}
+
+	changes := make(map[string]*auditModel.Change)
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = &auditModel.Change{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, exists := beforeFields[name]; !exists && value != nil {
+			changes[name] = &auditModel.Change{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// fields returns the JSON fields of an entity without the ignored ones
+func fields(entity any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if entity == nil || reflect.ValueOf(entity).Kind() == reflect.Pointer && reflect.ValueOf(entity).IsNil() {
+		return fields, nil
+	}
+
+	entityJSON, error := json.Marshal(entity)
+	if error != nil {
+		return nil, fmt.Errorf("failed to marshal entity: %v", error)
+	}
+
+	if error := json.Unmarshal(entityJSON, &fields); error != nil {
+		return nil, fmt.Errorf("failed to unmarshal entity fields: %v", error)
+	}
+
+	for name := range ignoredFields {
+		delete(fields, name)
+	}
+
+	return fields, nil
+}
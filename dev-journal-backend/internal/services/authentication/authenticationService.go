@@ -1,6 +1,11 @@
 package authenticationServices
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,3 +22,20 @@ func ComparePassword(hashedPassword string, plain []byte) bool {
 	error := bcrypt.CompareHashAndPassword([]byte(hashedPassword), plain)
 	return error == nil
 }
+
+// GenerateToken creates a random URL safe token and the hash to store for it
+func GenerateToken() (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, error := rand.Read(bytes); error != nil {
+		return "", "", error
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes a token for storage and lookup, tokens are random enough that a fast hash is sufficient
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
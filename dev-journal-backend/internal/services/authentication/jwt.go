@@ -2,6 +2,8 @@ package authenticationServices
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -11,6 +13,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/hwaengfan/dev-journal-backend/configs"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
 	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
@@ -18,21 +22,162 @@ type contextKey string
 
 const UserKey contextKey = "userID"
 
-// CreateJWT creates a new JWT token
-func CreateJWT(secret []byte, userID uuid.UUID) (string, error) {
+const WorkspaceKey contextKey = "workspaceID"
+
+// Purpose claim of the token issued between the password and the second factor of a login
+const twoFactorChallengePurpose = "two-factor-challenge"
+
+// How long a user has to enter the second factor after the password
+const twoFactorChallengeExpiration = 5 * time.Minute
+
+// Purpose claim of the token in data export download links
+const dataExportDownloadPurpose = "data-export-download"
+
+// Purpose claim of the token that carries a pending single sign-on login between the client and the callback
+const oidcLoginPurpose = "oidc-login"
+
+// How long a user has to complete the login at the provider
+const oidcLoginExpiration = 10 * time.Minute
+
+// Pending single sign-on logins carry the PKCE code verifier and nonce encrypted, they cannot be started without the key
+var ErrSingleSignOnNotConfigured = fmt.Errorf("single sign-on needs OIDC_STATE_ENCRYPTION_KEY to be set on the server")
+
+// oidcLoginSecrets are the parts of a pending login only the server may read
+type oidcLoginSecrets struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
+
+// workspaceStore resolves the active workspace of sessions, requests are not scoped to a workspace until one is set
+var workspaceStore workspaceModel.WorkspaceStore
+
+// UseWorkspaceStore makes JWTAuthentication check and resolve the active workspace of every session
+func UseWorkspaceStore(store workspaceModel.WorkspaceStore) {
+	workspaceStore = store
+}
+
+// CreateJWT creates a new JWT token, sessions without a workspace act in the user's default workspace
+func CreateJWT(userID uuid.UUID, workspaceID uuid.UUID) (string, error) {
 	expiration := time.Second * time.Duration(configs.GlobalEnvironmentVariables.JWTExpirationInSeconds)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID":    userID,
-		"expiredAt": time.Now().Add(expiration).Unix(),
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID.String(),
+		"iat": now.Unix(),
+		"exp": now.Add(expiration).Unix(),
+	}
+	if workspaceID != uuid.Nil {
+		claims["workspace"] = workspaceID.String()
+	}
+
+	return signToken(claims)
+}
+
+// CreateTwoFactorChallengeJWT creates a short-lived token that only proves the password of a user was checked
+func CreateTwoFactorChallengeJWT(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	return signToken(jwt.MapClaims{
+		"sub":     userID.String(),
+		"purpose": twoFactorChallengePurpose,
+		"iat":     now.Unix(),
+		"exp":     now.Add(twoFactorChallengeExpiration).Unix(),
 	})
+}
 
-	tokenString, error := token.SignedString(secret)
+// ParseTwoFactorChallengeJWT validates a token created by CreateTwoFactorChallengeJWT and returns its userID
+func ParseTwoFactorChallengeJWT(tokenString string) (uuid.UUID, error) {
+	userID, error := parsePurposeToken(tokenString, twoFactorChallengePurpose)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("invalid or expired challenge token")
+	}
+
+	return userID, nil
+}
+
+// CreateDataExportDownloadJWT creates a token that allows downloading the archive of a data export until expiresAt
+func CreateDataExportDownloadJWT(exportID uuid.UUID, expiresAt time.Time) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub":     exportID.String(),
+		"purpose": dataExportDownloadPurpose,
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+}
+
+// ParseDataExportDownloadJWT validates a token created by CreateDataExportDownloadJWT and returns its exportID
+func ParseDataExportDownloadJWT(tokenString string) (uuid.UUID, error) {
+	exportID, error := parsePurposeToken(tokenString, dataExportDownloadPurpose)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("invalid or expired download link")
+	}
+
+	return exportID, nil
+}
+
+// CreateOIDCLoginJWT signs a pending single sign-on login so the client can hand it back on the callback,
+// the nonce and code verifier are encrypted since the client, or anyone who gets hold of the token, must not read them
+func CreateOIDCLoginJWT(loginState oidcServices.LoginState) (string, error) {
+	key := configs.OIDCEnvironmentVariables.StateEncryptionKey
+	if key == "" {
+		return "", ErrSingleSignOnNotConfigured
+	}
+
+	secrets, error := json.Marshal(oidcLoginSecrets{Nonce: loginState.Nonce, CodeVerifier: loginState.CodeVerifier})
+	if error != nil {
+		return "", error
+	}
+
+	encryptedSecrets, error := encrypt(key, string(secrets))
 	if error != nil {
 		return "", error
 	}
 
-	return tokenString, nil
+	now := time.Now()
+	return signToken(jwt.MapClaims{
+		"purpose": oidcLoginPurpose,
+		"state":   loginState.State,
+		"secrets": encryptedSecrets,
+		"iat":     now.Unix(),
+		"exp":     now.Add(oidcLoginExpiration).Unix(),
+	})
+}
+
+// ParseOIDCLoginJWT validates a token created by CreateOIDCLoginJWT and returns the pending login
+func ParseOIDCLoginJWT(tokenString string) (*oidcServices.LoginState, error) {
+	invalid := fmt.Errorf("invalid or expired login")
+
+	key := configs.OIDCEnvironmentVariables.StateEncryptionKey
+	if key == "" {
+		return nil, ErrSingleSignOnNotConfigured
+	}
+
+	token, error := parseToken(tokenString)
+	if error != nil || !token.Valid {
+		return nil, invalid
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["purpose"] != oidcLoginPurpose {
+		return nil, invalid
+	}
+
+	state, _ := claims["state"].(string)
+	encryptedSecrets, _ := claims["secrets"].(string)
+	decrypted, error := decrypt(key, encryptedSecrets)
+	if error != nil {
+		return nil, invalid
+	}
+
+	var secrets oidcLoginSecrets
+	if error := json.Unmarshal([]byte(decrypted), &secrets); error != nil {
+		return nil, invalid
+	}
+
+	if state == "" || secrets.Nonce == "" || secrets.CodeVerifier == "" {
+		return nil, invalid
+	}
+
+	return &oidcServices.LoginState{State: state, Nonce: secrets.Nonce, CodeVerifier: secrets.CodeVerifier}, nil
 }
 
 // GetUserIDFromContext retrieves the userID from the context
@@ -45,6 +190,12 @@ func GetUserIDFromContext(ctx context.Context) uuid.NullUUID {
 	return uuid.NullUUID{UUID: userID, Valid: true}
 }
 
+// GetWorkspaceIDFromContext retrieves the active workspace from the context, uuid.Nil matches no workspace
+func GetWorkspaceIDFromContext(ctx context.Context) uuid.UUID {
+	workspaceID, _ := ctx.Value(WorkspaceKey).(uuid.UUID)
+	return workspaceID
+}
+
 // JWTAuthentication check for logged in users
 func JWTAuthentication(handlerFunction http.HandlerFunc, userStore userModel.UserStore) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -65,16 +216,22 @@ func JWTAuthentication(handlerFunction http.HandlerFunc, userStore userModel.Use
 			return
 		}
 
-		// extract userID if the JWT token is valid
+		// extract userID if the JWT token is valid, tokens issued for a purpose like a login challenge are not sessions
 		claims := token.Claims.(jwt.MapClaims)
-		userIDString, ok := claims["userID"].(string)
-		if !ok {
-			log.Printf("userID in claims is not a UUID: %v", claims["userID"])
+		if _, exists := claims["purpose"]; exists {
+			log.Printf("token with purpose %v used as session", claims["purpose"])
 			utils.WritePermissionDenied(writer)
 			return
 		}
 
-		userID, err := uuid.Parse(userIDString)
+		subject, err := claims.GetSubject()
+		if err != nil {
+			log.Printf("subject in claims is not a string: %v", claims["sub"])
+			utils.WritePermissionDenied(writer)
+			return
+		}
+
+		userID, err := uuid.Parse(subject)
 		if err != nil {
 			log.Printf("failed to parse userID: %v", err)
 			utils.WritePermissionDenied(writer)
@@ -89,9 +246,26 @@ func JWTAuthentication(handlerFunction http.HandlerFunc, userStore userModel.Use
 			return
 		}
 
-		// add userID to the context
+		// sessions issued before the password last changed are revoked
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil || (user.PasswordChangedAt != nil && issuedAt.Before(*user.PasswordChangedAt)) {
+			log.Printf("revoked session of user %s", user.ID)
+			utils.WritePermissionDenied(writer)
+			return
+		}
+
+		// resolve the active workspace, the user may have been removed from it since the token was issued
+		workspaceID, err := resolveWorkspace(claims, user.ID)
+		if err != nil {
+			log.Printf("failed to resolve workspace: %v", err)
+			utils.WritePermissionDenied(writer)
+			return
+		}
+
+		// add userID and workspaceID to the context
 		ctx := request.Context()
 		ctx = context.WithValue(ctx, UserKey, user.ID)
+		ctx = context.WithValue(ctx, WorkspaceKey, workspaceID)
 		request = request.WithContext(ctx)
 
 		// call the handler function
@@ -99,6 +273,34 @@ func JWTAuthentication(handlerFunction http.HandlerFunc, userStore userModel.Use
 	}
 }
 
+// resolveWorkspace returns the workspace of the session, falling back to the user's default workspace for tokens without one
+func resolveWorkspace(claims jwt.MapClaims, userID uuid.UUID) (uuid.UUID, error) {
+	if workspaceStore == nil {
+		return uuid.Nil, nil
+	}
+
+	claim, exists := claims["workspace"].(string)
+	if !exists {
+		return workspaceStore.GetDefaultWorkspaceID(userID)
+	}
+
+	workspaceID, error := uuid.Parse(claim)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("invalid workspace in claims: %v", claim)
+	}
+
+	role, error := workspaceStore.GetWorkspaceRole(workspaceID, userID)
+	if error != nil {
+		return uuid.Nil, error
+	}
+
+	if role == "" {
+		return uuid.Nil, fmt.Errorf("user %s is not a member of workspace %s", userID, workspaceID)
+	}
+
+	return workspaceID, nil
+}
+
 // getTokenFromRequest retrieves the JWT token from the request header
 func getTokenFromRequest(request *http.Request) string {
 	tokenString := strings.TrimSpace(request.Header.Get("Authorization"))
@@ -108,14 +310,43 @@ func getTokenFromRequest(request *http.Request) string {
 	return ""
 }
 
-// parseToken parses the JWT token
+// signToken signs claims with the key manager, or with JWT_SECRET when none is used
+func signToken(claims jwt.MapClaims) (string, error) {
+	if keyManager != nil {
+		return keyManager.Sign(claims)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(configs.GlobalEnvironmentVariables.JWTSecret))
+}
+
+// parsePurposeToken validates a token issued for a purpose and returns its subject
+func parsePurposeToken(tokenString string, purpose string) (uuid.UUID, error) {
+	token, error := parseToken(tokenString)
+	if error != nil || !token.Valid {
+		return uuid.Nil, fmt.Errorf("invalid token")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["purpose"] != purpose {
+		return uuid.Nil, fmt.Errorf("token issued for another purpose")
+	}
+
+	subject, _ := claims.GetSubject()
+	return uuid.Parse(subject)
+}
+
+// parseToken parses the JWT token, it must carry the standard exp claim
 func parseToken(tokenString string) (*jwt.Token, error) {
 	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if keyManager != nil {
+			return keyManager.VerificationKey(token)
+		}
+
 		// Validate the signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
 
 		return []byte(configs.GlobalEnvironmentVariables.JWTSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
 }
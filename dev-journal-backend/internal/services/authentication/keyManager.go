@@ -0,0 +1,371 @@
+package authenticationServices
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	signingKeyModel "github.com/hwaengfan/dev-journal-backend/internal/models/signingKey"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Signing algorithms, HS256 signs with JWT_SECRET and publishes no keys
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// How long a server signs with its loaded keys before reading them again, so it picks up a key another server rotated in
+const keyReloadInterval = 10 * time.Minute
+
+type signingKey struct {
+	id          string
+	algorithm   string
+	privateKey  crypto.Signer
+	dateCreated time.Time
+}
+
+// KeyManager signs tokens with the active key and verifies them with every key whose tokens may not have expired yet
+type KeyManager struct {
+	store            signingKeyModel.SigningKeyStore
+	algorithm        string
+	rotationInterval time.Duration
+	tokenLifetime    time.Duration
+	encryptionKey    string
+
+	mutex           sync.RWMutex
+	keys            map[string]*signingKey
+	activeKey       *signingKey
+	secretRetiredAt *time.Time // when the keys replaced JWT_SECRET, nil once its tokens have expired
+	reloadedAt      time.Time
+}
+
+// keyManager signs and verifies the tokens of this package, tokens are signed with JWT_SECRET until one is set
+var keyManager *KeyManager
+
+// UseKeyManager makes a key manager sign and verify the tokens of this package
+func UseKeyManager(manager *KeyManager) {
+	keyManager = manager
+}
+
+func NewKeyManager(store signingKeyModel.SigningKeyStore, algorithm string, rotationInterval time.Duration, tokenLifetime time.Duration, encryptionKey string) (*KeyManager, error) {
+	switch algorithm {
+	case AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unknown JWT signing algorithm %q", algorithm)
+	}
+
+	// the private keys are stored encrypted, a default would let anyone with the database read them
+	if algorithm != AlgorithmHS256 && encryptionKey == "" {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY must be set to sign with %s", algorithm)
+	}
+
+	return &KeyManager{
+		store:            store,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		tokenLifetime:    tokenLifetime,
+		encryptionKey:    encryptionKey,
+		keys:             make(map[string]*signingKey),
+	}, nil
+}
+
+// Start loads the keys before the server signs its first token, creating the first key when there is none
+func (manager *KeyManager) Start() error {
+	error := manager.Rotate(time.Now())
+	if error == nil || manager.algorithm == AlgorithmHS256 {
+		return error
+	}
+
+	// servers starting together race for the first key and the database may roll one of them back, the key of the winner serves both
+	if reloadError := manager.reload(); reloadError != nil {
+		return error
+	}
+
+	manager.mutex.RLock()
+	activeKey := manager.activeKey
+	manager.mutex.RUnlock()
+
+	if activeKey == nil || activeKey.algorithm != manager.algorithm {
+		return error
+	}
+
+	log.Printf("failed to rotate signing keys, using the active key: %v", error)
+	return nil
+}
+
+// Rotate creates a new active key once the current one is older than the rotation interval, and deletes retired keys whose tokens have all expired
+func (manager *KeyManager) Rotate(now time.Time) error {
+	if manager.algorithm == AlgorithmHS256 {
+		return nil
+	}
+
+	if error := manager.reload(); error != nil {
+		return error
+	}
+
+	manager.mutex.RLock()
+	activeKey := manager.activeKey
+	manager.mutex.RUnlock()
+
+	if activeKey == nil || activeKey.algorithm != manager.algorithm || now.Sub(activeKey.dateCreated) >= manager.rotationInterval {
+		key, error := manager.generateKey(now)
+		if error != nil {
+			return error
+		}
+
+		// the previous keys stop signing but keep verifying the tokens they signed
+		if error := manager.store.RotateSigningKey(key, now.Add(-manager.rotationInterval)); error != nil {
+			return error
+		}
+	}
+
+	// other servers may sign with a retired key until they reload, the tokens they signed meanwhile must still verify
+	if error := manager.store.DeleteSigningKeysRetiredBefore(now.Add(-manager.tokenLifetime - keyReloadInterval)); error != nil {
+		return error
+	}
+
+	return manager.reload()
+}
+
+// Sign signs the claims with the active key, or with JWT_SECRET when the algorithm is HS256
+func (manager *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	if manager.algorithm == AlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(configs.GlobalEnvironmentVariables.JWTSecret))
+	}
+
+	manager.mutex.RLock()
+	activeKey, reloadedAt := manager.activeKey, manager.reloadedAt
+	manager.mutex.RUnlock()
+
+	if time.Since(reloadedAt) >= keyReloadInterval {
+		if error := manager.reload(); error != nil {
+			log.Printf("failed to reload signing keys: %v", error)
+		} else {
+			manager.mutex.RLock()
+			activeKey = manager.activeKey
+			manager.mutex.RUnlock()
+		}
+	}
+
+	if activeKey == nil {
+		return "", fmt.Errorf("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(activeKey.algorithm), claims)
+	token.Header["kid"] = activeKey.id
+
+	return token.SignedString(activeKey.privateKey)
+}
+
+// VerificationKey returns the key for the kid of a token, an unknown kid triggers a reload to pick up keys other servers created
+func (manager *KeyManager) VerificationKey(token *jwt.Token) (any, error) {
+	if manager.algorithm == AlgorithmHS256 {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return []byte(configs.GlobalEnvironmentVariables.JWTSecret), nil
+	}
+
+	keyID, _ := token.Header["kid"].(string)
+	if keyID == "" {
+		return manager.secretVerificationKey(token)
+	}
+
+	if key := manager.findKey(keyID, token.Method.Alg()); key != nil {
+		return key, nil
+	}
+
+	// reloads are limited so tokens with made up key IDs cannot hammer the database
+	manager.mutex.RLock()
+	recentlyReloaded := time.Since(manager.reloadedAt) < 10*time.Second
+	manager.mutex.RUnlock()
+
+	if !recentlyReloaded {
+		if error := manager.reload(); error != nil {
+			return nil, error
+		}
+
+		if key := manager.findKey(keyID, token.Method.Alg()); key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+// secretVerificationKey returns JWT_SECRET for tokens signed before the keys replaced it, until those tokens have expired
+func (manager *KeyManager) secretVerificationKey(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	manager.mutex.RLock()
+	secretRetiredAt := manager.secretRetiredAt
+	manager.mutex.RUnlock()
+
+	if secretRetiredAt == nil || time.Since(*secretRetiredAt) >= manager.tokenLifetime {
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	// a token issued after the switch was not signed by this application
+	issuedAt, error := token.Claims.GetIssuedAt()
+	if error != nil || issuedAt == nil || issuedAt.After(*secretRetiredAt) {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return []byte(configs.GlobalEnvironmentVariables.JWTSecret), nil
+}
+
+// Handler function for publishing the public signing keys as a JSON Web Key Set
+func (manager *KeyManager) HandleJWKS(writer http.ResponseWriter, request *http.Request) {
+	manager.mutex.RLock()
+	keys := make([]map[string]string, 0, len(manager.keys))
+	for _, key := range manager.keys {
+		keys = append(keys, publicJWK(key))
+	}
+	manager.mutex.RUnlock()
+
+	writer.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSON(writer, http.StatusOK, map[string]any{"keys": keys})
+}
+
+// findKey returns the public key for a key ID, nil when it is unknown or belongs to another algorithm
+func (manager *KeyManager) findKey(keyID string, algorithm string) crypto.PublicKey {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	key, exists := manager.keys[keyID]
+	if !exists || key.algorithm != algorithm {
+		return nil
+	}
+
+	return key.privateKey.Public()
+}
+
+// reload replaces the keys in memory with the stored ones
+func (manager *KeyManager) reload() error {
+	storedKeys, error := manager.store.GetSigningKeys()
+	if error != nil {
+		return error
+	}
+
+	keys := make(map[string]*signingKey, len(storedKeys))
+	var activeKey *signingKey
+	var secretRetiredAt *time.Time
+	for _, storedKey := range storedKeys {
+		if storedKey.ID == signingKeyModel.SecretKeyID {
+			secretRetiredAt = storedKey.RetiredAt
+			continue
+		}
+
+		privateKey, error := manager.decodePrivateKey(storedKey.PrivateKey)
+		if error != nil {
+			log.Printf("failed to load signing key %s: %v", storedKey.ID, error)
+			continue
+		}
+
+		key := &signingKey{id: storedKey.ID, algorithm: storedKey.Algorithm, privateKey: privateKey, dateCreated: storedKey.DateCreated}
+		keys[key.id] = key
+
+		// keys are ordered oldest first, so the newest unretired key signs
+		if storedKey.RetiredAt == nil {
+			activeKey = key
+		}
+	}
+
+	manager.mutex.Lock()
+	manager.keys, manager.activeKey, manager.secretRetiredAt, manager.reloadedAt = keys, activeKey, secretRetiredAt, time.Now()
+	manager.mutex.Unlock()
+
+	return nil
+}
+
+// generateKey generates an encrypted key for the configured algorithm
+func (manager *KeyManager) generateKey(now time.Time) (signingKeyModel.SigningKey, error) {
+	var privateKey crypto.Signer
+	var error error
+	if manager.algorithm == AlgorithmEdDSA {
+		_, privateKey, error = ed25519.GenerateKey(rand.Reader)
+	} else {
+		privateKey, error = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if error != nil {
+		return signingKeyModel.SigningKey{}, fmt.Errorf("failed to generate signing key: %v", error)
+	}
+
+	der, error := x509.MarshalPKCS8PrivateKey(privateKey)
+	if error != nil {
+		return signingKeyModel.SigningKey{}, fmt.Errorf("failed to encode signing key: %v", error)
+	}
+
+	encrypted, error := encrypt(manager.encryptionKey, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if error != nil {
+		return signingKeyModel.SigningKey{}, error
+	}
+
+	return signingKeyModel.SigningKey{
+		ID:          uuid.New().String(),
+		Algorithm:   manager.algorithm,
+		PrivateKey:  encrypted,
+		DateCreated: now,
+	}, nil
+}
+
+// decodePrivateKey decrypts and parses a stored private key
+func (manager *KeyManager) decodePrivateKey(encrypted string) (crypto.Signer, error) {
+	decrypted, error := decrypt(manager.encryptionKey, encrypted)
+	if error != nil {
+		return nil, error
+	}
+
+	block, _ := pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM")
+	}
+
+	privateKey, error := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if error != nil {
+		return nil, error
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", privateKey)
+	}
+
+	return signer, nil
+}
+
+// publicJWK formats the public part of a key as a JSON Web Key (RFC 7517, RFC 8037)
+func publicJWK(key *signingKey) map[string]string {
+	jwk := map[string]string{"kid": key.id, "alg": key.algorithm, "use": "sig"}
+
+	switch publicKey := key.privateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk["kty"] = "OKP"
+		jwk["crv"] = "Ed25519"
+		jwk["x"] = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
@@ -0,0 +1,178 @@
+package authenticationServices
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+)
+
+// LoginPolicy decides how failed logins of one kind of key are slowed down
+type LoginPolicy struct {
+	FreeAttempts    int           // failures allowed before any delay
+	LockoutAttempts int           // failures that lock the key for LockoutDuration
+	MaxBackoff      time.Duration // longest delay between FreeAttempts and LockoutAttempts, the delay doubles with every failure
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration // failures older than this are forgotten
+}
+
+// Policies for the failures of one account and of one IP address, an IP address may try several accounts
+var (
+	AccountLoginPolicy = LoginPolicy{FreeAttempts: 3, LockoutAttempts: 10, MaxBackoff: 5 * time.Minute, LockoutDuration: 30 * time.Minute, ResetAfter: 24 * time.Hour}
+	IPLoginPolicy      = LoginPolicy{FreeAttempts: 20, LockoutAttempts: 100, MaxBackoff: 5 * time.Minute, LockoutDuration: time.Hour, ResetAfter: 24 * time.Hour}
+)
+
+// AccountAttemptKey returns the tracker key counting the failures of an email
+func AccountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPAttemptKey returns the tracker key counting the failures of an IP address
+func IPAttemptKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// LoginGuard applies exponential backoff and temporary lockouts to failed logins
+type LoginGuard struct {
+	tracker loginAttemptModel.AttemptTracker
+}
+
+func NewLoginGuard(tracker loginAttemptModel.AttemptTracker) *LoginGuard {
+	return &LoginGuard{tracker: tracker}
+}
+
+// RetryAfter returns how long the most restricted of the keys has to wait before the next attempt, zero when none is locked
+func (guard *LoginGuard) RetryAfter(keys ...string) (time.Duration, error) {
+	var retryAfter time.Duration
+	for _, key := range keys {
+		lockedUntil, error := guard.tracker.GetLockedUntil(key)
+		if error != nil {
+			return 0, error
+		}
+
+		if lockedUntil != nil && time.Until(*lockedUntil) > retryAfter {
+			retryAfter = time.Until(*lockedUntil)
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordFailure counts a failed login for a key and locks it according to the policy, it reports whether this failure started a lockout
+func (guard *LoginGuard) RecordFailure(key string, policy LoginPolicy) (bool, error) {
+	now := time.Now()
+	failures, error := guard.tracker.IncrementFailures(key, now, policy.ResetAfter)
+	if error != nil {
+		return false, error
+	}
+
+	if failures < policy.FreeAttempts {
+		return false, nil
+	}
+
+	if failures >= policy.LockoutAttempts {
+		// failures keep counting after a lockout ends, so the next failure locks the key again and starts a new lockout too
+		lockedUntil, error := guard.tracker.GetLockedUntil(key)
+		if error != nil {
+			return false, error
+		}
+
+		if error := guard.tracker.SetLockedUntil(key, now.Add(policy.LockoutDuration)); error != nil {
+			return false, error
+		}
+
+		return failures == policy.LockoutAttempts || lockedUntil == nil || !lockedUntil.After(now), nil
+	}
+
+	backoff := policy.MaxBackoff
+	if exponent := failures - policy.FreeAttempts; exponent < 30 {
+		backoff = min(time.Second<<exponent, policy.MaxBackoff)
+	}
+
+	return false, guard.tracker.SetLockedUntil(key, now.Add(backoff))
+}
+
+// Reset forgets the failures of a key, after a successful login or an unlock
+func (guard *LoginGuard) Reset(key string) error {
+	return guard.tracker.ResetAttempts(key)
+}
+
+type memoryAttempts struct {
+	failures      int
+	lastFailureAt time.Time
+	lockedUntil   *time.Time
+}
+
+// MemoryAttemptTracker keeps the counters in memory, they are lost on restart and not shared between servers
+type MemoryAttemptTracker struct {
+	mutex    sync.Mutex
+	attempts map[string]*memoryAttempts
+}
+
+func NewMemoryAttemptTracker() *MemoryAttemptTracker {
+	return &MemoryAttemptTracker{attempts: make(map[string]*memoryAttempts)}
+}
+
+// GetLockedUntil returns until when a key is locked, nil when it is not
+func (tracker *MemoryAttemptTracker) GetLockedUntil(key string) (*time.Time, error) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	attempts, exists := tracker.attempts[key]
+	if !exists {
+		return nil, nil
+	}
+
+	return attempts.lockedUntil, nil
+}
+
+// IncrementFailures counts a failed attempt for a key and returns the failures so far
+func (tracker *MemoryAttemptTracker) IncrementFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	attempts, exists := tracker.attempts[key]
+	if !exists || now.Sub(attempts.lastFailureAt) > resetAfter {
+		attempts = &memoryAttempts{}
+		tracker.attempts[key] = attempts
+	}
+
+	attempts.failures++
+	attempts.lastFailureAt = now
+
+	// drop keys that went quiet so the map does not grow unbounded
+	if len(tracker.attempts) > 10000 {
+		for otherKey, other := range tracker.attempts {
+			if now.Sub(other.lastFailureAt) > resetAfter && (other.lockedUntil == nil || other.lockedUntil.Before(now)) {
+				delete(tracker.attempts, otherKey)
+			}
+		}
+	}
+
+	return attempts.failures, nil
+}
+
+// SetLockedUntil locks a key until a time
+func (tracker *MemoryAttemptTracker) SetLockedUntil(key string, lockedUntil time.Time) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	attempts, exists := tracker.attempts[key]
+	if !exists {
+		return fmt.Errorf("no failed attempts for key %s", key)
+	}
+
+	attempts.lockedUntil = &lockedUntil
+	return nil
+}
+
+// ResetAttempts clears the failures and lock of a key
+func (tracker *MemoryAttemptTracker) ResetAttempts(key string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	delete(tracker.attempts, key)
+	return nil
+}
@@ -0,0 +1,43 @@
+package authenticationServices
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordFailureReportsEveryLockout(t *testing.T) {
+	policy := LoginPolicy{FreeAttempts: 1, LockoutAttempts: 3, MaxBackoff: time.Minute, LockoutDuration: time.Hour, ResetAfter: 24 * time.Hour}
+	tracker := NewMemoryAttemptTracker()
+	guard := NewLoginGuard(tracker)
+
+	for attempt := 1; attempt <= policy.LockoutAttempts; attempt++ {
+		locked, error := guard.RecordFailure("account:ada@example.com", policy)
+		if error != nil {
+			t.Fatal(error)
+		}
+		if locked != (attempt == policy.LockoutAttempts) {
+			t.Fatalf("failure %d reported locked %v", attempt, locked)
+		}
+	}
+
+	// a failure racing the lockout does not start another one
+	if locked, _ := guard.RecordFailure("account:ada@example.com", policy); locked {
+		t.Fatal("failure during the lockout reported a new lockout")
+	}
+
+	// the lockout ran out, the next failure locks the account again and must be reported so the user gets another unlock link
+	expired := time.Now().Add(-time.Second)
+	tracker.SetLockedUntil("account:ada@example.com", expired)
+	locked, error := guard.RecordFailure("account:ada@example.com", policy)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if !locked {
+		t.Fatal("failure after the lockout ended did not report a new lockout")
+	}
+
+	retryAfter, _ := guard.RetryAfter("account:ada@example.com")
+	if retryAfter < policy.LockoutDuration-time.Minute {
+		t.Fatalf("account is locked for %v, expected %v", retryAfter, policy.LockoutDuration)
+	}
+}
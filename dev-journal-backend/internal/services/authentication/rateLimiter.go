@@ -0,0 +1,77 @@
+package authenticationServices
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows a number of hits per key within a sliding window, kept in memory
+type RateLimiter struct {
+	mutex  sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// Allow records a hit for the key and reports whether it is within the limit
+func (limiter *RateLimiter) Allow(key string) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	if limiter.blocked(key, now) {
+		return false
+	}
+
+	limiter.record(key, now)
+	return true
+}
+
+// Blocked reports whether the key used up its hits within the window, without recording a hit
+func (limiter *RateLimiter) Blocked(key string) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	return limiter.blocked(key, time.Now())
+}
+
+// Record records a hit for the key, for callers that only count failures and check them with Blocked
+func (limiter *RateLimiter) Record(key string) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.record(key, time.Now())
+}
+
+// blocked reports whether the key used up its hits within the window
+func (limiter *RateLimiter) blocked(key string, now time.Time) bool {
+	return len(limiter.recentHits(key, now)) >= limiter.limit
+}
+
+// record adds a hit for the key
+func (limiter *RateLimiter) record(key string, now time.Time) {
+	limiter.hits[key] = append(limiter.recentHits(key, now), now)
+
+	// drop keys that went quiet so the map does not grow unbounded
+	if len(limiter.hits) > 10000 {
+		for otherKey := range limiter.hits {
+			if len(limiter.recentHits(otherKey, now)) == 0 {
+				delete(limiter.hits, otherKey)
+			}
+		}
+	}
+}
+
+// recentHits returns the hits of the key within the window
+func (limiter *RateLimiter) recentHits(key string, now time.Time) []time.Time {
+	hits := limiter.hits[key]
+	for len(hits) > 0 && now.Sub(hits[0]) >= limiter.window {
+		hits = hits[1:]
+	}
+
+	return hits
+}
@@ -0,0 +1,34 @@
+package authenticationServices
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterCountsOnlyRecordedHits(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Minute)
+
+	// checking a key does not use up its hits
+	for check := 0; check < 5; check++ {
+		if limiter.Blocked("share:link") {
+			t.Fatalf("check %d blocked a key without hits", check)
+		}
+	}
+
+	limiter.Record("share:link")
+	if limiter.Blocked("share:link") {
+		t.Fatal("blocked after one of two hits")
+	}
+
+	limiter.Record("share:link")
+	if !limiter.Blocked("share:link") {
+		t.Fatal("not blocked after using up the hits")
+	}
+	if limiter.Allow("share:link") {
+		t.Fatal("Allow let a blocked key through")
+	}
+
+	if limiter.Blocked("share:other-link") {
+		t.Fatal("hits of one key blocked another")
+	}
+}
@@ -0,0 +1,181 @@
+package authenticationServices
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hwaengfan/dev-journal-backend/configs"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1 // steps accepted before and after the current one to allow for clock drift
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP secrets are stored encrypted, a default key would let anyone with the database read them
+var ErrTwoFactorNotConfigured = fmt.Errorf("two-factor authentication needs TWO_FACTOR_ENCRYPTION_KEY to be set on the server")
+
+// GenerateTOTPSecret creates a random base32 encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	bytes := make([]byte, 20)
+	if _, error := rand.Read(bytes); error != nil {
+		return "", error
+	}
+
+	return base32Encoding.EncodeToString(bytes), nil
+}
+
+// TOTPProvisioningURI returns the otpauth URI authenticator apps read from a QR code
+func TOTPProvisioningURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode computes the code of a secret for a time step (RFC 4226 truncation)
+func TOTPCode(secret string, step int64) (string, error) {
+	key, error := base32Encoding.DecodeString(strings.ToUpper(secret))
+	if error != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %v", error)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// ValidateTOTP checks a code against the steps around now and returns the matched step, steps up to lastUsedStep are rejected so a code works only once
+func ValidateTOTP(secret string, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		expected, error := TOTPCode(secret, step)
+		if error != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes creates one-time recovery codes formatted as xxxx-xxxx-xxxx-xxxx
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for range count {
+		bytes := make([]byte, 10)
+		if _, error := rand.Read(bytes); error != nil {
+			return nil, error
+		}
+
+		code := strings.ToLower(base32Encoding.EncodeToString(bytes))
+		codes = append(codes, code[0:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:16])
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage, ignoring case, spaces and dashes the user may type differently
+func HashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	return HashToken(normalized)
+}
+
+// EncryptSecret encrypts a TOTP secret for storage with AES-GCM
+func EncryptSecret(secret string) (string, error) {
+	if configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey == "" {
+		return "", ErrTwoFactorNotConfigured
+	}
+
+	return encrypt(configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey, secret)
+}
+
+// DecryptSecret decrypts a TOTP secret encrypted by EncryptSecret
+func DecryptSecret(encrypted string) (string, error) {
+	if configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey == "" {
+		return "", ErrTwoFactorNotConfigured
+	}
+
+	return decrypt(configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey, encrypted)
+}
+
+// encrypt encrypts a value with AES-GCM keyed by the SHA-256 of a configured key
+func encrypt(key string, secret string) (string, error) {
+	aead, error := newCipher(key)
+	if error != nil {
+		return "", error
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, error := rand.Read(nonce); error != nil {
+		return "", error
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// decrypt decrypts a value encrypted by encrypt with the same key
+func decrypt(key string, encrypted string) (string, error) {
+	aead, error := newCipher(key)
+	if error != nil {
+		return "", error
+	}
+
+	data, error := base64.StdEncoding.DecodeString(encrypted)
+	if error != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted secret")
+	}
+
+	plain, error := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if error != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", error)
+	}
+
+	return string(plain), nil
+}
+
+// newCipher derives the AES key from a configured key
+func newCipher(key string) (cipher.AEAD, error) {
+	hash := sha256.Sum256([]byte(key))
+	block, error := aes.NewCipher(hash[:])
+	if error != nil {
+		return nil, error
+	}
+
+	return cipher.NewGCM(block)
+}
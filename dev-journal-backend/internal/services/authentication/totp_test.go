@@ -0,0 +1,101 @@
+package authenticationServices
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/hwaengfan/dev-journal-backend/configs"
+)
+
+// Secret of the RFC 6238 test vectors for SHA-1
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// the RFC lists eight digit codes, six digit codes are their last six digits
+	tests := []struct {
+		time     int64
+		expected string
+	}{
+		{time: 59, expected: "287082"},
+		{time: 1111111109, expected: "081804"},
+		{time: 1111111111, expected: "050471"},
+		{time: 1234567890, expected: "005924"},
+		{time: 2000000000, expected: "279037"},
+		{time: 20000000000, expected: "353130"},
+	}
+
+	for _, test := range tests {
+		code, error := TOTPCode(rfcSecret, test.time/totpPeriod)
+		if error != nil {
+			t.Fatal(error)
+		}
+		if code != test.expected {
+			t.Errorf("code at %d is %s, expected %s", test.time, code, test.expected)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	codeAt := func(step int64) string {
+		code, error := TOTPCode(rfcSecret, step)
+		if error != nil {
+			t.Fatal(error)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		expectedStep int64
+		valid        bool
+	}{
+		{name: "current step", code: codeAt(current), expectedStep: current, valid: true},
+		{name: "previous step within the window", code: codeAt(current - 1), expectedStep: current - 1, valid: true},
+		{name: "next step within the window", code: codeAt(current + 1), expectedStep: current + 1, valid: true},
+		{name: "step before the window", code: codeAt(current - 2)},
+		{name: "step after the window", code: codeAt(current + 2)},
+		{name: "step used already", code: codeAt(current), lastUsedStep: current},
+		{name: "step before the one used last", code: codeAt(current - 1), lastUsedStep: current},
+		{name: "step after the one used last", code: codeAt(current + 1), lastUsedStep: current, expectedStep: current + 1, valid: true},
+		{name: "wrong code", code: "000000"},
+		{name: "code of another length", code: codeAt(current)[1:]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, valid := ValidateTOTP(rfcSecret, test.code, now, test.lastUsedStep)
+			if valid != test.valid || step != test.expectedStep {
+				t.Errorf("code %s is valid %v at step %d, expected valid %v at step %d", test.code, valid, step, test.valid, test.expectedStep)
+			}
+		})
+	}
+}
+
+func TestSecretEncryptionNeedsAKey(t *testing.T) {
+	key := configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey
+	t.Cleanup(func() { configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey = key })
+
+	configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey = ""
+	if _, error := EncryptSecret(rfcSecret); error != ErrTwoFactorNotConfigured {
+		t.Fatalf("encrypting without a key returned %v", error)
+	}
+
+	configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey = "test-key"
+	encrypted, error := EncryptSecret(rfcSecret)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if secret, error := DecryptSecret(encrypted); error != nil || secret != rfcSecret {
+		t.Fatalf("decrypted %q, %v", secret, error)
+	}
+
+	configs.GlobalEnvironmentVariables.TwoFactorEncryptionKey = "other-key"
+	if _, error := DecryptSecret(encrypted); error == nil {
+		t.Fatal("secret was decrypted with another key")
+	}
+}
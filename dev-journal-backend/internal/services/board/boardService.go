@@ -0,0 +1,518 @@
+package boardService
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	columnModel "github.com/hwaengfan/dev-journal-backend/internal/models/column"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store               columnModel.ColumnStore
+	userStore           userModel.UserStore
+	memberStore         projectMemberModel.ProjectMemberStore
+	taskStore           taskModel.TaskStore
+	recurrenceScheduler *recurrenceServices.Scheduler
+}
+
+func NewHandler(store columnModel.ColumnStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, taskStore taskModel.TaskStore, recurrenceScheduler *recurrenceServices.Scheduler) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, taskStore: taskStore, recurrenceScheduler: recurrenceScheduler}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/boards/get-board-by-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetBoardByProjectID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/boards/create-new-column", authenticationServices.JWTAuthentication(handler.handleCreateNewColumn, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/boards/update-column-by-ID/{columnID}", authenticationServices.JWTAuthentication(handler.handleUpdateColumnByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/boards/delete-column-by-ID/{columnID}", authenticationServices.JWTAuthentication(handler.handleDeleteColumnByID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/boards/set-transitions-by-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleSetTransitionsByProjectID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/boards/move-task-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleMoveTaskByID, handler.userStore)).Methods(http.MethodPut)
+}
+
+// Handler function for getting a project's board with its columns and tasks
+func (handler *Handler) handleGetBoardByProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can read the project
+	if error := handler.validateLinkedProjectID(projectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// get the columns of the board
+	columns, error := handler.store.GetColumnsByLinkedProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	transitions, error := handler.store.GetTransitionsByLinkedProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	tasks, error := handler.taskStore.GetTasksByLinkedProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// sort the tasks into their columns, tasks without a column belong to the first one
+	board := columnModel.Board{LinkedProjectID: projectID, Columns: make([]*columnModel.BoardColumn, 0, len(columns)), Transitions: transitions}
+	boardColumns := make(map[uuid.UUID]*columnModel.BoardColumn)
+	for _, column := range columns {
+		boardColumn := &columnModel.BoardColumn{Column: *column, Tasks: make([]*taskModel.Task, 0)}
+		board.Columns = append(board.Columns, boardColumn)
+		boardColumns[column.ID] = boardColumn
+	}
+
+	for _, task := range tasks {
+		boardColumn, exists := boardColumns[task.ColumnID.UUID]
+		if !task.ColumnID.Valid || !exists {
+			if len(board.Columns) == 0 {
+				continue
+			}
+			boardColumn = board.Columns[0]
+		}
+
+		boardColumn.Tasks = append(boardColumn.Tasks, task)
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, board)
+}
+
+// Handler function for creating a new board column
+func (handler *Handler) handleCreateNewColumn(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload columnModel.CreateColumnPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(payload.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// new columns are appended to the end of the board
+	columns, error := handler.store.GetColumnsByLinkedProjectID(payload.LinkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	isDone := payload.IsDone
+	if isDone == "" {
+		isDone = "False"
+	}
+
+	columnID, error := handler.store.CreateColumn(columnModel.Column{
+		LinkedProjectID: payload.LinkedProjectID,
+		Title:           payload.Title,
+		Position:        len(columns),
+		IsDone:          isDone,
+	})
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// move the column into place if a position is requested
+	if payload.Position != nil && *payload.Position < len(columns) {
+		if error := handler.store.MoveColumnByID(columnID, max(*payload.Position, 0)); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"columnID": columnID})
+}
+
+// Handler function for updating a board column by ID
+func (handler *Handler) handleUpdateColumnByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get column ID from URL
+	columnID, error := utils.ParseIDFromURL(request, "columnID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload columnModel.UpdateColumnPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the column exists
+	column, error := handler.store.GetColumnByID(columnID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("column ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(column.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if payload.Title == "" && payload.IsDone == "" && payload.Position == nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("no fields to update"))
+		return
+	}
+
+	// update the column by ID
+	if payload.Title != "" || payload.IsDone != "" {
+		error = handler.store.UpdateColumnByID(columnModel.Column{
+			Title:  payload.Title,
+			IsDone: payload.IsDone,
+		}, columnID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	// reorder the column if requested
+	if payload.Position != nil {
+		if error := handler.store.MoveColumnByID(columnID, max(*payload.Position, 0)); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for deleting a board column by ID
+func (handler *Handler) handleDeleteColumnByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get column ID from URL
+	columnID, error := utils.ParseIDFromURL(request, "columnID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the column exists
+	column, error := handler.store.GetColumnByID(columnID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("column does not exist"))
+		return
+	}
+
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(column.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// a board always keeps at least one column
+	columns, error := handler.store.GetColumnsByLinkedProjectID(column.LinkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if len(columns) <= 1 {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("cannot delete the last column of a board"))
+		return
+	}
+
+	// tasks have to be moved out before the column can be deleted
+	count, error := handler.taskStore.CountTasksByColumnID(columnID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if count > 0 {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("column still contains %d tasks", count))
+		return
+	}
+
+	// delete the column by ID, tasks moved into it meanwhile keep it
+	if error := handler.store.DeleteColumnByID(columnID); error != nil {
+		if errors.Is(error, columnModel.ErrColumnNotEmpty) || errors.Is(error, columnModel.ErrLastColumn) {
+			utils.WriteError(writer, http.StatusConflict, error)
+			return
+		}
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for replacing the allowed column transitions of a project, an empty list allows every move
+func (handler *Handler) handleSetTransitionsByProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload columnModel.SetTransitionsPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(projectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// every transition has to connect two columns of this project
+	columns, error := handler.store.GetColumnsByLinkedProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	columnIDs := make(map[uuid.UUID]bool)
+	for _, column := range columns {
+		columnIDs[column.ID] = true
+	}
+
+	for _, transition := range payload.Transitions {
+		if !columnIDs[transition.FromColumnID] || !columnIDs[transition.ToColumnID] {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("transition references a column outside of the project"))
+			return
+		}
+		if transition.FromColumnID == transition.ToColumnID {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("transition must connect two different columns"))
+			return
+		}
+	}
+
+	if error := handler.store.SetTransitionsByLinkedProjectID(projectID, payload.Transitions); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for moving a task to a column and position
+func (handler *Handler) handleMoveTaskByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get task ID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload columnModel.MoveTaskPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the task and the target column exist on the same board
+	task, error := handler.taskStore.GetTaskByID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	column, error := handler.store.GetColumnByID(payload.ColumnID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("column ID does not exist"))
+		return
+	}
+
+	if column.LinkedProjectID != task.LinkedProjectID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("column does not belong to the task's project"))
+		return
+	}
+
+	// enforce the allowed transitions when moving between columns
+	columns, error := handler.store.GetColumnsByLinkedProjectID(task.LinkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	fromColumnID := columns[0].ID
+	if task.ColumnID.Valid {
+		fromColumnID = task.ColumnID.UUID
+	}
+
+	if fromColumnID != column.ID {
+		allowed, error := handler.isTransitionAllowed(task.LinkedProjectID, fromColumnID, column.ID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		if !allowed {
+			utils.WriteError(writer, http.StatusConflict, fmt.Errorf("moving the task to column %s is not an allowed transition", column.Title))
+			return
+		}
+	}
+
+	// move the task, the column decides whether it is completed
+	error = handler.taskStore.MoveTaskByID(taskID, column.ID, payload.Position, column.IsDone)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// completing a recurring task brings up its next occurrence
+	if column.IsDone == "True" && task.Completed != "True" {
+		if _, error := handler.recurrenceScheduler.GenerateNextOccurrence(task); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// isTransitionAllowed checks a move against the project's transitions, projects without transitions allow every move
+func (handler *Handler) isTransitionAllowed(linkedProjectID uuid.UUID, fromColumnID uuid.UUID, toColumnID uuid.UUID) (bool, error) {
+	transitions, error := handler.store.GetTransitionsByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		return false, error
+	}
+
+	if len(transitions) == 0 {
+		return true, nil
+	}
+
+	for _, transition := range transitions {
+		if transition.FromColumnID == fromColumnID && transition.ToColumnID == toColumnID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// validateLinkedProjectID check if the project exists in the active workspace and the user has at least a role in it
+func (handler *Handler) validateLinkedProjectID(linkedProjectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID, minimumRole string) error {
+	_, error := projectAccessServices.Authorize(handler.memberStore, linkedProjectID, userID, workspaceID, minimumRole)
+	return error
+}
@@ -0,0 +1,91 @@
+package commentService
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	commentModel "github.com/hwaengfan/dev-journal-backend/internal/models/comment"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+)
+
+// A mention is an @ followed by an email, like @jane@example.com
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9._%+-])@([A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,})`)
+
+// resolveMentions returns the members of a project mentioned in a comment, mentions of anyone else are left as text
+func (handler *Handler) resolveMentions(projectID uuid.UUID, content string) ([]uuid.UUID, error) {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	members, error := handler.memberStore.GetProjectMembers(projectID)
+	if error != nil {
+		return nil, error
+	}
+
+	membersByEmail := make(map[string]uuid.UUID, len(members))
+	for _, member := range members {
+		membersByEmail[strings.ToLower(member.Email)] = member.UserID
+	}
+
+	mentionedUserIDs := make([]uuid.UUID, 0)
+	mentioned := make(map[uuid.UUID]bool)
+	for _, match := range matches {
+		userID, exists := membersByEmail[strings.ToLower(match[1])]
+		if !exists || mentioned[userID] {
+			continue
+		}
+
+		mentioned[userID] = true
+		mentionedUserIDs = append(mentionedUserIDs, userID)
+	}
+
+	return mentionedUserIDs, nil
+}
+
+// notifyMentions lets the members mentioned in a comment know, members already mentioned before an edit are not notified again
+func (handler *Handler) notifyMentions(comment *commentModel.Comment, previousMentions []*commentModel.Mention) {
+	notified := make(map[uuid.UUID]bool, len(previousMentions))
+	for _, mention := range previousMentions {
+		notified[mention.UserID] = true
+	}
+
+	var newMentions []*commentModel.Mention
+	for _, mention := range comment.Mentions {
+		if !notified[mention.UserID] {
+			newMentions = append(newMentions, mention)
+		}
+	}
+
+	if len(newMentions) == 0 || !comment.UserID.Valid {
+		return
+	}
+
+	// name what the comment was made on
+	subject := "a comment"
+	if comment.NoteID.Valid {
+		if note, error := handler.noteStore.GetNoteByID(comment.NoteID.UUID); error == nil {
+			subject = fmt.Sprintf("a comment on the note %s", note.Title)
+		}
+	} else if task, error := handler.taskStore.GetTaskByID(comment.TaskID.UUID); error == nil {
+		subject = fmt.Sprintf("a comment on the task %s", task.Description)
+	}
+
+	for _, mention := range newMentions {
+		error := handler.notifier.Notify(notificationModel.Notification{
+			UserID:     mention.UserID,
+			Type:       notificationModel.TypeMention,
+			ActorID:    comment.UserID,
+			ProjectID:  uuid.NullUUID{UUID: comment.ProjectID, Valid: true},
+			EntityType: notificationModel.EntityComment,
+			EntityID:   comment.ID,
+			Message:    fmt.Sprintf("%s %s mentioned you in %s", comment.FirstName, comment.LastName, subject),
+		})
+		if error != nil {
+			log.Printf("failed to notify mention in comment %s: %v", comment.ID, error)
+		}
+	}
+}
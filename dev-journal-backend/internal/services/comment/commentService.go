@@ -0,0 +1,454 @@
+package commentService
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	commentModel "github.com/hwaengfan/dev-journal-backend/internal/models/comment"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	markdownServices "github.com/hwaengfan/dev-journal-backend/internal/services/markdown"
+	notificationService "github.com/hwaengfan/dev-journal-backend/internal/services/notification"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Comments listed for a project when no limit is asked for
+const defaultRecentComments = 50
+
+type Handler struct {
+	store       commentModel.CommentStore
+	userStore   userModel.UserStore
+	memberStore projectMemberModel.ProjectMemberStore
+	noteStore   noteModel.NoteStore
+	taskStore   taskModel.TaskStore
+	renderer    *markdownServices.Renderer
+	recorder    *auditServices.Recorder
+	notifier    *notificationService.Notifier
+}
+
+func NewHandler(store commentModel.CommentStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, noteStore noteModel.NoteStore, taskStore taskModel.TaskStore, renderer *markdownServices.Renderer, recorder *auditServices.Recorder, notifier *notificationService.Notifier) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, noteStore: noteStore, taskStore: taskStore, renderer: renderer, recorder: recorder, notifier: notifier}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/comments/create-comment", authenticationServices.JWTAuthentication(handler.handleCreateComment, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/comments/get-comments-by-note-ID/{noteID}", authenticationServices.JWTAuthentication(handler.handleGetCommentsByNoteID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/comments/get-comments-by-task-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleGetCommentsByTaskID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/comments/get-recent-comments-by-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetRecentCommentsByProjectID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/comments/update-comment-by-ID/{commentID}", authenticationServices.JWTAuthentication(handler.handleUpdateCommentByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/comments/delete-comment-by-ID/{commentID}", authenticationServices.JWTAuthentication(handler.handleDeleteCommentByID, handler.userStore)).Methods(http.MethodDelete)
+}
+
+// Handler function for commenting on a note or a task, or replying to a comment
+func (handler *Handler) handleCreateComment(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload commentModel.CreateCommentPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if (payload.NoteID == uuid.Nil) == (payload.TaskID == uuid.Nil) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("either a note ID or a task ID is required"))
+		return
+	}
+
+	comment := commentModel.Comment{
+		NoteID:  uuid.NullUUID{UUID: payload.NoteID, Valid: payload.NoteID != uuid.Nil},
+		TaskID:  uuid.NullUUID{UUID: payload.TaskID, Valid: payload.TaskID != uuid.Nil},
+		UserID:  userID,
+		Content: payload.Content,
+	}
+
+	// get the project of the note or task
+	if comment.NoteID.Valid {
+		note, error := handler.noteStore.GetNoteByID(comment.NoteID.UUID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note ID does not exist"))
+			return
+		}
+		comment.ProjectID = note.LinkedProjectID
+	} else {
+		task, error := handler.taskStore.GetTaskByID(comment.TaskID.UUID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+			return
+		}
+		comment.ProjectID = task.LinkedProjectID
+	}
+
+	// viewers take part in discussions without being able to edit
+	if _, error := projectAccessServices.Authorize(handler.memberStore, comment.ProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// replies are added to the thread of the comment they answer
+	if payload.ParentCommentID != uuid.Nil {
+		parent, error := handler.store.GetCommentByID(payload.ParentCommentID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+		if parent == nil || parent.NoteID != comment.NoteID || parent.TaskID != comment.TaskID {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("parent comment ID does not exist"))
+			return
+		}
+
+		comment.ParentCommentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		if parent.ParentCommentID.Valid {
+			comment.ParentCommentID = parent.ParentCommentID
+		}
+	}
+
+	mentionedUserIDs, error := handler.resolveMentions(comment.ProjectID, payload.Content)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	commentID, error := handler.store.CreateComment(comment, mentionedUserIDs)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	createdComment, error := handler.store.GetCommentByID(commentID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordCommentEvent(request, auditModel.ActionCreate, nil, createdComment)
+	handler.notifyMentions(createdComment, nil)
+
+	handler.render(createdComment)
+	utils.WriteJSON(writer, http.StatusCreated, createdComment)
+}
+
+// Handler function for getting the comment threads of a note
+func (handler *Handler) handleGetCommentsByNoteID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get note ID from URL
+	noteID, error := utils.ParseIDFromURL(request, "noteID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	note, error := handler.noteStore.GetNoteByID(noteID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note ID does not exist"))
+		return
+	}
+
+	// check if the user can read the project of the note
+	if _, error := projectAccessServices.Authorize(handler.memberStore, note.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	comments, error := handler.store.GetCommentsByNoteID(noteID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, handler.buildThreads(comments))
+}
+
+// Handler function for getting the comment threads of a task
+func (handler *Handler) handleGetCommentsByTaskID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get task ID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	task, error := handler.taskStore.GetTaskByID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can read the project of the task
+	if _, error := projectAccessServices.Authorize(handler.memberStore, task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	comments, error := handler.store.GetCommentsByTaskID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, handler.buildThreads(comments))
+}
+
+// Handler function for getting the latest comments in a project, newest first and limited by the limit query parameter
+func (handler *Handler) handleGetRecentCommentsByProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	limit := defaultRecentComments
+	if value, error := strconv.Atoi(request.URL.Query().Get("limit")); error == nil {
+		limit = min(max(value, 1), 200)
+	}
+
+	// check if the user can read the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	comments, error := handler.store.GetRecentCommentsByProjectID(projectID, limit)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	for _, comment := range comments {
+		handler.render(comment)
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, comments)
+}
+
+// Handler function for editing a comment, only its author can
+func (handler *Handler) handleUpdateCommentByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get comment ID from URL
+	commentID, error := utils.ParseIDFromURL(request, "commentID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload commentModel.UpdateCommentPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	comment, error := handler.getComment(commentID, userID.UUID, workspaceID)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if comment.UserID != userID {
+		utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("only the author can edit a comment"))
+		return
+	}
+	if comment.DateDeleted != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("comment was deleted"))
+		return
+	}
+
+	mentionedUserIDs, error := handler.resolveMentions(comment.ProjectID, payload.Content)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.UpdateCommentByID(commentID, payload.Content, mentionedUserIDs); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	updatedComment, error := handler.store.GetCommentByID(commentID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordCommentEvent(request, auditModel.ActionUpdate, comment, updatedComment)
+	handler.notifyMentions(updatedComment, comment.Mentions)
+
+	handler.render(updatedComment)
+	utils.WriteJSON(writer, http.StatusOK, updatedComment)
+}
+
+// Handler function for deleting a comment, its author and the owners of the project can
+func (handler *Handler) handleDeleteCommentByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get comment ID from URL
+	commentID, error := utils.ParseIDFromURL(request, "commentID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	comment, error := handler.getComment(commentID, userID.UUID, workspaceID)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// owners moderate the discussions of their project
+	if comment.UserID != userID {
+		if _, error := projectAccessServices.Authorize(handler.memberStore, comment.ProjectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+			utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("only the author or an owner of the project can delete a comment"))
+			return
+		}
+	}
+
+	if error := handler.store.DeleteCommentByID(commentID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordCommentEvent(request, auditModel.ActionDelete, comment, nil)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// recordCommentEvent records a change of a comment in the activity of its project
+func (handler *Handler) recordCommentEvent(request *http.Request, action string, before *commentModel.Comment, after *commentModel.Comment) {
+	comment := after
+	if comment == nil {
+		comment = before
+	}
+
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: comment.ProjectID, Valid: true},
+		EntityType: auditModel.EntityComment,
+		EntityID:   comment.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// getComment retrieves a comment the user can read in the active workspace, failing like Authorize otherwise
+func (handler *Handler) getComment(commentID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID) (*commentModel.Comment, error) {
+	comment, error := handler.store.GetCommentByID(commentID)
+	if error != nil {
+		return nil, error
+	}
+	if comment == nil {
+		return nil, projectAccessServices.NotFound("comment ID does not exist")
+	}
+
+	// comments in projects the user cannot read are reported like missing ones
+	if _, error := projectAccessServices.Authorize(handler.memberStore, comment.ProjectID, userID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		return nil, error
+	}
+
+	return comment, nil
+}
+
+// buildThreads nests replies under the comment starting their thread, keeping the order of the comments
+func (handler *Handler) buildThreads(comments []*commentModel.Comment) []*commentModel.Comment {
+	threads := make([]*commentModel.Comment, 0)
+	threadsByID := make(map[uuid.UUID]*commentModel.Comment)
+	for _, comment := range comments {
+		handler.render(comment)
+
+		if thread, exists := threadsByID[comment.ParentCommentID.UUID]; comment.ParentCommentID.Valid && exists {
+			thread.Replies = append(thread.Replies, comment)
+			continue
+		}
+
+		threads = append(threads, comment)
+		threadsByID[comment.ID] = comment
+	}
+
+	return threads
+}
+
+// render adds the rendered Markdown of a comment
+func (handler *Handler) render(comment *commentModel.Comment) {
+	comment.Rendered = handler.renderer.Render(comment.ID, comment.Content)
+}
@@ -0,0 +1,326 @@
+package dailyEntryService
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	dailyEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/dailyEntry"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store       dailyEntryModel.DailyEntryStore
+	userStore   userModel.UserStore
+	memberStore projectMemberModel.ProjectMemberStore
+}
+
+func NewHandler(store dailyEntryModel.DailyEntryStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/daily-entries/get-today-entry", authenticationServices.JWTAuthentication(handler.handleGetTodayEntry, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/daily-entries/get-entry-by-date/{date}", authenticationServices.JWTAuthentication(handler.handleGetEntryByDate, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/daily-entries/get-previous-entry/{date}", authenticationServices.JWTAuthentication(handler.handleGetPreviousEntry, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/daily-entries/get-next-entry/{date}", authenticationServices.JWTAuthentication(handler.handleGetNextEntry, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/daily-entries/get-calendar", authenticationServices.JWTAuthentication(handler.handleGetCalendar, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/daily-entries/update-entry-by-ID/{entryID}", authenticationServices.JWTAuthentication(handler.handleUpdateEntryByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/daily-entries/delete-entry-by-ID/{entryID}", authenticationServices.JWTAuthentication(handler.handleDeleteEntryByID, handler.userStore)).Methods(http.MethodDelete)
+}
+
+// Handler function for getting, or creating, the entry of the current day in the user's timezone
+func (handler *Handler) handleGetTodayEntry(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	today, error := handler.today(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	dailyEntry, error := handler.store.GetOrCreateDailyEntry(userID.UUID, workspaceID, today.Format(time.DateOnly))
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dailyEntry)
+}
+
+// Handler function for getting, or creating, the entry of a date up to today
+func (handler *Handler) handleGetEntryByDate(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	date, error := parseDateFromURL(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// entries are not created ahead of the user's current day
+	today, error := handler.today(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if date.After(today) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("cannot create an entry for a future date"))
+		return
+	}
+
+	dailyEntry, error := handler.store.GetOrCreateDailyEntry(userID.UUID, workspaceID, date.Format(time.DateOnly))
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dailyEntry)
+}
+
+// Handler function for getting the last written entry before a date
+func (handler *Handler) handleGetPreviousEntry(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	date, error := parseDateFromURL(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	dailyEntry, error := handler.store.GetPreviousDailyEntry(userID.UUID, workspaceID, date.Format(time.DateOnly))
+	if error != nil {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("no previous entry"))
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dailyEntry)
+}
+
+// Handler function for getting the first written entry after a date
+func (handler *Handler) handleGetNextEntry(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	date, error := parseDateFromURL(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	dailyEntry, error := handler.store.GetNextDailyEntry(userID.UUID, workspaceID, date.Format(time.DateOnly))
+	if error != nil {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("no next entry"))
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dailyEntry)
+}
+
+// Handler function for getting the days of a month with written entries, the month query parameter defaults to the current one
+func (handler *Handler) handleGetCalendar(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	today, error := handler.today(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value := request.URL.Query().Get("month"); value != "" {
+		month, error = time.Parse("2006-01", value)
+		if error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid month, expected YYYY-MM"))
+			return
+		}
+	}
+
+	dates, error := handler.store.GetDailyEntryDatesByUserID(userID.UUID, workspaceID, month.Format(time.DateOnly), month.AddDate(0, 1, -1).Format(time.DateOnly))
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]any{"month": month.Format("2006-01"), "dates": dates})
+}
+
+// Handler function for updating an entry's content and linked projects
+func (handler *Handler) handleUpdateEntryByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get entryID from URL
+	entryID, error := utils.ParseIDFromURL(request, "entryID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload dailyEntryModel.UpdateDailyEntryPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the entry belongs to the user and the active workspace
+	dailyEntry, error := handler.store.GetDailyEntryByID(entryID)
+	if error != nil || dailyEntry.UserID != userID.UUID || dailyEntry.WorkspaceID != workspaceID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("entry ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the linked projects
+	if payload.LinkedProjectIDs != nil {
+		for _, projectID := range *payload.LinkedProjectIDs {
+			_, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor)
+			if projectAccessServices.IsNotFound(error) {
+				utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("project ID %s does not exist", projectID))
+				return
+			} else if error != nil {
+				projectAccessServices.WriteError(writer, error)
+				return
+			}
+		}
+	}
+
+	error = handler.store.UpdateDailyEntryByID(entryID, payload.Content, payload.LinkedProjectIDs)
+	if error != nil {
+		if error.Error() == "no fields to update" {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for deleting an entry by ID
+func (handler *Handler) handleDeleteEntryByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get entryID from URL
+	entryID, error := utils.ParseIDFromURL(request, "entryID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the entry belongs to the user and the active workspace
+	dailyEntry, error := handler.store.GetDailyEntryByID(entryID)
+	if error != nil || dailyEntry.UserID != userID.UUID || dailyEntry.WorkspaceID != workspaceID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("entry ID does not exist"))
+		return
+	}
+
+	if error := handler.store.DeleteDailyEntryByID(entryID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// today returns the current calendar day in the user's timezone
+func (handler *Handler) today(userID uuid.UUID) (time.Time, error) {
+	user, error := handler.userStore.GetUserByID(userID)
+	if error != nil {
+		return time.Time{}, error
+	}
+
+	location, error := time.LoadLocation(user.Timezone)
+	if error != nil {
+		location = time.UTC
+	}
+
+	now := time.Now().In(location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseDateFromURL parses the date path parameter of the request
+func parseDateFromURL(request *http.Request) (time.Time, error) {
+	date, error := time.Parse(time.DateOnly, mux.Vars(request)["date"])
+	if error != nil {
+		return time.Time{}, fmt.Errorf("invalid date, expected YYYY-MM-DD")
+	}
+
+	return date, nil
+}
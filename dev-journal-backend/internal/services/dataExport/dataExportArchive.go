@@ -0,0 +1,191 @@
+package dataExportService
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+)
+
+// Version of the export.json layout, bumped whenever a field changes meaning or is removed
+const archiveFormatVersion = 1
+
+// Archive is everything a user can take with them, it is written as export.json
+type Archive struct {
+	FormatVersion int               `json:"formatVersion"`
+	ExportedAt    time.Time         `json:"exportedAt"`
+	Profile       *userModel.User   `json:"profile"`
+	Projects      []*ArchiveProject `json:"projects"`
+}
+
+type ArchiveProject struct {
+	Project *projectModel.Project `json:"project"`
+	Notes   []*noteModel.Note     `json:"notes"`
+	Tasks   []*taskModel.Task     `json:"tasks"` // flat, subtasks point to their parent through parentTaskID
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// writeArchive writes the archive as a zip with export.json and a Markdown rendering of the same data
+func writeArchive(writer io.Writer, archive *Archive) error {
+	zipWriter := zip.NewWriter(writer)
+
+	data, error := json.MarshalIndent(archive, "", "  ")
+	if error != nil {
+		return fmt.Errorf("failed to encode export: %v", error)
+	}
+
+	files := []struct{ name, content string }{
+		{"export.json", string(data)},
+		{"README.md", renderIndex(archive)},
+		{"profile.md", renderProfile(archive.Profile)},
+	}
+	for index, project := range archive.Projects {
+		files = append(files, struct{ name, content string }{projectFileName(index, project.Project), renderProject(project)})
+	}
+
+	for _, file := range files {
+		fileWriter, error := zipWriter.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: archive.ExportedAt})
+		if error != nil {
+			return fmt.Errorf("failed to add %s to archive: %v", file.name, error)
+		}
+
+		if _, error := io.WriteString(fileWriter, file.content); error != nil {
+			return fmt.Errorf("failed to write %s to archive: %v", file.name, error)
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// projectFileName names the Markdown file of a project, numbered so projects with the same title do not collide
+func projectFileName(index int, project *projectModel.Project) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(project.Title), "-"), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = "project"
+	}
+
+	return fmt.Sprintf("projects/%03d-%s.md", index+1, slug)
+}
+
+// renderIndex renders the overview of the archive linking to every file
+func renderIndex(archive *Archive) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# dev-journal export\n\n")
+	fmt.Fprintf(&builder, "Exported at %s for %s %s.\n\n", archive.ExportedAt.UTC().Format(time.RFC3339), archive.Profile.FirstName, archive.Profile.LastName)
+	fmt.Fprintf(&builder, "`export.json` holds the same data in a machine-readable form.\n\n")
+	fmt.Fprintf(&builder, "- [Profile](profile.md)\n")
+
+	for index, project := range archive.Projects {
+		fmt.Fprintf(&builder, "- [%s](%s) (%d notes, %d tasks)\n", escapeMarkdown(project.Project.Title), projectFileName(index, project.Project), len(project.Notes), len(project.Tasks))
+	}
+
+	return builder.String()
+}
+
+// renderProfile renders the profile of the user
+func renderProfile(user *userModel.User) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# Profile\n\n")
+	fmt.Fprintf(&builder, "- **ID:** %s\n", user.ID)
+	fmt.Fprintf(&builder, "- **First name:** %s\n", escapeMarkdown(user.FirstName))
+	fmt.Fprintf(&builder, "- **Last name:** %s\n", escapeMarkdown(user.LastName))
+	fmt.Fprintf(&builder, "- **Email:** %s\n", user.Email)
+	fmt.Fprintf(&builder, "- **Email verified:** %s\n", user.EmailVerified)
+	fmt.Fprintf(&builder, "- **Timezone:** %s\n", user.Timezone)
+
+	return builder.String()
+}
+
+// renderProject renders a project with its tasks as a nested checklist followed by its notes
+func renderProject(project *ArchiveProject) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n\n", escapeMarkdown(project.Project.Title))
+	fmt.Fprintf(&builder, "- **Priority:** %s\n", project.Project.Priority)
+	fmt.Fprintf(&builder, "- **Deadline:** %s\n", project.Project.Deadline)
+	fmt.Fprintf(&builder, "- **Created:** %s\n", project.Project.DateCreated)
+	fmt.Fprintf(&builder, "- **Last edited:** %s\n\n", project.Project.LastEdited)
+	fmt.Fprintf(&builder, "%s\n\n", project.Project.Description)
+
+	fmt.Fprintf(&builder, "## Tasks\n\n")
+	if len(project.Tasks) == 0 {
+		fmt.Fprintf(&builder, "No tasks.\n\n")
+	} else {
+		// subtasks are listed under their parent, tasks whose parent is missing are treated as root tasks
+		children := make(map[uuid.UUID][]*taskModel.Task)
+		exists := make(map[uuid.UUID]bool, len(project.Tasks))
+		for _, task := range project.Tasks {
+			exists[task.ID] = true
+		}
+
+		var roots []*taskModel.Task
+		for _, task := range project.Tasks {
+			if task.ParentTaskID.Valid && exists[task.ParentTaskID.UUID] {
+				children[task.ParentTaskID.UUID] = append(children[task.ParentTaskID.UUID], task)
+			} else {
+				roots = append(roots, task)
+			}
+		}
+
+		for _, task := range roots {
+			renderTask(&builder, task, children, 0)
+		}
+		builder.WriteString("\n")
+	}
+
+	fmt.Fprintf(&builder, "## Notes\n\n")
+	if len(project.Notes) == 0 {
+		fmt.Fprintf(&builder, "No notes.\n")
+	}
+
+	for _, note := range project.Notes {
+		fmt.Fprintf(&builder, "### %s\n\n", escapeMarkdown(note.Title))
+		fmt.Fprintf(&builder, "_Created %s, last edited %s", note.DateCreated, note.LastEdited)
+		if len(note.Tags) > 0 {
+			fmt.Fprintf(&builder, ", tagged %s", escapeMarkdown(strings.Join(note.Tags, ", ")))
+		}
+		if note.Favorited == "True" {
+			fmt.Fprintf(&builder, ", favorited")
+		}
+		fmt.Fprintf(&builder, "_\n\n%s\n\n", strings.TrimSpace(note.Content))
+	}
+
+	return builder.String()
+}
+
+// renderTask renders a task as a checklist item followed by its subtasks
+func renderTask(builder *strings.Builder, task *taskModel.Task, children map[uuid.UUID][]*taskModel.Task, depth int) {
+	checkbox := " "
+	if task.Completed == "True" {
+		checkbox = "x"
+	}
+
+	fmt.Fprintf(builder, "%s- [%s] %s", strings.Repeat("  ", depth), checkbox, escapeMarkdown(task.Description))
+	if task.DueDate != nil {
+		fmt.Fprintf(builder, " (due %s)", *task.DueDate)
+	}
+	builder.WriteString("\n")
+
+	// the depth limit of subtasks keeps this recursion shallow
+	for _, child := range children[task.ID] {
+		renderTask(builder, child, children, depth+1)
+	}
+}
+
+// escapeMarkdown keeps user text on one line and stops it from being read as Markdown syntax
+func escapeMarkdown(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`, "|", `\|`).Replace(text)
+}
@@ -0,0 +1,219 @@
+package dataExportService
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	dataExportModel "github.com/hwaengfan/dev-journal-backend/internal/models/dataExport"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Exports a user can request per day, building one reads all of their data
+const exportsPerDay = 3
+
+type Handler struct {
+	store     dataExportModel.DataExportStore
+	userStore userModel.UserStore
+	worker    *Worker
+}
+
+func NewHandler(store dataExportModel.DataExportStore, userStore userModel.UserStore, worker *Worker) *Handler {
+	return &Handler{store: store, userStore: userStore, worker: worker}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/exports/create-export", authenticationServices.JWTAuthentication(handler.handleCreateExport, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/exports/get-exports", authenticationServices.JWTAuthentication(handler.handleGetExports, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/exports/get-export-by-ID/{exportID}", authenticationServices.JWTAuthentication(handler.handleGetExportByID, handler.userStore)).Methods(http.MethodGet)
+
+	// the token in the link authorizes the download, so it works from a mail without logging in
+	router.HandleFunc("/exports/download", handler.handleDownloadExport).Methods(http.MethodGet)
+}
+
+// Handler function for requesting an export of the data of the user in the active workspace, it is built in the background
+func (handler *Handler) handleCreateExport(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// an export that is still being built already covers the request
+	activeExport, error := handler.store.GetActiveDataExportByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if activeExport != nil {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("an export is already in progress"))
+		return
+	}
+
+	// the limit counts the exports of every workspace
+	recentExports, error := handler.store.GetDataExportsByUserID(userID.UUID, uuid.NullUUID{}, exportsPerDay)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if len(recentExports) >= exportsPerDay && time.Since(recentExports[exportsPerDay-1].DateCreated) < 24*time.Hour {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many exports requested today, try again later"))
+		return
+	}
+
+	exportID, error := handler.store.CreateDataExport(userID.UUID, workspaceID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	dataExport, error := handler.store.GetDataExportByID(exportID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.worker.Wake()
+	utils.WriteJSON(writer, http.StatusAccepted, dataExport)
+}
+
+// Handler function for getting the latest exports of the user in the active workspace
+func (handler *Handler) handleGetExports(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := uuid.NullUUID{UUID: authenticationServices.GetWorkspaceIDFromContext(request.Context()), Valid: true}
+
+	dataExports, error := handler.store.GetDataExportsByUserID(userID.UUID, workspaceID, 20)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	for _, dataExport := range dataExports {
+		if error := setDownloadURL(dataExport); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dataExports)
+}
+
+// Handler function for getting the status of an export, completed exports come with their download link
+func (handler *Handler) handleGetExportByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get export ID from URL
+	exportID, error := utils.ParseIDFromURL(request, "exportID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	dataExport, error := handler.store.GetDataExportByID(exportID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if dataExport == nil || dataExport.UserID != userID.UUID || dataExport.WorkspaceID != authenticationServices.GetWorkspaceIDFromContext(request.Context()) {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("export not found"))
+		return
+	}
+
+	if error := setDownloadURL(dataExport); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, dataExport)
+}
+
+// Handler function for downloading the archive of a completed export through its link
+func (handler *Handler) handleDownloadExport(writer http.ResponseWriter, request *http.Request) {
+	exportID, error := authenticationServices.ParseDataExportDownloadJWT(request.URL.Query().Get("token"))
+	if error != nil {
+		utils.WriteError(writer, http.StatusForbidden, error)
+		return
+	}
+
+	dataExport, error := handler.store.GetDataExportByID(exportID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if dataExport == nil || dataExport.Status != dataExportModel.StatusCompleted || dataExport.ExpiresAt == nil || time.Now().After(*dataExport.ExpiresAt) {
+		utils.WriteError(writer, http.StatusGone, fmt.Errorf("export is no longer available"))
+		return
+	}
+
+	archive, error := handler.store.GetDataExportArchive(exportID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if archive == nil {
+		utils.WriteError(writer, http.StatusGone, fmt.Errorf("export is no longer available"))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dev-journal-export-%s.zip"`, dataExport.DateCompleted.UTC().Format("2006-01-02")))
+	writer.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(writer, request, "", *dataExport.DateCompleted, bytes.NewReader(archive))
+}
+
+// setDownloadURL adds the download link to a completed export
+func setDownloadURL(dataExport *dataExportModel.DataExport) error {
+	if dataExport.Status != dataExportModel.StatusCompleted || dataExport.ExpiresAt == nil {
+		return nil
+	}
+
+	link, _, error := downloadURL(dataExport.ID, *dataExport.ExpiresAt)
+	if error != nil {
+		return error
+	}
+
+	dataExport.DownloadURL = link
+	return nil
+}
+
+// downloadURL creates a link to the archive of an export and returns until when it works
+// Links expire with the archive, or earlier when sessions are shorter because rotated signing keys are only kept that long
+func downloadURL(exportID uuid.UUID, archiveExpiresAt time.Time) (string, time.Time, error) {
+	expiresAt := time.Now().Add(time.Second * time.Duration(configs.GlobalEnvironmentVariables.JWTExpirationInSeconds))
+	if archiveExpiresAt.Before(expiresAt) {
+		expiresAt = archiveExpiresAt
+	}
+
+	token, error := authenticationServices.CreateDataExportDownloadJWT(exportID, expiresAt)
+	if error != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create download token: %v", error)
+	}
+
+	server := configs.ServerEnvironmentVariables
+	return fmt.Sprintf("%s:%s/api/v1/exports/download?token=%s", server.PublicHost, server.Port, url.QueryEscape(token)), expiresAt, nil
+}
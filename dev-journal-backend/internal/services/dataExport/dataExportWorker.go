@@ -0,0 +1,275 @@
+package dataExportService
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	dataExportModel "github.com/hwaengfan/dev-journal-backend/internal/models/dataExport"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	queueService "github.com/hwaengfan/dev-journal-backend/internal/services/queue"
+)
+
+// How long a claimed export stays with its server, the lease is extended while the archive is built so only exports of stopped servers are claimed again
+const exportLease = 2 * time.Minute
+
+// Type of the mails telling a user their export is ready
+const exportReadyMail = "data-export-ready"
+
+// The reference of a queued export mail, its download link is only signed when the mail is sent
+type exportReadyMailReference struct {
+	ExportID uuid.UUID `json:"exportID"`
+}
+
+type Worker struct {
+	store        dataExportModel.DataExportStore
+	userStore    userModel.UserStore
+	projectStore projectModel.ProjectStore
+	noteStore    noteModel.NoteStore
+	taskStore    taskModel.TaskStore
+	mailer       *queueService.QueuedMailer
+	expiration   time.Duration
+	wake         chan struct{}
+	stopping     chan struct{}
+	stopOnce     sync.Once
+	waitGroup    sync.WaitGroup
+}
+
+func NewWorker(store dataExportModel.DataExportStore, userStore userModel.UserStore, projectStore projectModel.ProjectStore, noteStore noteModel.NoteStore, taskStore taskModel.TaskStore, mailer *queueService.QueuedMailer, expiration time.Duration) *Worker {
+	worker := &Worker{
+		store:        store,
+		userStore:    userStore,
+		projectStore: projectStore,
+		noteStore:    noteStore,
+		taskStore:    taskStore,
+		mailer:       mailer,
+		expiration:   expiration,
+		wake:         make(chan struct{}, 1),
+		stopping:     make(chan struct{}),
+	}
+	mailer.Compose(exportReadyMail, worker.composeReadyMail)
+
+	return worker
+}
+
+// Start starts building the pending exports and deleting the expired ones on every tick or wake up, until the worker is stopped
+func (worker *Worker) Start(interval time.Duration) {
+	worker.waitGroup.Add(1)
+	go worker.run(interval)
+}
+
+// Stop stops claiming exports and waits for the one being built, an export still running once ctx is done is claimed again once its lease runs out
+func (worker *Worker) Stop(ctx context.Context) error {
+	worker.stopOnce.Do(func() { close(worker.stopping) })
+
+	stopped := make(chan struct{})
+	go func() {
+		worker.waitGroup.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("left running data export: %v", ctx.Err())
+	}
+}
+
+// run processes the exports until the worker is stopped
+func (worker *Worker) run(interval time.Duration) {
+	defer worker.waitGroup.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		worker.ProcessPendingExports()
+		worker.DeleteExpiredExports(time.Now())
+
+		select {
+		case <-worker.stopping:
+			return
+		case <-ticker.C:
+		case <-worker.wake:
+		}
+	}
+}
+
+// Wake makes the worker look for pending exports without waiting for the next tick
+func (worker *Worker) Wake() {
+	select {
+	case worker.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessPendingExports builds the pending exports one after the other until none is left or the worker is stopped
+func (worker *Worker) ProcessPendingExports() {
+	for {
+		select {
+		case <-worker.stopping:
+			return
+		default:
+		}
+
+		now := time.Now()
+		dataExport, error := worker.store.ClaimNextDataExport(now, exportLease)
+		if error != nil {
+			log.Printf("failed to claim data export: %v", error)
+			return
+		}
+		if dataExport == nil {
+			return
+		}
+
+		if error := worker.processExport(dataExport); error != nil {
+			log.Printf("failed to build data export %s: %v", dataExport.ID, error)
+			if error := worker.store.FailDataExport(dataExport.ID, error.Error()); error != nil {
+				log.Printf("failed to mark data export %s as failed: %v", dataExport.ID, error)
+			}
+		}
+	}
+}
+
+// DeleteExpiredExports expires the exports past their expiration, which deletes the archives nobody can download anymore
+func (worker *Worker) DeleteExpiredExports(now time.Time) {
+	if _, error := worker.store.ExpireDataExports(now); error != nil {
+		log.Printf("failed to expire data exports: %v", error)
+	}
+}
+
+// processExport gathers the data of a user into an archive, stores it and mails the user a download link
+func (worker *Worker) processExport(dataExport *dataExportModel.DataExport) error {
+	// keep the export claimed while it is built, however long that takes
+	done := make(chan struct{})
+	defer close(done)
+	go worker.extendLease(dataExport.ID, done)
+
+	user, error := worker.userStore.GetUserByID(dataExport.UserID)
+	if error != nil {
+		return error
+	}
+
+	archive, error := worker.buildArchive(user, dataExport.WorkspaceID)
+	if error != nil {
+		return error
+	}
+
+	var buffer bytes.Buffer
+	if error := writeArchive(&buffer, archive); error != nil {
+		return error
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(worker.expiration)
+	if error := worker.store.CompleteDataExport(dataExport.ID, buffer.Bytes(), completedAt, expiresAt); error != nil {
+		return error
+	}
+
+	// the archive is ready even if the mail fails, the link is also shown with the export status
+	if error := worker.mailer.SendComposed(exportReadyMail, exportReadyMailReference{ExportID: dataExport.ID}); error != nil {
+		log.Printf("failed to mail data export %s: %v", dataExport.ID, error)
+	}
+
+	return nil
+}
+
+// composeReadyMail builds the mail of a completed export with a download link signed as it is sent
+func (worker *Worker) composeReadyMail(ctx context.Context, payload json.RawMessage) (mailServices.Message, error) {
+	var reference exportReadyMailReference
+	if error := json.Unmarshal(payload, &reference); error != nil {
+		return mailServices.Message{}, fmt.Errorf("failed to read export mail: %v", error)
+	}
+
+	dataExport, error := worker.store.GetDataExportByID(reference.ExportID)
+	if error != nil {
+		return mailServices.Message{}, error
+	}
+
+	// the export expired or was deleted before the mail went out
+	if dataExport == nil || dataExport.Status != dataExportModel.StatusCompleted || dataExport.ExpiresAt == nil {
+		return mailServices.Message{}, queueService.ErrMailNotNeeded
+	}
+
+	user, error := worker.userStore.GetUserByID(dataExport.UserID)
+	if error != nil {
+		return mailServices.Message{}, error
+	}
+
+	link, linkExpiresAt, error := downloadURL(dataExport.ID, *dataExport.ExpiresAt)
+	if error != nil {
+		return mailServices.Message{}, fmt.Errorf("failed to create download link: %v", error)
+	}
+
+	return mailServices.Message{
+		To:       user.Email,
+		Subject:  "Your dev-journal data export is ready",
+		TextBody: fmt.Sprintf("Hi %s,\n\nThe export of your dev-journal data you asked for is ready. Download it from the link below until %s. The export is kept until %s, a new link can be taken from your exports in the meantime.\n\n%s\n", user.FirstName, linkExpiresAt.UTC().Format(time.RFC1123), dataExport.ExpiresAt.UTC().Format(time.RFC1123), link),
+	}, nil
+}
+
+// extendLease extends the lease of an export until done is closed
+func (worker *Worker) extendLease(id uuid.UUID, done chan struct{}) {
+	ticker := time.NewTicker(exportLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if error := worker.store.ExtendDataExportLease(id, now.Add(exportLease)); error != nil {
+				log.Printf("failed to extend lease of data export %s: %v", id, error)
+			}
+		}
+	}
+}
+
+// buildArchive gathers the profile of a user and the projects, notes and tasks they own in a workspace
+func (worker *Worker) buildArchive(user *userModel.User, workspaceID uuid.UUID) (*Archive, error) {
+	memberships, error := worker.projectStore.GetProjectsByUserID(user.ID, uuid.NullUUID{UUID: workspaceID, Valid: true})
+	if error != nil {
+		return nil, error
+	}
+
+	// projects shared with the user belong to someone else
+	projects := make([]*projectModel.Project, 0, len(memberships))
+	for _, project := range memberships {
+		if project.UserID == user.ID {
+			projects = append(projects, project)
+		}
+	}
+
+	archive := &Archive{
+		FormatVersion: archiveFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Profile:       user,
+		Projects:      make([]*ArchiveProject, 0, len(projects)),
+	}
+
+	for _, project := range projects {
+		notes, error := worker.noteStore.GetNotesByLinkedProjectID(project.ID)
+		if error != nil {
+			return nil, error
+		}
+
+		tasks, error := worker.taskStore.GetTasksByLinkedProjectID(project.ID)
+		if error != nil {
+			return nil, error
+		}
+
+		archive.Projects = append(archive.Projects, &ArchiveProject{Project: project, Notes: notes, Tasks: tasks})
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,200 @@
+package digestService
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	digestModel "github.com/hwaengfan/dev-journal-backend/internal/models/digest"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+)
+
+// Local hour on Monday from which the weekly digest is sent
+const digestHour = 8
+
+// How long the digests sent are remembered
+const digestRetention = time.Hour * 24 * 7 * 5
+
+type Scheduler struct {
+	store          digestModel.DigestStore
+	workspaceStore workspaceModel.WorkspaceStore
+	projectStore   projectModel.ProjectStore
+	taskStore      taskModel.TaskStore
+	noteStore      noteModel.NoteStore
+	mailer         mailServices.Mailer
+}
+
+func NewScheduler(store digestModel.DigestStore, workspaceStore workspaceModel.WorkspaceStore, projectStore projectModel.ProjectStore, taskStore taskModel.TaskStore, noteStore noteModel.NoteStore, mailer mailServices.Mailer) *Scheduler {
+	return &Scheduler{store: store, workspaceStore: workspaceStore, projectStore: projectStore, taskStore: taskStore, noteStore: noteStore, mailer: mailer}
+}
+
+// SendDueDigests sends the weekly digest to every opted in user who has not received one since Monday morning in their timezone, each digest is sent once even across restarts
+func (scheduler *Scheduler) SendDueDigests(now time.Time) error {
+	recipients, error := scheduler.store.GetWeeklyDigestRecipients()
+	if error != nil {
+		return error
+	}
+
+	for _, recipient := range recipients {
+		location, error := time.LoadLocation(recipient.Timezone)
+		if error != nil {
+			location = time.UTC
+		}
+
+		due := weekStart(now.In(location)).Add(digestHour * time.Hour)
+		if now.Before(due) || (recipient.LastDigestSentAt != nil && !recipient.LastDigestSentAt.Before(due)) {
+			continue
+		}
+
+		if error := scheduler.sendDigest(recipient, now, location); error != nil {
+			log.Printf("failed to send weekly digest to user %s: %v", recipient.UserID, error)
+		}
+	}
+
+	return scheduler.store.DeleteWeeklyDigestsBefore(weekStart(now.Add(-digestRetention)).Format(time.DateOnly))
+}
+
+// sendDigest claims, builds, renders and mails the digest of a user, a digest that could not be sent is released to be tried again
+func (scheduler *Scheduler) sendDigest(recipient *digestModel.DigestRecipient, now time.Time, location *time.Location) error {
+	week := weekStart(now.In(location)).Format(time.DateOnly)
+	claimed, error := scheduler.store.ClaimWeeklyDigest(recipient.UserID, week)
+	if error != nil || !claimed {
+		return error
+	}
+
+	if error := scheduler.mailDigest(recipient, now, location); error != nil {
+		if releaseError := scheduler.store.ReleaseWeeklyDigest(recipient.UserID, week); releaseError != nil {
+			log.Printf("failed to release weekly digest of user %s: %v", recipient.UserID, releaseError)
+		}
+		return error
+	}
+
+	return scheduler.store.MarkDigestSentByUserID(recipient.UserID, now)
+}
+
+// mailDigest builds, renders and mails the digest of a user
+func (scheduler *Scheduler) mailDigest(recipient *digestModel.DigestRecipient, now time.Time, location *time.Location) error {
+	digest, error := scheduler.BuildDigest(recipient.UserID, recipient.FirstName, uuid.NullUUID{}, now, location)
+	if error != nil {
+		return error
+	}
+
+	text, html, error := renderDigest(digest)
+	if error != nil {
+		return error
+	}
+
+	return scheduler.mailer.Send(mailServices.Message{
+		To:       recipient.Email,
+		Subject:  "Your dev-journal week of " + digest.WeekStart,
+		TextBody: text,
+		HTMLBody: html,
+	})
+}
+
+// BuildDigest gathers the overdue project deadlines, open tasks and notes edited in the last week of a user, per workspace
+// the mailed digest covers every workspace of the user and the preview only the active one
+func (scheduler *Scheduler) BuildDigest(userID uuid.UUID, firstName string, workspaceID uuid.NullUUID, now time.Time, location *time.Location) (*digestModel.Digest, error) {
+	local := now.In(location)
+
+	digest := &digestModel.Digest{
+		FirstName:  firstName,
+		WeekStart:  weekStart(local).Format(time.DateOnly),
+		Workspaces: make([]*digestModel.WorkspaceDigest, 0),
+	}
+
+	workspaces, error := scheduler.workspaceStore.GetWorkspacesByUserID(userID)
+	if error != nil {
+		return nil, error
+	}
+
+	for _, workspace := range workspaces {
+		if workspaceID.Valid && workspace.ID != workspaceID.UUID {
+			continue
+		}
+
+		workspaceDigest, error := scheduler.buildWorkspaceDigest(userID, workspace, now, location)
+		if error != nil {
+			return nil, error
+		}
+
+		digest.Workspaces = append(digest.Workspaces, workspaceDigest)
+	}
+
+	return digest, nil
+}
+
+// buildWorkspaceDigest gathers the part of a digest covering the projects of one workspace
+func (scheduler *Scheduler) buildWorkspaceDigest(userID uuid.UUID, workspace *workspaceModel.Workspace, now time.Time, location *time.Location) (*digestModel.WorkspaceDigest, error) {
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	digest := &digestModel.WorkspaceDigest{
+		WorkspaceID:     workspace.ID,
+		WorkspaceName:   workspace.Name,
+		OverdueProjects: make([]*digestModel.OverdueProject, 0),
+		OpenTasks:       make([]*digestModel.ProjectTasks, 0),
+	}
+
+	scope := uuid.NullUUID{UUID: workspace.ID, Valid: true}
+	projects, error := scheduler.projectStore.GetProjectsByUserID(userID, scope)
+	if error != nil {
+		return nil, error
+	}
+
+	tasks, error := scheduler.taskStore.GetTasksByUserID(userID, scope)
+	if error != nil {
+		return nil, error
+	}
+
+	// open tasks per project, soonest due first and undated last
+	openTasks := make(map[uuid.UUID][]*taskModel.Task)
+	for _, task := range tasks {
+		if task.Completed != "True" {
+			openTasks[task.LinkedProjectID] = append(openTasks[task.LinkedProjectID], task)
+		}
+	}
+
+	for _, project := range projects {
+		projectTasks := openTasks[project.ID]
+		if len(projectTasks) == 0 {
+			continue
+		}
+
+		sort.SliceStable(projectTasks, func(i, j int) bool {
+			first, firstError := recurrenceServices.ParseDate(projectTasks[i].DueDate)
+			second, secondError := recurrenceServices.ParseDate(projectTasks[j].DueDate)
+			if firstError != nil || secondError != nil {
+				return firstError == nil
+			}
+			return first.Before(second)
+		})
+
+		digest.OpenTaskCount += len(projectTasks)
+		digest.OpenTasks = append(digest.OpenTasks, &digestModel.ProjectTasks{ProjectTitle: project.Title, Tasks: projectTasks})
+
+		// a project is overdue while it still has open tasks after its deadline
+		if deadline, error := recurrenceServices.ParseDate(&project.Deadline); error == nil && deadline.Before(today) {
+			digest.OverdueProjects = append(digest.OverdueProjects, &digestModel.OverdueProject{Project: project, OpenTasks: len(projectTasks)})
+		}
+	}
+
+	digest.RecentNotes, error = scheduler.noteStore.GetNotesEditedByUserID(userID, scope, now.AddDate(0, 0, -7), now)
+	if error != nil {
+		return nil, error
+	}
+
+	return digest, nil
+}
+
+// weekStart returns midnight of the Monday starting the week of the time, in its location
+func weekStart(value time.Time) time.Time {
+	day := time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, value.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
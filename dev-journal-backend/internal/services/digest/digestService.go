@@ -0,0 +1,134 @@
+package digestService
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	digestModel "github.com/hwaengfan/dev-journal-backend/internal/models/digest"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store     digestModel.DigestStore
+	userStore userModel.UserStore
+	scheduler *Scheduler
+}
+
+func NewHandler(store digestModel.DigestStore, userStore userModel.UserStore, scheduler *Scheduler) *Handler {
+	return &Handler{store: store, userStore: userStore, scheduler: scheduler}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/digests/get-digest-preference", authenticationServices.JWTAuthentication(handler.handleGetDigestPreference, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/digests/update-digest-preference", authenticationServices.JWTAuthentication(handler.handleUpdateDigestPreference, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/digests/get-digest-preview", authenticationServices.JWTAuthentication(handler.handleGetDigestPreview, handler.userStore)).Methods(http.MethodGet)
+}
+
+// Handler function for getting the digest preference of the user
+func (handler *Handler) handleGetDigestPreference(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	preference, error := handler.store.GetDigestPreferenceByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, preference)
+}
+
+// Handler function for opting in or out of the weekly digest
+func (handler *Handler) handleUpdateDigestPreference(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload digestModel.UpdateDigestPreferencePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// users opting in after Monday morning get the digest of the current week on the next run, unless it was sent before
+	if error := handler.store.SetWeeklyDigestByUserID(userID.UUID, payload.WeeklyDigest); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for previewing the digest of the user, format=html renders the HTML version and format=text the plain text one
+func (handler *Handler) handleGetDigestPreview(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	location, error := time.LoadLocation(user.Timezone)
+	if error != nil {
+		location = time.UTC
+	}
+
+	// the preview covers the active workspace only
+	workspaceID := uuid.NullUUID{UUID: authenticationServices.GetWorkspaceIDFromContext(request.Context()), Valid: true}
+	digest, error := handler.scheduler.BuildDigest(user.ID, user.FirstName, workspaceID, time.Now(), location)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	format := request.URL.Query().Get("format")
+	if format != "html" && format != "text" {
+		utils.WriteJSON(writer, http.StatusOK, digest)
+		return
+	}
+
+	text, html, error := renderDigest(digest)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if format == "html" {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte(html))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(text))
+}
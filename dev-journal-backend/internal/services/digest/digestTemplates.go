@@ -0,0 +1,95 @@
+package digestService
+
+import (
+	htmlTemplate "html/template"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	digestModel "github.com/hwaengfan/dev-journal-backend/internal/models/digest"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+)
+
+const digestText = `Hi {{.FirstName}},
+
+here is your dev-journal summary for the week of {{.WeekStart}}.
+{{range .Workspaces}}
+== {{.WorkspaceName}} ==
+
+Overdue project deadlines
+{{range .OverdueProjects}}- {{.Project.Title}}: due {{date .Project.Deadline}}, {{.OpenTasks}} open task(s)
+{{else}}- None, well done
+{{end}}
+Open tasks ({{.OpenTaskCount}})
+{{range .OpenTasks}}{{.ProjectTitle}}
+{{range .Tasks}}  - {{.Description}}{{with .DueDate}} (due {{date .}}){{end}}
+{{end}}{{else}}- None
+{{end}}
+Notes edited in the last week
+{{range .RecentNotes}}- {{.Title}}
+{{else}}- None
+{{end}}{{end}}
+You receive this mail because you opted in to the weekly digest, you can opt out in your preferences.
+`
+
+const digestHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<p>Hi {{.FirstName}},</p>
+<p>here is your dev-journal summary for the week of {{.WeekStart}}.</p>
+{{range .Workspaces}}<h1>{{.WorkspaceName}}</h1>
+<h2>Overdue project deadlines</h2>
+<ul>
+{{range .OverdueProjects}}<li><strong>{{.Project.Title}}</strong>: due {{date .Project.Deadline}}, {{.OpenTasks}} open task(s)</li>
+{{else}}<li>None, well done</li>
+{{end}}</ul>
+<h2>Open tasks ({{.OpenTaskCount}})</h2>
+{{range .OpenTasks}}<h3>{{.ProjectTitle}}</h3>
+<ul>
+{{range .Tasks}}<li>{{.Description}}{{with .DueDate}} (due {{date .}}){{end}}</li>
+{{end}}</ul>
+{{else}}<p>None</p>
+{{end}}<h2>Notes edited in the last week</h2>
+<ul>
+{{range .RecentNotes}}<li>{{.Title}}</li>
+{{else}}<li>None</li>
+{{end}}</ul>
+{{end}}<p style="color: #777;">You receive this mail because you opted in to the weekly digest, you can opt out in your preferences.</p>
+</body>
+</html>
+`
+
+// formatDate formats a date as stored by MySQL, dates are strings on projects and optional on tasks
+func formatDate(date any) string {
+	var value *string
+	switch typed := date.(type) {
+	case string:
+		value = &typed
+	case *string:
+		value = typed
+	}
+
+	parsed, error := recurrenceServices.ParseDate(value)
+	if error != nil {
+		return ""
+	}
+
+	return parsed.Format(time.DateOnly)
+}
+
+var digestTextTemplate = textTemplate.Must(textTemplate.New("digest.txt").Funcs(textTemplate.FuncMap{"date": formatDate}).Parse(digestText))
+
+var digestHTMLTemplate = htmlTemplate.Must(htmlTemplate.New("digest.html").Funcs(htmlTemplate.FuncMap{"date": formatDate}).Parse(digestHTML))
+
+// renderDigest renders the plain text and HTML versions of a digest
+func renderDigest(digest *digestModel.Digest) (string, string, error) {
+	var text, html strings.Builder
+	if error := digestTextTemplate.Execute(&text, digest); error != nil {
+		return "", "", error
+	}
+	if error := digestHTMLTemplate.Execute(&html, digest); error != nil {
+		return "", "", error
+	}
+
+	return text.String(), html.String(), nil
+}
@@ -0,0 +1,353 @@
+package focusSessionService
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	focusSessionModel "github.com/hwaengfan/dev-journal-backend/internal/models/focusSession"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store       focusSessionModel.FocusSessionStore
+	userStore   userModel.UserStore
+	memberStore projectMemberModel.ProjectMemberStore
+	taskStore   taskModel.TaskStore
+	noteStore   noteModel.NoteStore
+}
+
+func NewHandler(store focusSessionModel.FocusSessionStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, taskStore taskModel.TaskStore, noteStore noteModel.NoteStore) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, taskStore: taskStore, noteStore: noteStore}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/focus-sessions/start-focus-session", authenticationServices.JWTAuthentication(handler.handleStartFocusSession, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/focus-sessions/pause-focus-session", authenticationServices.JWTAuthentication(handler.handlePauseFocusSession, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/focus-sessions/resume-focus-session", authenticationServices.JWTAuthentication(handler.handleResumeFocusSession, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/focus-sessions/finish-focus-session", authenticationServices.JWTAuthentication(handler.handleFinishFocusSession, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/focus-sessions/get-active-focus-session", authenticationServices.JWTAuthentication(handler.handleGetActiveFocusSession, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/focus-sessions/get-focus-sessions-by-user-ID", authenticationServices.JWTAuthentication(handler.handleGetFocusSessionsByUserID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/focus-sessions/delete-focus-session-by-ID/{focusSessionID}", authenticationServices.JWTAuthentication(handler.handleDeleteFocusSessionByID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/focus-sessions/get-focus-statistics", authenticationServices.JWTAuthentication(handler.handleGetFocusStatistics, handler.userStore)).Methods(http.MethodGet)
+}
+
+// Handler function for starting a focus session, optionally on a task
+func (handler *Handler) handleStartFocusSession(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload focusSessionModel.StartFocusSessionPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// the session counts towards the project of its task
+	focusSession := focusSessionModel.FocusSession{
+		UserID:                 userID.UUID,
+		WorkspaceID:            workspaceID,
+		PlannedLengthInSeconds: int64(payload.PlannedLengthInMinutes) * 60,
+		StartedAt:              time.Now(),
+	}
+
+	if payload.TaskID != uuid.Nil {
+		task, error := handler.taskStore.GetTaskByID(payload.TaskID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+			return
+		}
+
+		// tasks of projects the user is not a member of are reported as missing
+		_, error = projectAccessServices.Authorize(handler.memberStore, task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor)
+		if projectAccessServices.IsNotFound(error) {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+			return
+		} else if error != nil {
+			projectAccessServices.WriteError(writer, error)
+			return
+		}
+
+		focusSession.TaskID = uuid.NullUUID{UUID: task.ID, Valid: true}
+		focusSession.LinkedProjectID = uuid.NullUUID{UUID: task.LinkedProjectID, Valid: true}
+	}
+
+	// only one session can be active at a time
+	if activeFocusSession, error := handler.store.GetActiveFocusSessionByUserID(userID.UUID); error == nil {
+		utils.WriteJSON(writer, http.StatusConflict, map[string]any{"error": "a focus session is already active", "focusSession": activeFocusSession})
+		return
+	}
+
+	focusSessionID, error := handler.store.CreateFocusSession(focusSession)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"focusSessionID": focusSessionID})
+}
+
+// Handler function for pausing the running focus session, interrupted=true counts the pause as an interruption
+func (handler *Handler) handlePauseFocusSession(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	focusSession, error := handler.store.GetActiveFocusSessionByUserID(userID.UUID)
+	if error != nil || focusSession.Status != focusSessionModel.FocusSessionRunning {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("no focus session is running"))
+		return
+	}
+
+	now := time.Now()
+	focusSession.Status = focusSessionModel.FocusSessionPaused
+	focusSession.PausedAt = &now
+	if request.URL.Query().Get("interrupted") == "true" {
+		focusSession.Interruptions++
+	}
+
+	handler.saveFocusSession(writer, focusSession)
+}
+
+// Handler function for resuming the paused focus session
+func (handler *Handler) handleResumeFocusSession(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	focusSession, error := handler.store.GetActiveFocusSessionByUserID(userID.UUID)
+	if error != nil || focusSession.Status != focusSessionModel.FocusSessionPaused {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("no focus session is paused"))
+		return
+	}
+
+	focusSession.Status = focusSessionModel.FocusSessionRunning
+	focusSession.PausedSeconds += int64(time.Since(*focusSession.PausedAt).Seconds())
+	focusSession.PausedAt = nil
+
+	handler.saveFocusSession(writer, focusSession)
+}
+
+// Handler function for finishing the active focus session with an optional reflection
+func (handler *Handler) handleFinishFocusSession(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload focusSessionModel.FinishFocusSessionPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	if payload.ReflectionNoteID != uuid.Nil && payload.Reflection != "" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("provide either a reflection or a reflection note ID"))
+		return
+	}
+
+	focusSession, error := handler.store.GetActiveFocusSessionByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("no focus session is active"))
+		return
+	}
+
+	// link an existing note, or write the reflection as a new note in the session's project
+	if payload.ReflectionNoteID != uuid.Nil {
+		note, error := handler.noteStore.GetNoteByID(payload.ReflectionNoteID)
+		if error != nil || note.UserID != userID.UUID {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note ID does not exist"))
+			return
+		}
+
+		focusSession.ReflectionNoteID = uuid.NullUUID{UUID: note.ID, Valid: true}
+	} else if payload.Reflection != "" {
+		if !focusSession.LinkedProjectID.Valid {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("a reflection needs a focus session linked to a task"))
+			return
+		}
+
+		noteID, error := handler.noteStore.CreateNote(noteModel.Note{
+			UserID:          userID.UUID,
+			LinkedProjectID: focusSession.LinkedProjectID.UUID,
+			Title:           fmt.Sprintf("Focus session reflection %s", focusSession.StartedAt.Format(time.DateOnly)),
+			Content:         payload.Reflection,
+			Favorited:       "False",
+			Tags:            []string{"focus-session"},
+		})
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		focusSession.ReflectionNoteID = uuid.NullUUID{UUID: noteID, Valid: true}
+	}
+
+	// a session finished while paused ends when it was paused
+	now := time.Now()
+	if focusSession.PausedAt != nil {
+		focusSession.PausedSeconds += int64(now.Sub(*focusSession.PausedAt).Seconds())
+		focusSession.PausedAt = nil
+	}
+	focusSession.Status = focusSessionModel.FocusSessionFinished
+	focusSession.EndedAt = &now
+
+	handler.saveFocusSession(writer, focusSession)
+}
+
+// Handler function for getting the active focus session
+func (handler *Handler) handleGetActiveFocusSession(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	focusSession, error := handler.store.GetActiveFocusSessionByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteJSON(writer, http.StatusOK, nil)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, focusSession)
+}
+
+// Handler function for getting the user's focus sessions, optionally filtered by range and project
+func (handler *Handler) handleGetFocusSessionsByUserID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	filter, _, error := parseFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	focusSessions, error := handler.store.GetFocusSessionsByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, focusSessions)
+}
+
+// Handler function for deleting a focus session by ID
+func (handler *Handler) handleDeleteFocusSessionByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get focusSessionID from URL
+	focusSessionID, error := utils.ParseIDFromURL(request, "focusSessionID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the focus session belongs to the user and the active workspace
+	focusSession, error := handler.store.GetFocusSessionByID(focusSessionID)
+	if error != nil || focusSession.UserID != userID.UUID || focusSession.WorkspaceID != workspaceID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("focus session does not exist"))
+		return
+	}
+
+	if error := handler.store.DeleteFocusSessionByID(focusSessionID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// saveFocusSession stores the new state of a focus session and writes it back
+func (handler *Handler) saveFocusSession(writer http.ResponseWriter, focusSession *focusSessionModel.FocusSession) {
+	if error := handler.store.UpdateFocusSessionStateByID(*focusSession, focusSession.ID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	focusSession, error := handler.store.GetFocusSessionByID(focusSession.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, focusSession)
+}
+
+// parseFilter reads the from, to, projectID and timezone query parameters, sessions are limited to the active workspace
+func parseFilter(request *http.Request) (focusSessionModel.FocusSessionFilter, *time.Location, error) {
+	filter := focusSessionModel.FocusSessionFilter{WorkspaceID: uuid.NullUUID{UUID: authenticationServices.GetWorkspaceIDFromContext(request.Context()), Valid: true}}
+
+	location, error := utils.ParseLocationFromQuery(request)
+	if error != nil {
+		return filter, nil, error
+	}
+
+	filter.From, filter.To, error = utils.ParseTimeRangeFromQuery(request, location)
+	if error != nil {
+		return filter, nil, error
+	}
+
+	if value := request.URL.Query().Get("projectID"); value != "" {
+		projectID, error := uuid.Parse(value)
+		if error != nil {
+			return filter, nil, fmt.Errorf("invalid projectID")
+		}
+		filter.LinkedProjectID = projectID
+	}
+
+	return filter, location, nil
+}
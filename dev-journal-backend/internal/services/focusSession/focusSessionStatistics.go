@@ -0,0 +1,104 @@
+package focusSessionService
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	focusSessionModel "github.com/hwaengfan/dev-journal-backend/internal/models/focusSession"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Handler function for getting daily or weekly focus statistics, in total and per project
+func (handler *Handler) handleGetFocusStatistics(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	period := request.URL.Query().Get("period")
+	if period == "" {
+		period = "day"
+	}
+	if period != "day" && period != "week" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("period must be day or week"))
+		return
+	}
+
+	filter, location, error := parseFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	focusSessions, error := handler.store.GetFocusSessionsByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, computeStatistics(focusSessions, period, location))
+}
+
+// computeStatistics groups the focus sessions by the day or week they started in and by project
+func computeStatistics(focusSessions []*focusSessionModel.FocusSession, period string, location *time.Location) focusSessionModel.FocusSessionStatistics {
+	statistics := focusSessionModel.FocusSessionStatistics{
+		Period:   period,
+		Periods:  make([]*focusSessionModel.PeriodStatistics, 0),
+		Projects: make([]*focusSessionModel.ProjectStatistics, 0),
+	}
+	periods := make(map[string]*focusSessionModel.PeriodStatistics)
+	projects := make(map[uuid.NullUUID]*focusSessionModel.ProjectStatistics)
+
+	for _, focusSession := range focusSessions {
+		periodStart := periodStartOf(focusSession.StartedAt.In(location), period).Format(time.DateOnly)
+		if periods[periodStart] == nil {
+			periods[periodStart] = &focusSessionModel.PeriodStatistics{PeriodStart: periodStart}
+			statistics.Periods = append(statistics.Periods, periods[periodStart])
+		}
+
+		if projects[focusSession.LinkedProjectID] == nil {
+			projects[focusSession.LinkedProjectID] = &focusSessionModel.ProjectStatistics{LinkedProjectID: focusSession.LinkedProjectID}
+			statistics.Projects = append(statistics.Projects, projects[focusSession.LinkedProjectID])
+		}
+
+		addSession(&statistics.Total, focusSession)
+		addSession(&periods[periodStart].FocusStatistics, focusSession)
+		addSession(&projects[focusSession.LinkedProjectID].FocusStatistics, focusSession)
+	}
+
+	sort.Slice(statistics.Periods, func(i, j int) bool {
+		return statistics.Periods[i].PeriodStart < statistics.Periods[j].PeriodStart
+	})
+	sort.Slice(statistics.Projects, func(i, j int) bool {
+		return statistics.Projects[i].ActualLengthInSeconds > statistics.Projects[j].ActualLengthInSeconds
+	})
+
+	return statistics
+}
+
+// addSession adds a focus session to the statistics
+func addSession(statistics *focusSessionModel.FocusStatistics, focusSession *focusSessionModel.FocusSession) {
+	statistics.Sessions++
+	if focusSession.ActualLengthInSeconds >= focusSession.PlannedLengthInSeconds {
+		statistics.CompletedSessions++
+	}
+	statistics.PlannedLengthInSeconds += focusSession.PlannedLengthInSeconds
+	statistics.ActualLengthInSeconds += focusSession.ActualLengthInSeconds
+	statistics.Interruptions += focusSession.Interruptions
+}
+
+// periodStartOf returns the start of the day, or of the week starting on Monday, containing the time
+func periodStartOf(value time.Time, period string) time.Time {
+	day := time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, value.Location())
+	if period == "week" {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+
+	return day
+}
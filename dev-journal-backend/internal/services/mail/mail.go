@@ -0,0 +1,141 @@
+package mailServices
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+)
+
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string // optional, sent as an alternative to the text body
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailer returns the mailer selected by the MAILER environment variable
+func NewMailer(mailConfigs configs.MailConfigs) (Mailer, error) {
+	switch mailConfigs.Mailer {
+	case "smtp":
+		return NewSMTPMailer(mailConfigs.SMTPHost, mailConfigs.SMTPPort, mailConfigs.SMTPUsername, mailConfigs.SMTPPassword, mailConfigs.From), nil
+	case "log", "":
+		return NewLogMailer(mailConfigs.Directory, mailConfigs.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", mailConfigs.Mailer)
+	}
+}
+
+type SMTPMailer struct {
+	address  string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	return &SMTPMailer{address: host + ":" + port, host: host, username: username, password: password, from: from}
+}
+
+// Send delivers a message through the SMTP server, authenticating when a username is configured
+func (mailer *SMTPMailer) Send(message Message) error {
+	content, error := buildMessage(mailer.from, message)
+	if error != nil {
+		return error
+	}
+
+	var authentication smtp.Auth
+	if mailer.username != "" {
+		authentication = smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)
+	}
+
+	if error := smtp.SendMail(mailer.address, authentication, mailer.from, []string{message.To}, content); error != nil {
+		return fmt.Errorf("failed to send mail: %v", error)
+	}
+
+	return nil
+}
+
+type LogMailer struct {
+	directory string
+	from      string
+}
+
+func NewLogMailer(directory string, from string) *LogMailer {
+	return &LogMailer{directory: directory, from: from}
+}
+
+// Send writes a message to a .eml file in the mail directory, or to the log when no directory is configured
+func (mailer *LogMailer) Send(message Message) error {
+	content, error := buildMessage(mailer.from, message)
+	if error != nil {
+		return error
+	}
+
+	if mailer.directory == "" {
+		log.Printf("mail to %s:\n%s", message.To, content)
+		return nil
+	}
+
+	if error := os.MkdirAll(mailer.directory, 0o755); error != nil {
+		return fmt.Errorf("failed to create mail directory: %v", error)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.New())
+	if error := os.WriteFile(filepath.Join(mailer.directory, name), content, 0o644); error != nil {
+		return fmt.Errorf("failed to write mail: %v", error)
+	}
+
+	return nil
+}
+
+// buildMessage formats a message as MIME, with a multipart/alternative body when it has an HTML part
+func buildMessage(from string, message Message) ([]byte, error) {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid mail header")
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n", from, message.To, message.Subject, time.Now().Format(time.RFC1123Z))
+
+	if message.HTMLBody == "" {
+		fmt.Fprintf(&buffer, "Content-Type: text/plain; charset=utf-8\r\n\r\n%s", message.TextBody)
+		return buffer.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.TextBody},
+		{"text/html; charset=utf-8", message.HTMLBody},
+	}
+	for _, part := range parts {
+		partWriter, error := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if error != nil {
+			return nil, fmt.Errorf("failed to build mail: %v", error)
+		}
+		partWriter.Write([]byte(part.content))
+	}
+	if error := writer.Close(); error != nil {
+		return nil, fmt.Errorf("failed to build mail: %v", error)
+	}
+
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	buffer.Write(body.Bytes())
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package markdownServices
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+	markdownModel "github.com/hwaengfan/dev-journal-backend/internal/models/markdown"
+)
+
+// Renderer renders Markdown and keeps the latest rendering of each key, so a note is only rendered again once its content changes
+type Renderer struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[uuid.UUID]*list.Element
+	order    *list.List // least recently used at the back
+}
+
+type cacheEntry struct {
+	key         uuid.UUID
+	contentHash [sha256.Size]byte
+	rendered    *markdownModel.RenderedMarkdown
+}
+
+func NewRenderer(capacity int) *Renderer {
+	return &Renderer{capacity: capacity, entries: make(map[uuid.UUID]*list.Element), order: list.New()}
+}
+
+// Render renders the content of a key, the result is shared between callers and must not be changed
+func (renderer *Renderer) Render(key uuid.UUID, source string) *markdownModel.RenderedMarkdown {
+	contentHash := sha256.Sum256([]byte(source))
+
+	renderer.mutex.Lock()
+	if element, exists := renderer.entries[key]; exists {
+		entry := element.Value.(*cacheEntry)
+		if entry.contentHash == contentHash {
+			renderer.order.MoveToFront(element)
+			renderer.mutex.Unlock()
+			return entry.rendered
+		}
+	}
+	renderer.mutex.Unlock()
+
+	// render outside of the lock, two requests for the same new version both render it and the last one is kept
+	rendered := Render(source)
+
+	renderer.mutex.Lock()
+	defer renderer.mutex.Unlock()
+
+	if element, exists := renderer.entries[key]; exists {
+		element.Value = &cacheEntry{key: key, contentHash: contentHash, rendered: rendered}
+		renderer.order.MoveToFront(element)
+		return rendered
+	}
+
+	renderer.entries[key] = renderer.order.PushFront(&cacheEntry{key: key, contentHash: contentHash, rendered: rendered})
+	for renderer.order.Len() > renderer.capacity {
+		oldest := renderer.order.Back()
+		renderer.order.Remove(oldest)
+		delete(renderer.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	return rendered
+}
+
+// Render renders GitHub flavored Markdown to HTML without caching, along with the table of contents of its headings
+func Render(source string) *markdownModel.RenderedMarkdown {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\x00", "�")
+
+	lines := strings.Split(source, "\n")
+	for index, line := range lines {
+		lines[index] = expandTabs(line)
+	}
+
+	document := &document{anchors: make(map[string]int), headings: make([]*markdownModel.Heading, 0)}
+	document.renderBlocks(lines, false)
+
+	return &markdownModel.RenderedMarkdown{
+		HTML:            document.output.String(),
+		TableOfContents: document.headings,
+	}
+}
+
+// A document collects the HTML of one rendering along with its headings
+type document struct {
+	output   bytes.Buffer
+	headings []*markdownModel.Heading
+	anchors  map[string]int // times each anchor was used, repeated headings get a numbered suffix
+}
+
+// expandTabs replaces tabs with spaces up to the next multiple of four columns
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var expanded strings.Builder
+	column := 0
+	for _, character := range line {
+		if character == '\t' {
+			spaces := 4 - column%4
+			expanded.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+
+		expanded.WriteRune(character)
+		column++
+	}
+
+	return expanded.String()
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// plainText turns rendered inline HTML back into text, every < in rendered output starts a tag because text is escaped
+func plainText(renderedHTML string) string {
+	return html.UnescapeString(tagPattern.ReplaceAllString(renderedHTML, ""))
+}
+
+// anchorFor creates a unique anchor for a heading the way GitHub does, lowercase words joined by hyphens
+func (document *document) anchorFor(text string) string {
+	var anchor strings.Builder
+	for _, character := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(character) || unicode.IsDigit(character) || character == '-' || character == '_':
+			anchor.WriteRune(character)
+		case character == ' ':
+			anchor.WriteRune('-')
+		}
+	}
+
+	base := anchor.String()
+	if base == "" {
+		base = "section"
+	}
+
+	count := document.anchors[base]
+	document.anchors[base] = count + 1
+	if count == 0 {
+		return base
+	}
+
+	return base + "-" + strconv.Itoa(count)
+}
+
+// safeURL reports whether a link target is relative or uses a scheme that cannot run code, images are limited to the web
+func safeURL(target string, image bool) bool {
+	schemeEnd := strings.IndexAny(target, ":/?#")
+	if schemeEnd <= 0 || target[schemeEnd] != ':' {
+		return true
+	}
+
+	switch strings.ToLower(target[:schemeEnd]) {
+	case "http", "https":
+		return true
+	case "mailto":
+		return !image
+	default:
+		return false
+	}
+}
@@ -0,0 +1,525 @@
+package markdownServices
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	markdownModel "github.com/hwaengfan/dev-journal-backend/internal/models/markdown"
+)
+
+var (
+	atxHeadingPattern      = regexp.MustCompile(`^(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	setextUnderlinePattern = regexp.MustCompile(`^(=+|-+)[ ]*$`)
+	thematicBreakPattern   = regexp.MustCompile(`^(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	listMarkerPattern      = regexp.MustCompile(`^([-*+]|[0-9]{1,9}[.)])( +|$)`)
+	tableDelimiterPattern  = regexp.MustCompile(`^:?-+:?$`)
+	taskMarkerPattern      = regexp.MustCompile(`^\[([ xX])\](?: |$)`)
+)
+
+// renderBlocks renders lines as blocks, paragraphs of tight list items are written without <p>
+func (document *document) renderBlocks(lines []string, tight bool) {
+	for index := 0; index < len(lines); {
+		line := lines[index]
+		if isBlank(line) {
+			index++
+			continue
+		}
+
+		indent := indentation(line)
+		if indent >= 4 {
+			index = document.renderIndentedCode(lines, index)
+			continue
+		}
+
+		trimmed := line[indent:]
+		switch {
+		case fenceOf(trimmed) != "":
+			index = document.renderFencedCode(lines, index)
+		case atxHeadingPattern.MatchString(trimmed):
+			match := atxHeadingPattern.FindStringSubmatch(trimmed)
+			document.renderHeading(len(match[1]), match[2])
+			index++
+		case thematicBreakPattern.MatchString(trimmed):
+			document.output.WriteString("<hr>\n")
+			index++
+		case strings.HasPrefix(trimmed, ">"):
+			index = document.renderBlockquote(lines, index)
+		case listMarkerPattern.MatchString(trimmed):
+			index = document.renderList(lines, index)
+		case isTableStart(lines, index):
+			index = document.renderTable(lines, index)
+		default:
+			index = document.renderParagraph(lines, index, tight)
+		}
+	}
+}
+
+// renderHeading writes a heading with an anchor and adds it to the table of contents
+func (document *document) renderHeading(level int, text string) {
+	content := renderInline(strings.TrimSpace(text))
+	plain := strings.TrimSpace(plainText(content))
+	anchor := document.anchorFor(plain)
+
+	document.headings = append(document.headings, &markdownModel.Heading{Level: level, Text: plain, Anchor: anchor})
+	fmt.Fprintf(&document.output, "<h%d id=\"%s\"><a class=\"anchor\" href=\"#%s\" aria-hidden=\"true\"></a>%s</h%d>\n", level, html.EscapeString(anchor), html.EscapeString(anchor), content, level)
+}
+
+// renderParagraph writes lines up to the next blank line or block as a paragraph, underlined ones become headings
+func (document *document) renderParagraph(lines []string, index int, tight bool) int {
+	paragraph := []string{strings.TrimLeft(lines[index], " ")}
+	index++
+
+	for ; index < len(lines); index++ {
+		line := lines[index]
+		if isBlank(line) {
+			break
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		if indentation(line) < 4 && setextUnderlinePattern.MatchString(trimmed) {
+			level := 1
+			if trimmed[0] == '-' {
+				level = 2
+			}
+			document.renderHeading(level, strings.Join(paragraph, " "))
+			return index + 1
+		}
+
+		if interruptsParagraph(lines, index) {
+			break
+		}
+
+		paragraph = append(paragraph, trimmed)
+	}
+
+	// two trailing spaces are a hard line break, like a trailing backslash
+	for lineIndex := range paragraph[:len(paragraph)-1] {
+		line := paragraph[lineIndex]
+		withoutSpaces := strings.TrimRight(line, " ")
+		if len(line)-len(withoutSpaces) >= 2 {
+			withoutSpaces += "\\"
+		}
+		paragraph[lineIndex] = withoutSpaces
+	}
+
+	content := renderInline(strings.TrimRight(strings.Join(paragraph, "\n"), " "))
+	if tight {
+		document.output.WriteString(content + "\n")
+	} else {
+		document.output.WriteString("<p>" + content + "</p>\n")
+	}
+
+	return index
+}
+
+// interruptsParagraph reports whether a line starts a block that ends the paragraph before it
+func interruptsParagraph(lines []string, index int) bool {
+	line := lines[index]
+	indent := indentation(line)
+	if indent >= 4 {
+		return false
+	}
+
+	trimmed := line[indent:]
+	if fenceOf(trimmed) != "" || atxHeadingPattern.MatchString(trimmed) || thematicBreakPattern.MatchString(trimmed) || strings.HasPrefix(trimmed, ">") || isTableStart(lines, index) {
+		return true
+	}
+
+	// only lists starting with a bullet or 1 interrupt, so numbers starting a line of text stay text
+	match := listMarkerPattern.FindStringSubmatch(trimmed)
+	if match == nil || strings.TrimSpace(trimmed[len(match[0]):]) == "" {
+		return false
+	}
+
+	marker := match[1]
+	return !isOrderedMarker(marker) || strings.TrimRight(marker, ".)") == "1"
+}
+
+// fenceOf returns the opening fence of a fenced code block, empty when the line does not open one
+func fenceOf(trimmed string) string {
+	if len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return ""
+	}
+
+	length := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if length < 3 {
+		return ""
+	}
+
+	// info strings of backtick fences cannot contain backticks, otherwise the line is inline code
+	if trimmed[0] == '`' && strings.Contains(trimmed[length:], "`") {
+		return ""
+	}
+
+	return trimmed[:length]
+}
+
+// renderFencedCode writes a fenced code block, highlighted when its language is known
+func (document *document) renderFencedCode(lines []string, index int) int {
+	indent := indentation(lines[index])
+	trimmed := lines[index][indent:]
+	fence := fenceOf(trimmed)
+	language := strings.ToLower(firstWord(trimmed[len(fence):]))
+
+	code := make([]string, 0)
+	for index++; index < len(lines); index++ {
+		line := lines[index]
+		closing := strings.TrimSpace(line)
+		if indentation(line) < 4 && strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+			index++
+			break
+		}
+
+		// content is indented relative to the fence
+		code = append(code, line[min(indent, indentation(line)):])
+	}
+
+	content := strings.Join(code, "\n")
+	if len(code) > 0 {
+		content += "\n"
+	}
+
+	if language == "" || !languageNamePattern.MatchString(language) {
+		document.output.WriteString("<pre><code>" + html.EscapeString(content) + "</code></pre>\n")
+		return index
+	}
+
+	fmt.Fprintf(&document.output, "<pre><code class=\"language-%s\">%s</code></pre>\n", html.EscapeString(language), highlight(content, language))
+	return index
+}
+
+// renderIndentedCode writes lines indented by four spaces as a code block
+func (document *document) renderIndentedCode(lines []string, index int) int {
+	code := make([]string, 0)
+	for ; index < len(lines); index++ {
+		line := lines[index]
+		if !isBlank(line) && indentation(line) < 4 {
+			break
+		}
+
+		if isBlank(line) {
+			code = append(code, "")
+		} else {
+			code = append(code, line[4:])
+		}
+	}
+
+	// trailing blank lines separate the block from what follows
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+
+	document.output.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")+"\n") + "</code></pre>\n")
+	return index
+}
+
+// renderBlockquote writes lines starting with > as a quote, lines continuing its last paragraph may leave out the >
+func (document *document) renderBlockquote(lines []string, index int) int {
+	quoted := make([]string, 0)
+	for ; index < len(lines); index++ {
+		line := lines[index]
+		trimmed := strings.TrimLeft(line, " ")
+		if indentation(line) < 4 && strings.HasPrefix(trimmed, ">") {
+			trimmed = strings.TrimPrefix(trimmed[1:], " ")
+			quoted = append(quoted, trimmed)
+			continue
+		}
+
+		lastLine := quoted[len(quoted)-1]
+		if isBlank(line) || isBlank(lastLine) || interruptsParagraph(lines, index) || fenceOf(strings.TrimLeft(lastLine, " ")) != "" {
+			break
+		}
+
+		quoted = append(quoted, line)
+	}
+
+	document.output.WriteString("<blockquote>\n")
+	document.renderBlocks(quoted, false)
+	document.output.WriteString("</blockquote>\n")
+	return index
+}
+
+type listItem struct {
+	lines   []string
+	checked *bool // set on task list items
+}
+
+// renderList writes a bulleted or numbered list, items are indented by the width of their marker
+func (document *document) renderList(lines []string, index int) int {
+	firstMarker := listMarkerPattern.FindStringSubmatch(lines[index][indentation(lines[index]):])[1]
+	ordered := isOrderedMarker(firstMarker)
+
+	items := make([]*listItem, 0)
+	loose := false
+	for index < len(lines) {
+		line := lines[index]
+		indent := indentation(line)
+		match := listMarkerPattern.FindStringSubmatch(line[indent:])
+		if indent >= 4 || match == nil || !sameListType(firstMarker, match[1]) {
+			break
+		}
+
+		// content starts after the marker and its spaces, unless the item starts with indented code
+		spaces := len(match[2])
+		if spaces > 4 || strings.TrimSpace(line[indent+len(match[0]):]) == "" {
+			spaces = 1
+		}
+		contentColumn := indent + len(match[1]) + spaces
+
+		item := &listItem{lines: []string{strings.TrimPrefix(line[min(len(line), indent+len(match[1])):], strings.Repeat(" ", spaces))}}
+		for index++; index < len(lines); index++ {
+			line := lines[index]
+			if isBlank(line) {
+				next := nextNonBlank(lines, index)
+				if next < len(lines) && indentation(lines[next]) >= contentColumn {
+					item.lines = append(item.lines, "")
+					continue
+				}
+				break
+			}
+
+			if indentation(line) >= contentColumn {
+				item.lines = append(item.lines, line[contentColumn:])
+				continue
+			}
+
+			// lines continuing the paragraph of the item may leave out the indentation
+			lastLine := item.lines[len(item.lines)-1]
+			if isBlank(lastLine) || listMarkerPattern.MatchString(strings.TrimLeft(line, " ")) || interruptsParagraph(lines, index) {
+				break
+			}
+			item.lines = append(item.lines, strings.TrimLeft(line, " "))
+		}
+
+		if hasBlankBetweenBlocks(item.lines) {
+			loose = true
+		}
+
+		if match := taskMarkerPattern.FindStringSubmatch(item.lines[0]); match != nil {
+			checked := match[1] != " "
+			item.checked = &checked
+			item.lines[0] = item.lines[0][len(match[0]):]
+		}
+
+		items = append(items, item)
+
+		// a blank line between items makes the list loose
+		if index < len(lines) && isBlank(lines[index]) {
+			next := nextNonBlank(lines, index)
+			if next >= len(lines) || indentation(lines[next]) >= 4 {
+				break
+			}
+			nextMatch := listMarkerPattern.FindStringSubmatch(lines[next][indentation(lines[next]):])
+			if nextMatch == nil || !sameListType(firstMarker, nextMatch[1]) {
+				break
+			}
+
+			loose = true
+			index = next
+		}
+	}
+
+	tag, attributes := "ul", ""
+	if ordered {
+		tag = "ol"
+		if start, _ := strconv.Atoi(strings.TrimRight(firstMarker, ".)")); start != 1 {
+			attributes = fmt.Sprintf(" start=\"%d\"", start)
+		}
+	}
+	for _, item := range items {
+		if item.checked != nil {
+			attributes += " class=\"contains-task-list\""
+			break
+		}
+	}
+
+	fmt.Fprintf(&document.output, "<%s%s>\n", tag, attributes)
+	for _, item := range items {
+		if item.checked == nil {
+			document.output.WriteString("<li>")
+		} else if *item.checked {
+			document.output.WriteString("<li class=\"task-list-item\"><input type=\"checkbox\" disabled checked> ")
+		} else {
+			document.output.WriteString("<li class=\"task-list-item\"><input type=\"checkbox\" disabled> ")
+		}
+
+		// tight items keep their text inline, blocks after it go on their own lines
+		if !loose {
+			document.renderBlocks(item.lines, true)
+			if document.output.Len() > 0 && document.output.Bytes()[document.output.Len()-1] == '\n' {
+				document.output.Truncate(document.output.Len() - 1)
+			}
+		} else {
+			document.output.WriteString("\n")
+			document.renderBlocks(item.lines, false)
+		}
+
+		document.output.WriteString("</li>\n")
+	}
+	fmt.Fprintf(&document.output, "</%s>\n", tag)
+
+	return index
+}
+
+// isTableStart reports whether a line is the header row of a table, followed by a delimiter row with as many cells
+func isTableStart(lines []string, index int) bool {
+	if index+1 >= len(lines) || indentation(lines[index]) >= 4 || !strings.Contains(lines[index], "|") || !strings.Contains(lines[index+1], "|") {
+		return false
+	}
+
+	header := splitTableRow(lines[index])
+	delimiters := splitTableRow(lines[index+1])
+	if len(header) != len(delimiters) {
+		return false
+	}
+
+	for _, delimiter := range delimiters {
+		if !tableDelimiterPattern.MatchString(delimiter) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// renderTable writes a table, its rows end at a blank line or another block
+func (document *document) renderTable(lines []string, index int) int {
+	header := splitTableRow(lines[index])
+	alignments := make([]string, len(header))
+	for column, delimiter := range splitTableRow(lines[index+1]) {
+		switch {
+		case strings.HasPrefix(delimiter, ":") && strings.HasSuffix(delimiter, ":"):
+			alignments[column] = " align=\"center\""
+		case strings.HasPrefix(delimiter, ":"):
+			alignments[column] = " align=\"left\""
+		case strings.HasSuffix(delimiter, ":"):
+			alignments[column] = " align=\"right\""
+		}
+	}
+
+	document.output.WriteString("<table>\n<thead>\n")
+	document.renderTableRow(header, alignments, "th")
+	document.output.WriteString("</thead>\n")
+
+	index += 2
+	if index < len(lines) && !isBlank(lines[index]) && !interruptsParagraph(lines, index) {
+		document.output.WriteString("<tbody>\n")
+		for ; index < len(lines) && !isBlank(lines[index]) && !interruptsParagraph(lines, index); index++ {
+			document.renderTableRow(splitTableRow(lines[index]), alignments, "td")
+		}
+		document.output.WriteString("</tbody>\n")
+	}
+
+	document.output.WriteString("</table>\n")
+	return index
+}
+
+// renderTableRow writes a row with as many cells as the header, missing cells are left empty and extra ones dropped
+func (document *document) renderTableRow(cells []string, alignments []string, tag string) {
+	document.output.WriteString("<tr>\n")
+	for column, alignment := range alignments {
+		content := ""
+		if column < len(cells) {
+			content = renderInline(cells[column])
+		}
+		fmt.Fprintf(&document.output, "<%s%s>%s</%s>\n", tag, alignment, content, tag)
+	}
+	document.output.WriteString("</tr>\n")
+}
+
+// splitTableRow splits a row into its trimmed cells, escaped pipes stay in the cell
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	cells := make([]string, 0)
+	var cell strings.Builder
+	for index := 0; index < len(line); index++ {
+		switch {
+		case line[index] == '\\' && index+1 < len(line) && line[index+1] == '|':
+			cell.WriteByte('|')
+			index++
+		case line[index] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[index])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// hasBlankBetweenBlocks reports whether a blank line separates blocks of a list item, which makes its list loose
+// Blank lines in fenced code or between the items of a nested list do not count
+func hasBlankBetweenBlocks(lines []string) bool {
+	fence := ""
+	for index, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(strings.TrimSpace(trimmed), fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if opening := fenceOf(trimmed); opening != "" {
+			fence = opening
+			continue
+		}
+
+		if index > 0 && isBlank(lines[index-1]) && !isBlank(line) && indentation(line) == 0 && !listMarkerPattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBlank reports whether a line only holds spaces
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentation returns the number of spaces a line starts with
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// nextNonBlank returns the index of the next line that is not blank, len(lines) when there is none
+func nextNonBlank(lines []string, index int) int {
+	for index < len(lines) && isBlank(lines[index]) {
+		index++
+	}
+
+	return index
+}
+
+// firstWord returns the first word of a text, empty when it has none
+func firstWord(text string) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return ""
+	}
+
+	return words[0]
+}
+
+// isOrderedMarker reports whether a list marker is a number
+func isOrderedMarker(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// sameListType reports whether two markers belong to the same list, changing the bullet or delimiter starts a new list
+func sameListType(first string, other string) bool {
+	if isOrderedMarker(first) != isOrderedMarker(other) {
+		return false
+	}
+
+	return first[len(first)-1] == other[len(other)-1]
+}
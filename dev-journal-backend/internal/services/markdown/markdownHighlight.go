@@ -0,0 +1,247 @@
+package markdownServices
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Languages of fenced code blocks may only use these characters, the name ends up in a class attribute
+var languageNamePattern = regexp.MustCompile(`^[a-z0-9_+#.-]{1,32}$`)
+
+// A language describes enough of a syntax to mark keywords, strings, numbers and comments
+type language struct {
+	keywords           map[string]bool
+	caseInsensitive    bool
+	lineComments       []string
+	blockComment       [2]string
+	quotes             string // characters that start a string
+	multilineQuotes    string // quotes whose strings can span lines
+	tripleQuoteStrings bool
+}
+
+func keywordSet(keywords string) map[string]bool {
+	set := make(map[string]bool)
+	for _, keyword := range strings.Fields(keywords) {
+		set[keyword] = true
+	}
+
+	return set
+}
+
+var cStyleComment = [2]string{"/*", "*/"}
+
+var languages = map[string]*language{
+	"go": {
+		keywords:        keywordSet("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var true false nil iota"),
+		lineComments:    []string{"//"},
+		blockComment:    cStyleComment,
+		quotes:          "\"'`",
+		multilineQuotes: "`",
+	},
+	"javascript": {
+		keywords:        keywordSet("async await break case catch class const continue debugger default delete do else export extends false finally for from function if import in instanceof let new null of return static super switch this throw true try typeof undefined var void while yield"),
+		lineComments:    []string{"//"},
+		blockComment:    cStyleComment,
+		quotes:          "\"'`",
+		multilineQuotes: "`",
+	},
+	"typescript": {
+		keywords:        keywordSet("abstract any as async await boolean break case catch class const continue declare default delete do else enum export extends false finally for from function if implements import in instanceof interface let never new null number of private protected public readonly return static string super switch this throw true try type typeof undefined unknown var void while yield"),
+		lineComments:    []string{"//"},
+		blockComment:    cStyleComment,
+		quotes:          "\"'`",
+		multilineQuotes: "`",
+	},
+	"python": {
+		keywords:           keywordSet("and as assert async await break class continue def del elif else except False finally for from global if import in is lambda None nonlocal not or pass raise return True try while with yield"),
+		lineComments:       []string{"#"},
+		quotes:             "\"'",
+		tripleQuoteStrings: true,
+	},
+	"java": {
+		keywords:     keywordSet("abstract boolean break byte case catch char class const continue default do double else enum extends false final finally float for if implements import instanceof int interface long new null package private protected public return short static super switch synchronized this throw throws true try void volatile while var record"),
+		lineComments: []string{"//"},
+		blockComment: cStyleComment,
+		quotes:       "\"'",
+	},
+	"c": {
+		keywords:     keywordSet("auto break case char const continue default do double else enum extern float for goto if inline int long register return short signed sizeof static struct switch typedef union unsigned void volatile while NULL true false"),
+		lineComments: []string{"//"},
+		blockComment: cStyleComment,
+		quotes:       "\"'",
+	},
+	"cpp": {
+		keywords:     keywordSet("auto bool break case catch char class const constexpr continue default delete do double else enum explicit extern false float for friend goto if inline int long namespace new nullptr operator private protected public return short signed sizeof static struct switch template this throw true try typedef typename union unsigned using virtual void volatile while"),
+		lineComments: []string{"//"},
+		blockComment: cStyleComment,
+		quotes:       "\"'",
+	},
+	"rust": {
+		keywords:     keywordSet("as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		lineComments: []string{"//"},
+		blockComment: cStyleComment,
+		quotes:       "\"",
+	},
+	"sql": {
+		keywords:        keywordSet("add all alter and as asc between by case check column constraint create cross database default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not null on or order outer primary references right select set table then union unique update values view when where with"),
+		caseInsensitive: true,
+		lineComments:    []string{"--", "#"},
+		blockComment:    cStyleComment,
+		quotes:          "\"'`",
+	},
+	"bash": {
+		keywords:     keywordSet("case do done elif else esac export fi for function if in local return select then until while"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"json": {
+		keywords: keywordSet("true false null"),
+		quotes:   "\"",
+	},
+	"yaml": {
+		keywords:     keywordSet("true false null yes no"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+}
+
+// Other names clients use for the same languages
+var languageAliases = map[string]string{
+	"golang": "go",
+	"js":     "javascript",
+	"jsx":    "javascript",
+	"ts":     "typescript",
+	"tsx":    "typescript",
+	"py":     "python",
+	"h":      "c",
+	"c++":    "cpp",
+	"hpp":    "cpp",
+	"rs":     "rust",
+	"mysql":  "sql",
+	"sh":     "bash",
+	"shell":  "bash",
+	"zsh":    "bash",
+	"yml":    "yaml",
+}
+
+// highlight escapes code and wraps its tokens in hl-keyword, hl-string, hl-number and hl-comment spans, unknown languages are only escaped
+func highlight(code string, name string) string {
+	if alias, exists := languageAliases[name]; exists {
+		name = alias
+	}
+
+	syntax, exists := languages[name]
+	if !exists {
+		return html.EscapeString(code)
+	}
+
+	var output strings.Builder
+	span := func(class string, token string) {
+		output.WriteString("<span class=\"hl-" + class + "\">" + html.EscapeString(token) + "</span>")
+	}
+
+	for index := 0; index < len(code); {
+		rest := code[index:]
+
+		if end := commentEnd(syntax, rest); end > 0 {
+			span("comment", rest[:end])
+			index += end
+			continue
+		}
+
+		if end := stringEnd(syntax, rest); end > 0 {
+			span("string", rest[:end])
+			index += end
+			continue
+		}
+
+		character := code[index]
+		previousIsWord := index > 0 && isIdentifierCharacter(code[index-1])
+		if character >= '0' && character <= '9' && !previousIsWord {
+			end := 1
+			for end < len(rest) && (isIdentifierCharacter(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			span("number", rest[:end])
+			index += end
+			continue
+		}
+
+		if isIdentifierCharacter(character) && !previousIsWord {
+			end := 1
+			for end < len(rest) && isIdentifierCharacter(rest[end]) {
+				end++
+			}
+
+			word := rest[:end]
+			if syntax.keywords[word] || (syntax.caseInsensitive && syntax.keywords[strings.ToLower(word)]) {
+				span("keyword", word)
+			} else {
+				output.WriteString(html.EscapeString(word))
+			}
+			index += end
+			continue
+		}
+
+		output.WriteString(html.EscapeString(code[index : index+1]))
+		index++
+	}
+
+	return output.String()
+}
+
+// commentEnd returns the length of the comment code starts with, 0 when it does not start with one
+func commentEnd(syntax *language, code string) int {
+	for _, prefix := range syntax.lineComments {
+		if strings.HasPrefix(code, prefix) {
+			if end := strings.IndexByte(code, '\n'); end >= 0 {
+				return end
+			}
+			return len(code)
+		}
+	}
+
+	if syntax.blockComment[0] != "" && strings.HasPrefix(code, syntax.blockComment[0]) {
+		if end := strings.Index(code[len(syntax.blockComment[0]):], syntax.blockComment[1]); end >= 0 {
+			return len(syntax.blockComment[0]) + end + len(syntax.blockComment[1])
+		}
+		return len(code)
+	}
+
+	return 0
+}
+
+// stringEnd returns the length of the string code starts with, unterminated strings end with their line
+func stringEnd(syntax *language, code string) int {
+	if syntax.tripleQuoteStrings && (strings.HasPrefix(code, `"""`) || strings.HasPrefix(code, "'''")) {
+		if end := strings.Index(code[3:], code[:3]); end >= 0 {
+			return end + 6
+		}
+		return len(code)
+	}
+
+	quote := code[0]
+	if strings.IndexByte(syntax.quotes, quote) < 0 {
+		return 0
+	}
+
+	multiline := strings.IndexByte(syntax.multilineQuotes, quote) >= 0
+	for index := 1; index < len(code); index++ {
+		switch {
+		case code[index] == '\\' && quote != '`':
+			index++
+		case code[index] == quote:
+			return index + 1
+		case code[index] == '\n' && !multiline:
+			return index
+		}
+	}
+
+	return len(code)
+}
+
+// isIdentifierCharacter reports whether a character can be part of a keyword or number
+func isIdentifierCharacter(character byte) bool {
+	return character == '_' || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9')
+}
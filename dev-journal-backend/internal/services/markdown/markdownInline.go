@@ -0,0 +1,587 @@
+package markdownServices
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	autolinkPattern      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailAutolinkPattern = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+	bareAutolinkPattern  = regexp.MustCompile(`^(?:[Hh][Tt][Tt][Pp][Ss]?://|[Ww][Ww][Ww]\.)[^\s<]+`)
+)
+
+// Attributes of every rendered link, notes are written by users and links in them must not pass on the page
+const linkAttributes = ` rel="nofollow noopener noreferrer"`
+
+// Images nested deeper than this are text, the text of every level is rendered once more by the level around it
+const maxLinkNesting = 16
+
+// Parentheses a link target can nest, a target nesting deeper is not a link
+const maxDestinationParentheses = 32
+
+// An inlineParser renders the inline Markdown of a text, it remembers what it scanned so no construct rescans the rest of the text
+type inlineParser struct {
+	source    string
+	depth     int           // links and images the text is inside of
+	backticks map[int][]int // starts of the runs of backticks by their length, in order
+	brackets  map[int]int   // the ] closing each [ scanned so far, -1 when it is not closed
+	searches  map[string]inlineSearch
+
+	// end of the text a bare autolink was not a link in, the ones starting inside it are not either
+	bareAutolinkEnd int
+}
+
+// The last search for a set of characters, it also answers searches starting between from and found
+type inlineSearch struct {
+	from  int
+	found int
+}
+
+// renderInline renders the text of a block, escaping everything that is not Markdown
+func renderInline(source string) string {
+	return renderInlineText(source, 0)
+}
+
+// renderInlineText renders inline Markdown nested in depth links or images, links cannot be nested so text inside a link renders without them
+func renderInlineText(source string, depth int) string {
+	parser := &inlineParser{source: source, depth: depth, backticks: make(map[int][]int), brackets: make(map[int]int), searches: make(map[string]inlineSearch)}
+	for index := 0; index < len(source); index++ {
+		if source[index] == '`' {
+			run := runLength(source, index)
+			parser.backticks[run] = append(parser.backticks[run], index)
+			index += run - 1
+		}
+	}
+
+	return parser.render()
+}
+
+// render renders the text of the parser
+func (parser *inlineParser) render() string {
+	source := parser.source
+	inLink := parser.depth > 0
+
+	// HTML is collected until a run of emphasis delimiters, which gets a node of its own to be paired later
+	head := &inlineNode{}
+	last, lastRun := head, head
+	var output strings.Builder
+	flush := func() {
+		if output.Len() > 0 {
+			last.next = &inlineNode{html: output.String(), previous: last}
+			last = last.next
+			output.Reset()
+		}
+	}
+
+	for index := 0; index < len(source); {
+		character := source[index]
+		switch character {
+		case '\\':
+			if index+1 < len(source) && source[index+1] == '\n' {
+				output.WriteString("<br>\n")
+				index += 2
+				continue
+			}
+			if index+1 < len(source) && isASCIIPunctuation(source[index+1]) {
+				output.WriteString(html.EscapeString(source[index+1 : index+2]))
+				index += 2
+				continue
+			}
+
+		case '`':
+			if end, code, ok := parser.codeSpan(index); ok {
+				output.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				index = end
+				continue
+			}
+
+			// an unmatched run of backticks is text, including the backticks after the first
+			run := runLength(source, index)
+			output.WriteString(source[index : index+run])
+			index += run
+			continue
+
+		case '!':
+			if parser.depth < maxLinkNesting && index+1 < len(source) && source[index+1] == '[' {
+				if end, rendered, ok := parser.renderLink(index+1, true); ok {
+					output.WriteString(rendered)
+					index = end
+					continue
+				}
+			}
+
+		case '[':
+			if !inLink {
+				if end, rendered, ok := parser.renderLink(index, false); ok {
+					output.WriteString(rendered)
+					index = end
+					continue
+				}
+			}
+
+		case '<':
+			if !inLink {
+				if end, rendered, ok := renderAutolink(source, index); ok {
+					output.WriteString(rendered)
+					index = end
+					continue
+				}
+			}
+
+		case '*', '_', '~':
+			run := runLength(source, index)
+			flush()
+			last.next = delimiterRun(source, index, run)
+			last.next.previous = last
+			last = last.next
+
+			// runs are also linked to each other, pairing skips the runs inside a pair
+			last.position = lastRun.position + 1
+			lastRun.nextRun = last
+			last.previousRun = lastRun
+			lastRun = last
+			index += run
+			continue
+
+		case 'h', 'H', 'w', 'W':
+			if !inLink && (index == 0 || !isWordCharacter(source[index-1])) {
+				if end, rendered, ok := parser.renderBareAutolink(index); ok {
+					output.WriteString(rendered)
+					index = end
+					continue
+				}
+			}
+		}
+
+		output.WriteString(html.EscapeString(source[index : index+1]))
+		index++
+	}
+	flush()
+
+	processEmphasis(head)
+
+	var rendered strings.Builder
+	for node := head.next; node != nil; node = node.next {
+		rendered.WriteString(node.render())
+	}
+
+	return rendered.String()
+}
+
+// codeSpan returns the end and content of the code span starting at index, closed by the next run of as many backticks
+func (parser *inlineParser) codeSpan(index int) (int, string, bool) {
+	source := parser.source
+	run := runLength(source, index)
+	starts := parser.backticks[run]
+	next := sort.SearchInts(starts, index+run)
+	if next == len(starts) {
+		return 0, "", false
+	}
+
+	closing := starts[next]
+	code := strings.ReplaceAll(source[index+run:closing], "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		code = code[1 : len(code)-1]
+	}
+
+	return closing + run, code, true
+}
+
+// renderLink renders a link or image whose text starts at the [ at index, like [text](target "title")
+func (parser *inlineParser) renderLink(index int, image bool) (int, string, bool) {
+	source := parser.source
+	textEnd := parser.closingBracket(index)
+	if textEnd < 0 || textEnd+1 >= len(source) || source[textEnd+1] != '(' {
+		return 0, "", false
+	}
+
+	target, title, end, ok := parser.linkDestination(textEnd + 2)
+	if !ok {
+		return 0, "", false
+	}
+
+	titleAttribute := ""
+	if title != "" {
+		titleAttribute = fmt.Sprintf(" title=\"%s\"", html.EscapeString(title))
+	}
+
+	text := renderInlineText(source[index+1:textEnd], parser.depth+1)
+	if image {
+		alt := html.EscapeString(plainText(text))
+		if !safeURL(target, true) {
+			return end, alt, true
+		}
+
+		return end, fmt.Sprintf("<img src=\"%s\" alt=\"%s\"%s>", html.EscapeString(target), alt, titleAttribute), true
+	}
+
+	// links to unsafe targets keep their text
+	if !safeURL(target, false) {
+		return end, text, true
+	}
+
+	return end, fmt.Sprintf("<a href=\"%s\"%s%s>%s</a>", html.EscapeString(target), titleAttribute, linkAttributes, text), true
+}
+
+// closingBracket returns the index of the ] closing the [ at index, -1 when it is not closed
+func (parser *inlineParser) closingBracket(index int) int {
+	if end, scanned := parser.brackets[index]; scanned {
+		return end
+	}
+
+	// the scan passes the [ inside the bracket too and remembers where they close
+	source := parser.source
+	open := make([]int, 0)
+	for position := index; position < len(source); position++ {
+		switch source[position] {
+		case '\\':
+			position++
+		case '`':
+			if end, _, ok := parser.codeSpan(position); ok {
+				position = end - 1
+			} else {
+				position += runLength(source, position) - 1
+			}
+		case '[':
+			open = append(open, position)
+		case ']':
+			parser.brackets[open[len(open)-1]] = position
+			open = open[:len(open)-1]
+			if len(open) == 0 {
+				return position
+			}
+		}
+	}
+
+	for _, position := range open {
+		parser.brackets[position] = -1
+	}
+
+	return -1
+}
+
+// indexAny returns the index of the first of characters at or after from, -1 when there is none
+func (parser *inlineParser) indexAny(characters string, from int) int {
+	// searches move forward through the text, one that started earlier knows there is none before what it found
+	if search, searched := parser.searches[characters]; searched && from >= search.from && (search.found < 0 || from <= search.found) {
+		return search.found
+	}
+
+	found := strings.IndexAny(parser.source[from:], characters)
+	if found >= 0 {
+		found += from
+	}
+	parser.searches[characters] = inlineSearch{from: from, found: found}
+
+	return found
+}
+
+// linkDestination parses the target and optional title of a link starting after its (, up to and including the )
+func (parser *inlineParser) linkDestination(index int) (string, string, int, bool) {
+	source := parser.source
+	index = skipSpaces(source, index)
+
+	var target strings.Builder
+	if index < len(source) && source[index] == '<' {
+		end := parser.indexAny(">\n", index+1)
+		if end < 0 || source[end] != '>' {
+			return "", "", 0, false
+		}
+
+		target.WriteString(source[index+1 : end])
+		index = end + 1
+	} else {
+		depth := 0
+		for ; index < len(source); index++ {
+			character := source[index]
+			if character == ' ' || character == '\n' || character < 0x20 {
+				break
+			}
+			if character == '(' {
+				depth++
+				if depth > maxDestinationParentheses {
+					return "", "", 0, false
+				}
+			}
+			if character == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+			if character == '\\' && index+1 < len(source) && isASCIIPunctuation(source[index+1]) {
+				index++
+				character = source[index]
+			}
+
+			target.WriteByte(character)
+		}
+	}
+
+	title := ""
+	if titleStart := skipSpaces(source, index); titleStart > index && titleStart < len(source) && strings.ContainsRune(`"'(`, rune(source[titleStart])) {
+		closing := source[titleStart]
+		if closing == '(' {
+			closing = ')'
+		}
+
+		titleEnd := parser.indexAny(string(closing), titleStart+1)
+		if titleEnd < 0 {
+			return "", "", 0, false
+		}
+
+		title = source[titleStart+1 : titleEnd]
+		index = titleEnd + 1
+	}
+
+	index = skipSpaces(source, index)
+	if index >= len(source) || source[index] != ')' {
+		return "", "", 0, false
+	}
+
+	return target.String(), title, index + 1, true
+}
+
+// renderAutolink renders a link written as <https://example.com> or <someone@example.com>
+func renderAutolink(source string, index int) (int, string, bool) {
+	if match := autolinkPattern.FindStringSubmatch(source[index:]); match != nil && safeURL(match[1], false) {
+		return index + len(match[0]), fmt.Sprintf("<a href=\"%s\"%s>%s</a>", html.EscapeString(match[1]), linkAttributes, html.EscapeString(match[1])), true
+	}
+
+	if match := emailAutolinkPattern.FindStringSubmatch(source[index:]); match != nil {
+		return index + len(match[0]), fmt.Sprintf("<a href=\"mailto:%s\"%s>%s</a>", html.EscapeString(match[1]), linkAttributes, html.EscapeString(match[1])), true
+	}
+
+	return 0, "", false
+}
+
+// renderBareAutolink renders a URL written without brackets, punctuation ending a sentence is not part of it
+func (parser *inlineParser) renderBareAutolink(index int) (int, string, bool) {
+	if index < parser.bareAutolinkEnd {
+		return 0, "", false
+	}
+
+	link := bareAutolinkPattern.FindString(parser.source[index:])
+	if link == "" {
+		return 0, "", false
+	}
+	end := index + len(link)
+
+	opening, closing := strings.Count(link, "("), strings.Count(link, ")")
+	for len(link) > 0 {
+		last := link[len(link)-1]
+		if strings.IndexByte(`?!.,:*_~'"`, last) >= 0 || (last == ')' && closing > opening) {
+			if last == ')' {
+				closing--
+			}
+			link = link[:len(link)-1]
+			continue
+		}
+		break
+	}
+
+	// a link needs more than its prefix
+	if !strings.Contains(strings.TrimPrefix(strings.ToLower(link), "www."), ".") && !strings.Contains(link, "://") || strings.HasSuffix(link, "://") {
+		parser.bareAutolinkEnd = end
+		return 0, "", false
+	}
+
+	target := link
+	if strings.HasPrefix(strings.ToLower(link), "www.") {
+		target = "http://" + link
+	}
+
+	return index + len(link), fmt.Sprintf("<a href=\"%s\"%s>%s</a>", html.EscapeString(target), linkAttributes, html.EscapeString(link)), true
+}
+
+// A node of inline content, either rendered HTML or a run of emphasis delimiters waiting for its partner
+type inlineNode struct {
+	html          string
+	delimiter     byte // *, _ or ~ for delimiter runs
+	count         int  // delimiters of the run not used yet
+	originalCount int
+	canOpen       bool
+	canClose      bool
+	position      int      // order of the run among the runs of the text
+	opened        []string // tags opened after the delimiters left, innermost first
+	closed        []string // tags closed before the delimiters left, innermost first
+	previous      *inlineNode
+	next          *inlineNode
+	previousRun   *inlineNode // runs that can still pair, the runs inside a pair cannot
+	nextRun       *inlineNode
+}
+
+// delimiterRun creates the node of a run of delimiters, whether it can open or close depends on what surrounds it
+func delimiterRun(source string, index int, length int) *inlineNode {
+	before, after := byte(' '), byte(' ')
+	if index > 0 {
+		before = source[index-1]
+	}
+	if index+length < len(source) {
+		after = source[index+length]
+	}
+
+	leftFlanking := !isSpace(after) && (!isASCIIPunctuation(after) || isSpace(before) || isASCIIPunctuation(before))
+	rightFlanking := !isSpace(before) && (!isASCIIPunctuation(before) || isSpace(after) || isASCIIPunctuation(after))
+
+	node := &inlineNode{delimiter: source[index], count: length, originalCount: length, canOpen: leftFlanking, canClose: rightFlanking}
+	switch node.delimiter {
+	case '_':
+		// underscores do not emphasize parts of words
+		node.canOpen = leftFlanking && (!rightFlanking || isASCIIPunctuation(before))
+		node.canClose = rightFlanking && (!leftFlanking || isASCIIPunctuation(after))
+	case '~':
+		if length > 2 {
+			node.canOpen, node.canClose = false, false
+		}
+	}
+
+	return node
+}
+
+// processEmphasis pairs delimiter runs from left to right, each closer with the nearest opener before it, and adds the tags of the pairs to them
+func processEmphasis(head *inlineNode) {
+	// openers that did not match a closer will not match a later closer of the same kind, so the search stops at them
+	type closerKind struct {
+		delimiter byte
+		canOpen   bool
+		remainder int
+	}
+	openersBottom := make(map[closerKind]int)
+
+	for closer := head.nextRun; closer != nil; {
+		if !closer.canClose || closer.count == 0 {
+			closer = closer.nextRun
+			continue
+		}
+
+		kind := closerKind{delimiter: closer.delimiter, canOpen: closer.canOpen, remainder: closer.originalCount % 3}
+		bottom, searched := openersBottom[kind]
+		if !searched {
+			bottom = -1
+		}
+
+		var opener *inlineNode
+		for candidate := closer.previousRun; candidate != head && candidate.position > bottom; candidate = candidate.previousRun {
+			if candidate.delimiter != closer.delimiter || !candidate.canOpen || candidate.count == 0 {
+				continue
+			}
+
+			// a run that can both open and close only pairs when the lengths are not a multiple of three together
+			if (candidate.canClose || closer.canOpen) && (candidate.originalCount+closer.originalCount)%3 == 0 && (candidate.originalCount%3 != 0 || closer.originalCount%3 != 0) {
+				continue
+			}
+
+			// strikethrough needs runs of the same length
+			if closer.delimiter == '~' && candidate.count != closer.count {
+				continue
+			}
+
+			opener = candidate
+			break
+		}
+
+		if opener == nil {
+			openersBottom[kind] = closer.position - 1
+			closer = closer.nextRun
+			continue
+		}
+
+		used := 1
+		if opener.count >= 2 && closer.count >= 2 {
+			used = 2
+		}
+		opener.count -= used
+		closer.count -= used
+
+		tag := "em"
+		switch {
+		case closer.delimiter == '~':
+			tag = "del"
+		case used == 2:
+			tag = "strong"
+		}
+
+		// everything between the pair becomes its content, unused delimiters in between are text
+		opener.opened = append(opener.opened, "<"+tag+">")
+		closer.closed = append(closer.closed, "</"+tag+">")
+		opener.nextRun = closer
+		closer.previousRun = opener
+		if opener.count == 0 {
+			opener.unlinkRun()
+		}
+
+		// a closer with delimiters left can close another opener
+		if closer.count == 0 {
+			next := closer.nextRun
+			closer.unlinkRun()
+			closer = next
+		}
+	}
+}
+
+// unlinkRun removes a run from the runs that can still pair
+func (node *inlineNode) unlinkRun() {
+	node.previousRun.nextRun = node.nextRun
+	if node.nextRun != nil {
+		node.nextRun.previousRun = node.previousRun
+	}
+}
+
+// render returns the HTML of a node, delimiters left without a partner are text between the tags of their pairs
+func (node *inlineNode) render() string {
+	if node.delimiter == 0 {
+		return node.html
+	}
+
+	var rendered strings.Builder
+	for _, tag := range node.closed {
+		rendered.WriteString(tag)
+	}
+	rendered.WriteString(strings.Repeat(string(node.delimiter), node.count))
+
+	// pairs made later enclose the earlier ones
+	for index := len(node.opened) - 1; index >= 0; index-- {
+		rendered.WriteString(node.opened[index])
+	}
+
+	return rendered.String()
+}
+
+// runLength returns how many times the character at index repeats from there
+func runLength(source string, index int) int {
+	length := 1
+	for index+length < len(source) && source[index+length] == source[index] {
+		length++
+	}
+
+	return length
+}
+
+// skipSpaces returns the index of the next character that is not a space or line break
+func skipSpaces(source string, index int) int {
+	for index < len(source) && isSpace(source[index]) {
+		index++
+	}
+
+	return index
+}
+
+// isSpace reports whether a character separates words
+func isSpace(character byte) bool {
+	return character == ' ' || character == '\n' || character == '\t'
+}
+
+// isWordCharacter reports whether a character is part of a word, bytes of non ASCII characters count as letters
+func isWordCharacter(character byte) bool {
+	return character >= 0x80 || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9')
+}
+
+// isASCIIPunctuation reports whether a character can be escaped with a backslash
+func isASCIIPunctuation(character byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", character) >= 0
+}
@@ -0,0 +1,94 @@
+package markdownServices
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderKeepsUnsafeMarkdownInert(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "javascript link", source: "[x](javascript:alert(1))", expected: "<p>x</p>\n"},
+		{name: "javascript link in mixed case", source: "[x](JaVaScRiPt:alert(1))", expected: "<p>x</p>\n"},
+		{name: "javascript link in angle brackets", source: "[x](<javascript:alert(1)>)", expected: "<p>x</p>\n"},
+		{name: "javascript link with escaped colon", source: "[x](javascript\\:alert(1))", expected: "<p>x</p>\n"},
+		{name: "javascript autolink", source: "<javascript:alert(1)>", expected: "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{name: "vbscript link", source: "[x](vbscript:msgbox)", expected: "<p>x</p>\n"},
+		{name: "javascript image", source: "![x](javascript:alert(1))", expected: "<p>x</p>\n"},
+		{name: "data image", source: "![x](data:image/svg+xml;base64,PHN2Zz4=)", expected: "<p>x</p>\n"},
+		{name: "mailto image", source: "![x](mailto:ada@example.com)", expected: "<p>x</p>\n"},
+		{
+			name:     "entity encoded scheme",
+			source:   "[x](&#106;avascript:alert(1))",
+			expected: "<p><a href=\"&amp;#106;avascript:alert(1)\"" + linkAttributes + ">x</a></p>\n",
+		},
+		{
+			name:     "entity encoded colon",
+			source:   "[x](javascript&#58;alert(1))",
+			expected: "<p><a href=\"javascript&amp;#58;alert(1)\"" + linkAttributes + ">x</a></p>\n",
+		},
+		{name: "script tag", source: "<script>alert(1)</script>", expected: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{name: "event handler", source: "<img src=x onerror=alert(1)>", expected: "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{name: "html in code span", source: "`<b>`", expected: "<p><code>&lt;b&gt;</code></p>\n"},
+		{
+			name:     "quotes in link text",
+			source:   "[x\" onclick=\"y](https://example.com)",
+			expected: "<p><a href=\"https://example.com\"" + linkAttributes + ">x&#34; onclick=&#34;y</a></p>\n",
+		},
+		{
+			name:     "quotes in link title",
+			source:   "[x](https://example.com 'a\" onclick=\"y')",
+			expected: "<p><a href=\"https://example.com\" title=\"a&#34; onclick=&#34;y\"" + linkAttributes + ">x</a></p>\n",
+		},
+		{
+			name:     "quotes in bare autolink",
+			source:   "www.example.com/\"onclick=x",
+			expected: "<p><a href=\"http://www.example.com/&#34;onclick=x\"" + linkAttributes + ">www.example.com/&#34;onclick=x</a></p>\n",
+		},
+		{name: "quotes in code fence language", source: "```js\" onmouseover=\"alert(1)\nx\n```", expected: "<pre><code>x\n</code></pre>\n"},
+		{name: "html in code fence language", source: "```<script>\nx\n```", expected: "<pre><code>x\n</code></pre>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if rendered := Render(test.source).HTML; rendered != test.expected {
+				t.Errorf("rendered %q as %q, expected %q", test.source, rendered, test.expected)
+			}
+		})
+	}
+}
+
+func TestRenderWorstCaseInputInLinearTime(t *testing.T) {
+	// each input is rendered in well under a second, rescanning the rest of the text at every character takes minutes
+	const size = 100000
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "unclosed brackets", source: strings.Repeat("[", size)},
+		{name: "nested brackets", source: strings.Repeat("[", size/2) + strings.Repeat("]", size/2)},
+		{name: "unclosed links", source: strings.Repeat("[a](", size/4)},
+		{name: "unclosed link titles", source: strings.Repeat("[a](b (", size/7)},
+		{name: "unclosed angle bracket targets", source: strings.Repeat("[a](<", size/5)},
+		{name: "nested images", source: strings.Repeat("![", size/4) + strings.Repeat("](x)", size/4)},
+		{name: "unmatched backticks", source: strings.Repeat("` `` ", size/5)},
+		{name: "nested emphasis", source: strings.Repeat("*a ", size/6) + strings.Repeat(" a*", size/6)},
+		{name: "unmatched emphasis", source: strings.Repeat("*a _b ", size/6)},
+		{name: "bare autolinks without a host", source: strings.Repeat("http://!", size/8)},
+		{name: "nested lists and quotes", source: strings.Repeat("> - ", size/4) + "a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Now()
+			Render(test.source)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("rendering took %v", elapsed)
+			}
+		})
+	}
+}
@@ -7,21 +7,27 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
 	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
-	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
 	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	markdownServices "github.com/hwaengfan/dev-journal-backend/internal/services/markdown"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
 	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
 type Handler struct {
-	store        noteModel.NoteStore
-	userStore    userModel.UserStore
-	projectStore projectModel.ProjectStore
+	store       noteModel.NoteStore
+	userStore   userModel.UserStore
+	memberStore projectMemberModel.ProjectMemberStore
+	renderer    *markdownServices.Renderer
+	recorder    *auditServices.Recorder
 }
 
-func NewHandler(store noteModel.NoteStore, userStore userModel.UserStore, projectStore projectModel.ProjectStore) *Handler {
-	return &Handler{store: store, userStore: userStore, projectStore: projectStore}
+func NewHandler(store noteModel.NoteStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, renderer *markdownServices.Renderer, recorder *auditServices.Recorder) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, renderer: renderer, recorder: recorder}
 }
 
 func (handler *Handler) RegisterRoutes(router *mux.Router) {
@@ -45,6 +51,9 @@ func (handler *Handler) handleCreateNewNote(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get JSON payload
 	var payload noteModel.CreateNotePayload
 	if error := utils.ParseJSON(request, &payload); error != nil {
@@ -65,26 +74,29 @@ func (handler *Handler) handleCreateNewNote(writer http.ResponseWriter, request
 		return
 	}
 
-	// check if the project exists
-	if error := handler.validateLinkedProjectID(payload.LinkedProjectID); error != nil {
-		utils.WriteError(writer, http.StatusBadRequest, error)
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(payload.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
 		return
 	}
 
 	// insert the new note into the database
-	noteID, error := handler.store.CreateNote(noteModel.Note{
+	note := noteModel.Note{
 		UserID:          userID.UUID,
 		LinkedProjectID: payload.LinkedProjectID,
 		Title:           payload.Title,
 		Content:         payload.Content,
 		Favorited:       payload.Favorited,
 		Tags:            payload.Tags,
-	})
+	}
+	noteID, error := handler.store.CreateNote(note)
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
 	}
 
+	handler.recordNoteEvent(request, auditModel.ActionCreate, noteID, nil, &note)
+
 	utils.WriteJSON(writer, http.StatusCreated, map[string]string{"noteID": noteID.String()})
 }
 
@@ -97,6 +109,9 @@ func (handler *Handler) handleGetNotesByLinkedProjectID(writer http.ResponseWrit
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get project ID from URL
 	linkedProjectIDString, exists := mux.Vars(request)["projectID"]
 	if !exists {
@@ -116,12 +131,18 @@ func (handler *Handler) handleGetNotesByLinkedProjectID(writer http.ResponseWrit
 		return
 	}
 
-	// check if the project exists
-	if error := handler.validateLinkedProjectID(linkedProjectID); error != nil {
+	render, error := renderRequested(request)
+	if error != nil {
 		utils.WriteError(writer, http.StatusBadRequest, error)
 		return
 	}
 
+	// check if the user can read the project
+	if error := handler.validateLinkedProjectID(linkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
 	// get notes by projectID
 	notes, error := handler.store.GetNotesByLinkedProjectID(linkedProjectID)
 	if error != nil {
@@ -129,6 +150,12 @@ func (handler *Handler) handleGetNotesByLinkedProjectID(writer http.ResponseWrit
 		return
 	}
 
+	if render {
+		for _, note := range notes {
+			note.Rendered = handler.renderer.Render(note.ID, note.Content)
+		}
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, notes)
 }
 
@@ -141,6 +168,9 @@ func (handler *Handler) handleGetNoteByID(writer http.ResponseWriter, request *h
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get noteID from URL
 	noteIDString, exists := mux.Vars(request)["noteID"]
 	if !exists {
@@ -154,12 +184,28 @@ func (handler *Handler) handleGetNoteByID(writer http.ResponseWriter, request *h
 		return
 	}
 
+	render, error := renderRequested(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
 	note, error := handler.store.GetNoteByID(noteID)
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to get note by ID: %v", error))
 		return
 	}
 
+	// check if the user can read the project of the note
+	if error := handler.validateLinkedProjectID(note.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if render {
+		note.Rendered = handler.renderer.Render(note.ID, note.Content)
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, note)
 }
 
@@ -172,6 +218,9 @@ func (handler *Handler) handleUpdateNoteByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get noteID from URL
 	noteIDString, exists := mux.Vars(request)["noteID"]
 	if !exists {
@@ -200,17 +249,23 @@ func (handler *Handler) handleUpdateNoteByID(writer http.ResponseWriter, request
 	}
 
 	// check if the note exists
-	error = handler.validateNoteID(noteID)
+	note, error := handler.store.GetNoteByID(noteID)
 	if error != nil {
-		utils.WriteError(writer, http.StatusBadRequest, error)
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the note
+	if error := handler.validateLinkedProjectID(note.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
 		return
 	}
 
 	// check if the linkedProjectID is provided
 	if payload.LinkedProjectID != uuid.Nil {
-		// check if the project exists
-		if error := handler.validateLinkedProjectID(payload.LinkedProjectID); error != nil {
-			utils.WriteError(writer, http.StatusBadRequest, error)
+		// check if the user can edit the project the note moves to
+		if error := handler.validateLinkedProjectID(payload.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+			projectAccessServices.WriteError(writer, error)
 			return
 		}
 	}
@@ -232,6 +287,11 @@ func (handler *Handler) handleUpdateNoteByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// record what changed, the note is read again since only the provided fields are updated
+	if updatedNote, error := handler.store.GetNoteByID(noteID); error == nil {
+		handler.recordNoteEvent(request, auditModel.ActionUpdate, noteID, note, updatedNote)
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
 
@@ -244,6 +304,9 @@ func (handler *Handler) handleDeleteNoteByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get noteID from URL
 	noteIDString, exists := mux.Vars(request)["noteID"]
 	if !exists {
@@ -258,12 +321,18 @@ func (handler *Handler) handleDeleteNoteByID(writer http.ResponseWriter, request
 	}
 
 	// check if the note exists
-	_, error = handler.store.GetNoteByID(noteID)
+	note, error := handler.store.GetNoteByID(noteID)
 	if error != nil {
 		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note does not exist"))
 		return
 	}
 
+	// check if the user can edit the project of the note
+	if error := handler.validateLinkedProjectID(note.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
 	// delete the note by ID
 	error = handler.store.DeleteNoteByID(noteID)
 	if error != nil {
@@ -271,25 +340,41 @@ func (handler *Handler) handleDeleteNoteByID(writer http.ResponseWriter, request
 		return
 	}
 
+	handler.recordNoteEvent(request, auditModel.ActionDelete, noteID, note, nil)
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
 
-// validateLinkedProjectID check if the project exists
-func (handler *Handler) validateLinkedProjectID(linkedProjectID uuid.UUID) error {
-	_, error := handler.projectStore.GetProjectByID(linkedProjectID)
-	if error != nil {
-		return fmt.Errorf("project ID does not exist")
+// validateLinkedProjectID check if the project exists in the active workspace and the user has at least a role in it
+func (handler *Handler) validateLinkedProjectID(linkedProjectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID, minimumRole string) error {
+	_, error := projectAccessServices.Authorize(handler.memberStore, linkedProjectID, userID, workspaceID, minimumRole)
+	return error
+}
+
+// recordNoteEvent records a change of a note in the activity of its project
+func (handler *Handler) recordNoteEvent(request *http.Request, action string, noteID uuid.UUID, before *noteModel.Note, after *noteModel.Note) {
+	// a note moved to another project shows up in the activity of the project it moved to
+	note := after
+	if note == nil {
+		note = before
 	}
 
-	return nil
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: note.LinkedProjectID, Valid: true},
+		EntityType: auditModel.EntityNote,
+		EntityID:   noteID,
+		Action:     action,
+	}, before, after)
 }
 
-// validateNoteID check if the note exists
-func (handler *Handler) validateNoteID(noteID uuid.UUID) error {
-	_, error := handler.store.GetNoteByID(noteID)
-	if error != nil {
-		return fmt.Errorf("note ID does not exist")
+// renderRequested reports whether a request asks for the rendered content next to the Markdown, with ?render=html
+func renderRequested(request *http.Request) (bool, error) {
+	switch request.URL.Query().Get("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid render option, only html is supported")
 	}
-
-	return nil
 }
@@ -0,0 +1,243 @@
+package notificationService
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Notifications listed in the inbox when no limit is asked for
+const defaultInboxSize = 50
+
+type Handler struct {
+	store     notificationModel.NotificationStore
+	userStore userModel.UserStore
+}
+
+func NewHandler(store notificationModel.NotificationStore, userStore userModel.UserStore) *Handler {
+	return &Handler{store: store, userStore: userStore}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications/get-notifications", authenticationServices.JWTAuthentication(handler.handleGetNotifications, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/notifications/get-unread-count", authenticationServices.JWTAuthentication(handler.handleGetUnreadCount, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/notifications/mark-notification-read-by-ID/{notificationID}", authenticationServices.JWTAuthentication(handler.handleMarkNotificationReadByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/notifications/mark-notification-unread-by-ID/{notificationID}", authenticationServices.JWTAuthentication(handler.handleMarkNotificationUnreadByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/notifications/mark-notifications-read", authenticationServices.JWTAuthentication(handler.handleMarkNotificationsRead, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/notifications/get-notification-preferences", authenticationServices.JWTAuthentication(handler.handleGetNotificationPreferences, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/notifications/update-notification-preferences", authenticationServices.JWTAuthentication(handler.handleUpdateNotificationPreferences, handler.userStore)).Methods(http.MethodPut)
+}
+
+// Handler function for getting the inbox of the user, filtered by status=unread and type
+func (handler *Handler) handleGetNotifications(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	query := request.URL.Query()
+	filter := notificationModel.NotificationFilter{Limit: defaultInboxSize, Type: query.Get("type")}
+	if value, error := strconv.Atoi(query.Get("limit")); error == nil {
+		filter.Limit = min(max(value, 1), 200)
+	}
+
+	switch query.Get("status") {
+	case "unread":
+		filter.Unread = true
+	case "", "all":
+	default:
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("status must be unread or all"))
+		return
+	}
+
+	if filter.Type != "" && !isNotificationType(filter.Type) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("unknown notification type %q", filter.Type))
+		return
+	}
+
+	notifications, error := handler.store.GetNotificationsByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, notifications)
+}
+
+// Handler function for counting the unread notifications of the user
+func (handler *Handler) handleGetUnreadCount(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	count, error := handler.store.CountUnreadNotificationsByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]int{"unreadCount": count})
+}
+
+// Handler function for marking a notification as read
+func (handler *Handler) handleMarkNotificationReadByID(writer http.ResponseWriter, request *http.Request) {
+	handler.setNotificationRead(writer, request, true)
+}
+
+// Handler function for marking a notification as unread again
+func (handler *Handler) handleMarkNotificationUnreadByID(writer http.ResponseWriter, request *http.Request) {
+	handler.setNotificationRead(writer, request, false)
+}
+
+// Handler function for marking several notifications of the user as read, or all of them
+func (handler *Handler) handleMarkNotificationsRead(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload notificationModel.MarkNotificationsReadPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	if len(payload.NotificationIDs) > 500 {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("at most 500 notifications can be marked at once"))
+		return
+	}
+
+	// notifications of other users are left alone
+	marked, error := handler.store.MarkNotificationsReadByUserID(userID.UUID, payload.NotificationIDs)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]int64{"marked": marked})
+}
+
+// Handler function for getting how the user receives each type of notification
+func (handler *Handler) handleGetNotificationPreferences(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	preferences, error := handler.store.GetNotificationPreferencesByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, preferences)
+}
+
+// Handler function for choosing how the user receives some types of notifications, types left out keep their preference
+func (handler *Handler) handleUpdateNotificationPreferences(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload notificationModel.UpdateNotificationPreferencesPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	for _, preference := range payload.Preferences {
+		if error := handler.store.SetNotificationPreferenceByUserID(userID.UUID, preference); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	preferences, error := handler.store.GetNotificationPreferencesByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, preferences)
+}
+
+// setNotificationRead marks a notification of the user as read or unread
+func (handler *Handler) setNotificationRead(writer http.ResponseWriter, request *http.Request, read bool) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get notification ID from URL
+	notificationID, error := utils.ParseIDFromURL(request, "notificationID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// notifications of other users are reported as missing
+	notification, error := handler.store.GetNotificationByID(notificationID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if notification == nil || notification.UserID != userID.UUID {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("notification not found"))
+		return
+	}
+
+	if error := handler.store.SetNotificationReadByID(notificationID, read); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// isNotificationType checks if a type of notification exists
+func isNotificationType(notificationType string) bool {
+	for _, candidate := range notificationModel.Types {
+		if candidate == notificationType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package notificationService
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+)
+
+// Longest message a notification keeps, longer ones are cut off
+const maxMessageLength = 512
+
+// A Deliverer hands a notification to a channel outside the inbox, like email or a live connection
+type Deliverer interface {
+	Deliver(recipient *userModel.User, notification *notificationModel.Notification) error
+}
+
+// Notifier puts notifications in the inbox of their users and hands them to the channels each user chose
+type Notifier struct {
+	store      notificationModel.NotificationStore
+	userStore  userModel.UserStore
+	email      Deliverer
+	deliverers []Deliverer
+	mutex      sync.RWMutex
+}
+
+func NewNotifier(store notificationModel.NotificationStore, userStore userModel.UserStore, email Deliverer) *Notifier {
+	return &Notifier{store: store, userStore: userStore, email: email}
+}
+
+// AddDeliverer plugs in a channel that gets every notification put in an inbox, like a stream of server-sent events
+func (notifier *Notifier) AddDeliverer(deliverer Deliverer) {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	notifier.deliverers = append(notifier.deliverers, deliverer)
+}
+
+// Notify sends a notification to its user as their preference for its type asks, users are not notified of their own actions
+func (notifier *Notifier) Notify(notification notificationModel.Notification) error {
+	if notification.ActorID.Valid && notification.ActorID.UUID == notification.UserID {
+		return nil
+	}
+
+	preferences, error := notifier.store.GetNotificationPreferencesByUserID(notification.UserID)
+	if error != nil {
+		return error
+	}
+
+	if runes := []rune(notification.Message); len(runes) > maxMessageLength {
+		notification.Message = string(runes[:maxMessageLength-1]) + "…"
+	}
+
+	var preference *notificationModel.NotificationPreference
+	for _, candidate := range preferences {
+		if candidate.Type == notification.Type {
+			preference = candidate
+		}
+	}
+
+	if preference == nil {
+		return fmt.Errorf("unknown notification type %q", notification.Type)
+	}
+
+	// the inbox keeps the notification, live channels only get what is in it
+	var deliverers []Deliverer
+	if preference.InApp == "True" {
+		notificationID, error := notifier.store.CreateNotification(notification)
+		if error != nil {
+			return error
+		}
+
+		notification.ID = notificationID
+		notification.DateCreated = time.Now().UTC()
+
+		notifier.mutex.RLock()
+		deliverers = append(deliverers, notifier.deliverers...)
+		notifier.mutex.RUnlock()
+	}
+
+	if preference.Email == "True" && notifier.email != nil {
+		deliverers = append(deliverers, notifier.email)
+	}
+
+	if len(deliverers) == 0 {
+		return nil
+	}
+
+	recipient, error := notifier.userStore.GetUserByID(notification.UserID)
+	if error != nil {
+		return error
+	}
+
+	// slow channels do not hold up the change that caused the notification
+	go notifier.deliver(deliverers, recipient, &notification)
+
+	return nil
+}
+
+// deliver hands a notification to each channel, a failing channel does not keep it from the others
+func (notifier *Notifier) deliver(deliverers []Deliverer, recipient *userModel.User, notification *notificationModel.Notification) {
+	for _, deliverer := range deliverers {
+		if error := deliverer.Deliver(recipient, notification); error != nil {
+			log.Printf("failed to deliver %s notification to user %s: %v", notification.Type, recipient.ID, error)
+		}
+	}
+}
+
+// EmailDeliverer mails notifications to users who opted in to email for their type
+type EmailDeliverer struct {
+	mailer mailServices.Mailer
+}
+
+func NewEmailDeliverer(mailer mailServices.Mailer) *EmailDeliverer {
+	return &EmailDeliverer{mailer: mailer}
+}
+
+// Deliver mails a notification, users who have not verified their email are skipped
+func (deliverer *EmailDeliverer) Deliver(recipient *userModel.User, notification *notificationModel.Notification) error {
+	if recipient.EmailVerified != "True" {
+		return nil
+	}
+
+	return deliverer.mailer.Send(mailServices.Message{
+		To:       recipient.Email,
+		Subject:  notification.Message,
+		TextBody: fmt.Sprintf("Hi %s,\n\n%s.\n\n%s\n\nYou can choose which notifications are mailed to you in your notification preferences.\n", recipient.FirstName, notification.Message, configs.ServerEnvironmentVariables.ClientURL+"/notifications"),
+	})
+}
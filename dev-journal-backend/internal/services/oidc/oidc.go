@@ -0,0 +1,406 @@
+package oidcServices
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+)
+
+// How long discovered metadata and signing keys are cached before they are fetched again
+const cacheDuration = time.Hour
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// IDTokenClaims are the claims of a verified ID token used to find or create the user
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+	Email           string `json:"email"`
+	EmailVerified   any    `json:"email_verified"` // some providers send it as a string
+	Name            string `json:"name"`
+	GivenName       string `json:"given_name"`
+	FamilyName      string `json:"family_name"`
+}
+
+// IsEmailVerified reports whether the provider verified the email of the user
+func (claims *IDTokenClaims) IsEmailVerified() bool {
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		return verified
+	case string:
+		return verified == "true"
+	}
+
+	return false
+}
+
+// Provider runs the authorization code flow with PKCE against an OpenID Connect provider
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       string
+	httpClient   *http.Client
+
+	mutex             sync.Mutex
+	metadata          *providerMetadata
+	metadataFetchedAt time.Time
+	keys              map[string]any
+	keysFetchedAt     time.Time
+}
+
+// NewProvider returns a provider for the configured issuer, requests go through the given HTTP client
+func NewProvider(oidcConfigs configs.OIDCConfigs, httpClient *http.Client) *Provider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Provider{
+		issuer:       strings.TrimSuffix(oidcConfigs.IssuerURL, "/"),
+		clientID:     oidcConfigs.ClientID,
+		clientSecret: oidcConfigs.ClientSecret,
+		redirectURL:  oidcConfigs.RedirectURL,
+		scopes:       oidcConfigs.Scopes,
+		httpClient:   httpClient,
+	}
+}
+
+// Enabled reports whether an issuer is configured
+func (provider *Provider) Enabled() bool {
+	return provider.issuer != "" && provider.clientID != ""
+}
+
+// Issuer returns the issuer identifier, identities are unique per issuer and subject
+func (provider *Provider) Issuer() string {
+	return provider.issuer
+}
+
+// GenerateCodeVerifier creates a PKCE code verifier (RFC 7636)
+func GenerateCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// GenerateState creates a random value for the state or nonce of a login
+func GenerateState() (string, error) {
+	return randomString(16)
+}
+
+// AuthorizationURL returns the URL of the provider login page for a state, nonce and code verifier
+func (provider *Provider) AuthorizationURL(state string, nonce string, codeVerifier string) (string, error) {
+	metadata, error := provider.getMetadata()
+	if error != nil {
+		return "", error
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.clientID)
+	query.Set("redirect_uri", provider.redirectURL)
+	query.Set("scope", provider.scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified claims of the ID token
+func (provider *Provider) Exchange(code string, codeVerifier string, nonce string) (*IDTokenClaims, error) {
+	metadata, error := provider.getMetadata()
+	if error != nil {
+		return nil, error
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.redirectURL)
+	form.Set("client_id", provider.clientID)
+	form.Set("code_verifier", codeVerifier)
+
+	request, error := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if error != nil {
+		return nil, fmt.Errorf("failed to create token request: %v", error)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if provider.clientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(provider.clientID), url.QueryEscape(provider.clientSecret))
+	}
+
+	var response struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if error := provider.doJSON(request, &response); error != nil {
+		if response.Error != "" {
+			return nil, fmt.Errorf("provider rejected the code: %s %s", response.Error, response.ErrorDescription)
+		}
+		return nil, error
+	}
+
+	if response.IDToken == "" {
+		return nil, fmt.Errorf("provider returned no ID token")
+	}
+
+	return provider.VerifyIDToken(response.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (provider *Provider) VerifyIDToken(rawIDToken string, nonce string) (*IDTokenClaims, error) {
+	claims := new(IDTokenClaims)
+	_, error := jwt.ParseWithClaims(rawIDToken, claims, provider.getKey,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(provider.issuer),
+		jwt.WithAudience(provider.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if error != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", error)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce does not match")
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != provider.clientID {
+		return nil, fmt.Errorf("invalid ID token: token was issued to another client")
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid ID token: missing subject")
+	}
+
+	return claims, nil
+}
+
+// getMetadata discovers the provider endpoints from its openid-configuration document
+func (provider *Provider) getMetadata() (*providerMetadata, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.metadata != nil && time.Since(provider.metadataFetchedAt) < cacheDuration {
+		return provider.metadata, nil
+	}
+
+	request, error := http.NewRequest(http.MethodGet, provider.issuer+"/.well-known/openid-configuration", nil)
+	if error != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %v", error)
+	}
+
+	metadata := new(providerMetadata)
+	if error := provider.doJSON(request, metadata); error != nil {
+		return nil, fmt.Errorf("failed to discover provider: %v", error)
+	}
+
+	if strings.TrimSuffix(metadata.Issuer, "/") != provider.issuer {
+		return nil, fmt.Errorf("provider issuer %q does not match %q", metadata.Issuer, provider.issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("provider metadata is missing endpoints")
+	}
+
+	provider.metadata, provider.metadataFetchedAt = metadata, time.Now()
+	return metadata, nil
+}
+
+// getKey returns the signing key of a token, the keys are fetched again once when the key ID is unknown so rotated keys are picked up
+func (provider *Provider) getKey(token *jwt.Token) (any, error) {
+	keyID, _ := token.Header["kid"].(string)
+
+	metadata, error := provider.getMetadata()
+	if error != nil {
+		return nil, error
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if key := provider.findKey(keyID); key != nil && time.Since(provider.keysFetchedAt) < cacheDuration {
+		return key, nil
+	}
+
+	// refetching is limited so tokens with made up key IDs cannot hammer the provider
+	if time.Since(provider.keysFetchedAt) > 10*time.Second {
+		if error := provider.fetchKeys(metadata.JWKSURI); error != nil {
+			return nil, error
+		}
+	}
+
+	if key := provider.findKey(keyID); key != nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+// findKey looks up a cached key, a token without key ID matches when the provider has a single key
+func (provider *Provider) findKey(keyID string) any {
+	if keyID == "" && len(provider.keys) == 1 {
+		for _, key := range provider.keys {
+			return key
+		}
+	}
+
+	return provider.keys[keyID]
+}
+
+// fetchKeys replaces the cached keys with the provider JWKS
+func (provider *Provider) fetchKeys(jwksURI string) error {
+	request, error := http.NewRequest(http.MethodGet, jwksURI, nil)
+	if error != nil {
+		return fmt.Errorf("failed to create JWKS request: %v", error)
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if error := provider.doJSON(request, &keySet); error != nil {
+		return fmt.Errorf("failed to fetch provider keys: %v", error)
+	}
+
+	keys := make(map[string]any)
+	for _, webKey := range keySet.Keys {
+		if webKey.Use != "" && webKey.Use != "sig" {
+			continue
+		}
+
+		key, error := parseKey(webKey)
+		if error != nil {
+			continue
+		}
+		keys[webKey.KeyID] = key
+	}
+
+	provider.keys, provider.keysFetchedAt = keys, time.Now()
+	return nil
+}
+
+// doJSON sends a request and decodes the JSON response, non 2xx responses are still decoded into the target
+func (provider *Provider) doJSON(request *http.Request, target any) error {
+	response, error := provider.httpClient.Do(request)
+	if error != nil {
+		return error
+	}
+	defer response.Body.Close()
+
+	body, error := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if error != nil {
+		return error
+	}
+
+	decodeError := json.Unmarshal(body, target)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return decodeError
+}
+
+// parseKey converts an RSA or EC JSON web key to a public key
+func parseKey(webKey jsonWebKey) (any, error) {
+	switch webKey.KeyType {
+	case "RSA":
+		n, error := decodeBigInt(webKey.N)
+		if error != nil {
+			return nil, error
+		}
+		e, error := decodeBigInt(webKey.E)
+		if error != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, exists := curves[webKey.Curve]
+		if !exists {
+			return nil, fmt.Errorf("unsupported curve %q", webKey.Curve)
+		}
+
+		x, error := decodeBigInt(webKey.X)
+		if error != nil {
+			return nil, error
+		}
+		y, error := decodeBigInt(webKey.Y)
+		if error != nil {
+			return nil, error
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", webKey.KeyType)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, error := base64.RawURLEncoding.DecodeString(value)
+	if error != nil || len(bytes) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+// randomString returns random bytes encoded as base64url
+func randomString(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, error := rand.Read(bytes); error != nil {
+		return "", error
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// LoginState is what the callback needs to finish a login started by the client, see authenticationServices.CreateOIDCLoginJWT
+type LoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
@@ -0,0 +1,203 @@
+package oidcMockServices
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Client the mock provider issues ID tokens to
+const ClientID = "dev-journal"
+
+// Key ID of the key the mock provider signs with
+const KeyID = "mock-key"
+
+// Identity is the provider account a user logs in with
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type authorization struct {
+	identity      Identity
+	nonce         string
+	codeChallenge string
+	redirectURL   string
+}
+
+// Provider is an OpenID Connect provider on a local test server with discovery, JWKS and token endpoints
+type Provider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	codes  map[string]authorization
+	issued int
+}
+
+// NewProvider starts a mock provider, call Close when done
+func NewProvider() (*Provider, error) {
+	key, error := rsa.GenerateKey(rand.Reader, 2048)
+	if error != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %v", error)
+	}
+
+	provider := &Provider{key: key, codes: make(map[string]authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", provider.handleDiscovery)
+	mux.HandleFunc("GET /jwks", provider.handleJWKS)
+	mux.HandleFunc("POST /token", provider.handleToken)
+	provider.server = httptest.NewServer(mux)
+
+	return provider, nil
+}
+
+// Close stops the test server
+func (provider *Provider) Close() {
+	provider.server.Close()
+}
+
+// Issuer returns the issuer identifier, the URL of the test server
+func (provider *Provider) Issuer() string {
+	return provider.server.URL
+}
+
+// Configs returns the single sign-on configuration pointing at the mock provider
+func (provider *Provider) Configs() configs.OIDCConfigs {
+	return configs.OIDCConfigs{
+		IssuerURL:   provider.server.URL,
+		ClientID:    ClientID,
+		RedirectURL: "http://localhost:3000/oidc/callback",
+		Scopes:      "openid email profile",
+	}
+}
+
+// Authorize plays the user logging in at the provider, it returns the code and state the provider redirects back with
+func (provider *Provider) Authorize(authorizationURL string, identity Identity) (string, string, error) {
+	parsedURL, error := url.Parse(authorizationURL)
+	if error != nil {
+		return "", "", error
+	}
+
+	query := parsedURL.Query()
+	if query.Get("client_id") != ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		return "", "", fmt.Errorf("invalid authorization request %q", authorizationURL)
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	provider.issued++
+	code := fmt.Sprintf("code-%d", provider.issued)
+	provider.codes[code] = authorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURL:   query.Get("redirect_uri"),
+	}
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken signs claims with the provider key, the claims are used as given
+func (provider *Provider) SignIDToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+
+	return token.SignedString(provider.key)
+}
+
+// IDTokenClaims returns valid ID token claims for an identity and nonce
+func (provider *Provider) IDTokenClaims(identity Identity, nonce string) jwt.MapClaims {
+	now := time.Now()
+
+	return jwt.MapClaims{
+		"iss":            provider.server.URL,
+		"sub":            identity.Subject,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          identity.Email,
+		"email_verified": identity.EmailVerified,
+		"given_name":     identity.GivenName,
+		"family_name":    identity.FamilyName,
+	}
+}
+
+func (provider *Provider) handleDiscovery(writer http.ResponseWriter, request *http.Request) {
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{
+		"issuer":                 provider.server.URL,
+		"authorization_endpoint": provider.server.URL + "/authorize",
+		"token_endpoint":         provider.server.URL + "/token",
+		"jwks_uri":               provider.server.URL + "/jwks",
+	})
+}
+
+func (provider *Provider) handleJWKS(writer http.ResponseWriter, request *http.Request) {
+	publicKey := provider.key.PublicKey
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": KeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// handleToken redeems a code once, the code verifier must match the challenge of the authorization request
+func (provider *Provider) handleToken(writer http.ResponseWriter, request *http.Request) {
+	if error := request.ParseForm(); error != nil {
+		writeTokenError(writer, "invalid_request")
+		return
+	}
+
+	provider.mutex.Lock()
+	code := request.PostForm.Get("code")
+	authorization, exists := provider.codes[code]
+	delete(provider.codes, code)
+	provider.mutex.Unlock()
+
+	if request.PostForm.Get("grant_type") != "authorization_code" || request.PostForm.Get("client_id") != ClientID {
+		writeTokenError(writer, "invalid_request")
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(request.PostForm.Get("code_verifier")))
+	if !exists || authorization.redirectURL != request.PostForm.Get("redirect_uri") || base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.codeChallenge {
+		writeTokenError(writer, "invalid_grant")
+		return
+	}
+
+	idToken, error := provider.SignIDToken(provider.IDTokenClaims(authorization.identity, authorization.nonce))
+	if error != nil {
+		writeTokenError(writer, "server_error")
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"access_token": "mock-access-token", "token_type": "Bearer", "id_token": idToken})
+}
+
+func writeTokenError(writer http.ResponseWriter, code string) {
+	utils.WriteJSON(writer, http.StatusBadRequest, map[string]string{"error": code})
+}
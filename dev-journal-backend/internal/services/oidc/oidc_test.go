@@ -0,0 +1,186 @@
+package oidcServices_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
+	oidcMockServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc/oidcMock"
+)
+
+var identity = oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace"}
+
+func newProvider(t *testing.T) (*oidcMockServices.Provider, *oidcServices.Provider) {
+	t.Helper()
+
+	mockProvider, error := oidcMockServices.NewProvider()
+	if error != nil {
+		t.Fatal(error)
+	}
+	t.Cleanup(mockProvider.Close)
+
+	return mockProvider, oidcServices.NewProvider(mockProvider.Configs(), nil)
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, error := rsa.GenerateKey(rand.Reader, 2048)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	return key
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	mockProvider, provider := newProvider(t)
+
+	authorizationURL, error := provider.AuthorizationURL("state", "nonce", "verifier")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if !strings.HasPrefix(authorizationURL, mockProvider.Issuer()+"/authorize?") {
+		t.Fatalf("authorization URL %q does not use the discovered endpoint", authorizationURL)
+	}
+
+	parsedURL, _ := url.Parse(authorizationURL)
+	query := parsedURL.Query()
+	for name, expected := range map[string]string{"state": "state", "nonce": "nonce", "client_id": oidcMockServices.ClientID, "code_challenge_method": "S256"} {
+		if query.Get(name) != expected {
+			t.Errorf("%s is %q, expected %q", name, query.Get(name), expected)
+		}
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge") == "verifier" {
+		t.Errorf("code challenge %q is not derived from the verifier", query.Get("code_challenge"))
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name          string
+		codeVerifier  string
+		nonce         string
+		redeemTwice   bool
+		expectedError string
+	}{
+		{name: "valid code", codeVerifier: "verifier", nonce: "nonce"},
+		{name: "wrong code verifier", codeVerifier: "other-verifier", nonce: "nonce", expectedError: "invalid_grant"},
+		{name: "wrong nonce", codeVerifier: "verifier", nonce: "other-nonce", expectedError: "nonce does not match"},
+		{name: "code redeemed twice", codeVerifier: "verifier", nonce: "nonce", redeemTwice: true, expectedError: "invalid_grant"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockProvider, provider := newProvider(t)
+
+			authorizationURL, error := provider.AuthorizationURL("state", "nonce", "verifier")
+			if error != nil {
+				t.Fatal(error)
+			}
+			code, _, error := mockProvider.Authorize(authorizationURL, identity)
+			if error != nil {
+				t.Fatal(error)
+			}
+
+			if test.redeemTwice {
+				if _, error := provider.Exchange(code, test.codeVerifier, test.nonce); error != nil {
+					t.Fatal(error)
+				}
+			}
+
+			claims, error := provider.Exchange(code, test.codeVerifier, test.nonce)
+			if test.expectedError != "" {
+				if error == nil || !strings.Contains(error.Error(), test.expectedError) {
+					t.Fatalf("error is %v, expected %q", error, test.expectedError)
+				}
+				return
+			}
+
+			if error != nil {
+				t.Fatal(error)
+			}
+			if claims.Subject != identity.Subject || claims.Email != identity.Email || !claims.IsEmailVerified() {
+				t.Fatalf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	otherKey := generateKey(t)
+
+	tests := []struct {
+		name          string
+		change        func(claims jwt.MapClaims)
+		sign          func(mockProvider *oidcMockServices.Provider, claims jwt.MapClaims) (string, error)
+		expectedError string
+	}{
+		{name: "valid token"},
+		{name: "wrong audience", change: func(claims jwt.MapClaims) { claims["aud"] = "other-client" }, expectedError: "audience"},
+		{name: "wrong issuer", change: func(claims jwt.MapClaims) { claims["iss"] = "https://other.example.com" }, expectedError: "issuer"},
+		{name: "expired", change: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }, expectedError: "expired"},
+		{name: "missing subject", change: func(claims jwt.MapClaims) { delete(claims, "sub") }, expectedError: "missing subject"},
+		{
+			name:          "several audiences without authorized party",
+			change:        func(claims jwt.MapClaims) { claims["aud"] = []string{oidcMockServices.ClientID, "other-client"} },
+			expectedError: "issued to another client",
+		},
+		{
+			name: "unknown key",
+			sign: func(mockProvider *oidcMockServices.Provider, claims jwt.MapClaims) (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+				token.Header["kid"] = "unknown-key"
+				return token.SignedString(otherKey)
+			},
+			expectedError: "unknown signing key",
+		},
+		{
+			name: "symmetric algorithm",
+			sign: func(mockProvider *oidcMockServices.Provider, claims jwt.MapClaims) (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				token.Header["kid"] = oidcMockServices.KeyID
+				return token.SignedString([]byte("secret"))
+			},
+			expectedError: "signing method",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockProvider, provider := newProvider(t)
+
+			claims := mockProvider.IDTokenClaims(identity, "nonce")
+			if test.change != nil {
+				test.change(claims)
+			}
+
+			sign := test.sign
+			if sign == nil {
+				sign = (*oidcMockServices.Provider).SignIDToken
+			}
+			idToken, error := sign(mockProvider, claims)
+			if error != nil {
+				t.Fatal(error)
+			}
+
+			_, error = provider.VerifyIDToken(idToken, "nonce")
+			if test.expectedError == "" {
+				if error != nil {
+					t.Fatal(error)
+				}
+				return
+			}
+
+			if error == nil || !strings.Contains(error.Error(), test.expectedError) {
+				t.Fatalf("error is %v, expected %q", error, test.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package projectService
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Entity types and actions the activity of a project can be filtered by
+var (
+	activityEntityTypes = map[string]bool{
+		auditModel.EntityProject:           true,
+		auditModel.EntityProjectMember:     true,
+		auditModel.EntityProjectInvitation: true,
+		auditModel.EntityNote:              true,
+		auditModel.EntityTask:              true,
+		auditModel.EntityComment:           true,
+	}
+	activityActions = map[string]bool{
+		auditModel.ActionCreate: true,
+		auditModel.ActionUpdate: true,
+		auditModel.ActionDelete: true,
+	}
+)
+
+// Handler function for getting the latest changes made to a project and everything in it
+func (handler *Handler) handleGetActivityByProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	filter, error := parseActivityFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can read the project
+	role, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	events, error := handler.auditStore.GetAuditEventsByProjectID(projectID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// where members connect from is only shown to owners
+	if !projectAccessServices.HasRole(role, projectMemberModel.RoleOwner) {
+		for _, event := range events {
+			event.IPAddress = ""
+			event.UserAgent = ""
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, events)
+}
+
+// parseActivityFilter reads the entityType, entityID, actorID, action, from, to, timezone and limit query parameters
+func parseActivityFilter(request *http.Request) (auditModel.AuditEventFilter, error) {
+	filter := auditModel.AuditEventFilter{Limit: 50}
+	query := request.URL.Query()
+
+	location, error := utils.ParseLocationFromQuery(request)
+	if error != nil {
+		return filter, error
+	}
+
+	filter.From, filter.To, error = utils.ParseTimeRangeFromQuery(request, location)
+	if error != nil {
+		return filter, error
+	}
+
+	if value := query.Get("entityType"); value != "" {
+		if !activityEntityTypes[value] {
+			return filter, fmt.Errorf("invalid entityType")
+		}
+		filter.EntityType = value
+	}
+
+	if value := query.Get("action"); value != "" {
+		if !activityActions[value] {
+			return filter, fmt.Errorf("invalid action")
+		}
+		filter.Action = value
+	}
+
+	if value := query.Get("entityID"); value != "" {
+		entityID, error := uuid.Parse(value)
+		if error != nil {
+			return filter, fmt.Errorf("invalid entityID")
+		}
+		filter.EntityID = entityID
+	}
+
+	if value := query.Get("actorID"); value != "" {
+		actorID, error := uuid.Parse(value)
+		if error != nil {
+			return filter, fmt.Errorf("invalid actorID")
+		}
+		filter.ActorID = actorID
+	}
+
+	if value, error := strconv.Atoi(query.Get("limit")); error == nil {
+		filter.Limit = min(max(value, 1), 200)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,586 @@
+package projectService
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// How long an invitation can be answered
+const invitationExpiration = time.Hour * 24 * 14
+
+// Handler function for getting the members of a project
+func (handler *Handler) handleGetProjectMembers(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can read the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// get the members of the project
+	members, error := handler.memberStore.GetProjectMembers(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, members)
+}
+
+// Handler function for inviting someone to a project by email
+func (handler *Handler) handleInviteProjectMember(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload projectMemberModel.InviteProjectMemberPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user owns the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// check if the invitee is already a member
+	if invitee, error := handler.userStore.GetUserByEmail(payload.Email); error == nil {
+		role, error := handler.memberStore.GetProjectRole(projectID, invitee.ID, workspaceID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		if role != "" {
+			utils.WriteError(writer, http.StatusConflict, fmt.Errorf("%s is already a member of the project", payload.Email))
+			return
+		}
+	}
+
+	// the workspace may only let projects invite its own members
+	allowed, error := handler.canInvite(workspaceID, payload.Email)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !allowed {
+		utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("%s is not a member of the workspace, which only allows inviting its members", payload.Email))
+		return
+	}
+
+	// check if the invitee already has an invitation to answer
+	invitations, error := handler.memberStore.GetPendingProjectInvitationsByProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	for _, invitation := range invitations {
+		if strings.EqualFold(invitation.Email, payload.Email) {
+			utils.WriteError(writer, http.StatusConflict, fmt.Errorf("%s is already invited to the project", payload.Email))
+			return
+		}
+	}
+
+	// get the project for the invitation mail
+	project, error := handler.store.GetProjectByID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	inviter, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// insert the invitation into the database
+	expiresAt := time.Now().Add(invitationExpiration)
+	invitation := projectMemberModel.ProjectInvitation{
+		ProjectID:    projectID,
+		ProjectTitle: project.Title,
+		Email:        payload.Email,
+		Role:         payload.Role,
+		InvitedBy:    uuid.NullUUID{UUID: userID.UUID, Valid: true},
+		Status:       projectMemberModel.InvitationPending,
+		ExpiresAt:    expiresAt,
+	}
+	invitationID, error := handler.memberStore.CreateProjectInvitation(invitation)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordMembershipEvent(request, auditModel.EntityProjectInvitation, auditModel.ActionCreate, projectID, invitationID, nil, &invitation)
+
+	// the invitation is also listed for the invitee once they log in, so a failed mail is not fatal
+	error = handler.mailer.Send(mailServices.Message{
+		To:       payload.Email,
+		Subject:  fmt.Sprintf("%s %s invited you to %s on dev-journal", inviter.FirstName, inviter.LastName, project.Title),
+		TextBody: fmt.Sprintf("Hi,\n\n%s %s invited you to join the project %s on dev-journal as %s. Log in or sign up with this email address and answer the invitation before %s.\n\n%s\n", inviter.FirstName, inviter.LastName, project.Title, strings.ToLower(payload.Role), expiresAt.UTC().Format(time.RFC1123), configs.ServerEnvironmentVariables.ClientURL+"/invitations"),
+	})
+	if error != nil {
+		log.Printf("failed to mail project invitation %s: %v", invitationID, error)
+	}
+
+	// invitees who already have an account also find the invitation in their inbox
+	if invitee, error := handler.userStore.GetUserByEmail(payload.Email); error == nil {
+		notification := notificationModel.Notification{
+			UserID:     invitee.ID,
+			Type:       notificationModel.TypeProjectInvitation,
+			ActorID:    uuid.NullUUID{UUID: userID.UUID, Valid: true},
+			ProjectID:  uuid.NullUUID{UUID: projectID, Valid: true},
+			EntityType: notificationModel.EntityProjectInvitation,
+			EntityID:   invitationID,
+			Message:    fmt.Sprintf("%s %s invited you to the project %s as %s", inviter.FirstName, inviter.LastName, project.Title, strings.ToLower(payload.Role)),
+		}
+		if error := handler.notifier.Notify(notification); error != nil {
+			log.Printf("failed to notify invitee of project invitation %s: %v", invitationID, error)
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"invitationID": invitationID})
+}
+
+// Handler function for getting the pending invitations of a project
+func (handler *Handler) handleGetProjectInvitations(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user owns the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// get the pending invitations of the project
+	invitations, error := handler.memberStore.GetPendingProjectInvitationsByProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, invitations)
+}
+
+// Handler function for revoking an invitation that was not answered yet
+func (handler *Handler) handleRevokeProjectInvitation(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get invitation ID from URL
+	invitationID, error := utils.ParseIDFromURL(request, "invitationID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the invitation exists
+	invitation, error := handler.memberStore.GetProjectInvitationByID(invitationID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if invitation == nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invitation ID does not exist"))
+		return
+	}
+
+	// check if the user owns the project of the invitation
+	if _, error := projectAccessServices.Authorize(handler.memberStore, invitation.ProjectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// revoke the invitation
+	if error := handler.memberStore.SetProjectInvitationStatus(invitationID, projectMemberModel.InvitationRevoked); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	handler.recordInvitationStatus(request, invitation, projectMemberModel.InvitationRevoked)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for changing the role of a project member
+func (handler *Handler) handleUpdateProjectMemberRole(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID and member ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	memberID, error := utils.ParseIDFromURL(request, "userID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload projectMemberModel.UpdateProjectMemberRolePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user owns the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// check if the member exists
+	role, error := handler.validateMember(projectID, memberID, workspaceID)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// update the role of the member
+	if error := handler.memberStore.UpdateProjectMemberRole(projectID, memberID, payload.Role); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	handler.recordMembershipEvent(request, auditModel.EntityProjectMember, auditModel.ActionUpdate, projectID, memberID, map[string]string{"role": role}, map[string]string{"role": payload.Role})
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for removing a member from a project, members can also remove themselves to leave it
+func (handler *Handler) handleRemoveProjectMember(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID and member ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	memberID, error := utils.ParseIDFromURL(request, "userID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// only owners can remove other members
+	minimumRole := projectMemberModel.RoleOwner
+	if memberID == userID.UUID {
+		minimumRole = projectMemberModel.RoleViewer
+	}
+
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, minimumRole); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// check if the member exists
+	role, error := handler.validateMember(projectID, memberID, workspaceID)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// remove the member from the project
+	if error := handler.memberStore.RemoveProjectMember(projectID, memberID); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	handler.recordMembershipEvent(request, auditModel.EntityProjectMember, auditModel.ActionDelete, projectID, memberID, map[string]string{"role": role}, nil)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for getting the pending invitations sent to the user's email
+func (handler *Handler) handleGetMyInvitations(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// get the pending invitations of the user
+	invitations, error := handler.memberStore.GetPendingProjectInvitationsByEmail(user.Email)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, invitations)
+}
+
+// Handler function for accepting an invitation and joining its project
+func (handler *Handler) handleAcceptInvitation(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get invitation ID from URL
+	invitationID, error := utils.ParseIDFromURL(request, "invitationID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// check if the invitation was sent to the user
+	invitation, error := handler.getInvitationForEmail(invitationID, user.Email)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// only the owner of the email address can take the invitation
+	if user.EmailVerified != "True" {
+		utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("email must be verified to accept invitations"))
+		return
+	}
+
+	// join the project
+	if error := handler.memberStore.AcceptProjectInvitation(invitationID, userID.UUID); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	handler.recordInvitationStatus(request, invitation, projectMemberModel.InvitationAccepted)
+	handler.recordMembershipEvent(request, auditModel.EntityProjectMember, auditModel.ActionCreate, invitation.ProjectID, userID.UUID, nil, map[string]string{"role": invitation.Role})
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for declining an invitation
+func (handler *Handler) handleDeclineInvitation(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get invitation ID from URL
+	invitationID, error := utils.ParseIDFromURL(request, "invitationID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// check if the invitation was sent to the user
+	invitation, error := handler.getInvitationForEmail(invitationID, user.Email)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// decline the invitation
+	if error := handler.memberStore.SetProjectInvitationStatus(invitationID, projectMemberModel.InvitationDeclined); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	handler.recordInvitationStatus(request, invitation, projectMemberModel.InvitationDeclined)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// validateMember check if a user is a member of the project and returns their role
+func (handler *Handler) validateMember(projectID uuid.UUID, memberID uuid.UUID, workspaceID uuid.UUID) (string, error) {
+	role, error := handler.memberStore.GetProjectRole(projectID, memberID, workspaceID)
+	if error != nil {
+		return "", error
+	}
+
+	if role == "" {
+		return "", projectAccessServices.NotFound("user is not a member of the project")
+	}
+
+	return role, nil
+}
+
+// recordMembershipEvent records a change of the members or invitations of a project in its activity
+func (handler *Handler) recordMembershipEvent(request *http.Request, entityType string, action string, projectID uuid.UUID, entityID uuid.UUID, before any, after any) {
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: projectID, Valid: true},
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+	}, before, after)
+}
+
+// recordInvitationStatus records the answer to an invitation or its revocation
+func (handler *Handler) recordInvitationStatus(request *http.Request, invitation *projectMemberModel.ProjectInvitation, status string) {
+	handler.recordMembershipEvent(request, auditModel.EntityProjectInvitation, auditModel.ActionUpdate, invitation.ProjectID, invitation.ID, map[string]string{"status": invitation.Status}, map[string]string{"status": status})
+}
+
+// canInvite check if the workspace allows external invitations or the invitee is a member of it, guests are external
+func (handler *Handler) canInvite(workspaceID uuid.UUID, email string) (bool, error) {
+	workspace, error := handler.workspaceStore.GetWorkspaceByID(workspaceID)
+	if error != nil {
+		return false, error
+	}
+
+	if workspace.AllowExternalInvitations == "True" {
+		return true, nil
+	}
+
+	// people without an account cannot be members yet
+	invitee, error := handler.userStore.GetUserByEmail(email)
+	if error != nil {
+		return false, nil
+	}
+
+	role, error := handler.workspaceStore.GetWorkspaceRole(workspaceID, invitee.ID)
+	return role != "" && role != workspaceModel.RoleGuest, error
+}
+
+// getInvitationForEmail retrieves an invitation, invitations sent to other emails are reported as missing
+func (handler *Handler) getInvitationForEmail(invitationID uuid.UUID, email string) (*projectMemberModel.ProjectInvitation, error) {
+	invitation, error := handler.memberStore.GetProjectInvitationByID(invitationID)
+	if error != nil {
+		return nil, error
+	}
+
+	if invitation == nil || !strings.EqualFold(invitation.Email, email) {
+		return nil, projectAccessServices.NotFound("invitation ID does not exist")
+	}
+
+	return invitation, nil
+}
+
+// writeMembershipError writes the response for an error of the member store, conflicts with the current state are 409
+func writeMembershipError(writer http.ResponseWriter, error error) {
+	switch {
+	case errors.Is(error, projectMemberModel.ErrLastOwner), errors.Is(error, projectMemberModel.ErrInvitationNotPending), errors.Is(error, projectMemberModel.ErrAlreadyProjectMember):
+		utils.WriteError(writer, http.StatusConflict, error)
+	default:
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+	}
+}
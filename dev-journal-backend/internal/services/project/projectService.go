@@ -7,23 +7,38 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	columnModel "github.com/hwaengfan/dev-journal-backend/internal/models/column"
 	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
 	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
 	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
 	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	notificationService "github.com/hwaengfan/dev-journal-backend/internal/services/notification"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
 	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
 type Handler struct {
-	store     projectModel.ProjectStore
-	userStore userModel.UserStore
-	noteStore noteModel.NoteStore
-	taskStore taskModel.TaskStore
+	store          projectModel.ProjectStore
+	userStore      userModel.UserStore
+	noteStore      noteModel.NoteStore
+	taskStore      taskModel.TaskStore
+	columnStore    columnModel.ColumnStore
+	memberStore    projectMemberModel.ProjectMemberStore
+	workspaceStore workspaceModel.WorkspaceStore
+	auditStore     auditModel.AuditEventStore
+	mailer         mailServices.Mailer
+	recorder       *auditServices.Recorder
+	notifier       *notificationService.Notifier
 }
 
-func NewHandler(store projectModel.ProjectStore, userStore userModel.UserStore, noteStore noteModel.NoteStore, taskStore taskModel.TaskStore) *Handler {
-	return &Handler{store: store, userStore: userStore, noteStore: noteStore, taskStore: taskStore}
+func NewHandler(store projectModel.ProjectStore, userStore userModel.UserStore, noteStore noteModel.NoteStore, taskStore taskModel.TaskStore, columnStore columnModel.ColumnStore, memberStore projectMemberModel.ProjectMemberStore, workspaceStore workspaceModel.WorkspaceStore, auditStore auditModel.AuditEventStore, mailer mailServices.Mailer, recorder *auditServices.Recorder, notifier *notificationService.Notifier) *Handler {
+	return &Handler{store: store, userStore: userStore, noteStore: noteStore, taskStore: taskStore, columnStore: columnStore, memberStore: memberStore, workspaceStore: workspaceStore, auditStore: auditStore, mailer: mailer, recorder: recorder, notifier: notifier}
 }
 
 func (handler *Handler) RegisterRoutes(router *mux.Router) {
@@ -36,6 +51,26 @@ func (handler *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/projects/update-project-by-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleUpdateProjectByID, handler.userStore)).Methods(http.MethodPut)
 
 	router.HandleFunc("/projects/delete-project-by-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleDeleteProjectByID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/projects/get-activity-by-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetActivityByProjectID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/projects/get-project-members/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetProjectMembers, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/projects/invite-project-member/{projectID}", authenticationServices.JWTAuthentication(handler.handleInviteProjectMember, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/projects/get-project-invitations/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetProjectInvitations, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/projects/revoke-project-invitation/{invitationID}", authenticationServices.JWTAuthentication(handler.handleRevokeProjectInvitation, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/projects/update-project-member-role/{projectID}/{userID}", authenticationServices.JWTAuthentication(handler.handleUpdateProjectMemberRole, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/projects/remove-project-member/{projectID}/{userID}", authenticationServices.JWTAuthentication(handler.handleRemoveProjectMember, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/invitations/get-my-invitations", authenticationServices.JWTAuthentication(handler.handleGetMyInvitations, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/invitations/accept-invitation/{invitationID}", authenticationServices.JWTAuthentication(handler.handleAcceptInvitation, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/invitations/decline-invitation/{invitationID}", authenticationServices.JWTAuthentication(handler.handleDeclineInvitation, handler.userStore)).Methods(http.MethodPost)
 }
 
 // Handler function for creating a new project
@@ -61,9 +96,23 @@ func (handler *Handler) handleCreateNewProject(writer http.ResponseWriter, reque
 		return
 	}
 
+	// check if the user can create projects in the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+	allowed, error := handler.canCreateProject(workspaceID, userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !allowed {
+		utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("only admins can create projects in this workspace"))
+		return
+	}
+
 	// insert the new project into the database
 	projectID, error := handler.store.CreateProject(projectModel.Project{
 		UserID:      userID.UUID,
+		WorkspaceID: workspaceID,
 		Title:       payload.Title,
 		Description: payload.Description,
 		Priority:    payload.Priority,
@@ -74,6 +123,16 @@ func (handler *Handler) handleCreateNewProject(writer http.ResponseWriter, reque
 		return
 	}
 
+	// set up the default board columns
+	if error := handler.columnStore.CreateDefaultColumns(projectID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if project, error := handler.store.GetProjectByID(projectID); error == nil {
+		handler.recordProjectEvent(request, auditModel.ActionCreate, projectID, nil, project)
+	}
+
 	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"projectID": projectID})
 }
 
@@ -86,8 +145,11 @@ func (handler *Handler) handleGetProjectsByUserID(writer http.ResponseWriter, re
 		return
 	}
 
-	// get the user's projects
-	projects, error := handler.store.GetProjectsByUserID(userID.UUID)
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get the user's projects in the workspace
+	projects, error := handler.store.GetProjectsByUserID(userID.UUID, uuid.NullUUID{UUID: workspaceID, Valid: true})
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
@@ -105,6 +167,9 @@ func (handler *Handler) handleGetProjectByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get project ID from URL
 	projectIDString, exists := mux.Vars(request)["projectID"]
 	if !exists {
@@ -118,12 +183,20 @@ func (handler *Handler) handleGetProjectByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// check if the user can read the project
+	role, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
 	// get the project by ID
 	project, error := handler.store.GetProjectByID(projectID)
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to get project by ID: %v", error))
 		return
 	}
+	project.Role = role
 
 	utils.WriteJSON(writer, http.StatusOK, project)
 }
@@ -137,6 +210,9 @@ func (handler *Handler) handleUpdateProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get project ID from URL
 	projectIDString, exists := mux.Vars(request)["projectID"]
 	if !exists {
@@ -150,6 +226,12 @@ func (handler *Handler) handleUpdateProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// check if the user can edit the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
 	// get JSON payload
 	var payload projectModel.UpdateProjectPayload
 	if error := utils.ParseJSON(request, &payload); error != nil {
@@ -164,6 +246,12 @@ func (handler *Handler) handleUpdateProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	project, error := handler.store.GetProjectByID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to get project by ID: %v", error))
+		return
+	}
+
 	// update the project by ID
 	error = handler.store.UpdateProjectByID(projectModel.Project{
 		Title:       payload.Title,
@@ -180,6 +268,11 @@ func (handler *Handler) handleUpdateProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// record what changed, the project is read again since only the provided fields are updated
+	if updatedProject, error := handler.store.GetProjectByID(projectID); error == nil {
+		handler.recordProjectEvent(request, auditModel.ActionUpdate, projectID, project, updatedProject)
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
 
@@ -192,6 +285,9 @@ func (handler *Handler) handleDeleteProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get project ID from URL
 	projectIDString, exists := mux.Vars(request)["projectID"]
 	if !exists {
@@ -205,6 +301,18 @@ func (handler *Handler) handleDeleteProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// check if the user can delete the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleOwner); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	project, error := handler.store.GetProjectByID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to get project by ID: %v", error))
+		return
+	}
+
 	// delete all tasks linked to the project by ID
 	error = handler.taskStore.DeleteTasksByLinkedProjectID(projectID)
 	if error != nil {
@@ -212,6 +320,13 @@ func (handler *Handler) handleDeleteProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// delete the board columns of the project by ID
+	error = handler.columnStore.DeleteColumnsByLinkedProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
 	// delete all notes linked to the project by ID
 	error = handler.noteStore.DeleteNotesByLinkedProjectID(projectID)
 	if error != nil {
@@ -226,5 +341,37 @@ func (handler *Handler) handleDeleteProjectByID(writer http.ResponseWriter, requ
 		return
 	}
 
+	// the notes and tasks deleted with the project are covered by this event
+	handler.recordProjectEvent(request, auditModel.ActionDelete, projectID, project, nil)
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
+
+// canCreateProject check if the user is an admin of the workspace or a member of a workspace that lets members create projects
+func (handler *Handler) canCreateProject(workspaceID uuid.UUID, userID uuid.UUID) (bool, error) {
+	role, error := handler.workspaceStore.GetWorkspaceRole(workspaceID, userID)
+	if error != nil || role == "" || role == workspaceModel.RoleGuest {
+		return false, error
+	}
+
+	if role == workspaceModel.RoleAdmin {
+		return true, nil
+	}
+
+	workspace, error := handler.workspaceStore.GetWorkspaceByID(workspaceID)
+	if error != nil {
+		return false, error
+	}
+
+	return workspace.AllowMemberProjectCreation == "True", nil
+}
+
+// recordProjectEvent records a change of a project in its own activity
+func (handler *Handler) recordProjectEvent(request *http.Request, action string, projectID uuid.UUID, before *projectModel.Project, after *projectModel.Project) {
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: projectID, Valid: true},
+		EntityType: auditModel.EntityProject,
+		EntityID:   projectID,
+		Action:     action,
+	}, before, after)
+}
@@ -0,0 +1,85 @@
+package projectAccessServices
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Rank of each role, a role can do everything a lower ranked role can
+var roleRanks = map[string]int{
+	projectMemberModel.RoleViewer: 1,
+	projectMemberModel.RoleEditor: 2,
+	projectMemberModel.RoleOwner:  3,
+}
+
+// Projects a user is not a member of or of another workspace are reported like missing ones, so their IDs cannot be probed
+var errNotAMember = NotFound("project ID does not exist")
+
+type notFoundError struct {
+	message string
+}
+
+func (error *notFoundError) Error() string {
+	return error.message
+}
+
+// NotFound creates an error WriteError reports like a project the user is not a member of
+func NotFound(message string) error {
+	return &notFoundError{message: message}
+}
+
+type roleError struct {
+	minimumRole string
+}
+
+func (error *roleError) Error() string {
+	return fmt.Sprintf("requires the %s role in the project", error.minimumRole)
+}
+
+// IsNotFound reports whether an error is reported like a project the user is not a member of
+func IsNotFound(error error) bool {
+	var notFound *notFoundError
+	return errors.As(error, &notFound)
+}
+
+// HasRole reports whether a role allows what minimumRole allows
+func HasRole(role string, minimumRole string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[minimumRole]
+}
+
+// Authorize returns the role of a user in a project of the active workspace, failing when they are not a member or their role is below minimumRole
+func Authorize(store projectMemberModel.ProjectMemberStore, projectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID, minimumRole string) (string, error) {
+	role, error := store.GetProjectRole(projectID, userID, workspaceID)
+	if error != nil {
+		return "", error
+	}
+
+	if role == "" {
+		return "", errNotAMember
+	}
+
+	if !HasRole(role, minimumRole) {
+		return "", &roleError{minimumRole: minimumRole}
+	}
+
+	return role, nil
+}
+
+// WriteError writes the response for an error returned by Authorize
+func WriteError(writer http.ResponseWriter, error error) {
+	var notFound *notFoundError
+	var roleTooLow *roleError
+	switch {
+	case errors.As(error, &notFound):
+		utils.WriteError(writer, http.StatusBadRequest, error)
+	case errors.As(error, &roleTooLow):
+		utils.WriteError(writer, http.StatusForbidden, error)
+	default:
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+	}
+}
@@ -0,0 +1,326 @@
+package queueService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jobModel "github.com/hwaengfan/dev-journal-backend/internal/models/job"
+)
+
+// Attempts a job gets unless it asks for another number
+const defaultMaxAttempts = 5
+
+// Failed jobs wait twice as long before each retry, up to the maximum
+const (
+	baseBackoff    = time.Second * 10
+	maximumBackoff = time.Hour
+)
+
+// A JobFunc runs a job of one type, its context is cancelled once the job's lock is lost or the server stops
+type JobFunc func(ctx context.Context, payload json.RawMessage) error
+
+type EnqueueOptions struct {
+	MaxAttempts int
+	Delay       time.Duration // how long to wait before the first attempt
+}
+
+type HandleOptions struct {
+	DeleteOnSuccess bool // jobs are deleted once they succeed instead of being kept for the retention
+}
+
+// The function running the jobs of a type and how they are handled
+type jobHandler struct {
+	run     JobFunc
+	options HandleOptions
+}
+
+// Queue runs the jobs stored in the database on a pool of workers, every server runs its own pool
+type Queue struct {
+	store             jobModel.JobStore
+	handlers          map[string]jobHandler
+	mutex             sync.RWMutex
+	visibilityTimeout time.Duration
+	pollInterval      time.Duration
+	retention         time.Duration
+	wake              chan struct{}
+	stopping          chan struct{}
+	stopOnce          sync.Once
+	context           context.Context
+	cancel            context.CancelFunc
+	waitGroup         sync.WaitGroup
+}
+
+func NewQueue(store jobModel.JobStore, visibilityTimeout time.Duration, pollInterval time.Duration, retention time.Duration) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Queue{
+		store:             store,
+		handlers:          make(map[string]jobHandler),
+		visibilityTimeout: visibilityTimeout,
+		pollInterval:      pollInterval,
+		retention:         retention,
+		wake:              make(chan struct{}, 1),
+		stopping:          make(chan struct{}),
+		context:           ctx,
+		cancel:            cancel,
+	}
+}
+
+// Handle registers the function running the jobs of a type, workers only claim the types they can run
+func (queue *Queue) Handle(jobType string, run JobFunc) {
+	queue.HandleWithOptions(jobType, run, HandleOptions{})
+}
+
+// HandleWithOptions registers the function running the jobs of a type, with its jobs deleted once they succeed
+func (queue *Queue) HandleWithOptions(jobType string, run JobFunc, options HandleOptions) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	queue.handlers[jobType] = jobHandler{run: run, options: options}
+}
+
+// Enqueue adds a job to run as soon as a worker is free
+func (queue *Queue) Enqueue(jobType string, payload any) (uuid.UUID, error) {
+	return queue.EnqueueWithOptions(jobType, payload, EnqueueOptions{})
+}
+
+// EnqueueWithOptions adds a job with its own number of attempts or a delay
+func (queue *Queue) EnqueueWithOptions(jobType string, payload any, options EnqueueOptions) (uuid.UUID, error) {
+	payloadJSON, error := json.Marshal(payload)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("failed to convert job payload to JSON: %v", error)
+	}
+
+	maxAttempts := options.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	jobID, error := queue.store.CreateJob(jobModel.Job{
+		Type:        jobType,
+		Payload:     payloadJSON,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now().Add(options.Delay),
+	})
+	if error != nil {
+		return uuid.Nil, error
+	}
+
+	if options.Delay <= 0 {
+		queue.Wake()
+	}
+
+	return jobID, nil
+}
+
+// Wake makes an idle worker look for due jobs without waiting for the next poll
+func (queue *Queue) Wake() {
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts the workers, they run until the queue is stopped
+func (queue *Queue) Start(workers int) {
+	for range max(workers, 1) {
+		queue.waitGroup.Add(1)
+		go queue.work(uuid.New())
+	}
+}
+
+// Stop stops claiming jobs and waits for the running ones, jobs still running once ctx is done are cancelled and retried later
+func (queue *Queue) Stop(ctx context.Context) error {
+	queue.stopOnce.Do(func() { close(queue.stopping) })
+
+	stopped := make(chan struct{})
+	go func() {
+		queue.waitGroup.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		queue.cancel()
+		<-stopped
+		return fmt.Errorf("cancelled running jobs: %v", ctx.Err())
+	}
+}
+
+// PurgeSucceededJobs deletes the jobs that succeeded longer than the retention ago, dead jobs are kept until retried
+func (queue *Queue) PurgeSucceededJobs(now time.Time) error {
+	deleted, error := queue.store.DeleteSucceededJobs(now.Add(-queue.retention))
+	if error != nil {
+		return error
+	}
+
+	if deleted > 0 {
+		log.Printf("purged %d succeeded jobs", deleted)
+	}
+
+	return nil
+}
+
+// work claims and runs due jobs one after the other, polling while there are none
+func (queue *Queue) work(workerID uuid.UUID) {
+	defer queue.waitGroup.Done()
+
+	for {
+		select {
+		case <-queue.stopping:
+			return
+		default:
+		}
+
+		now := time.Now()
+		job, error := queue.store.ClaimNextJob(queue.types(), workerID, now, now.Add(queue.visibilityTimeout))
+		if error != nil {
+			log.Printf("failed to claim job: %v", error)
+		}
+
+		if job != nil {
+			queue.process(workerID, job)
+			continue
+		}
+
+		select {
+		case <-queue.stopping:
+			return
+		case <-queue.wake:
+		case <-time.After(queue.pollInterval):
+		}
+	}
+}
+
+// process runs a claimed job and records the outcome, failed jobs are retried with backoff until they run out of attempts
+func (queue *Queue) process(workerID uuid.UUID, job *jobModel.Job) {
+	// the last attempt of the job ran out of time on a worker that is gone
+	if job.Attempts > job.MaxAttempts {
+		queue.kill(workerID, job, fmt.Errorf("lock expired during the last attempt"))
+		return
+	}
+
+	queue.mutex.RLock()
+	handler, exists := queue.handlers[job.Type]
+	queue.mutex.RUnlock()
+
+	if !exists {
+		queue.kill(workerID, job, fmt.Errorf("no handler for job type %q", job.Type))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(queue.context)
+	go queue.keepLocked(ctx, cancel, workerID, job)
+	error := runJob(ctx, handler.run, job)
+	cancel()
+
+	now := time.Now()
+	if error == nil && handler.options.DeleteOnSuccess {
+		if error := queue.store.DeleteJob(job.ID, workerID); error != nil {
+			log.Printf("failed to delete job %s: %v", job.ID, error)
+		}
+		return
+	}
+
+	if error == nil {
+		if error := queue.store.CompleteJob(job.ID, workerID, now); error != nil {
+			log.Printf("failed to complete job %s: %v", job.ID, error)
+		}
+		return
+	}
+
+	// jobs cut off by a shutdown are picked up again right away
+	if queue.context.Err() != nil {
+		if error := queue.store.RetryJob(job.ID, workerID, now, "interrupted by shutdown"); error != nil {
+			log.Printf("failed to retry job %s: %v", job.ID, error)
+		}
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		queue.kill(workerID, job, error)
+		return
+	}
+
+	log.Printf("job %s of type %s failed on attempt %d: %v", job.ID, job.Type, job.Attempts, error)
+	message := error.Error()
+	if error := queue.store.RetryJob(job.ID, workerID, now.Add(backoff(job.Attempts)), message); error != nil {
+		log.Printf("failed to retry job %s: %v", job.ID, error)
+	}
+}
+
+// keepLocked extends the lock of a running job until ctx is done, the job is cancelled once another worker took it over
+func (queue *Queue) keepLocked(ctx context.Context, cancel context.CancelFunc, workerID uuid.UUID, job *jobModel.Job) {
+	ticker := time.NewTicker(queue.visibilityTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		extended, error := queue.store.ExtendJobLock(job.ID, workerID, time.Now().Add(queue.visibilityTimeout))
+		if error != nil {
+			log.Printf("failed to extend lock of job %s: %v", job.ID, error)
+			continue
+		}
+
+		if !extended {
+			log.Printf("lost lock of job %s", job.ID)
+			cancel()
+			return
+		}
+	}
+}
+
+// kill moves a job to the dead letters
+func (queue *Queue) kill(workerID uuid.UUID, job *jobModel.Job, reason error) {
+	log.Printf("job %s of type %s is dead after %d attempts: %v", job.ID, job.Type, job.Attempts, reason)
+	if error := queue.store.KillJob(job.ID, workerID, reason.Error()); error != nil {
+		log.Printf("failed to kill job %s: %v", job.ID, error)
+	}
+}
+
+// types lists the job types the workers can run
+func (queue *Queue) types() []string {
+	queue.mutex.RLock()
+	defer queue.mutex.RUnlock()
+
+	types := make([]string, 0, len(queue.handlers))
+	for jobType := range queue.handlers {
+		types = append(types, jobType)
+	}
+
+	return types
+}
+
+// runJob runs a job, a panicking job fails like any other
+func runJob(ctx context.Context, run JobFunc, job *jobModel.Job) (error error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			error = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return run(ctx, job.Payload)
+}
+
+// backoff returns how long a job waits after its failed attempt, with some jitter so failed jobs do not retry all at once
+func backoff(attempts int) time.Duration {
+	delay := maximumBackoff
+	if attempts < 20 {
+		delay = min(baseBackoff<<(attempts-1), maximumBackoff)
+	}
+
+	return delay + rand.N(delay/5+1)
+}
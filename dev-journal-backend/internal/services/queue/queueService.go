@@ -0,0 +1,177 @@
+package queueService
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	jobModel "github.com/hwaengfan/dev-journal-backend/internal/models/job"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Jobs listed when no limit is asked for
+const defaultJobListSize = 50
+
+type Handler struct {
+	store     jobModel.JobStore
+	userStore userModel.UserStore
+	queue     *Queue
+}
+
+func NewHandler(store jobModel.JobStore, userStore userModel.UserStore, queue *Queue) *Handler {
+	return &Handler{store: store, userStore: userStore, queue: queue}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/jobs/get-jobs", authenticationServices.JWTAuthentication(handler.handleGetJobs, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/jobs/get-job-counts", authenticationServices.JWTAuthentication(handler.handleGetJobCounts, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/jobs/get-job-by-ID/{jobID}", authenticationServices.JWTAuthentication(handler.handleGetJobByID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/jobs/retry-job-by-ID/{jobID}", authenticationServices.JWTAuthentication(handler.handleRetryJobByID, handler.userStore)).Methods(http.MethodPost)
+}
+
+// Handler function for listing the latest jobs, filtered by status and type
+func (handler *Handler) handleGetJobs(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is an administrator
+	if !handler.isAdministrator(request) {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	query := request.URL.Query()
+	filter := jobModel.JobFilter{Status: strings.ToUpper(query.Get("status")), Type: query.Get("type"), Limit: defaultJobListSize}
+	if value, error := strconv.Atoi(query.Get("limit")); error == nil {
+		filter.Limit = min(max(value, 1), 200)
+	}
+
+	if filter.Status != "" && !isJobStatus(filter.Status) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("unknown job status %q", filter.Status))
+		return
+	}
+
+	jobs, error := handler.store.GetJobs(filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, jobs)
+}
+
+// Handler function for counting the jobs in each status
+func (handler *Handler) handleGetJobCounts(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is an administrator
+	if !handler.isAdministrator(request) {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	counts, error := handler.store.CountJobsByStatus()
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, counts)
+}
+
+// Handler function for getting a job by ID
+func (handler *Handler) handleGetJobByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is an administrator
+	if !handler.isAdministrator(request) {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get job ID from URL
+	jobID, error := utils.ParseIDFromURL(request, "jobID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	job, error := handler.store.GetJobByID(jobID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if job == nil {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("job not found"))
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, job)
+}
+
+// Handler function for retrying a dead job with a fresh set of attempts
+func (handler *Handler) handleRetryJobByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is an administrator
+	if !handler.isAdministrator(request) {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get job ID from URL
+	jobID, error := utils.ParseIDFromURL(request, "jobID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	requeued, error := handler.store.RequeueDeadJob(jobID, time.Now())
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !requeued {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("only dead jobs can be retried"))
+		return
+	}
+
+	handler.queue.Wake()
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]uuid.UUID{"jobID": jobID})
+}
+
+// isAdministrator checks if the logged in user has a verified email listed in the administrator emails
+func (handler *Handler) isAdministrator(request *http.Request) bool {
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		return false
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil || user.EmailVerified != "True" {
+		return false
+	}
+
+	for _, email := range strings.Split(configs.GlobalEnvironmentVariables.AdminEmails, ",") {
+		if email = strings.TrimSpace(email); email != "" && strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isJobStatus checks if a job status exists
+func isJobStatus(status string) bool {
+	for _, candidate := range jobModel.Statuses {
+		if candidate == status {
+			return true
+		}
+	}
+
+	return false
+}
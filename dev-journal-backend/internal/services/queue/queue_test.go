@@ -0,0 +1,393 @@
+package queueService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	jobModel "github.com/hwaengfan/dev-journal-backend/internal/models/job"
+)
+
+// memoryJobStore keeps jobs the way the jobs table does, methods the queue does not use panic when called
+type memoryJobStore struct {
+	jobModel.JobStore
+	mutex sync.Mutex
+	jobs  map[uuid.UUID]*jobModel.Job
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{jobs: make(map[uuid.UUID]*jobModel.Job)}
+}
+
+func (store *memoryJobStore) CreateJob(job jobModel.Job) (uuid.UUID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	job.ID, job.Status, job.DateCreated = uuid.New(), jobModel.StatusPending, time.Now()
+	store.jobs[job.ID] = &job
+	return job.ID, nil
+}
+
+func (store *memoryJobStore) ClaimNextJob(types []string, workerID uuid.UUID, now time.Time, lockedUntil time.Time) (*jobModel.Job, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var next *jobModel.Job
+	for _, job := range store.jobs {
+		due := job.Status == jobModel.StatusPending && !job.RunAt.After(now)
+		expired := job.Status == jobModel.StatusRunning && job.LockedUntil.Before(now)
+		if slices.Contains(types, job.Type) && (due || expired) && (next == nil || job.RunAt.Before(next.RunAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status, next.Attempts = jobModel.StatusRunning, next.Attempts+1
+	next.LockedBy, next.LockedUntil = uuid.NullUUID{UUID: workerID, Valid: true}, &lockedUntil
+	claimed := *next
+	return &claimed, nil
+}
+
+func (store *memoryJobStore) ExtendJobLock(id uuid.UUID, workerID uuid.UUID, lockedUntil time.Time) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	job := store.heldJob(id, workerID)
+	if job == nil || job.Status != jobModel.StatusRunning {
+		return false, nil
+	}
+
+	job.LockedUntil = &lockedUntil
+	return true, nil
+}
+
+func (store *memoryJobStore) CompleteJob(id uuid.UUID, workerID uuid.UUID, completedAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if job := store.heldJob(id, workerID); job != nil {
+		job.Status, job.LockedBy, job.LockedUntil, job.DateCompleted = jobModel.StatusSucceeded, uuid.NullUUID{}, nil, &completedAt
+	}
+	return nil
+}
+
+func (store *memoryJobStore) DeleteJob(id uuid.UUID, workerID uuid.UUID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.heldJob(id, workerID) != nil {
+		delete(store.jobs, id)
+	}
+	return nil
+}
+
+func (store *memoryJobStore) RetryJob(id uuid.UUID, workerID uuid.UUID, runAt time.Time, message string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if job := store.heldJob(id, workerID); job != nil {
+		job.Status, job.LockedBy, job.LockedUntil, job.RunAt, job.LastError = jobModel.StatusPending, uuid.NullUUID{}, nil, runAt, &message
+	}
+	return nil
+}
+
+func (store *memoryJobStore) KillJob(id uuid.UUID, workerID uuid.UUID, message string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if job := store.heldJob(id, workerID); job != nil {
+		job.Status, job.LockedBy, job.LockedUntil, job.LastError = jobModel.StatusDead, uuid.NullUUID{}, nil, &message
+	}
+	return nil
+}
+
+// heldJob returns a job while the worker still holds its lock
+func (store *memoryJobStore) heldJob(id uuid.UUID, workerID uuid.UUID) *jobModel.Job {
+	job, exists := store.jobs[id]
+	if !exists || job.LockedBy != (uuid.NullUUID{UUID: workerID, Valid: true}) {
+		return nil
+	}
+
+	return job
+}
+
+// job returns a copy of a stored job, nil once it is deleted
+func (store *memoryJobStore) job(id uuid.UUID) *jobModel.Job {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	job, exists := store.jobs[id]
+	if !exists {
+		return nil
+	}
+
+	copied := *job
+	return &copied
+}
+
+// claim claims the next due job for a new worker, failing the test when there is none
+func claim(t *testing.T, queue *Queue, now time.Time) (uuid.UUID, *jobModel.Job) {
+	t.Helper()
+
+	workerID := uuid.New()
+	job, error := queue.store.ClaimNextJob(queue.types(), workerID, now, now.Add(queue.visibilityTimeout))
+	if error != nil {
+		t.Fatal(error)
+	}
+	if job == nil {
+		t.Fatal("no job was claimed")
+	}
+
+	return workerID, job
+}
+
+func TestQueueRunsClaimedJobs(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, time.Minute, time.Hour, time.Hour)
+
+	ran := make(chan string, 2)
+	run := func(ctx context.Context, payload json.RawMessage) error {
+		var message string
+		if error := json.Unmarshal(payload, &message); error != nil {
+			return error
+		}
+		ran <- message
+		return nil
+	}
+	queue.Handle("kept", run)
+	queue.HandleWithOptions("deleted", run, HandleOptions{DeleteOnSuccess: true})
+
+	keptID, error := queue.Enqueue("kept", "first")
+	if error != nil {
+		t.Fatal(error)
+	}
+	deletedID, error := queue.Enqueue("deleted", "second")
+	if error != nil {
+		t.Fatal(error)
+	}
+	delayedID, error := queue.EnqueueWithOptions("kept", "later", EnqueueOptions{Delay: time.Hour})
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	queue.Start(2)
+	for range 2 {
+		select {
+		case <-ran:
+		case <-time.After(5 * time.Second):
+			t.Fatal("queued jobs did not run")
+		}
+	}
+	if error := queue.Stop(context.Background()); error != nil {
+		t.Fatal(error)
+	}
+
+	if job := store.job(keptID); job == nil || job.Status != jobModel.StatusSucceeded || job.Attempts != 1 {
+		t.Errorf("job is %+v, expected it to have succeeded on the first attempt", job)
+	}
+	if job := store.job(deletedID); job != nil {
+		t.Errorf("job is %+v, expected it to be deleted once it succeeded", job)
+	}
+	if job := store.job(delayedID); job == nil || job.Status != jobModel.StatusPending || job.Attempts != 0 {
+		t.Errorf("job is %+v, expected it to wait for its delay", job)
+	}
+}
+
+func TestQueueRetriesFailedJobsWithBackoff(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, time.Minute, time.Hour, time.Hour)
+	queue.Handle("failing", func(ctx context.Context, payload json.RawMessage) error {
+		return fmt.Errorf("mail server unavailable")
+	})
+
+	jobID, error := queue.EnqueueWithOptions("failing", nil, EnqueueOptions{MaxAttempts: 3})
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	now := time.Now()
+	for attempt := 1; attempt < 3; attempt++ {
+		workerID, job := claim(t, queue, now)
+		failedAt := time.Now()
+		queue.process(workerID, job)
+
+		// the job waits out its backoff before it is claimed again
+		retried := store.job(jobID)
+		minimum := failedAt.Add(baseBackoff << (attempt - 1))
+		if retried.Status != jobModel.StatusPending || retried.RunAt.Before(minimum) || retried.RunAt.After(minimum.Add(time.Minute)) {
+			t.Fatalf("job after attempt %d is %+v, expected it to be retried after %v", attempt, retried, minimum)
+		}
+		if retried.LastError == nil || *retried.LastError != "mail server unavailable" {
+			t.Fatalf("job after attempt %d recorded error %v", attempt, retried.LastError)
+		}
+		if job, _ := store.ClaimNextJob(queue.types(), uuid.New(), minimum.Add(-time.Second), minimum); job != nil {
+			t.Fatalf("job was claimed again before its backoff after attempt %d", attempt)
+		}
+
+		now = retried.RunAt
+	}
+
+	// the last attempt moves the job to the dead letters
+	workerID, job := claim(t, queue, now)
+	queue.process(workerID, job)
+	if dead := store.job(jobID); dead.Status != jobModel.StatusDead || dead.Attempts != 3 {
+		t.Fatalf("job after its last attempt is %+v, expected it to be dead", dead)
+	}
+	if job, _ := store.ClaimNextJob(queue.types(), uuid.New(), now.Add(maximumBackoff*2), now.Add(maximumBackoff*3)); job != nil {
+		t.Fatal("dead job was claimed again")
+	}
+}
+
+func TestQueueKillsPanickingJobsOnTheirLastAttempt(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, time.Minute, time.Hour, time.Hour)
+	queue.Handle("panicking", func(ctx context.Context, payload json.RawMessage) error {
+		panic("nil map")
+	})
+
+	jobID, error := queue.EnqueueWithOptions("panicking", nil, EnqueueOptions{MaxAttempts: 1})
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	workerID, job := claim(t, queue, time.Now())
+	queue.process(workerID, job)
+	if dead := store.job(jobID); dead.Status != jobModel.StatusDead || dead.LastError == nil || *dead.LastError != "job panicked: nil map" {
+		t.Fatalf("job is %+v, expected it to be dead with the panic as its error", dead)
+	}
+}
+
+func TestQueueTakesOverJobsPastTheirVisibilityTimeout(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, time.Minute, time.Hour, time.Hour)
+	queue.Handle("slow", func(ctx context.Context, payload json.RawMessage) error {
+		return nil
+	})
+
+	jobID, error := queue.EnqueueWithOptions("slow", nil, EnqueueOptions{MaxAttempts: 2})
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	// the first worker stops giving signs of life, the job stays locked until its visibility timeout
+	now := time.Now()
+	firstWorkerID, _ := claim(t, queue, now)
+	if job, _ := store.ClaimNextJob(queue.types(), uuid.New(), now.Add(queue.visibilityTimeout/2), now.Add(queue.visibilityTimeout)); job != nil {
+		t.Fatal("job was taken over while its lock was still held")
+	}
+
+	later := now.Add(queue.visibilityTimeout + time.Second)
+	secondWorkerID, job := claim(t, queue, later)
+	if job.Attempts != 2 {
+		t.Fatalf("taken over job is on attempt %d, expected 2", job.Attempts)
+	}
+
+	// the first worker lost the job and cannot extend or finish it anymore
+	if extended, _ := store.ExtendJobLock(jobID, firstWorkerID, later.Add(time.Minute)); extended {
+		t.Fatal("worker that lost the job extended its lock")
+	}
+	if error := store.CompleteJob(jobID, firstWorkerID, later); error != nil {
+		t.Fatal(error)
+	}
+	if running := store.job(jobID); running.Status != jobModel.StatusRunning || running.LockedBy.UUID != secondWorkerID {
+		t.Fatalf("job is %+v, expected it to stay with the worker that took it over", running)
+	}
+
+	queue.process(secondWorkerID, job)
+	if done := store.job(jobID); done.Status != jobModel.StatusSucceeded {
+		t.Fatalf("job is %+v, expected it to have succeeded", done)
+	}
+}
+
+func TestQueueKillsJobsWhoseLastAttemptTimedOut(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, time.Minute, time.Hour, time.Hour)
+	ran := false
+	queue.Handle("slow", func(ctx context.Context, payload json.RawMessage) error {
+		ran = true
+		return nil
+	})
+
+	jobID, error := queue.EnqueueWithOptions("slow", nil, EnqueueOptions{MaxAttempts: 1})
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	// the worker of the only attempt disappeared, the job is not run a second time
+	now := time.Now()
+	claim(t, queue, now)
+	workerID, job := claim(t, queue, now.Add(queue.visibilityTimeout+time.Second))
+	queue.process(workerID, job)
+
+	if ran {
+		t.Fatal("job ran again after its last attempt timed out")
+	}
+	if dead := store.job(jobID); dead.Status != jobModel.StatusDead {
+		t.Fatalf("job is %+v, expected it to be dead", dead)
+	}
+}
+
+func TestQueueCancelsJobsWhenTheirLockIsLost(t *testing.T) {
+	store := newMemoryJobStore()
+	queue := NewQueue(store, 30*time.Millisecond, time.Hour, time.Hour)
+	queue.Handle("slow", func(ctx context.Context, payload json.RawMessage) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+
+	jobID, error := queue.Enqueue("slow", nil)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	workerID, job := claim(t, queue, time.Now())
+
+	// another worker takes the job over while it runs
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		store.mutex.Lock()
+		store.jobs[jobID].LockedBy = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		store.mutex.Unlock()
+	}()
+
+	start := time.Now()
+	queue.process(workerID, job)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("job kept running for %v after its lock was lost", elapsed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		minimum  time.Duration
+	}{
+		{attempts: 1, minimum: baseBackoff},
+		{attempts: 2, minimum: 2 * baseBackoff},
+		{attempts: 4, minimum: 8 * baseBackoff},
+		{attempts: 12, minimum: maximumBackoff},
+		{attempts: 60, minimum: maximumBackoff},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("attempt %d", test.attempts), func(t *testing.T) {
+			// jitter adds up to a fifth of the delay
+			for range 100 {
+				if delay := backoff(test.attempts); delay < test.minimum || delay > test.minimum+test.minimum/5 {
+					t.Fatalf("backoff is %v, expected between %v and %v", delay, test.minimum, test.minimum+test.minimum/5)
+				}
+			}
+		})
+	}
+}
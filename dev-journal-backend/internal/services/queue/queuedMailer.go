@@ -0,0 +1,71 @@
+package queueService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+)
+
+// Type of the jobs sending a mail
+const JobSendMail = "send-mail"
+
+// A MailComposer builds a queued mail from its reference when it is sent, so secrets like links with tokens are never stored with the job
+type MailComposer func(ctx context.Context, reference json.RawMessage) (mailServices.Message, error)
+
+// ErrMailNotNeeded is returned by a MailComposer when there is nothing to send anymore, like for a token used in the meantime
+var ErrMailNotNeeded = fmt.Errorf("mail is not needed anymore")
+
+// QueuedMailer sends mails from the queue so they are retried while the mail server is unavailable
+// Sent mails are deleted from the queue, the ones that failed for good are kept as dead jobs
+type QueuedMailer struct {
+	queue  *Queue
+	mailer mailServices.Mailer
+}
+
+// NewQueuedMailer returns a mailer queueing its mails, the workers send them with mailer
+func NewQueuedMailer(queue *Queue, mailer mailServices.Mailer) *QueuedMailer {
+	queue.HandleWithOptions(JobSendMail, func(ctx context.Context, payload json.RawMessage) error {
+		var message mailServices.Message
+		if error := json.Unmarshal(payload, &message); error != nil {
+			return fmt.Errorf("failed to read queued mail: %v", error)
+		}
+
+		return mailer.Send(message)
+	}, HandleOptions{DeleteOnSuccess: true})
+
+	return &QueuedMailer{queue: queue, mailer: mailer}
+}
+
+// Send queues a mail, it is only sent once a worker gets to it
+func (mailer *QueuedMailer) Send(message mailServices.Message) error {
+	_, error := mailer.queue.Enqueue(JobSendMail, message)
+	return error
+}
+
+// Compose registers how the mails of a type are built from their references
+func (mailer *QueuedMailer) Compose(mailType string, compose MailComposer) {
+	mailer.queue.HandleWithOptions(composedMailJobType(mailType), func(ctx context.Context, payload json.RawMessage) error {
+		message, error := compose(ctx, payload)
+		if error == ErrMailNotNeeded {
+			return nil
+		}
+		if error != nil {
+			return fmt.Errorf("failed to compose %s mail: %v", mailType, error)
+		}
+
+		return mailer.mailer.Send(message)
+	}, HandleOptions{DeleteOnSuccess: true})
+}
+
+// SendComposed queues a mail of a type registered with Compose, only the reference is stored until it is sent
+func (mailer *QueuedMailer) SendComposed(mailType string, reference any) error {
+	_, error := mailer.queue.Enqueue(composedMailJobType(mailType), reference)
+	return error
+}
+
+// composedMailJobType returns the type of the jobs sending the mails of a type
+func composedMailJobType(mailType string) string {
+	return JobSendMail + ":" + mailType
+}
@@ -0,0 +1,155 @@
+package recurrenceServices
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	columnModel "github.com/hwaengfan/dev-journal-backend/internal/models/column"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+type Scheduler struct {
+	taskStore   taskModel.TaskStore
+	columnStore columnModel.ColumnStore
+}
+
+func NewScheduler(taskStore taskModel.TaskStore, columnStore columnModel.ColumnStore) *Scheduler {
+	return &Scheduler{taskStore: taskStore, columnStore: columnStore}
+}
+
+// GenerateScheduledOccurrences creates the next occurrence of every scheduled recurring task whose latest occurrence is due, run it on one server at a time
+func (scheduler *Scheduler) GenerateScheduledOccurrences(now time.Time) error {
+	tasks, error := scheduler.taskStore.GetLatestScheduledRecurringTasks()
+	if error != nil {
+		return error
+	}
+
+	today := truncateToDay(now)
+	for _, task := range tasks {
+		dueDate, error := ParseDate(task.DueDate)
+		if error != nil || dueDate.After(today) {
+			continue
+		}
+
+		if _, error := scheduler.GenerateNextOccurrence(task); error != nil {
+			log.Printf("failed to generate next occurrence of task %s: %v", task.ID, error)
+		}
+	}
+
+	return nil
+}
+
+// GenerateNextOccurrence creates the occurrence following a recurring task unless the series has ended or it already exists
+func (scheduler *Scheduler) GenerateNextOccurrence(task *taskModel.Task) (uuid.UUID, error) {
+	if task.RecurrenceRule == nil || !task.RecurrenceSeriesID.Valid {
+		return uuid.Nil, nil
+	}
+
+	next, found, error := NextOccurrence(task)
+	if error != nil || !found {
+		return uuid.Nil, error
+	}
+
+	// the occurrence may already exist from an earlier completion or tick
+	series, error := scheduler.taskStore.GetTasksByRecurrenceSeriesID(task.RecurrenceSeriesID.UUID)
+	if error != nil {
+		return uuid.Nil, error
+	}
+
+	for _, occurrence := range series {
+		if dueDate, error := ParseDate(occurrence.DueDate); error == nil && !dueDate.Before(next) {
+			return uuid.Nil, nil
+		}
+	}
+
+	// the new occurrence starts at the end of the board's first column
+	columnID := uuid.NullUUID{}
+	position := 0
+	columns, error := scheduler.columnStore.GetColumnsByLinkedProjectID(task.LinkedProjectID)
+	if error != nil {
+		return uuid.Nil, error
+	}
+
+	if len(columns) > 0 {
+		columnID = uuid.NullUUID{UUID: columns[0].ID, Valid: true}
+		position, error = scheduler.taskStore.CountTasksByColumnID(columns[0].ID)
+		if error != nil {
+			return uuid.Nil, error
+		}
+	}
+
+	dueDate := next.Format(time.DateOnly)
+	return scheduler.taskStore.CreateTask(taskModel.Task{
+		LinkedProjectID:      task.LinkedProjectID,
+		ParentTaskID:         task.ParentTaskID,
+		ColumnID:             columnID,
+		Position:             position,
+		Description:          task.Description,
+		AssigneeID:           task.AssigneeID,
+		Completed:            "False",
+		DueDate:              &dueDate,
+		RecurrenceRule:       task.RecurrenceRule,
+		RecurrenceMode:       task.RecurrenceMode,
+		RecurrenceStart:      task.RecurrenceStart,
+		RecurrenceExceptions: task.RecurrenceExceptions,
+		RecurrenceSeriesID:   task.RecurrenceSeriesID,
+	})
+}
+
+// NextOccurrence returns the occurrence following a recurring task's due date, false once the series has ended
+func NextOccurrence(task *taskModel.Task) (time.Time, bool, error) {
+	occurrences, error := UpcomingOccurrences(task, 1)
+	if error != nil || len(occurrences) == 0 {
+		return time.Time{}, false, error
+	}
+
+	return occurrences[0], true, nil
+}
+
+// UpcomingOccurrences returns up to limit occurrences following a recurring task's due date
+func UpcomingOccurrences(task *taskModel.Task, limit int) ([]time.Time, error) {
+	if task.RecurrenceRule == nil {
+		return nil, nil
+	}
+
+	rule, error := ParseRule(*task.RecurrenceRule)
+	if error != nil {
+		return nil, error
+	}
+
+	start, error := ParseDate(task.RecurrenceStart)
+	if error != nil {
+		return nil, fmt.Errorf("invalid recurrence start: %v", error)
+	}
+
+	after := start
+	if dueDate, error := ParseDate(task.DueDate); error == nil {
+		after = dueDate
+	}
+
+	exceptions := make([]time.Time, 0, len(task.RecurrenceExceptions))
+	for _, exception := range task.RecurrenceExceptions {
+		if date, error := ParseDate(&exception); error == nil {
+			exceptions = append(exceptions, date)
+		}
+	}
+
+	return rule.Between(start, after.AddDate(0, 0, 1), limit, exceptions), nil
+}
+
+// ParseDate parses a date as returned by MySQL or sent by a client
+func ParseDate(date *string) (time.Time, error) {
+	if date == nil {
+		return time.Time{}, fmt.Errorf("missing date")
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, error := time.Parse(layout, *date); error == nil {
+			return truncateToDay(parsed), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", *date)
+}
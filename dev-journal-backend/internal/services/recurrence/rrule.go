@@ -0,0 +1,320 @@
+package recurrenceServices
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Longest stretch searched past the start of the window for the next occurrence before giving up
+const searchLimitInDays = 366 * 10
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// ByDay is a BYDAY entry, an ordinal of 0 matches every such weekday of the period
+type ByDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule is a date based subset of an RFC 5545 RRULE
+type Rule struct {
+	Frequency  Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []ByDay
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+// ParseRule parses an RRULE such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", the "RRULE:" prefix is optional
+func ParseRule(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, partValue, found := strings.Cut(part, "=")
+		if !found || partValue == "" {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = Frequency(strings.ToUpper(partValue))
+			if rule.Frequency != Daily && rule.Frequency != Weekly && rule.Frequency != Monthly && rule.Frequency != Yearly {
+				return nil, fmt.Errorf("unsupported frequency %q", partValue)
+			}
+		case "INTERVAL":
+			interval, error := strconv.Atoi(partValue)
+			if error != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid interval %q", partValue)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, error := strconv.Atoi(partValue)
+			if error != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count %q", partValue)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, error := parseUntil(partValue)
+			if error != nil {
+				return nil, error
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				byDay, error := parseByDay(day)
+				if error != nil {
+					return nil, error
+				}
+				rule.ByDay = append(rule.ByDay, byDay)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				monthDay, error := strconv.Atoi(day)
+				if error != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid month day %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(partValue, ",") {
+				monthNumber, error := strconv.Atoi(month)
+				if error != nil || monthNumber < 1 || monthNumber > 12 {
+					return nil, fmt.Errorf("invalid month %q", month)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(monthNumber))
+			}
+		case "WKST":
+			// weeks always start on Monday
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %q", key)
+		}
+	}
+
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("recurrence rule is missing FREQ")
+	}
+
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("recurrence rule cannot have both COUNT and UNTIL")
+	}
+
+	return rule, nil
+}
+
+// Between returns up to limit occurrences on or after from for a series starting at start, skipping the exception dates
+func (rule *Rule) Between(start time.Time, from time.Time, limit int, exceptions []time.Time) []time.Time {
+	start = truncateToDay(start)
+	from = truncateToDay(from)
+
+	skipped := make(map[time.Time]bool)
+	for _, exception := range exceptions {
+		skipped[truncateToDay(exception)] = true
+	}
+
+	windowStart := start
+	if from.After(start) {
+		windowStart = from
+	}
+
+	// the search starts at the window, except for series with a COUNT which are counted from their start
+	first := windowStart
+	if rule.Count > 0 {
+		first = start
+	}
+	last := windowStart.AddDate(0, 0, searchLimitInDays)
+
+	occurrences := make([]time.Time, 0, limit)
+	index := 0
+	for day := first; len(occurrences) < limit && !day.After(last); day = day.AddDate(0, 0, 1) {
+		if rule.Until != nil && day.After(*rule.Until) {
+			break
+		}
+
+		if !rule.matches(start, day) {
+			continue
+		}
+
+		// COUNT includes occurrences that were skipped
+		index++
+		if rule.Count > 0 && index > rule.Count {
+			break
+		}
+
+		if !day.Before(from) && !skipped[day] {
+			occurrences = append(occurrences, day)
+		}
+	}
+
+	return occurrences
+}
+
+// matches checks whether a day is an occurrence of a series starting at start
+func (rule *Rule) matches(start time.Time, day time.Time) bool {
+	// the day has to fall into a period selected by the interval
+	var period int
+	switch rule.Frequency {
+	case Daily:
+		period = int(day.Sub(start).Hours() / 24)
+	case Weekly:
+		period = int(startOfWeek(day).Sub(startOfWeek(start)).Hours() / 24 / 7)
+	case Monthly:
+		period = (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+	case Yearly:
+		period = day.Year() - start.Year()
+	}
+	if period%rule.Interval != 0 {
+		return false
+	}
+
+	if len(rule.ByMonth) > 0 && !containsMonth(rule.ByMonth, day.Month()) {
+		return false
+	}
+
+	if len(rule.ByMonthDay) > 0 && !matchesMonthDay(rule.ByMonthDay, day) {
+		return false
+	}
+
+	if len(rule.ByDay) > 0 && !rule.matchesByDay(day) {
+		return false
+	}
+
+	// without BY* parts the start date decides which days of the period occur
+	switch rule.Frequency {
+	case Weekly:
+		if len(rule.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+	case Monthly:
+		if len(rule.ByDay) == 0 && len(rule.ByMonthDay) == 0 {
+			return day.Day() == start.Day()
+		}
+	case Yearly:
+		if len(rule.ByDay) == 0 && len(rule.ByMonthDay) == 0 {
+			if len(rule.ByMonth) == 0 && day.Month() != start.Month() {
+				return false
+			}
+			return day.Day() == start.Day()
+		}
+	}
+
+	return true
+}
+
+// matchesByDay checks the BYDAY part, ordinals count within the month for monthly rules and yearly rules with BYMONTH,
+// otherwise within the year
+func (rule *Rule) matchesByDay(day time.Time) bool {
+	for _, byDay := range rule.ByDay {
+		if byDay.Weekday != day.Weekday() {
+			continue
+		}
+
+		if byDay.Ordinal == 0 || rule.Frequency == Daily || rule.Frequency == Weekly {
+			return true
+		}
+
+		if rule.Frequency == Monthly || len(rule.ByMonth) > 0 {
+			lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+			if byDay.Ordinal == (day.Day()-1)/7+1 || byDay.Ordinal == -((lastDay-day.Day())/7+1) {
+				return true
+			}
+			continue
+		}
+
+		yearDays := time.Date(day.Year(), 12, 31, 0, 0, 0, 0, day.Location()).YearDay()
+		if byDay.Ordinal == (day.YearDay()-1)/7+1 || byDay.Ordinal == -((yearDays-day.YearDay())/7+1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseByDay parses a BYDAY entry such as "MO", "2TU" or "-1FR"
+func parseByDay(value string) (ByDay, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return ByDay{}, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	weekday, exists := weekdays[value[len(value)-2:]]
+	if !exists {
+		return ByDay{}, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	ordinal := 0
+	if len(value) > 2 {
+		parsed, error := strconv.Atoi(value[:len(value)-2])
+		if error != nil || parsed == 0 || parsed < -53 || parsed > 53 {
+			return ByDay{}, fmt.Errorf("invalid weekday ordinal %q", value)
+		}
+		ordinal = parsed
+	}
+
+	return ByDay{Ordinal: ordinal, Weekday: weekday}, nil
+}
+
+// parseUntil parses an UNTIL date in either the date or the UTC date time form
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if until, error := time.Parse(layout, value); error == nil {
+			return truncateToDay(until), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid until %q", value)
+}
+
+func matchesMonthDay(monthDays []int, day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, monthDay := range monthDays {
+		if monthDay == day.Day() || (monthDay < 0 && lastDay+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, candidate := range months {
+		if candidate == month {
+			return true
+		}
+	}
+
+	return false
+}
+
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func truncateToDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+}
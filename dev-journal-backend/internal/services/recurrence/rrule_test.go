@@ -0,0 +1,164 @@
+package recurrenceServices
+
+import (
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, error := time.Parse(time.DateOnly, value)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return parsed
+}
+
+func parseRule(t *testing.T, value string) *Rule {
+	t.Helper()
+	rule, error := ParseRule(value)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return rule
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		start      string
+		from       string
+		limit      int
+		exceptions []string
+		expected   []string
+	}{
+		{name: "daily with interval", rule: "FREQ=DAILY;INTERVAL=2", start: "2024-01-01", limit: 4, expected: []string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-07"}},
+		{name: "window after the start", rule: "FREQ=DAILY", start: "2024-01-01", from: "2024-02-10", limit: 2, expected: []string{"2024-02-10", "2024-02-11"}},
+		{name: "weekly on weekdays", rule: "FREQ=WEEKLY;BYDAY=MO,WE", start: "2024-01-01", limit: 4, expected: []string{"2024-01-01", "2024-01-03", "2024-01-08", "2024-01-10"}},
+		{name: "every other week on the start weekday", rule: "FREQ=WEEKLY;INTERVAL=2", start: "2024-01-03", limit: 3, expected: []string{"2024-01-03", "2024-01-17", "2024-01-31"}},
+		{name: "monthly on the start day skips short months", rule: "FREQ=MONTHLY", start: "2024-01-31", limit: 3, expected: []string{"2024-01-31", "2024-03-31", "2024-05-31"}},
+		{name: "second Tuesday of the month", rule: "FREQ=MONTHLY;BYDAY=2TU", start: "2024-01-01", limit: 3, expected: []string{"2024-01-09", "2024-02-13", "2024-03-12"}},
+		{name: "last Friday of the month", rule: "FREQ=MONTHLY;BYDAY=-1FR", start: "2024-01-01", limit: 3, expected: []string{"2024-01-26", "2024-02-23", "2024-03-29"}},
+		{name: "fifth Monday only in months that have one", rule: "FREQ=MONTHLY;BYDAY=5MO", start: "2024-01-01", limit: 2, expected: []string{"2024-01-29", "2024-04-29"}},
+		{name: "last day of the month", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: "2024-01-01", limit: 4, expected: []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		{name: "third to last day of the month", rule: "FREQ=MONTHLY;BYMONTHDAY=-3", start: "2024-01-01", limit: 3, expected: []string{"2024-01-29", "2024-02-27", "2024-03-29"}},
+		{name: "fourth Thursday of November", rule: "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", start: "2024-01-01", limit: 3, expected: []string{"2024-11-28", "2025-11-27", "2026-11-26"}},
+		{name: "first Monday of the year", rule: "FREQ=YEARLY;BYDAY=1MO", start: "2024-01-01", limit: 3, expected: []string{"2024-01-01", "2025-01-06", "2026-01-05"}},
+		{name: "last Sunday of the year", rule: "FREQ=YEARLY;BYDAY=-1SU", start: "2024-01-01", limit: 2, expected: []string{"2024-12-29", "2025-12-28"}},
+		{name: "leap day", rule: "FREQ=YEARLY", start: "2024-02-29", limit: 2, expected: []string{"2024-02-29", "2028-02-29"}},
+		{name: "until is inclusive", rule: "FREQ=DAILY;UNTIL=20240103", start: "2024-01-01", limit: 10, expected: []string{"2024-01-01", "2024-01-02", "2024-01-03"}},
+		{name: "exceptions are skipped", rule: "FREQ=WEEKLY", start: "2024-01-01", limit: 2, exceptions: []string{"2024-01-08"}, expected: []string{"2024-01-01", "2024-01-15"}},
+		{name: "count includes exceptions", rule: "FREQ=DAILY;COUNT=5", start: "2024-01-01", limit: 10, exceptions: []string{"2024-01-02", "2024-01-04"}, expected: []string{"2024-01-01", "2024-01-03", "2024-01-05"}},
+		{name: "count is counted from the start", rule: "FREQ=DAILY;COUNT=5", start: "2024-01-01", from: "2024-01-04", limit: 10, expected: []string{"2024-01-04", "2024-01-05"}},
+		{name: "count with weekdays and exceptions", rule: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", start: "2024-01-01", limit: 10, exceptions: []string{"2024-01-05"}, expected: []string{"2024-01-01", "2024-01-08"}},
+		{name: "count used up before the window", rule: "FREQ=DAILY;COUNT=3", start: "2024-01-01", from: "2024-01-10", limit: 10, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from := test.start
+			if test.from != "" {
+				from = test.from
+			}
+
+			exceptions := make([]time.Time, 0)
+			for _, exception := range test.exceptions {
+				exceptions = append(exceptions, date(t, exception))
+			}
+
+			occurrences := parseRule(t, test.rule).Between(date(t, test.start), date(t, from), test.limit, exceptions)
+
+			found := make([]string, 0)
+			for _, occurrence := range occurrences {
+				found = append(found, occurrence.Format(time.DateOnly))
+			}
+			if len(found) != len(test.expected) {
+				t.Fatalf("found %v, expected %v", found, test.expected)
+			}
+			for index := range found {
+				if found[index] != test.expected[index] {
+					t.Fatalf("found %v, expected %v", found, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		rule     string
+		start    string
+		day      string
+		expected bool
+	}{
+		{rule: "FREQ=DAILY;INTERVAL=3", start: "2024-01-01", day: "2024-01-04", expected: true},
+		{rule: "FREQ=DAILY;INTERVAL=3", start: "2024-01-01", day: "2024-01-03"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", start: "2024-01-01", day: "2024-01-07", expected: true},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", start: "2024-01-01", day: "2024-01-14"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2", start: "2023-12-27", day: "2024-01-10", expected: true},
+		{rule: "FREQ=MONTHLY;INTERVAL=2", start: "2024-11-15", day: "2025-01-15", expected: true},
+		{rule: "FREQ=MONTHLY;INTERVAL=2", start: "2024-11-15", day: "2024-12-15"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1", start: "2024-01-01", day: "2024-02-29", expected: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1", start: "2024-01-01", day: "2024-02-28"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR", start: "2024-01-01", day: "2024-09-13", expected: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR", start: "2024-01-01", day: "2024-08-13"},
+		{rule: "FREQ=YEARLY;BYMONTH=3,9", start: "2024-01-05", day: "2024-09-05", expected: true},
+		{rule: "FREQ=YEARLY;BYMONTH=3,9", start: "2024-01-05", day: "2024-09-06"},
+		{rule: "FREQ=YEARLY", start: "2024-02-29", day: "2025-02-28"},
+	}
+
+	for _, test := range tests {
+		if matched := parseRule(t, test.rule).matches(date(t, test.start), date(t, test.day)); matched != test.expected {
+			t.Errorf("%s starting %s matches %s is %v, expected %v", test.rule, test.start, test.day, matched, test.expected)
+		}
+	}
+}
+
+func TestMatchesByDay(t *testing.T) {
+	tests := []struct {
+		rule     string
+		day      string
+		expected bool
+	}{
+		{rule: "FREQ=MONTHLY;BYDAY=1FR", day: "2024-01-05", expected: true},
+		{rule: "FREQ=MONTHLY;BYDAY=1FR", day: "2024-01-12"},
+		{rule: "FREQ=MONTHLY;BYDAY=-2FR", day: "2024-01-19", expected: true},
+		{rule: "FREQ=MONTHLY;BYDAY=-2FR", day: "2024-01-26"},
+		{rule: "FREQ=MONTHLY;BYDAY=-1TH", day: "2024-02-29", expected: true},
+		{rule: "FREQ=MONTHLY;BYDAY=MO,-1FR", day: "2024-01-15", expected: true},
+		{rule: "FREQ=MONTHLY;BYDAY=MO,-1FR", day: "2024-01-19"},
+		{rule: "FREQ=WEEKLY;BYDAY=2MO", day: "2024-01-01", expected: true},
+		{rule: "FREQ=YEARLY;BYDAY=10FR", day: "2024-03-08", expected: true},
+		{rule: "FREQ=YEARLY;BYDAY=10FR", day: "2024-03-15"},
+		{rule: "FREQ=YEARLY;BYDAY=-53TU", day: "2024-01-02", expected: true},
+		{rule: "FREQ=YEARLY;BYMONTH=1;BYDAY=-1WE", day: "2024-01-31", expected: true},
+		{rule: "FREQ=YEARLY;BYMONTH=1;BYDAY=5WE", day: "2024-01-31", expected: true},
+	}
+
+	for _, test := range tests {
+		if matched := parseRule(t, test.rule).matchesByDay(date(t, test.day)); matched != test.expected {
+			t.Errorf("%s matches %s is %v, expected %v", test.rule, test.day, matched, test.expected)
+		}
+	}
+}
+
+func TestParseRuleRejectsInvalidRules(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		if _, error := ParseRule(value); error == nil {
+			t.Errorf("parsed invalid rule %q", value)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package reminderServices
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+	reminderModel "github.com/hwaengfan/dev-journal-backend/internal/models/reminder"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+)
+
+// How long the reminders of a passed deadline are kept
+const reminderRetention = time.Hour * 24 * 30
+
+// A Notifier sends reminders to users
+type Notifier interface {
+	Notify(notification notificationModel.Notification) error
+}
+
+// DeadlineReminder reminds users of the project and task deadlines coming up within its windows
+type DeadlineReminder struct {
+	store    reminderModel.ReminderStore
+	notifier Notifier
+	windows  []time.Duration
+}
+
+func NewDeadlineReminder(store reminderModel.ReminderStore, notifier Notifier, windows []time.Duration) *DeadlineReminder {
+	windows = slices.Clone(windows)
+	slices.Sort(windows)
+
+	return &DeadlineReminder{store: store, notifier: notifier, windows: windows}
+}
+
+// ParseWindows parses a comma separated list of hours before a deadline to remind of it, like 72,24
+func ParseWindows(value string) ([]time.Duration, error) {
+	windows := make([]time.Duration, 0)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		hours, error := strconv.Atoi(field)
+		if error != nil || hours <= 0 {
+			return nil, fmt.Errorf("invalid reminder window %q", field)
+		}
+
+		windows = append(windows, time.Hour*time.Duration(hours))
+	}
+
+	return windows, nil
+}
+
+// SendDueReminders reminds the users of every deadline that entered one of the windows, each reminder is sent once even across restarts
+func (reminder *DeadlineReminder) SendDueReminders(now time.Time) error {
+	if len(reminder.windows) == 0 {
+		return nil
+	}
+
+	// deadlines are dates, look a day around the widest window to cover every timezone
+	today := now.UTC()
+	from := today.AddDate(0, 0, -1).Format(time.DateOnly)
+	to := today.Add(reminder.windows[len(reminder.windows)-1]).AddDate(0, 0, 1).Format(time.DateOnly)
+
+	projectDeadlines, error := reminder.store.GetProjectDeadlines(from, to)
+	if error != nil {
+		return error
+	}
+
+	taskDeadlines, error := reminder.store.GetTaskDeadlines(from, to)
+	if error != nil {
+		return error
+	}
+
+	for _, deadline := range append(projectDeadlines, taskDeadlines...) {
+		if error := reminder.remind(deadline, now); error != nil {
+			log.Printf("failed to remind user %s of %s %s: %v", deadline.UserID, strings.ToLower(deadline.EntityType), deadline.EntityID, error)
+		}
+	}
+
+	return reminder.store.DeleteRemindersDueBefore(now.Add(-reminderRetention).UTC().Format(time.DateOnly))
+}
+
+// remind sends the reminder of the narrowest window a deadline is in, unless it was sent before
+func (reminder *DeadlineReminder) remind(deadline *reminderModel.Deadline, now time.Time) error {
+	dueDate, error := recurrenceServices.ParseDate(&deadline.DueDate)
+	if error != nil {
+		return error
+	}
+
+	// a deadline is met until the end of its day in the timezone of the user
+	location, error := time.LoadLocation(deadline.Timezone)
+	if error != nil {
+		location = time.UTC
+	}
+
+	dueAt := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day()+1, 0, 0, 0, 0, location)
+	remaining := dueAt.Sub(now)
+	if remaining <= 0 {
+		return nil
+	}
+
+	index := slices.IndexFunc(reminder.windows, func(window time.Duration) bool { return remaining <= window })
+	if index < 0 {
+		return nil
+	}
+
+	// claim the reminder first so no other run sends it as well
+	sent := reminderModel.Reminder{
+		EntityType:    deadline.EntityType,
+		EntityID:      deadline.EntityID,
+		UserID:        deadline.UserID,
+		DueDate:       dueDate.Format(time.DateOnly),
+		WindowInHours: int(reminder.windows[index].Hours()),
+	}
+
+	claimed, error := reminder.store.ClaimReminder(sent)
+	if error != nil || !claimed {
+		return error
+	}
+
+	error = reminder.notifier.Notify(toNotification(deadline, dueDate))
+	if error != nil {
+		if releaseError := reminder.store.ReleaseReminder(sent); releaseError != nil {
+			log.Printf("failed to release reminder of %s %s: %v", strings.ToLower(deadline.EntityType), deadline.EntityID, releaseError)
+		}
+		return error
+	}
+
+	return nil
+}
+
+// toNotification words the reminder of a deadline
+func toNotification(deadline *reminderModel.Deadline, dueDate time.Time) notificationModel.Notification {
+	entityType := notificationModel.EntityProject
+	subject := "The project"
+	if deadline.EntityType == reminderModel.EntityTask {
+		entityType = notificationModel.EntityTask
+		subject = "The task"
+	}
+
+	return notificationModel.Notification{
+		UserID:     deadline.UserID,
+		Type:       notificationModel.TypeDeadlineApproaching,
+		ProjectID:  uuid.NullUUID{UUID: deadline.ProjectID, Valid: true},
+		EntityType: entityType,
+		EntityID:   deadline.EntityID,
+		Message:    fmt.Sprintf("%s %s is due on %s", subject, deadline.Title, dueDate.Format("Monday, January 2")),
+	}
+}
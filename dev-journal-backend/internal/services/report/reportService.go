@@ -0,0 +1,374 @@
+package reportService
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	focusSessionModel "github.com/hwaengfan/dev-journal-backend/internal/models/focusSession"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	reportModel "github.com/hwaengfan/dev-journal-backend/internal/models/report"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	timeEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/timeEntry"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store             reportModel.ReportStore
+	userStore         userModel.UserStore
+	projectStore      projectModel.ProjectStore
+	taskStore         taskModel.TaskStore
+	noteStore         noteModel.NoteStore
+	timeEntryStore    timeEntryModel.TimeEntryStore
+	focusSessionStore focusSessionModel.FocusSessionStore
+}
+
+func NewHandler(store reportModel.ReportStore, userStore userModel.UserStore, projectStore projectModel.ProjectStore, taskStore taskModel.TaskStore, noteStore noteModel.NoteStore, timeEntryStore timeEntryModel.TimeEntryStore, focusSessionStore focusSessionModel.FocusSessionStore) *Handler {
+	return &Handler{store: store, userStore: userStore, projectStore: projectStore, taskStore: taskStore, noteStore: noteStore, timeEntryStore: timeEntryStore, focusSessionStore: focusSessionStore}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/reports/get-standup-report", authenticationServices.JWTAuthentication(handler.handleGetStandupReport, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/reports/get-standup-template", authenticationServices.JWTAuthentication(handler.handleGetStandupTemplate, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/reports/update-standup-template", authenticationServices.JWTAuthentication(handler.handleUpdateStandupTemplate, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/reports/reset-standup-template", authenticationServices.JWTAuthentication(handler.handleResetStandupTemplate, handler.userStore)).Methods(http.MethodDelete)
+}
+
+// Handler function for getting the standup report of a range of days, as Markdown or with format=json as JSON
+func (handler *Handler) handleGetStandupReport(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	format := request.URL.Query().Get("format")
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "json" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("format must be markdown or json"))
+		return
+	}
+
+	user, error := handler.userStore.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// the range covers whole days in the user's timezone, yesterday and today by default
+	location, error := time.LoadLocation(user.Timezone)
+	if error != nil {
+		location = time.UTC
+	}
+
+	now := time.Now().In(location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	from := to.AddDate(0, 0, -1)
+	if value := request.URL.Query().Get("from"); value != "" {
+		if from, error = time.ParseInLocation(time.DateOnly, value, location); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid from, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	if value := request.URL.Query().Get("to"); value != "" {
+		if to, error = time.ParseInLocation(time.DateOnly, value, location); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid to, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > 31*24*time.Hour {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("range must cover between 1 and 32 days"))
+		return
+	}
+
+	// the report covers the projects of the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	report, error := handler.buildStandupReport(userID.UUID, workspaceID, from, to, location)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if format == "json" {
+		utils.WriteJSON(writer, http.StatusOK, report)
+		return
+	}
+
+	text, error := handler.standupTemplate(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	markdown, error := renderStandupReport(text, report)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(markdown))
+}
+
+// Handler function for getting the standup template of the user
+func (handler *Handler) handleGetStandupTemplate(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	text, error := handler.standupTemplate(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]any{"template": text, "default": text == defaultStandupTemplate})
+}
+
+// Handler function for configuring the standup template of the user
+func (handler *Handler) handleUpdateStandupTemplate(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload reportModel.UpdateStandupTemplatePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check that the template renders before storing it
+	if _, error := parseStandupTemplate(payload.Template); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := handler.store.SetStandupTemplateByUserID(userID.UUID, payload.Template); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for going back to the default standup template
+func (handler *Handler) handleResetStandupTemplate(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	if error := handler.store.DeleteStandupTemplateByUserID(userID.UUID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// standupTemplate returns the template configured by the user or the default one
+func (handler *Handler) standupTemplate(userID uuid.UUID) (string, error) {
+	text, error := handler.store.GetStandupTemplateByUserID(userID)
+	if error != nil {
+		if error.Error() == "standup template not found" {
+			return defaultStandupTemplate, nil
+		}
+		return "", error
+	}
+
+	return text, nil
+}
+
+// buildStandupReport gathers the user's activity in a workspace between the start of from and the end of to, grouped by project
+func (handler *Handler) buildStandupReport(userID uuid.UUID, workspaceID uuid.UUID, from time.Time, to time.Time, location *time.Location) (*reportModel.StandupReport, error) {
+	start, end := from, to.AddDate(0, 0, 1)
+	inRange := func(value *time.Time) bool {
+		return value != nil && !value.Before(start) && value.Before(end)
+	}
+
+	report := &reportModel.StandupReport{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Timezone: location.String(),
+		Projects: make([]*reportModel.ProjectReport, 0),
+	}
+
+	scope := uuid.NullUUID{UUID: workspaceID, Valid: true}
+	projects, error := handler.projectStore.GetProjectsByUserID(userID, scope)
+	if error != nil {
+		return nil, error
+	}
+
+	projectReports := make(map[uuid.UUID]*reportModel.ProjectReport)
+	for _, project := range projects {
+		projectReports[project.ID] = &reportModel.ProjectReport{
+			LinkedProjectID: project.ID,
+			ProjectTitle:    project.Title,
+			CompletedTasks:  make([]*taskModel.Task, 0),
+			CreatedTasks:    make([]*taskModel.Task, 0),
+			MovedTasks:      make([]*taskModel.Task, 0),
+			EditedNotes:     make([]*noteModel.Note, 0),
+			PlannedTasks:    make([]*taskModel.Task, 0),
+			Blockers:        make([]*reportModel.Blocker, 0),
+		}
+	}
+
+	// time and focus spent per project, tasks worked on in the range are planned for today
+	workedOn := make(map[uuid.UUID]bool)
+
+	timeEntries, error := handler.timeEntryStore.GetTimeEntriesByUserID(userID, timeEntryModel.TimeEntryFilter{From: &start, To: &end, WorkspaceID: scope})
+	if error != nil {
+		return nil, error
+	}
+
+	for _, timeEntry := range timeEntries {
+		workedOn[timeEntry.TaskID] = true
+		if projectReport, exists := projectReports[timeEntry.LinkedProjectID]; exists {
+			projectReport.TrackedSeconds += clippedSeconds(timeEntry.StartedAt, timeEntry.EndedAt, start, end)
+		}
+	}
+
+	focusSessions, error := handler.focusSessionStore.GetFocusSessionsByUserID(userID, focusSessionModel.FocusSessionFilter{From: &start, To: &end, WorkspaceID: scope})
+	if error != nil {
+		return nil, error
+	}
+
+	for _, focusSession := range focusSessions {
+		if focusSession.TaskID.Valid {
+			workedOn[focusSession.TaskID.UUID] = true
+		}
+		if projectReport, exists := projectReports[focusSession.LinkedProjectID.UUID]; exists && focusSession.LinkedProjectID.Valid {
+			projectReport.FocusedSeconds += focusSession.ActualLengthInSeconds
+			projectReport.FocusSessions++
+		}
+	}
+
+	// task activity
+	tasks, error := handler.taskStore.GetTasksByUserID(userID, scope)
+	if error != nil {
+		return nil, error
+	}
+
+	dependencies, error := handler.taskStore.GetTaskDependenciesByUserID(userID, scope)
+	if error != nil {
+		return nil, error
+	}
+
+	tasksByID := make(map[uuid.UUID]*taskModel.Task)
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+
+	blockedBy := make(map[uuid.UUID][]*taskModel.Task)
+	for _, dependency := range dependencies {
+		if blocker, exists := tasksByID[dependency.BlockedByTaskID]; exists && blocker.Completed != "True" {
+			blockedBy[dependency.TaskID] = append(blockedBy[dependency.TaskID], blocker)
+		}
+	}
+
+	for _, task := range tasks {
+		projectReport, exists := projectReports[task.LinkedProjectID]
+		if !exists {
+			continue
+		}
+
+		if task.Completed == "True" {
+			if inRange(task.DateCompleted) {
+				projectReport.CompletedTasks = append(projectReport.CompletedTasks, task)
+			}
+			continue
+		}
+
+		if inRange(&task.DateCreated) {
+			projectReport.CreatedTasks = append(projectReport.CreatedTasks, task)
+		} else if inRange(task.LastMoved) {
+			projectReport.MovedTasks = append(projectReport.MovedTasks, task)
+		}
+
+		// open tasks due by the end of the range or touched in it are planned next
+		due := false
+		if dueDate, error := recurrenceServices.ParseDate(task.DueDate); error == nil {
+			due = !dueDate.After(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC))
+		}
+		if due || workedOn[task.ID] || inRange(task.LastMoved) {
+			projectReport.PlannedTasks = append(projectReport.PlannedTasks, task)
+			if len(blockedBy[task.ID]) > 0 {
+				task.Blocked = true
+				projectReport.Blockers = append(projectReport.Blockers, &reportModel.Blocker{Task: task, BlockedBy: blockedBy[task.ID]})
+			}
+		}
+	}
+
+	// notes
+	notes, error := handler.noteStore.GetNotesEditedByUserID(userID, scope, start, end)
+	if error != nil {
+		return nil, error
+	}
+
+	for _, note := range notes {
+		if projectReport, exists := projectReports[note.LinkedProjectID]; exists {
+			projectReport.EditedNotes = append(projectReport.EditedNotes, note)
+		}
+	}
+
+	// only projects with something to report are listed, in the order of the user's projects
+	for _, project := range projects {
+		projectReport := projectReports[project.ID]
+		if len(projectReport.CompletedTasks)+len(projectReport.CreatedTasks)+len(projectReport.MovedTasks)+len(projectReport.EditedNotes)+len(projectReport.PlannedTasks) > 0 || projectReport.TrackedSeconds > 0 || projectReport.FocusSessions > 0 {
+			report.Projects = append(report.Projects, projectReport)
+		}
+	}
+
+	return report, nil
+}
+
+// clippedSeconds returns the seconds of an entry falling within the range, running entries count up to now
+func clippedSeconds(startedAt time.Time, endedAt *time.Time, start time.Time, end time.Time) int64 {
+	finish := time.Now()
+	if endedAt != nil {
+		finish = *endedAt
+	}
+
+	if startedAt.Before(start) {
+		startedAt = start
+	}
+	if finish.After(end) {
+		finish = end
+	}
+	if !finish.After(startedAt) {
+		return 0
+	}
+
+	return int64(finish.Sub(startedAt).Seconds())
+}
@@ -0,0 +1,114 @@
+package reportService
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	reportModel "github.com/hwaengfan/dev-journal-backend/internal/models/report"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+)
+
+// Template used for users who have not configured their own, it receives a reportModel.StandupReport
+const defaultStandupTemplate = `# Standup {{.From}}{{if ne .From .To}} to {{.To}}{{end}}
+{{range .Projects}}
+## {{.ProjectTitle}}
+
+### Yesterday
+{{range .CompletedTasks}}- Completed: {{.Description}}
+{{end}}{{range .CreatedTasks}}- Created: {{.Description}}
+{{end}}{{range .MovedTasks}}- Moved: {{.Description}}
+{{end}}{{range .EditedNotes}}- Edited note: {{.Title}}
+{{end}}{{if .TrackedSeconds}}- Tracked: {{duration .TrackedSeconds}}
+{{end}}{{if .FocusSessions}}- Focused: {{duration .FocusedSeconds}} in {{.FocusSessions}} session(s)
+{{end}}
+### Today
+{{range .PlannedTasks}}- {{.Description}}{{with .DueDate}} (due {{date .}}){{end}}
+{{else}}- Nothing planned
+{{end}}
+### Blockers
+{{range .Blockers}}- {{.Task.Description}}, waiting on {{range $index, $task := .BlockedBy}}{{if $index}}, {{end}}{{$task.Description}}{{end}}
+{{else}}- None
+{{end}}{{else}}
+No activity.
+{{end}}`
+
+var templateFunctions = template.FuncMap{
+	"duration": formatDuration,
+	"date": func(date *string) string {
+		parsed, error := recurrenceServices.ParseDate(date)
+		if error != nil {
+			return ""
+		}
+		return parsed.Format(time.DateOnly)
+	},
+}
+
+// parseStandupTemplate parses a standup template and checks that it renders a report with every section filled
+func parseStandupTemplate(text string) (*template.Template, error) {
+	standupTemplate, error := template.New("standup").Funcs(templateFunctions).Parse(text)
+	if error != nil {
+		return nil, fmt.Errorf("invalid template: %v", error)
+	}
+
+	if error := standupTemplate.Execute(&strings.Builder{}, sampleReport()); error != nil {
+		return nil, fmt.Errorf("invalid template: %v", error)
+	}
+
+	return standupTemplate, nil
+}
+
+// renderStandupReport renders a report as Markdown with the given template
+func renderStandupReport(text string, report *reportModel.StandupReport) (string, error) {
+	standupTemplate, error := parseStandupTemplate(text)
+	if error != nil {
+		return "", error
+	}
+
+	var builder strings.Builder
+	if error := standupTemplate.Execute(&builder, report); error != nil {
+		return "", fmt.Errorf("failed to render standup report: %v", error)
+	}
+
+	return builder.String(), nil
+}
+
+// formatDuration formats seconds as hours and minutes
+func formatDuration(seconds int64) string {
+	hours, minutes := seconds/3600, seconds%3600/60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// sampleReport builds a report with every section filled, used to check custom templates
+func sampleReport() *reportModel.StandupReport {
+	dueDate := time.Now().UTC().Format(time.DateOnly)
+	task := &taskModel.Task{ID: uuid.New(), Description: "Sample task", Completed: "False", DueDate: &dueDate}
+	tasks := []*taskModel.Task{task}
+
+	return &reportModel.StandupReport{
+		From:     dueDate,
+		To:       dueDate,
+		Timezone: "UTC",
+		Projects: []*reportModel.ProjectReport{{
+			LinkedProjectID: uuid.New(),
+			ProjectTitle:    "Sample project",
+			CompletedTasks:  tasks,
+			CreatedTasks:    tasks,
+			MovedTasks:      tasks,
+			EditedNotes:     []*noteModel.Note{{ID: uuid.New(), Title: "Sample note"}},
+			TrackedSeconds:  3600,
+			FocusedSeconds:  1500,
+			FocusSessions:   1,
+			PlannedTasks:    tasks,
+			Blockers:        []*reportModel.Blocker{{Task: task, BlockedBy: tasks}},
+		}},
+	}
+}
@@ -0,0 +1,128 @@
+package runnerServices
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	jobLockModel "github.com/hwaengfan/dev-journal-backend/internal/models/jobLock"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(now time.Time) error
+}
+
+// Runner runs background jobs on their interval inside the server, each job runs on the one server holding its lock
+type Runner struct {
+	lockStore jobLockModel.JobLockStore
+	holder    uuid.UUID
+	jobs      []*job
+	stopping  chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+func NewRunner(lockStore jobLockModel.JobLockStore) *Runner {
+	return &Runner{lockStore: lockStore, holder: uuid.New(), stopping: make(chan struct{})}
+}
+
+// Register adds a job to run every interval once the runner runs
+func (runner *Runner) Register(name string, interval time.Duration, run func(now time.Time) error) {
+	runner.jobs = append(runner.jobs, &job{name: name, interval: interval, run: run})
+}
+
+// Run runs every registered job on its own interval until the runner is stopped
+func (runner *Runner) Run() {
+	for _, job := range runner.jobs {
+		runner.waitGroup.Add(1)
+		go func() {
+			defer runner.waitGroup.Done()
+			runner.runJob(job)
+		}()
+	}
+
+	runner.waitGroup.Wait()
+}
+
+// Stop waits for the running jobs to finish and hands their locks over to the other servers
+func (runner *Runner) Stop() {
+	runner.stopOnce.Do(func() { close(runner.stopping) })
+	runner.waitGroup.Wait()
+}
+
+// runJob runs a job on every tick its lock is held, the lease outlasts the next tick so the holder keeps it while it is alive
+func (runner *Runner) runJob(job *job) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		runner.tick(job)
+
+		select {
+		case <-ticker.C:
+		case <-runner.stopping:
+			if error := runner.lockStore.ReleaseJobLock(job.name, runner.holder); error != nil {
+				log.Printf("failed to release lock of job %s: %v", job.name, error)
+			}
+			return
+		}
+	}
+}
+
+// tick runs a job once if no other server holds its lock, the lease is renewed while the job runs so a run that outlasts it is not started again elsewhere
+func (runner *Runner) tick(job *job) {
+	lease := job.interval * 2
+	acquired, error := runner.lockStore.AcquireJobLock(job.name, runner.holder, lease)
+	if error != nil {
+		log.Printf("failed to acquire lock of job %s: %v", job.name, error)
+		return
+	}
+
+	if !acquired {
+		return
+	}
+
+	// the heartbeat is done before the lock can be released, so it never renews a lease handed over on shutdown
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		runner.keepLocked(job, lease, done)
+	}()
+
+	error = job.run(time.Now())
+	close(done)
+	<-stopped
+
+	if error != nil {
+		log.Printf("failed to run job %s: %v", job.name, error)
+	}
+}
+
+// keepLocked renews the lease of a running job until done is closed, a run cannot be cut short so losing the lock is only logged
+func (runner *Runner) keepLocked(job *job, lease time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		renewed, error := runner.lockStore.AcquireJobLock(job.name, runner.holder, lease)
+		if error != nil {
+			log.Printf("failed to renew lock of job %s: %v", job.name, error)
+			continue
+		}
+
+		if !renewed {
+			log.Printf("lost lock of job %s while it was running", job.name)
+			return
+		}
+	}
+}
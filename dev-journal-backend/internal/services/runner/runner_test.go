@@ -0,0 +1,131 @@
+package runnerServices
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryLock struct {
+	holder      uuid.UUID
+	lockedUntil time.Time
+}
+
+// memoryJobLockStore keeps the job locks in memory with the semantics of the MySQL store
+type memoryJobLockStore struct {
+	mutex sync.Mutex
+	locks map[string]*memoryLock
+}
+
+func newMemoryJobLockStore() *memoryJobLockStore {
+	return &memoryJobLockStore{locks: make(map[string]*memoryLock)}
+}
+
+func (store *memoryJobLockStore) AcquireJobLock(name string, holder uuid.UUID, lease time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	lock, exists := store.locks[name]
+	if !exists || lock.holder == holder || lock.lockedUntil.Before(now) {
+		store.locks[name] = &memoryLock{holder: holder, lockedUntil: now.Add(lease)}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (store *memoryJobLockStore) ReleaseJobLock(name string, holder uuid.UUID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if lock, exists := store.locks[name]; exists && lock.holder == holder {
+		lock.lockedUntil = time.Now()
+	}
+
+	return nil
+}
+
+func TestRunnerKeepsTheLockOfLongRuns(t *testing.T) {
+	store := newMemoryJobLockStore()
+	first, second := NewRunner(store), NewRunner(store)
+
+	// the first run takes several leases, the second server keeps ticking meanwhile
+	const interval = 20 * time.Millisecond
+	var running, runs atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	run := func(now time.Time) error {
+		if running.Add(1) > 1 {
+			t.Error("job runs on two servers at the same time")
+		}
+		defer running.Add(-1)
+
+		if runs.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+
+	longJob := &job{name: "long", interval: interval, run: run}
+	firstDone := make(chan struct{})
+	go func() {
+		first.tick(longJob)
+		close(firstDone)
+	}()
+	<-started
+
+	deadline := time.Now().Add(interval * 10)
+	for time.Now().Before(deadline) {
+		second.tick(longJob)
+		time.Sleep(interval / 4)
+	}
+
+	close(release)
+	<-firstDone
+	if runs.Load() != 1 {
+		t.Fatalf("job ran %d times while the first run held the lock", runs.Load())
+	}
+
+	// once the holder stops renewing, the lease runs out and another server takes over
+	time.Sleep(interval * 5)
+	second.tick(longJob)
+	if runs.Load() != 2 {
+		t.Fatalf("job ran %d times after the first run ended, expected 2", runs.Load())
+	}
+}
+
+func TestRunnerReleasesLocksOnStop(t *testing.T) {
+	store := newMemoryJobLockStore()
+	first, second := NewRunner(store), NewRunner(store)
+
+	var runs atomic.Int32
+	first.Register("job", time.Hour, func(now time.Time) error {
+		runs.Add(1)
+		return nil
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		first.Run()
+		close(stopped)
+	}()
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	first.Stop()
+	<-stopped
+
+	// the lease of an hour was handed over, so the other server runs the job right away
+	second.tick(&job{name: "job", interval: time.Hour, run: func(now time.Time) error {
+		runs.Add(1)
+		return nil
+	}})
+	if runs.Load() != 2 {
+		t.Fatalf("job ran %d times, expected 2", runs.Load())
+	}
+}
@@ -0,0 +1,432 @@
+package shareLinkService
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	noteModel "github.com/hwaengfan/dev-journal-backend/internal/models/note"
+	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	shareLinkModel "github.com/hwaengfan/dev-journal-backend/internal/models/shareLink"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	markdownServices "github.com/hwaengfan/dev-journal-backend/internal/services/markdown"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Header a client can send the password of a protected link in instead of posting the form
+const passwordHeader = "X-Share-Password"
+
+type Handler struct {
+	store           shareLinkModel.ShareLinkStore
+	userStore       userModel.UserStore
+	memberStore     projectMemberModel.ProjectMemberStore
+	projectStore    projectModel.ProjectStore
+	noteStore       noteModel.NoteStore
+	taskStore       taskModel.TaskStore
+	renderer        *markdownServices.Renderer
+	recorder        *auditServices.Recorder
+	passwordLimiter *authenticationServices.RateLimiter
+}
+
+func NewHandler(store shareLinkModel.ShareLinkStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, projectStore projectModel.ProjectStore, noteStore noteModel.NoteStore, taskStore taskModel.TaskStore, renderer *markdownServices.Renderer, recorder *auditServices.Recorder) *Handler {
+	return &Handler{
+		store:           store,
+		userStore:       userStore,
+		memberStore:     memberStore,
+		projectStore:    projectStore,
+		noteStore:       noteStore,
+		taskStore:       taskStore,
+		renderer:        renderer,
+		recorder:        recorder,
+		passwordLimiter: authenticationServices.NewRateLimiter(10, 15*time.Minute),
+	}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/share-links/create-share-link", authenticationServices.JWTAuthentication(handler.handleCreateShareLink, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/share-links/get-share-links-by-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetShareLinksByProjectID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/share-links/revoke-share-link/{shareLinkID}", authenticationServices.JWTAuthentication(handler.handleRevokeShareLink, handler.userStore)).Methods(http.MethodDelete)
+
+	// the token in the link authorizes reading, so people without an account can open it, protected links post their password form here
+	router.HandleFunc("/shared/{token}", handler.handleViewSharedContent).Methods(http.MethodGet, http.MethodPost)
+}
+
+// Handler function for creating a share link to a note or a whole project
+func (handler *Handler) handleCreateShareLink(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload shareLinkModel.CreateShareLinkPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if (payload.ProjectID == uuid.Nil) == (payload.NoteID == uuid.Nil) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("either a project ID or a note ID is required"))
+		return
+	}
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("expiry must be in the future"))
+		return
+	}
+
+	shareLink := shareLinkModel.ShareLink{
+		ProjectID: payload.ProjectID,
+		CreatedBy: userID,
+		ExpiresAt: payload.ExpiresAt,
+	}
+
+	// editors can share a note, sharing the whole project is up to its owners
+	minimumRole := projectMemberModel.RoleOwner
+	if payload.NoteID != uuid.Nil {
+		note, error := handler.noteStore.GetNoteByID(payload.NoteID)
+		if error != nil && strings.Contains(error.Error(), "not found") {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("note ID does not exist"))
+			return
+		} else if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		shareLink.ProjectID = note.LinkedProjectID
+		shareLink.NoteID = uuid.NullUUID{UUID: note.ID, Valid: true}
+		minimumRole = projectMemberModel.RoleEditor
+	}
+
+	if _, error := projectAccessServices.Authorize(handler.memberStore, shareLink.ProjectID, userID.UUID, workspaceID, minimumRole); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if payload.Password != "" {
+		passwordHash, error := authenticationServices.HashPassword(payload.Password)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		shareLink.PasswordHash = &passwordHash
+	}
+
+	// only the hash of the token is stored, the link cannot be shown again
+	token, tokenHash, error := authenticationServices.GenerateToken()
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	shareLink.TokenHash = tokenHash
+
+	shareLinkID, error := handler.store.CreateShareLink(shareLink)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recorder.RecordTokenCreated(request, userID.UUID, auditModel.TokenShareLink)
+
+	createdShareLink, error := handler.store.GetShareLinkByID(shareLinkID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	server := configs.ServerEnvironmentVariables
+	createdShareLink.URL = fmt.Sprintf("%s:%s/api/v1/shared/%s", server.PublicHost, server.Port, token)
+
+	utils.WriteJSON(writer, http.StatusCreated, createdShareLink)
+}
+
+// Handler function for getting the share links of a project and its notes
+func (handler *Handler) handleGetShareLinksByProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get project ID from URL
+	projectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can share in the project
+	if _, error := projectAccessServices.Authorize(handler.memberStore, projectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	shareLinks, error := handler.store.GetShareLinksByProjectID(projectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, shareLinks)
+}
+
+// Handler function for revoking a share link, editors can revoke their own links and owners any link of the project
+func (handler *Handler) handleRevokeShareLink(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get share link ID from URL
+	shareLinkID, error := utils.ParseIDFromURL(request, "shareLinkID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	shareLink, error := handler.store.GetShareLinkByID(shareLinkID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+	if shareLink == nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("share link ID does not exist"))
+		return
+	}
+
+	role, error := projectAccessServices.Authorize(handler.memberStore, shareLink.ProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor)
+	if error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if role != projectMemberModel.RoleOwner && shareLink.CreatedBy != userID {
+		utils.WriteError(writer, http.StatusForbidden, fmt.Errorf("only owners can revoke share links of other members"))
+		return
+	}
+
+	if error := handler.store.RevokeShareLink(shareLink.ID, time.Now()); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for viewing shared content through its link, as a page or as JSON with ?format=json
+func (handler *Handler) handleViewSharedContent(writer http.ResponseWriter, request *http.Request) {
+	asJSON := request.URL.Query().Get("format") == "json"
+
+	shareLink, error := handler.store.GetShareLinkByTokenHash(authenticationServices.HashToken(mux.Vars(request)["token"]))
+	if error != nil {
+		writeSharedError(writer, asJSON, http.StatusInternalServerError, error)
+		return
+	}
+	if shareLink == nil {
+		writeSharedError(writer, asJSON, http.StatusNotFound, fmt.Errorf("share link not found"))
+		return
+	}
+	if shareLink.RevokedAt != nil || (shareLink.ExpiresAt != nil && time.Now().After(*shareLink.ExpiresAt)) {
+		writeSharedError(writer, asJSON, http.StatusGone, fmt.Errorf("share link is no longer available"))
+		return
+	}
+
+	// protected links need their password on every view, nothing is remembered between views
+	if shareLink.PasswordHash != nil {
+		password := request.Header.Get(passwordHeader)
+		if password == "" {
+			password = request.PostFormValue("password")
+		}
+
+		if password == "" {
+			writePasswordRequired(writer, asJSON, "")
+			return
+		}
+
+		// limit wrong guesses per link and address, bcrypt alone does not stop a patient guesser
+		limiterKey := "share:" + shareLink.ID.String() + ":" + utils.GetClientIP(request)
+		if handler.passwordLimiter.Blocked(limiterKey) {
+			writeSharedError(writer, asJSON, http.StatusTooManyRequests, fmt.Errorf("too many password attempts, try again later"))
+			return
+		}
+
+		// viewers who know the password can view as often as they like, only wrong guesses count
+		if !authenticationServices.ComparePassword(*shareLink.PasswordHash, []byte(password)) {
+			handler.passwordLimiter.Record(limiterKey)
+			writePasswordRequired(writer, asJSON, "wrong password")
+			return
+		}
+	}
+
+	content, error := handler.getSharedContent(shareLink)
+	if error != nil {
+		writeSharedError(writer, asJSON, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.RecordShareLinkView(shareLink.ID, time.Now()); error != nil {
+		writeSharedError(writer, asJSON, http.StatusInternalServerError, error)
+		return
+	}
+
+	if asJSON {
+		setSharedHeaders(writer)
+		utils.WriteJSON(writer, http.StatusOK, content)
+		return
+	}
+
+	writeSharedPage(writer, http.StatusOK, sharedPage{Content: content})
+}
+
+// getSharedContent collects what a share link shows, leaving out everything about members and workspaces
+func (handler *Handler) getSharedContent(shareLink *shareLinkModel.ShareLink) (*shareLinkModel.SharedContent, error) {
+	content := &shareLinkModel.SharedContent{ExpiresAt: shareLink.ExpiresAt}
+
+	if shareLink.NoteID.Valid {
+		note, error := handler.noteStore.GetNoteByID(shareLink.NoteID.UUID)
+		if error != nil {
+			return nil, error
+		}
+
+		content.Note = handler.toSharedNote(note)
+		return content, nil
+	}
+
+	project, error := handler.projectStore.GetProjectByID(shareLink.ProjectID)
+	if error != nil {
+		return nil, error
+	}
+
+	notes, error := handler.noteStore.GetNotesByLinkedProjectID(project.ID)
+	if error != nil {
+		return nil, error
+	}
+
+	tasks, error := handler.taskStore.GetTasksByLinkedProjectID(project.ID)
+	if error != nil {
+		return nil, error
+	}
+
+	content.Project = &shareLinkModel.SharedProject{
+		Title:       project.Title,
+		Description: project.Description,
+		Priority:    project.Priority,
+		Deadline:    project.Deadline,
+		Tasks:       toSharedTasks(tasks),
+		Notes:       make([]*shareLinkModel.SharedNote, 0, len(notes)),
+	}
+	for _, note := range notes {
+		content.Project.Notes = append(content.Project.Notes, handler.toSharedNote(note))
+	}
+
+	return content, nil
+}
+
+// toSharedNote copies the readable fields of a note along with its rendered content
+func (handler *Handler) toSharedNote(note *noteModel.Note) *shareLinkModel.SharedNote {
+	return &shareLinkModel.SharedNote{
+		Title:      note.Title,
+		Content:    note.Content,
+		Rendered:   handler.renderer.Render(note.ID, note.Content),
+		Tags:       note.Tags,
+		LastEdited: note.LastEdited,
+	}
+}
+
+// toSharedTasks nests the tasks of a project under their parent tasks, keeping their order
+func toSharedTasks(tasks []*taskModel.Task) []*shareLinkModel.SharedTask {
+	sharedTasks := make(map[uuid.UUID]*shareLinkModel.SharedTask, len(tasks))
+	for _, task := range tasks {
+		sharedTasks[task.ID] = &shareLinkModel.SharedTask{
+			Description: task.Description,
+			Completed:   task.Completed,
+			DueDate:     task.DueDate,
+		}
+	}
+
+	roots := make([]*shareLinkModel.SharedTask, 0)
+	for _, task := range tasks {
+		parent, exists := sharedTasks[task.ParentTaskID.UUID]
+		if task.ParentTaskID.Valid && exists {
+			parent.Subtasks = append(parent.Subtasks, sharedTasks[task.ID])
+		} else {
+			roots = append(roots, sharedTasks[task.ID])
+		}
+	}
+
+	return roots
+}
+
+// setSharedHeaders keeps shared content out of caches and referrers, and stops the page from loading anything
+func setSharedHeaders(writer http.ResponseWriter) {
+	writer.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'")
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.Header().Set("Referrer-Policy", "no-referrer")
+	writer.Header().Set("Cache-Control", "no-store")
+}
+
+// writePasswordRequired asks for the password of a protected link, with the reason the last one was refused
+func writePasswordRequired(writer http.ResponseWriter, asJSON bool, reason string) {
+	if asJSON {
+		if reason == "" {
+			reason = "password required"
+		}
+		setSharedHeaders(writer)
+		utils.WriteError(writer, http.StatusUnauthorized, fmt.Errorf("%s", reason))
+		return
+	}
+
+	writeSharedPage(writer, http.StatusUnauthorized, sharedPage{PasswordRequired: true, Error: reason})
+}
+
+// writeSharedError writes an error as JSON or as a page, matching how the link was opened
+func writeSharedError(writer http.ResponseWriter, asJSON bool, status int, error error) {
+	if asJSON {
+		setSharedHeaders(writer)
+		utils.WriteError(writer, status, error)
+		return
+	}
+
+	// internal errors are not shown to readers without an account
+	message := error.Error()
+	if status == http.StatusInternalServerError {
+		message = "something went wrong, try again later"
+	}
+
+	writeSharedPage(writer, status, sharedPage{Error: message})
+}
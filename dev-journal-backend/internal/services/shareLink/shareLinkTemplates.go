@@ -0,0 +1,82 @@
+package shareLinkService
+
+import (
+	"bytes"
+	htmlTemplate "html/template"
+	"log"
+	"net/http"
+
+	shareLinkModel "github.com/hwaengfan/dev-journal-backend/internal/models/shareLink"
+)
+
+const sharedHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{with .Content}}{{with .Note}}{{.Title}}{{else}}{{.Project.Title}}{{end}}{{else}}dev-journal{{end}}</title>
+</head>
+<body style="font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem;">
+{{if .PasswordRequired}}<h1>This link is password protected</h1>
+{{with .Error}}<p style="color: #b00;">{{.}}</p>
+{{end}}<form method="post">
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+{{else if .Content}}{{with .Content.Note}}{{with .Rendered.TableOfContents}}{{if gt (len .) 1}}<nav>
+<ul>
+{{range .}}<li style="margin-left: {{.Level}}em;"><a href="#{{.Anchor}}">{{.Text}}</a></li>
+{{end}}</ul>
+</nav>
+{{end}}{{end}}{{template "note" .}}{{end}}{{with .Content.Project}}<h1>{{.Title}}</h1>
+<p>{{.Description}}</p>
+<p style="color: #777;">Priority {{.Priority}}, due {{.Deadline}}</p>
+<h2>Tasks</h2>
+{{template "tasks" .Tasks}}<h2>Notes</h2>
+{{range .Notes}}<section style="border-top: 1px solid #ddd;">
+{{template "note" .}}</section>
+{{else}}<p>None</p>
+{{end}}{{end}}{{else}}<h1>{{.Error}}</h1>
+{{end}}<p style="color: #777;">Shared read-only from dev-journal.</p>
+</body>
+</html>
+{{define "note"}}<h2>{{.Title}}</h2>
+{{with .Tags}}<p style="color: #777;">{{range $index, $tag := .}}{{if $index}}, {{end}}{{$tag}}{{end}}</p>
+{{end}}<div>{{markdown .Rendered.HTML}}</div>
+<p style="color: #777;">Last edited {{.LastEdited}}</p>
+{{end}}{{define "tasks"}}<ul>
+{{range .}}<li>{{if eq .Completed "True"}}<s>{{.Description}}</s>{{else}}{{.Description}}{{end}}{{with .DueDate}} (due {{.}}){{end}}{{with .Subtasks}}
+{{template "tasks" .}}{{end}}</li>
+{{else}}<li>None</li>
+{{end}}</ul>
+{{end}}`
+
+// markdown marks rendered note content as safe, the renderer escapes all HTML written in notes and drops unsafe links
+func markdown(renderedHTML string) htmlTemplate.HTML {
+	return htmlTemplate.HTML(renderedHTML)
+}
+
+var sharedHTMLTemplate = htmlTemplate.Must(htmlTemplate.New("shared.html").Funcs(htmlTemplate.FuncMap{"markdown": markdown}).Parse(sharedHTML))
+
+// A shared page shows content, the password form of a protected link or why the link cannot be opened
+type sharedPage struct {
+	Content          *shareLinkModel.SharedContent
+	PasswordRequired bool
+	Error            string
+}
+
+// writeSharedPage renders a shared page, the page is rendered before writing so a failure still gets a status
+func writeSharedPage(writer http.ResponseWriter, status int, page sharedPage) {
+	var html bytes.Buffer
+	if error := sharedHTMLTemplate.Execute(&html, page); error != nil {
+		log.Printf("failed to render shared page: %v", error)
+		http.Error(writer, "something went wrong, try again later", http.StatusInternalServerError)
+		return
+	}
+
+	setSharedHeaders(writer)
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(status)
+	writer.Write(html.Bytes())
+}
@@ -0,0 +1,337 @@
+package taskService
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Handler function for marking a task as blocked by another task
+func (handler *Handler) handleAddDependencyByTaskID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get taskID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload taskModel.AddTaskDependencyPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if taskID == payload.BlockedByTaskID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("a task cannot block itself"))
+		return
+	}
+
+	// the user has to be able to edit the task and to see its blocker
+	if error := handler.validateTaskOwnership(taskID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if error := handler.validateTaskOwnership(payload.BlockedByTaskID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// the store rejects the dependency if the blocker already waits on the task, in any project
+	if error := handler.store.CreateTaskDependency(taskID, payload.BlockedByTaskID); error != nil {
+		if error == taskModel.ErrDependencyExists || error == taskModel.ErrDependencyCycle {
+			utils.WriteError(writer, http.StatusConflict, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	handler.recordDependencyEvent(request, taskID, nil, payload.BlockedByTaskID)
+
+	utils.WriteJSON(writer, http.StatusCreated, nil)
+}
+
+// Handler function for removing a blocking task from a task
+func (handler *Handler) handleRemoveDependencyByTaskID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get taskID and blockedByTaskID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	blockedByTaskID, error := utils.ParseIDFromURL(request, "blockedByTaskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can edit the task
+	if error := handler.validateTaskOwnership(taskID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if error := handler.store.DeleteTaskDependency(taskID, blockedByTaskID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordDependencyEvent(request, taskID, blockedByTaskID, nil)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for computing the critical path of a project from its dependencies and due dates
+func (handler *Handler) handleGetCriticalPathByLinkedProjectID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get projectID from URL
+	linkedProjectID, error := utils.ParseIDFromURL(request, "projectID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user can read the project
+	if error := handler.validateLinkedProjectID(linkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	tasks, error := handler.store.GetTasksByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	dependencies, error := handler.store.GetTaskDependenciesByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	annotateDependencies(tasks, dependencies)
+
+	utils.WriteJSON(writer, http.StatusOK, computeCriticalPath(linkedProjectID, tasks))
+}
+
+// validateTaskOwnership check if the task exists in a project of the active workspace the user has at least a role in
+func (handler *Handler) validateTaskOwnership(taskID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID, minimumRole string) error {
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		return projectAccessServices.NotFound(fmt.Sprintf("task ID %s does not exist", taskID))
+	}
+
+	// tasks of projects the user is not a member of are reported as missing
+	error = handler.validateLinkedProjectID(task.LinkedProjectID, userID, workspaceID, minimumRole)
+	if projectAccessServices.IsNotFound(error) {
+		return projectAccessServices.NotFound(fmt.Sprintf("task ID %s does not exist", taskID))
+	}
+
+	return error
+}
+
+// recordDependencyEvent records a blocking task added to or removed from a task as an update of the task
+func (handler *Handler) recordDependencyEvent(request *http.Request, taskID uuid.UUID, before any, after any) {
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		log.Printf("failed to record dependency of task %s: %v", taskID, error)
+		return
+	}
+
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: task.LinkedProjectID, Valid: true},
+		EntityType: auditModel.EntityTask,
+		EntityID:   taskID,
+		Action:     auditModel.ActionUpdate,
+	}, blockedBy(before), blockedBy(after))
+}
+
+// blockedBy wraps a blocking task ID as the field of a dependency diff, nil when there is none
+func blockedBy(blockedByTaskID any) map[string]any {
+	if blockedByTaskID == nil {
+		return nil
+	}
+
+	return map[string]any{"blockedByTaskID": blockedByTaskID}
+}
+
+// annotateDependencies fills in the blocking tasks of each task and flags tasks with incomplete blockers
+func annotateDependencies(tasks []*taskModel.Task, dependencies []*taskModel.TaskDependency) {
+	tasksByID := make(map[uuid.UUID]*taskModel.Task)
+	for _, task := range tasks {
+		task.BlockedByTaskIDs = make([]uuid.UUID, 0)
+		tasksByID[task.ID] = task
+	}
+
+	for _, dependency := range dependencies {
+		task, exists := tasksByID[dependency.TaskID]
+		if !exists {
+			continue
+		}
+
+		task.BlockedByTaskIDs = append(task.BlockedByTaskIDs, dependency.BlockedByTaskID)
+		if dependency.BlockerCompleted != "True" {
+			task.Blocked = true
+		}
+	}
+}
+
+// computeCriticalPath finds the chain of dependent tasks that spans the most days between due dates,
+// a task's duration is the time between its due date and the latest due date of its blockers
+func computeCriticalPath(linkedProjectID uuid.UUID, tasks []*taskModel.Task) *taskModel.CriticalPath {
+	criticalPath := &taskModel.CriticalPath{
+		LinkedProjectID: linkedProjectID,
+		Tasks:           make([]*taskModel.Task, 0),
+		Conflicts:       make([]*taskModel.ScheduleConflict, 0),
+	}
+
+	tasksByID := make(map[uuid.UUID]*taskModel.Task)
+	dueDates := make(map[uuid.UUID]time.Time)
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+		if dueDate, error := recurrenceServices.ParseDate(task.DueDate); error == nil {
+			dueDates[task.ID] = dueDate
+		}
+	}
+
+	// order the tasks so that blockers come before the tasks they block, blockers outside the project are ignored
+	dependents := make(map[uuid.UUID][]uuid.UUID)
+	remainingBlockers := make(map[uuid.UUID]int)
+	for _, task := range tasks {
+		for _, blockerID := range task.BlockedByTaskIDs {
+			if _, exists := tasksByID[blockerID]; exists {
+				dependents[blockerID] = append(dependents[blockerID], task.ID)
+				remainingBlockers[task.ID]++
+			}
+		}
+	}
+
+	queue := make([]uuid.UUID, 0)
+	for _, task := range tasks {
+		if remainingBlockers[task.ID] == 0 {
+			queue = append(queue, task.ID)
+		}
+	}
+
+	// longest path by days, walking the tasks in dependency order
+	days := make(map[uuid.UUID]int)
+	previous := make(map[uuid.UUID]uuid.UUID)
+	var last uuid.UUID
+	for len(queue) > 0 {
+		taskID := queue[0]
+		queue = queue[1:]
+		task := tasksByID[taskID]
+
+		// start from the blocker with the longest path, the latest due date breaks ties
+		var latestBlockerID uuid.UUID
+		for _, blockerID := range task.BlockedByTaskIDs {
+			if _, exists := tasksByID[blockerID]; !exists {
+				continue
+			}
+
+			if latestBlockerID == uuid.Nil || days[blockerID] > days[latestBlockerID] ||
+				(days[blockerID] == days[latestBlockerID] && dueDates[blockerID].After(dueDates[latestBlockerID])) {
+				latestBlockerID = blockerID
+			}
+		}
+
+		duration := 0
+		if latestBlockerID != uuid.Nil {
+			previous[taskID] = latestBlockerID
+			duration = days[latestBlockerID]
+
+			dueDate, hasDueDate := dueDates[taskID]
+			blockerDueDate, blockerHasDueDate := dueDates[latestBlockerID]
+			if hasDueDate && blockerHasDueDate {
+				span := int(dueDate.Sub(blockerDueDate).Hours() / 24)
+				if span < 0 {
+					criticalPath.Conflicts = append(criticalPath.Conflicts, &taskModel.ScheduleConflict{
+						TaskID:          taskID,
+						BlockedByTaskID: latestBlockerID,
+						Reason:          "task is due before its blocking task",
+					})
+					span = 0
+				}
+				duration += span
+			}
+		}
+		days[taskID] = duration
+
+		if last == uuid.Nil || days[taskID] > days[last] ||
+			(days[taskID] == days[last] && dueDates[taskID].After(dueDates[last])) {
+			last = taskID
+		}
+
+		for _, dependentID := range dependents[taskID] {
+			remainingBlockers[dependentID]--
+			if remainingBlockers[dependentID] == 0 {
+				queue = append(queue, dependentID)
+			}
+		}
+	}
+
+	if last == uuid.Nil {
+		return criticalPath
+	}
+
+	// walk back from the end of the path
+	for taskID, exists := last, true; exists; taskID, exists = previous[taskID] {
+		criticalPath.Tasks = append([]*taskModel.Task{tasksByID[taskID]}, criticalPath.Tasks...)
+	}
+
+	criticalPath.StartDate = criticalPath.Tasks[0].DueDate
+	criticalPath.EndDate = criticalPath.Tasks[len(criticalPath.Tasks)-1].DueDate
+	criticalPath.DurationInDays = days[last]
+
+	return criticalPath
+}
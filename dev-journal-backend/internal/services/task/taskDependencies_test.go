@@ -0,0 +1,104 @@
+package taskService
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+// newTask creates a task due on dueDate, an empty due date means none
+func newTask(dueDate string, blockedBy ...*taskModel.Task) *taskModel.Task {
+	task := &taskModel.Task{ID: uuid.New(), BlockedByTaskIDs: make([]uuid.UUID, 0)}
+	if dueDate != "" {
+		task.DueDate = &dueDate
+	}
+	for _, blocker := range blockedBy {
+		task.BlockedByTaskIDs = append(task.BlockedByTaskIDs, blocker.ID)
+	}
+	return task
+}
+
+func TestComputeCriticalPath(t *testing.T) {
+	outside := newTask("2024-01-01")
+	first := newTask("2024-01-01")
+	second := newTask("2024-01-05", first, outside)
+	third := newTask("2024-01-11", second)
+	shortcut := newTask("2024-01-03", first)
+	early := newTask("2024-01-02", third)
+	undated := newTask("", shortcut)
+
+	tests := []struct {
+		name      string
+		tasks     []*taskModel.Task
+		path      []*taskModel.Task
+		days      int
+		conflicts []*taskModel.ScheduleConflict
+	}{
+		{name: "no tasks", tasks: []*taskModel.Task{}, path: []*taskModel.Task{}},
+		{name: "single task", tasks: []*taskModel.Task{first}, path: []*taskModel.Task{first}},
+		{name: "longest chain wins", tasks: []*taskModel.Task{third, shortcut, second, first}, path: []*taskModel.Task{first, second, third}, days: 10},
+		{name: "undated tasks add no days", tasks: []*taskModel.Task{first, shortcut, undated}, path: []*taskModel.Task{first, shortcut}, days: 2},
+		{
+			name:      "task due before its blocker",
+			tasks:     []*taskModel.Task{first, second, third, early},
+			path:      []*taskModel.Task{first, second, third},
+			days:      10,
+			conflicts: []*taskModel.ScheduleConflict{{TaskID: early.ID, BlockedByTaskID: third.ID}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projectID := uuid.New()
+			criticalPath := computeCriticalPath(projectID, test.tasks)
+
+			if criticalPath.LinkedProjectID != projectID {
+				t.Errorf("path is of project %s, expected %s", criticalPath.LinkedProjectID, projectID)
+			}
+			if len(criticalPath.Tasks) != len(test.path) {
+				t.Fatalf("path has %d tasks, expected %d", len(criticalPath.Tasks), len(test.path))
+			}
+			for index, task := range test.path {
+				if criticalPath.Tasks[index] != task {
+					t.Errorf("task %d of the path is %s, expected %s", index, criticalPath.Tasks[index].ID, task.ID)
+				}
+			}
+			if criticalPath.DurationInDays != test.days {
+				t.Errorf("path spans %d days, expected %d", criticalPath.DurationInDays, test.days)
+			}
+			if len(test.path) > 0 && (criticalPath.StartDate != test.path[0].DueDate || criticalPath.EndDate != test.path[len(test.path)-1].DueDate) {
+				t.Errorf("path runs from %v to %v", criticalPath.StartDate, criticalPath.EndDate)
+			}
+
+			if len(criticalPath.Conflicts) != len(test.conflicts) {
+				t.Fatalf("found %d conflicts, expected %d", len(criticalPath.Conflicts), len(test.conflicts))
+			}
+			for index, conflict := range test.conflicts {
+				found := criticalPath.Conflicts[index]
+				if found.TaskID != conflict.TaskID || found.BlockedByTaskID != conflict.BlockedByTaskID {
+					t.Errorf("conflict %d is %s blocked by %s", index, found.TaskID, found.BlockedByTaskID)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotateDependencies(t *testing.T) {
+	blocker, done, task := newTask(""), newTask(""), newTask("")
+	annotateDependencies([]*taskModel.Task{blocker, done, task}, []*taskModel.TaskDependency{
+		{TaskID: task.ID, BlockedByTaskID: done.ID, BlockerCompleted: "True"},
+		{TaskID: done.ID, BlockedByTaskID: uuid.New(), BlockerCompleted: "False"},
+		{TaskID: uuid.New(), BlockedByTaskID: blocker.ID, BlockerCompleted: "False"},
+	})
+
+	if len(task.BlockedByTaskIDs) != 1 || task.Blocked {
+		t.Errorf("task is blocked by %v, blocked %v", task.BlockedByTaskIDs, task.Blocked)
+	}
+	if len(done.BlockedByTaskIDs) != 1 || !done.Blocked {
+		t.Errorf("blocked task is blocked by %v, blocked %v", done.BlockedByTaskIDs, done.Blocked)
+	}
+	if len(blocker.BlockedByTaskIDs) != 0 || blocker.Blocked {
+		t.Errorf("blocker is blocked by %v, blocked %v", blocker.BlockedByTaskIDs, blocker.Blocked)
+	}
+}
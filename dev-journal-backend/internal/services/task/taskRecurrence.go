@@ -0,0 +1,285 @@
+package taskService
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Number of upcoming occurrences listed for recurring tasks unless requested otherwise
+const defaultUpcomingOccurrences = 3
+
+// Handler function for setting or clearing the recurrence rule of a task
+func (handler *Handler) handleSetRecurrenceByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get taskID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload taskModel.SetRecurrencePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the task exists
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if error := handler.applyRecurrence(task, payload.RecurrenceRule, payload.RecurrenceMode); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	handler.recordTaskUpdate(request, task)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for skipping the current occurrence of a recurring task
+func (handler *Handler) handleSkipOccurrenceByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get taskID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the task exists and recurs
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if task.RecurrenceRule == nil || !task.RecurrenceSeriesID.Valid {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task does not recur"))
+		return
+	}
+
+	if task.Completed == "True" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("cannot skip a completed occurrence"))
+		return
+	}
+
+	// the task moves on to the following occurrence
+	next, found, error := recurrenceServices.NextOccurrence(task)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !found {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("recurrence has no further occurrences"))
+		return
+	}
+
+	// record the skipped date so it is not generated again
+	current, error := recurrenceServices.ParseDate(task.DueDate)
+	if error == nil {
+		exceptions := addException(task.RecurrenceExceptions, current.Format(time.DateOnly))
+		if error := handler.store.SetRecurrenceExceptionsBySeriesID(exceptions, task.RecurrenceSeriesID.UUID); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	nextDueDate := next.Format(time.DateOnly)
+	if error := handler.store.UpdateTaskByID(taskModel.Task{DueDate: &nextDueDate}, taskID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordTaskUpdate(request, task)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"dueDate": nextDueDate})
+}
+
+// Handler function for excluding a date from a recurring task
+func (handler *Handler) handleAddRecurrenceExceptionByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get taskID from URL
+	taskID, error := utils.ParseIDFromURL(request, "taskID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload taskModel.AddRecurrenceExceptionPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the task exists and recurs
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	if task.RecurrenceRule == nil || !task.RecurrenceSeriesID.Valid {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task does not recur"))
+		return
+	}
+
+	exceptions := addException(task.RecurrenceExceptions, payload.Date)
+	if error := handler.store.SetRecurrenceExceptionsBySeriesID(exceptions, task.RecurrenceSeriesID.UUID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordTaskUpdate(request, task)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string][]string{"recurrenceExceptions": exceptions})
+}
+
+// applyRecurrence sets the rule of a task and moves its due date onto the first occurrence, an empty rule stops the recurrence
+func (handler *Handler) applyRecurrence(task *taskModel.Task, recurrenceRule string, recurrenceMode string) error {
+	if recurrenceRule == "" {
+		return handler.store.SetRecurrenceByID(taskModel.Task{}, task.ID)
+	}
+
+	rule, error := recurrenceServices.ParseRule(recurrenceRule)
+	if error != nil {
+		return error
+	}
+
+	// the series starts at the task's due date, or today if it has none
+	start := time.Now().UTC()
+	if dueDate, error := recurrenceServices.ParseDate(task.DueDate); error == nil {
+		start = dueDate
+	}
+
+	occurrences := rule.Between(start, start, 1, nil)
+	if len(occurrences) == 0 {
+		return fmt.Errorf("recurrence rule has no occurrences")
+	}
+
+	first := occurrences[0].Format(time.DateOnly)
+	seriesID := task.RecurrenceSeriesID
+	if !seriesID.Valid {
+		seriesID = uuid.NullUUID{UUID: task.ID, Valid: true}
+	}
+
+	return handler.store.SetRecurrenceByID(taskModel.Task{
+		DueDate:            &first,
+		RecurrenceRule:     &recurrenceRule,
+		RecurrenceMode:     recurrenceMode,
+		RecurrenceStart:    &first,
+		RecurrenceSeriesID: seriesID,
+	}, task.ID)
+}
+
+// annotateUpcomingOccurrences lists the next occurrences of the recurring tasks, limited by the occurrences query parameter
+func annotateUpcomingOccurrences(request *http.Request, tasks []*taskModel.Task) {
+	limit := defaultUpcomingOccurrences
+	if value, error := strconv.Atoi(request.URL.Query().Get("occurrences")); error == nil {
+		limit = min(max(value, 0), 50)
+	}
+
+	for _, task := range tasks {
+		if task.RecurrenceRule == nil || limit == 0 {
+			continue
+		}
+
+		occurrences, error := recurrenceServices.UpcomingOccurrences(task, limit)
+		if error != nil {
+			continue
+		}
+
+		task.UpcomingOccurrences = make([]string, 0, len(occurrences))
+		for _, occurrence := range occurrences {
+			task.UpcomingOccurrences = append(task.UpcomingOccurrences, occurrence.Format(time.DateOnly))
+		}
+	}
+}
+
+// addException adds a date to the exception dates unless it is already excluded
+func addException(exceptions []string, date string) []string {
+	for _, exception := range exceptions {
+		if exception == date {
+			return exceptions
+		}
+	}
+
+	return append(exceptions, date)
+}
@@ -2,26 +2,38 @@ package taskService
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	projectModel "github.com/hwaengfan/dev-journal-backend/internal/models/project"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	columnModel "github.com/hwaengfan/dev-journal-backend/internal/models/column"
+	notificationModel "github.com/hwaengfan/dev-journal-backend/internal/models/notification"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
 	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
 	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	notificationService "github.com/hwaengfan/dev-journal-backend/internal/services/notification"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	recurrenceServices "github.com/hwaengfan/dev-journal-backend/internal/services/recurrence"
 	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
 type Handler struct {
-	store        taskModel.TaskStore
-	userStore    userModel.UserStore
-	projectStore projectModel.ProjectStore
+	store               taskModel.TaskStore
+	userStore           userModel.UserStore
+	memberStore         projectMemberModel.ProjectMemberStore
+	columnStore         columnModel.ColumnStore
+	recurrenceScheduler *recurrenceServices.Scheduler
+	recorder            *auditServices.Recorder
+	notifier            *notificationService.Notifier
 }
 
-func NewHandler(store taskModel.TaskStore, userStore userModel.UserStore, projectStore projectModel.ProjectStore) *Handler {
-	return &Handler{store: store, userStore: userStore, projectStore: projectStore}
+func NewHandler(store taskModel.TaskStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, columnStore columnModel.ColumnStore, recurrenceScheduler *recurrenceServices.Scheduler, recorder *auditServices.Recorder, notifier *notificationService.Notifier) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, columnStore: columnStore, recurrenceScheduler: recurrenceScheduler, recorder: recorder, notifier: notifier}
 }
 
 func (handler *Handler) RegisterRoutes(router *mux.Router) {
@@ -32,6 +44,18 @@ func (handler *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/tasks/update-task-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleUpdateTaskByID, handler.userStore)).Methods(http.MethodPut)
 
 	router.HandleFunc("/tasks/delete-task-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleDeleteTaskByID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/tasks/add-dependency-by-task-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleAddDependencyByTaskID, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/tasks/remove-dependency-by-task-ID/{taskID}/{blockedByTaskID}", authenticationServices.JWTAuthentication(handler.handleRemoveDependencyByTaskID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/tasks/get-critical-path-by-linked-project-ID/{projectID}", authenticationServices.JWTAuthentication(handler.handleGetCriticalPathByLinkedProjectID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/tasks/set-recurrence-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleSetRecurrenceByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/tasks/skip-occurrence-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleSkipOccurrenceByID, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/tasks/add-recurrence-exception-by-ID/{taskID}", authenticationServices.JWTAuthentication(handler.handleAddRecurrenceExceptionByID, handler.userStore)).Methods(http.MethodPost)
 }
 
 // Handler function for creating a new task
@@ -43,6 +67,9 @@ func (handler *Handler) handleCreateNewTask(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get JSON payload
 	var payload taskModel.CreateTaskPayload
 	if error := utils.ParseJSON(request, &payload); error != nil {
@@ -63,23 +90,96 @@ func (handler *Handler) handleCreateNewTask(writer http.ResponseWriter, request
 		return
 	}
 
-	// check if the project exists
-	if error := handler.validateLinkedProjectID(payload.LinkedProjectID); error != nil {
+	// check if the user can edit the project
+	if error := handler.validateLinkedProjectID(payload.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// check if the recurrence rule is valid
+	if payload.RecurrenceRule != "" {
+		if _, error := recurrenceServices.ParseRule(payload.RecurrenceRule); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	// tasks can only be assigned to members of their project
+	if payload.AssigneeID != uuid.Nil {
+		if error := handler.validateAssignee(payload.LinkedProjectID, payload.AssigneeID); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	// check if the parent task can hold another level of subtasks
+	if payload.ParentTaskID != uuid.Nil {
+		tree, error := handler.getTaskTree(payload.LinkedProjectID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		if error := tree.validateParent(uuid.Nil, payload.ParentTaskID); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	// find the board column of the new task
+	column, error := handler.resolveColumn(payload.LinkedProjectID, payload.ColumnID, payload.Completed)
+	if error != nil {
 		utils.WriteError(writer, http.StatusBadRequest, error)
 		return
 	}
 
+	// new tasks are appended to the end of the column
+	position, error := handler.store.CountTasksByColumnID(column.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	completed := payload.Completed
+	if column.IsDone == "True" {
+		completed = "True"
+	}
+
 	// insert the new task into the database
 	taskID, error := handler.store.CreateTask(taskModel.Task{
 		LinkedProjectID: payload.LinkedProjectID,
+		ParentTaskID:    uuid.NullUUID{UUID: payload.ParentTaskID, Valid: payload.ParentTaskID != uuid.Nil},
+		ColumnID:        uuid.NullUUID{UUID: column.ID, Valid: true},
+		Position:        position,
 		Description:     payload.Description,
-		Completed:       payload.Completed,
+		AssigneeID:      uuid.NullUUID{UUID: payload.AssigneeID, Valid: payload.AssigneeID != uuid.Nil},
+		Completed:       completed,
+		DueDate:         optionalString(payload.DueDate),
 	})
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
 	}
 
+	// start the recurrence series with the new task
+	if payload.RecurrenceRule != "" {
+		task, error := handler.store.GetTaskByID(taskID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		if error := handler.applyRecurrence(task, payload.RecurrenceRule, payload.RecurrenceMode); error != nil {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	if task, error := handler.store.GetTaskByID(taskID); error == nil {
+		handler.recordTaskEvent(request, auditModel.ActionCreate, nil, task)
+		handler.notifyAssignee(userID.UUID, task)
+	}
+
 	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"taskID": taskID})
 }
 
@@ -92,6 +192,9 @@ func (handler *Handler) handleGetTasksByLinkedProjectID(writer http.ResponseWrit
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get projectID from URL
 	linkedProjectIDString, exists := mux.Vars(request)["projectID"]
 	if !exists {
@@ -111,19 +214,36 @@ func (handler *Handler) handleGetTasksByLinkedProjectID(writer http.ResponseWrit
 		return
 	}
 
-	// check if the project exists
-	if error := handler.validateLinkedProjectID(linkedProjectID); error != nil {
-		utils.WriteError(writer, http.StatusBadRequest, error)
+	// check if the user can read the project
+	if error := handler.validateLinkedProjectID(linkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleViewer); error != nil {
+		projectAccessServices.WriteError(writer, error)
 		return
 	}
 
-	// get tasks by projectID
+	// get tasks by projectID with their completion rolled up
 	tasks, error := handler.store.GetTasksByLinkedProjectID(linkedProjectID)
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
 	}
 
+	// flag the tasks that wait on incomplete blockers
+	dependencies, error := handler.store.GetTaskDependenciesByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	annotateDependencies(tasks, dependencies)
+	annotateUpcomingOccurrences(request, tasks)
+	tree := newTaskTree(tasks)
+
+	// return the subtasks nested below their parents when requested
+	if request.URL.Query().Get("nested") == "true" {
+		utils.WriteJSON(writer, http.StatusOK, tree.nest())
+		return
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, tasks)
 }
 
@@ -136,6 +256,9 @@ func (handler *Handler) handleUpdateTaskByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get taskID from URL
 	taskIDString, exists := mux.Vars(request)["taskID"]
 	if !exists {
@@ -164,36 +287,210 @@ func (handler *Handler) handleUpdateTaskByID(writer http.ResponseWriter, request
 	}
 
 	// check if the task exists
-	error = handler.validateTaskID(taskID)
+	task, error := handler.store.GetTaskByID(taskID)
 	if error != nil {
-		utils.WriteError(writer, http.StatusBadRequest, error)
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task ID does not exist"))
+		return
+	}
+
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
 		return
 	}
 
 	// check if the linkedProjectID is provided
 	if payload.LinkedProjectID != uuid.Nil {
-		// check if the project exists
-		if error := handler.validateLinkedProjectID(payload.LinkedProjectID); error != nil {
-			utils.WriteError(writer, http.StatusBadRequest, error)
+		// check if the user can edit the project the task moves to
+		if error := handler.validateLinkedProjectID(payload.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+			projectAccessServices.WriteError(writer, error)
 			return
 		}
 	}
 
-	// update the task by ID
-	error = handler.store.UpdateTaskByID(taskModel.Task{
-		LinkedProjectID: payload.LinkedProjectID,
-		Description:     payload.Description,
-		Completed:       payload.Completed,
-	}, taskID)
+	tree, error := handler.getTaskTree(task.LinkedProjectID)
 	if error != nil {
-		if error.Error() == "no fields to update" {
-			utils.WriteError(writer, http.StatusBadRequest, error)
-		} else {
-			utils.WriteError(writer, http.StatusInternalServerError, error)
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// subtasks live in the same project as their parent
+	changesProject := payload.LinkedProjectID != uuid.Nil && payload.LinkedProjectID != task.LinkedProjectID
+	if changesProject && len(tree.children[taskID]) > 0 {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("a task with subtasks cannot be moved to another project"))
+		return
+	}
+
+	// work out the new parent of the task
+	parentTaskID := task.ParentTaskID
+	if changesProject {
+		parentTaskID = uuid.NullUUID{}
+	}
+
+	if payload.ParentTaskID != nil {
+		parentTaskID = uuid.NullUUID{UUID: *payload.ParentTaskID, Valid: *payload.ParentTaskID != uuid.Nil}
+		if parentTaskID.Valid {
+			targetTree := tree
+			if changesProject {
+				targetTree, error = handler.getTaskTree(payload.LinkedProjectID)
+				if error != nil {
+					utils.WriteError(writer, http.StatusInternalServerError, error)
+					return
+				}
+			}
+
+			if error := targetTree.validateParent(taskID, parentTaskID.UUID); error != nil {
+				utils.WriteError(writer, http.StatusBadRequest, error)
+				return
+			}
+		}
+	}
+
+	// work out the new assignee, who has to be a member of the project the task ends up in
+	assigneeID := task.AssigneeID
+	if payload.AssigneeID != nil {
+		assigneeID = uuid.NullUUID{UUID: *payload.AssigneeID, Valid: *payload.AssigneeID != uuid.Nil}
+	}
+
+	if assigneeID.Valid && (payload.AssigneeID != nil || changesProject) {
+		linkedProjectID := task.LinkedProjectID
+		if changesProject {
+			linkedProjectID = payload.LinkedProjectID
+		}
+
+		if error := handler.validateAssignee(linkedProjectID, assigneeID.UUID); error != nil {
+			// a task moved to a project its assignee is not part of is unassigned
+			if payload.AssigneeID != nil {
+				utils.WriteError(writer, http.StatusBadRequest, error)
+				return
+			}
+			assigneeID = uuid.NullUUID{}
 		}
+	}
+
+	// the due date is either set or removed
+	if payload.ClearDueDate && payload.DueDate != "" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("dueDate and clearDueDate cannot be combined"))
+		return
+	}
+
+	// the occurrences of a recurring task are counted from its due date
+	if payload.ClearDueDate && task.RecurrenceRule != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("a recurring task keeps its due date, stop the recurrence first"))
+		return
+	}
+
+	changesParent := parentTaskID != task.ParentTaskID
+	changesAssignee := assigneeID != task.AssigneeID
+	clearsDueDate := payload.ClearDueDate && task.DueDate != nil
+	if payload.LinkedProjectID == uuid.Nil && payload.Description == "" && payload.Completed == "" && payload.DueDate == "" && !changesParent && !changesAssignee && !payload.ClearDueDate {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("no fields to update"))
 		return
 	}
 
+	// update the task by ID
+	if payload.LinkedProjectID != uuid.Nil || payload.Description != "" || payload.Completed != "" || payload.DueDate != "" {
+		error = handler.store.UpdateTaskByID(taskModel.Task{
+			LinkedProjectID: payload.LinkedProjectID,
+			Description:     payload.Description,
+			Completed:       payload.Completed,
+			DueDate:         optionalString(payload.DueDate),
+		}, taskID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	if changesParent {
+		if error := handler.store.SetParentTaskByID(taskID, parentTaskID); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	if changesAssignee {
+		if error := handler.store.SetAssigneeByID(taskID, assigneeID); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	if clearsDueDate {
+		if error := handler.store.SetDueDateByID(taskID, nil); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	// the board column follows the completion state, a task moved to another project is placed below
+	if payload.Completed != "" && !changesProject {
+		if error := handler.syncColumn(task, payload.Completed); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	// cascade the completion state to all subtasks when requested
+	if payload.Completed != "" && payload.CascadeCompletion {
+		for _, descendant := range tree.descendants(taskID) {
+			if error := handler.store.UpdateTaskByID(taskModel.Task{Completed: payload.Completed}, descendant.ID); error != nil {
+				utils.WriteError(writer, http.StatusInternalServerError, error)
+				return
+			}
+
+			if error := handler.syncColumn(descendant, payload.Completed); error != nil {
+				utils.WriteError(writer, http.StatusInternalServerError, error)
+				return
+			}
+
+			handler.recordTaskUpdate(request, descendant)
+
+			// recurring subtasks completed along with the task recur like the task itself
+			if payload.Completed == "True" && descendant.Completed != "True" && descendant.RecurrenceRule != nil {
+				if error := handler.generateNextOccurrence(descendant.ID); error != nil {
+					utils.WriteError(writer, http.StatusInternalServerError, error)
+					return
+				}
+			}
+		}
+	}
+
+	// completing a recurring task brings up its next occurrence
+	if payload.Completed == "True" && task.Completed != "True" && task.RecurrenceRule != nil {
+		if error := handler.generateNextOccurrence(taskID); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	// a task moved to another project starts over on that project's board
+	if changesProject {
+		column, error := handler.resolveColumn(payload.LinkedProjectID, uuid.Nil, payload.Completed)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		completed := payload.Completed
+		if completed == "" {
+			completed = task.Completed
+		}
+
+		if error := handler.store.MoveTaskByID(taskID, column.ID, 0, completed); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	handler.recordTaskUpdate(request, task)
+
+	if changesAssignee && assigneeID.Valid {
+		if updatedTask, error := handler.store.GetTaskByID(taskID); error == nil {
+			handler.notifyAssignee(userID.UUID, updatedTask)
+		}
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
 
@@ -206,6 +503,9 @@ func (handler *Handler) handleDeleteTaskByID(writer http.ResponseWriter, request
 		return
 	}
 
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
 	// get taskID from URL
 	taskIDString, exists := mux.Vars(request)["taskID"]
 	if !exists {
@@ -220,12 +520,37 @@ func (handler *Handler) handleDeleteTaskByID(writer http.ResponseWriter, request
 	}
 
 	// check if the task exists
-	_, error = handler.store.GetTaskByID(taskID)
+	task, error := handler.store.GetTaskByID(taskID)
 	if error != nil {
 		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("task does not exist"))
 		return
 	}
 
+	// check if the user can edit the project of the task
+	if error := handler.validateLinkedProjectID(task.LinkedProjectID, userID.UUID, workspaceID, projectMemberModel.RoleEditor); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	tree, error := handler.getTaskTree(task.LinkedProjectID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// subtasks are deleted along with the task when cascading, otherwise they move up a level
+	cascade := request.URL.Query().Get("cascade") == "true"
+	if !cascade {
+		for _, child := range tree.children[taskID] {
+			if error := handler.store.SetParentTaskByID(child.ID, task.ParentTaskID); error != nil {
+				utils.WriteError(writer, http.StatusInternalServerError, error)
+				return
+			}
+
+			handler.recordTaskUpdate(request, child)
+		}
+	}
+
 	// delete the task by ID
 	error = handler.store.DeleteTaskByID(taskID)
 	if error != nil {
@@ -233,25 +558,183 @@ func (handler *Handler) handleDeleteTaskByID(writer http.ResponseWriter, request
 		return
 	}
 
+	handler.recordTaskEvent(request, auditModel.ActionDelete, task, nil)
+	if cascade {
+		for _, descendant := range tree.descendants(taskID) {
+			handler.recordTaskEvent(request, auditModel.ActionDelete, descendant, nil)
+		}
+	}
+
 	utils.WriteJSON(writer, http.StatusOK, nil)
 }
 
-// validateLinkedProjectID check if the project exists
-func (handler *Handler) validateLinkedProjectID(linkedProjectID uuid.UUID) error {
-	_, error := handler.projectStore.GetProjectByID(linkedProjectID)
+// validateLinkedProjectID check if the project exists in the active workspace and the user has at least a role in it
+func (handler *Handler) validateLinkedProjectID(linkedProjectID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID, minimumRole string) error {
+	_, error := projectAccessServices.Authorize(handler.memberStore, linkedProjectID, userID, workspaceID, minimumRole)
+	return error
+}
+
+// validateAssignee checks if a user is a member of the project a task is assigned in
+func (handler *Handler) validateAssignee(linkedProjectID uuid.UUID, assigneeID uuid.UUID) error {
+	members, error := handler.memberStore.GetProjectMembers(linkedProjectID)
 	if error != nil {
-		return fmt.Errorf("project ID does not exist")
+		return error
 	}
 
-	return nil
+	for _, member := range members {
+		if member.UserID == assigneeID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("assignee is not a member of the project")
 }
 
-// validateTaskID check if the task exists
-func (handler *Handler) validateTaskID(taskID uuid.UUID) error {
-	_, error := handler.store.GetTaskByID(taskID)
+// notifyAssignee lets the assignee of a task know someone else assigned it to them
+func (handler *Handler) notifyAssignee(actorID uuid.UUID, task *taskModel.Task) {
+	if !task.AssigneeID.Valid {
+		return
+	}
+
+	actor, error := handler.userStore.GetUserByID(actorID)
 	if error != nil {
-		return fmt.Errorf("task ID does not exist")
+		log.Printf("failed to notify assignee of task %s: %v", task.ID, error)
+		return
 	}
 
+	error = handler.notifier.Notify(notificationModel.Notification{
+		UserID:     task.AssigneeID.UUID,
+		Type:       notificationModel.TypeTaskAssigned,
+		ActorID:    uuid.NullUUID{UUID: actorID, Valid: true},
+		ProjectID:  uuid.NullUUID{UUID: task.LinkedProjectID, Valid: true},
+		EntityType: notificationModel.EntityTask,
+		EntityID:   task.ID,
+		Message:    fmt.Sprintf("%s %s assigned you to the task %s", actor.FirstName, actor.LastName, task.Description),
+	})
+	if error != nil {
+		log.Printf("failed to notify assignee of task %s: %v", task.ID, error)
+	}
+}
+
+// recordTaskEvent records a change of a task in the activity of its project, a task moved to another project shows up in the activity of the project it moved to
+func (handler *Handler) recordTaskEvent(request *http.Request, action string, before *taskModel.Task, after *taskModel.Task) {
+	task := after
+	if task == nil {
+		task = before
+	}
+
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		ProjectID:  uuid.NullUUID{UUID: task.LinkedProjectID, Valid: true},
+		EntityType: auditModel.EntityTask,
+		EntityID:   task.ID,
+		Action:     action,
+	}, before, after)
+}
+
+// recordTaskUpdate records the changes made to a task since before was read
+func (handler *Handler) recordTaskUpdate(request *http.Request, before *taskModel.Task) {
+	after, error := handler.store.GetTaskByID(before.ID)
+	if error != nil {
+		log.Printf("failed to record update of task %s: %v", before.ID, error)
+		return
+	}
+
+	handler.recordTaskEvent(request, auditModel.ActionUpdate, before, after)
+}
+
+// getTaskTree retrieves the tasks of a project indexed by parent
+func (handler *Handler) getTaskTree(linkedProjectID uuid.UUID) (*taskTree, error) {
+	tasks, error := handler.store.GetTasksByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		return nil, error
+	}
+
+	return newTaskTree(tasks), nil
+}
+
+// resolveColumn finds the board column a task is placed in, defaulting to the first column or the first done column for completed tasks
+func (handler *Handler) resolveColumn(linkedProjectID uuid.UUID, columnID uuid.UUID, completed string) (*columnModel.Column, error) {
+	if columnID != uuid.Nil {
+		column, error := handler.columnStore.GetColumnByID(columnID)
+		if error != nil {
+			return nil, fmt.Errorf("column ID does not exist")
+		}
+
+		if column.LinkedProjectID != linkedProjectID {
+			return nil, fmt.Errorf("column does not belong to the linked project")
+		}
+
+		return column, nil
+	}
+
+	columns, error := handler.columnStore.GetColumnsByLinkedProjectID(linkedProjectID)
+	if error != nil {
+		return nil, error
+	}
+
+	if completed == "True" {
+		for _, column := range columns {
+			if column.IsDone == "True" {
+				return column, nil
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("project has no board columns")
+	}
+
+	return columns[0], nil
+}
+
+// generateNextOccurrence brings up the next occurrence of a recurring task that was just completed, as stored after the completion
+func (handler *Handler) generateNextOccurrence(taskID uuid.UUID) error {
+	task, error := handler.store.GetTaskByID(taskID)
+	if error != nil {
+		return error
+	}
+
+	_, error = handler.recurrenceScheduler.GenerateNextOccurrence(task)
+	return error
+}
+
+// syncColumn moves a task whose completion changed outside of the board to the end of the first column matching it, like a move on the board would
+func (handler *Handler) syncColumn(task *taskModel.Task, completed string) error {
+	// tasks already in a matching column stay where they are
+	if task.ColumnID.Valid {
+		column, error := handler.columnStore.GetColumnByID(task.ColumnID.UUID)
+		if error == nil && (column.IsDone == "True") == (completed == "True") {
+			return nil
+		}
+	}
+
+	columns, error := handler.columnStore.GetColumnsByLinkedProjectID(task.LinkedProjectID)
+	if error != nil {
+		return error
+	}
+
+	for _, column := range columns {
+		if (column.IsDone == "True") != (completed == "True") {
+			continue
+		}
+
+		position, error := handler.store.CountTasksByColumnID(column.ID)
+		if error != nil {
+			return error
+		}
+
+		return handler.store.MoveTaskByID(task.ID, column.ID, position, completed)
+	}
+
+	// boards without a matching column keep the task where it is
 	return nil
 }
+
+// optionalString turns an omitted string field into nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,134 @@
+package taskService
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+)
+
+// taskTree indexes the tasks of a project by ID and by parent
+type taskTree struct {
+	tasks    map[uuid.UUID]*taskModel.Task
+	children map[uuid.UUID][]*taskModel.Task
+	roots    []*taskModel.Task
+}
+
+// newTaskTree indexes a project's tasks and rolls the completion percentage up to the parents
+func newTaskTree(tasks []*taskModel.Task) *taskTree {
+	tree := &taskTree{
+		tasks:    make(map[uuid.UUID]*taskModel.Task),
+		children: make(map[uuid.UUID][]*taskModel.Task),
+		roots:    make([]*taskModel.Task, 0),
+	}
+
+	for _, task := range tasks {
+		tree.tasks[task.ID] = task
+	}
+
+	for _, task := range tasks {
+		if _, exists := tree.tasks[task.ParentTaskID.UUID]; task.ParentTaskID.Valid && exists {
+			tree.children[task.ParentTaskID.UUID] = append(tree.children[task.ParentTaskID.UUID], task)
+		} else {
+			tree.roots = append(tree.roots, task)
+		}
+	}
+
+	for _, root := range tree.roots {
+		tree.rollUpCompletion(root)
+	}
+
+	return tree
+}
+
+// rollUpCompletion sets the completion percentage of a task, a parent is the average of its subtasks unless completed itself
+func (tree *taskTree) rollUpCompletion(task *taskModel.Task) float64 {
+	children := tree.children[task.ID]
+
+	total := 0.0
+	for _, child := range children {
+		total += tree.rollUpCompletion(child)
+	}
+
+	switch {
+	case task.Completed == "True":
+		task.CompletionPercentage = 100
+	case len(children) == 0:
+		task.CompletionPercentage = 0
+	default:
+		task.CompletionPercentage = total / float64(len(children))
+	}
+
+	return task.CompletionPercentage
+}
+
+// nest attaches the subtasks to their parents and returns the root tasks
+func (tree *taskTree) nest() []*taskModel.Task {
+	for parentID, children := range tree.children {
+		tree.tasks[parentID].Subtasks = children
+	}
+
+	return tree.roots
+}
+
+// descendants returns all subtasks below a task, deepest first
+func (tree *taskTree) descendants(taskID uuid.UUID) []*taskModel.Task {
+	descendants := make([]*taskModel.Task, 0)
+	for _, child := range tree.children[taskID] {
+		descendants = append(descendants, tree.descendants(child.ID)...)
+		descendants = append(descendants, child)
+	}
+
+	return descendants
+}
+
+// depth returns the nesting level of a task, root tasks are at depth 1
+func (tree *taskTree) depth(taskID uuid.UUID) int {
+	depth := 0
+	for task, exists := tree.tasks[taskID]; exists && depth <= taskModel.MaxTaskDepth; task, exists = tree.tasks[task.ParentTaskID.UUID] {
+		depth++
+		if !task.ParentTaskID.Valid {
+			break
+		}
+	}
+
+	return depth
+}
+
+// height returns the number of levels in the subtree of a task, including the task itself
+func (tree *taskTree) height(taskID uuid.UUID) int {
+	height := 0
+	for _, child := range tree.children[taskID] {
+		height = max(height, tree.height(child.ID))
+	}
+
+	return height + 1
+}
+
+// validateParent checks that a task can be placed below a parent without a cycle or exceeding the depth limit
+func (tree *taskTree) validateParent(taskID uuid.UUID, parentTaskID uuid.UUID) error {
+	if _, exists := tree.tasks[parentTaskID]; !exists {
+		return fmt.Errorf("parent task must belong to the same project")
+	}
+
+	if taskID == parentTaskID {
+		return fmt.Errorf("a task cannot be its own parent")
+	}
+
+	for _, descendant := range tree.descendants(taskID) {
+		if descendant.ID == parentTaskID {
+			return fmt.Errorf("a task cannot be placed below its own subtask")
+		}
+	}
+
+	height := 1
+	if taskID != uuid.Nil {
+		height = tree.height(taskID)
+	}
+
+	if tree.depth(parentTaskID)+height > taskModel.MaxTaskDepth {
+		return fmt.Errorf("subtasks cannot be nested deeper than %d levels", taskModel.MaxTaskDepth)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package timeEntryService
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	timeEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/timeEntry"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Handler function for getting the user's tracked time per project and per day
+func (handler *Handler) handleGetTimeTotals(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	filter, location, error := parseFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	timeEntries, error := handler.store.GetTimeEntriesByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, computeTotals(timeEntries, filter, location, time.Now()))
+}
+
+// Handler function for exporting the user's time entries as CSV
+func (handler *Handler) handleExportTimeEntries(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	filter, location, error := parseFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	timeEntries, error := handler.store.GetTimeEntriesByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/csv")
+	writer.Header().Set("Content-Disposition", "attachment; filename=\"time-entries.csv\"")
+	writer.WriteHeader(http.StatusOK)
+
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"project", "task", "description", "startedAt", "endedAt", "durationInSeconds"})
+
+	// oldest entries first reads better in a spreadsheet
+	for index := len(timeEntries) - 1; index >= 0; index-- {
+		timeEntry := timeEntries[index]
+
+		endedAt := ""
+		if timeEntry.EndedAt != nil {
+			endedAt = timeEntry.EndedAt.In(location).Format(time.RFC3339)
+		}
+
+		csvWriter.Write([]string{
+			timeEntry.ProjectTitle,
+			timeEntry.TaskDescription,
+			timeEntry.Description,
+			timeEntry.StartedAt.In(location).Format(time.RFC3339),
+			endedAt,
+			strconv.FormatInt(timeEntry.DurationInSeconds, 10),
+		})
+	}
+
+	csvWriter.Flush()
+}
+
+// parseFilter reads the from, to, projectID and timezone query parameters, entries are limited to the active workspace
+func parseFilter(request *http.Request) (timeEntryModel.TimeEntryFilter, *time.Location, error) {
+	filter := timeEntryModel.TimeEntryFilter{WorkspaceID: uuid.NullUUID{UUID: authenticationServices.GetWorkspaceIDFromContext(request.Context()), Valid: true}}
+
+	location, error := utils.ParseLocationFromQuery(request)
+	if error != nil {
+		return filter, nil, error
+	}
+
+	filter.From, filter.To, error = utils.ParseTimeRangeFromQuery(request, location)
+	if error != nil {
+		return filter, nil, error
+	}
+
+	if value := request.URL.Query().Get("projectID"); value != "" {
+		projectID, error := uuid.Parse(value)
+		if error != nil {
+			return filter, nil, fmt.Errorf("invalid projectID")
+		}
+		filter.LinkedProjectID = projectID
+	}
+
+	return filter, location, nil
+}
+
+// computeTotals sums the time entries per project and per day, clipped to the filter's range, entries crossing midnight are split
+func computeTotals(timeEntries []*timeEntryModel.TimeEntry, filter timeEntryModel.TimeEntryFilter, location *time.Location, now time.Time) timeEntryModel.TimeTotals {
+	totals := timeEntryModel.TimeTotals{Projects: make([]*timeEntryModel.ProjectTotal, 0), Days: make([]*timeEntryModel.DayTotal, 0)}
+	projects := make(map[uuid.UUID]*timeEntryModel.ProjectTotal)
+	days := make(map[string]*timeEntryModel.DayTotal)
+
+	for _, timeEntry := range timeEntries {
+		start := timeEntry.StartedAt.In(location)
+		end := now.In(location)
+		if timeEntry.EndedAt != nil {
+			end = timeEntry.EndedAt.In(location)
+		}
+
+		if filter.From != nil && start.Before(*filter.From) {
+			start = filter.From.In(location)
+		}
+		if filter.To != nil && end.After(*filter.To) {
+			end = filter.To.In(location)
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		// split the entry at each midnight of the location
+		for cursor := start; cursor.Before(end); {
+			midnight := time.Date(cursor.Year(), cursor.Month(), cursor.Day()+1, 0, 0, 0, 0, location)
+			segmentEnd := end
+			if midnight.Before(end) {
+				segmentEnd = midnight
+			}
+
+			seconds := int64(segmentEnd.Sub(cursor).Seconds())
+			date := cursor.Format(time.DateOnly)
+			if days[date] == nil {
+				days[date] = &timeEntryModel.DayTotal{Date: date}
+				totals.Days = append(totals.Days, days[date])
+			}
+			days[date].DurationInSeconds += seconds
+
+			cursor = segmentEnd
+		}
+
+		seconds := int64(end.Sub(start).Seconds())
+		if projects[timeEntry.LinkedProjectID] == nil {
+			projects[timeEntry.LinkedProjectID] = &timeEntryModel.ProjectTotal{LinkedProjectID: timeEntry.LinkedProjectID, ProjectTitle: timeEntry.ProjectTitle}
+			totals.Projects = append(totals.Projects, projects[timeEntry.LinkedProjectID])
+		}
+		projects[timeEntry.LinkedProjectID].DurationInSeconds += seconds
+		totals.DurationInSeconds += seconds
+	}
+
+	sort.Slice(totals.Days, func(i, j int) bool {
+		return totals.Days[i].Date < totals.Days[j].Date
+	})
+	sort.Slice(totals.Projects, func(i, j int) bool {
+		return totals.Projects[i].DurationInSeconds > totals.Projects[j].DurationInSeconds
+	})
+
+	return totals
+}
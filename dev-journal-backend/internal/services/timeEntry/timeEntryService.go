@@ -0,0 +1,359 @@
+package timeEntryService
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	projectMemberModel "github.com/hwaengfan/dev-journal-backend/internal/models/projectMember"
+	taskModel "github.com/hwaengfan/dev-journal-backend/internal/models/task"
+	timeEntryModel "github.com/hwaengfan/dev-journal-backend/internal/models/timeEntry"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	projectAccessServices "github.com/hwaengfan/dev-journal-backend/internal/services/projectAccess"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+type Handler struct {
+	store       timeEntryModel.TimeEntryStore
+	userStore   userModel.UserStore
+	memberStore projectMemberModel.ProjectMemberStore
+	taskStore   taskModel.TaskStore
+}
+
+func NewHandler(store timeEntryModel.TimeEntryStore, userStore userModel.UserStore, memberStore projectMemberModel.ProjectMemberStore, taskStore taskModel.TaskStore) *Handler {
+	return &Handler{store: store, userStore: userStore, memberStore: memberStore, taskStore: taskStore}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/time-entries/start-timer", authenticationServices.JWTAuthentication(handler.handleStartTimer, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/time-entries/stop-timer", authenticationServices.JWTAuthentication(handler.handleStopTimer, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/time-entries/get-running-timer", authenticationServices.JWTAuthentication(handler.handleGetRunningTimer, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/time-entries/create-new-time-entry", authenticationServices.JWTAuthentication(handler.handleCreateNewTimeEntry, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/time-entries/get-time-entries-by-user-ID", authenticationServices.JWTAuthentication(handler.handleGetTimeEntriesByUserID, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/time-entries/update-time-entry-by-ID/{timeEntryID}", authenticationServices.JWTAuthentication(handler.handleUpdateTimeEntryByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/time-entries/delete-time-entry-by-ID/{timeEntryID}", authenticationServices.JWTAuthentication(handler.handleDeleteTimeEntryByID, handler.userStore)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/time-entries/get-time-totals", authenticationServices.JWTAuthentication(handler.handleGetTimeTotals, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/time-entries/export-time-entries", authenticationServices.JWTAuthentication(handler.handleExportTimeEntries, handler.userStore)).Methods(http.MethodGet)
+}
+
+// Handler function for starting a timer on a task
+func (handler *Handler) handleStartTimer(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload timeEntryModel.StartTimerPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the task exists in a project the user can edit
+	if error := handler.validateTaskOwnership(payload.TaskID, userID.UUID, workspaceID); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	// only one timer can run at a time
+	if runningTimeEntry, error := handler.store.GetRunningTimeEntryByUserID(userID.UUID); error == nil {
+		utils.WriteJSON(writer, http.StatusConflict, map[string]any{"error": "a timer is already running", "timeEntry": runningTimeEntry})
+		return
+	}
+
+	timeEntryID, error := handler.store.CreateTimeEntry(timeEntryModel.TimeEntry{
+		UserID:      userID.UUID,
+		TaskID:      payload.TaskID,
+		Description: payload.Description,
+		StartedAt:   time.Now(),
+	})
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"timeEntryID": timeEntryID})
+}
+
+// Handler function for stopping the running timer
+func (handler *Handler) handleStopTimer(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	runningTimeEntry, error := handler.store.GetRunningTimeEntryByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("no timer is running"))
+		return
+	}
+
+	if error := handler.store.StopTimeEntryByID(runningTimeEntry.ID, time.Now()); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	timeEntry, error := handler.store.GetTimeEntryByID(runningTimeEntry.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, timeEntry)
+}
+
+// Handler function for getting the running timer
+func (handler *Handler) handleGetRunningTimer(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	runningTimeEntry, error := handler.store.GetRunningTimeEntryByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteJSON(writer, http.StatusOK, nil)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, runningTimeEntry)
+}
+
+// Handler function for creating a manual time entry
+func (handler *Handler) handleCreateNewTimeEntry(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get JSON payload
+	var payload timeEntryModel.CreateTimeEntryPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the task exists in a project the user can edit
+	if error := handler.validateTaskOwnership(payload.TaskID, userID.UUID, workspaceID); error != nil {
+		projectAccessServices.WriteError(writer, error)
+		return
+	}
+
+	timeEntryID, error := handler.store.CreateTimeEntry(timeEntryModel.TimeEntry{
+		UserID:      userID.UUID,
+		TaskID:      payload.TaskID,
+		Description: payload.Description,
+		StartedAt:   payload.StartedAt,
+		EndedAt:     &payload.EndedAt,
+	})
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"timeEntryID": timeEntryID})
+}
+
+// Handler function for getting the user's time entries, optionally filtered by range and project
+func (handler *Handler) handleGetTimeEntriesByUserID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	filter, _, error := parseFilter(request)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	timeEntries, error := handler.store.GetTimeEntriesByUserID(userID.UUID, filter)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, timeEntries)
+}
+
+// Handler function for updating a time entry by ID
+func (handler *Handler) handleUpdateTimeEntryByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get timeEntryID from URL
+	timeEntryID, error := utils.ParseIDFromURL(request, "timeEntryID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload timeEntryModel.UpdateTimeEntryPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the time entry belongs to the user and the active workspace
+	timeEntry, error := handler.store.GetTimeEntryByID(timeEntryID)
+	if error != nil || timeEntry.UserID != userID.UUID || timeEntry.WorkspaceID != workspaceID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("time entry ID does not exist"))
+		return
+	}
+
+	if payload.TaskID != uuid.Nil {
+		if error := handler.validateTaskOwnership(payload.TaskID, userID.UUID, workspaceID); error != nil {
+			projectAccessServices.WriteError(writer, error)
+			return
+		}
+	}
+
+	// the entry has to end after it starts, a running timer is stopped through stop-timer
+	startedAt := timeEntry.StartedAt
+	if payload.StartedAt != nil {
+		startedAt = *payload.StartedAt
+	}
+
+	endedAt := timeEntry.EndedAt
+	if payload.EndedAt != nil {
+		if timeEntry.EndedAt == nil {
+			utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("stop the running timer before editing its end"))
+			return
+		}
+		endedAt = payload.EndedAt
+	}
+
+	if endedAt != nil && !endedAt.After(startedAt) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("time entry must end after it starts"))
+		return
+	}
+
+	// update the time entry by ID
+	update := timeEntryModel.TimeEntry{
+		TaskID:      payload.TaskID,
+		Description: payload.Description,
+		EndedAt:     payload.EndedAt,
+	}
+	if payload.StartedAt != nil {
+		update.StartedAt = *payload.StartedAt
+	}
+
+	error = handler.store.UpdateTimeEntryByID(update, timeEntryID)
+	if error != nil {
+		if error.Error() == "no fields to update" {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for deleting a time entry by ID
+func (handler *Handler) handleDeleteTimeEntryByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the active workspace
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+
+	// get timeEntryID from URL
+	timeEntryID, error := utils.ParseIDFromURL(request, "timeEntryID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the time entry belongs to the user and the active workspace
+	timeEntry, error := handler.store.GetTimeEntryByID(timeEntryID)
+	if error != nil || timeEntry.UserID != userID.UUID || timeEntry.WorkspaceID != workspaceID {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("time entry does not exist"))
+		return
+	}
+
+	if error := handler.store.DeleteTimeEntryByID(timeEntryID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// validateTaskOwnership check if the task exists in a project of the active workspace the user can edit
+func (handler *Handler) validateTaskOwnership(taskID uuid.UUID, userID uuid.UUID, workspaceID uuid.UUID) error {
+	task, error := handler.taskStore.GetTaskByID(taskID)
+	if error != nil {
+		return projectAccessServices.NotFound("task ID does not exist")
+	}
+
+	// tasks of projects the user is not a member of are reported as missing
+	_, error = projectAccessServices.Authorize(handler.memberStore, task.LinkedProjectID, userID, workspaceID, projectMemberModel.RoleEditor)
+	if projectAccessServices.IsNotFound(error) {
+		return projectAccessServices.NotFound("task ID does not exist")
+	}
+
+	return error
+}
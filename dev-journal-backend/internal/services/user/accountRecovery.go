@@ -0,0 +1,349 @@
+package userService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	userTokenModel "github.com/hwaengfan/dev-journal-backend/internal/models/userToken"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	mailServices "github.com/hwaengfan/dev-journal-backend/internal/services/mail"
+	queueService "github.com/hwaengfan/dev-journal-backend/internal/services/queue"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// How long the tokens sent by mail stay valid
+const (
+	emailVerificationExpiration = 24 * time.Hour
+	passwordResetExpiration     = time.Hour
+	accountUnlockExpiration     = time.Hour
+)
+
+// Tokens a user can be sent per purpose within an hour
+const tokensPerHour = 3
+
+// Type of the mails with the link of a user token
+const userTokenMail = "user-token"
+
+// The reference of a queued token mail, the token itself is only created when the mail is sent
+type userTokenMailReference struct {
+	TokenID uuid.UUID `json:"tokenID"`
+	UserID  uuid.UUID `json:"userID"`
+	Purpose string    `json:"purpose"`
+}
+
+// Handler function for verifying an email with the token sent on registration
+func (handler *Handler) handleVerifyEmail(writer http.ResponseWriter, request *http.Request) {
+	// get JSON payload
+	var payload userModel.VerifyEmailPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("verify:" + utils.GetClientIP(request)) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	userID, error := handler.tokenStore.ConsumeUserToken(userTokenModel.PurposeEmailVerification, authenticationServices.HashToken(payload.Token))
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.SetEmailVerifiedByID(userID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordUserUpdate(request, user)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for sending a new verification mail to the logged in user
+func (handler *Handler) handleResendVerificationEmail(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.EmailVerified == "True" {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("email is already verified"))
+		return
+	}
+
+	if error := handler.sendUserToken(request, user, userTokenModel.PurposeEmailVerification); error != nil {
+		if error == errTooManyTokens {
+			utils.WriteError(writer, http.StatusTooManyRequests, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for requesting a password reset mail, it answers the same whether the email is registered or not
+func (handler *Handler) handleForgotPassword(writer http.ResponseWriter, request *http.Request) {
+	// get JSON payload
+	var payload userModel.ForgotPasswordPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("forgot:" + utils.GetClientIP(request)) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	// unknown emails and per user limits are not revealed to the caller
+	if user, error := handler.store.GetUserByEmail(payload.Email); error == nil {
+		if error := handler.sendUserToken(request, user, userTokenModel.PurposePasswordReset); error != nil && error != errTooManyTokens {
+			log.Printf("failed to send password reset mail to user %s: %v", user.ID, error)
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for setting a new password with the token of a password reset mail
+func (handler *Handler) handleResetPassword(writer http.ResponseWriter, request *http.Request) {
+	// get JSON payload
+	var payload userModel.ResetPasswordPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("reset:" + utils.GetClientIP(request)) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	userID, error := handler.tokenStore.ConsumeUserToken(userTokenModel.PurposePasswordReset, authenticationServices.HashToken(payload.Token))
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	hashedPassword, error := authenticationServices.HashPassword(payload.Password)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.UpdatePasswordByID(userID, hashedPassword); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, userID, auditModel.ActionPasswordChanged, nil, nil)
+
+	// the reset mail proved the user owns the email, and older reset mails stop working
+	if error := handler.store.SetEmailVerifiedByID(userID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordUserUpdate(request, user)
+
+	if error := handler.tokenStore.InvalidateUserTokens(userID, userTokenModel.PurposePasswordReset); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// a new password also lifts a lockout caused by guessing the old one
+	if error := handler.unlockAccount(userID, utils.GetClientIP(request)); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for lifting a login lockout with the token of an unlock mail
+func (handler *Handler) handleUnlockAccount(writer http.ResponseWriter, request *http.Request) {
+	// get JSON payload
+	var payload loginAttemptModel.UnlockAccountPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("unlock:" + utils.GetClientIP(request)) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	userID, error := handler.tokenStore.ConsumeUserToken(userTokenModel.PurposeAccountUnlock, authenticationServices.HashToken(payload.Token))
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	if error := handler.unlockAccount(userID, utils.GetClientIP(request)); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// unlockAccount forgets the failed logins of a user and records the unlock
+func (handler *Handler) unlockAccount(userID uuid.UUID, ipAddress string) error {
+	user, error := handler.store.GetUserByID(userID)
+	if error != nil {
+		return error
+	}
+
+	if error := handler.loginGuard.Reset(authenticationServices.AccountAttemptKey(user.Email)); error != nil {
+		return error
+	}
+
+	handler.recordLoginEvent(user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, ipAddress, loginAttemptModel.EventAccountUnlocked)
+	return nil
+}
+
+var errTooManyTokens = fmt.Errorf("too many mails sent, try again later")
+
+// sendUserToken issues a token for the purpose and queues the mail with its link to the user
+func (handler *Handler) sendUserToken(request *http.Request, user *userModel.User, purpose string) error {
+	count, error := handler.tokenStore.CountUserTokensSince(user.ID, purpose, time.Now().Add(-time.Hour))
+	if error != nil {
+		return error
+	}
+	if count >= tokensPerHour {
+		return errTooManyTokens
+	}
+
+	// the token is stored with the hash of one nobody knows, the mail replaces it with the one it sends
+	_, unusableHash, error := authenticationServices.GenerateToken()
+	if error != nil {
+		return fmt.Errorf("failed to generate token: %v", error)
+	}
+
+	expiration, _, _, _ := userTokenMailContent(purpose)
+	tokenID, error := handler.tokenStore.CreateUserToken(userTokenModel.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: unusableHash,
+		ExpiresAt: time.Now().Add(expiration),
+	})
+	if error != nil {
+		return error
+	}
+
+	handler.recorder.RecordTokenCreated(request, user.ID, purpose)
+
+	return handler.mailer.SendComposed(userTokenMail, userTokenMailReference{TokenID: tokenID, UserID: user.ID, Purpose: purpose})
+}
+
+// composeUserTokenMail creates the token of a queued mail and builds the mail with its link, a mail sent again gets a new token and the one before stops working
+func (handler *Handler) composeUserTokenMail(ctx context.Context, payload json.RawMessage) (mailServices.Message, error) {
+	var reference userTokenMailReference
+	if error := json.Unmarshal(payload, &reference); error != nil {
+		return mailServices.Message{}, fmt.Errorf("failed to read token mail: %v", error)
+	}
+
+	token, tokenHash, error := authenticationServices.GenerateToken()
+	if error != nil {
+		return mailServices.Message{}, fmt.Errorf("failed to generate token: %v", error)
+	}
+
+	reissued, error := handler.tokenStore.ReissueUserToken(reference.TokenID, tokenHash)
+	if error != nil {
+		return mailServices.Message{}, error
+	}
+
+	// the token was used, replaced or expired before the mail went out
+	if !reissued {
+		return mailServices.Message{}, queueService.ErrMailNotNeeded
+	}
+
+	user, error := handler.store.GetUserByID(reference.UserID)
+	if error != nil {
+		return mailServices.Message{}, error
+	}
+
+	_, path, subject, text := userTokenMailContent(reference.Purpose)
+	link := configs.ServerEnvironmentVariables.ClientURL + path + "?token=" + url.QueryEscape(token)
+	return mailServices.Message{
+		To:       user.Email,
+		Subject:  subject,
+		TextBody: fmt.Sprintf("Hi %s,\n\n%s\n\n%s\n", user.FirstName, text, link),
+	}, nil
+}
+
+// userTokenMailContent returns how long a token of the purpose works and the client path, subject and text of its mail
+func userTokenMailContent(purpose string) (time.Duration, string, string, string) {
+	switch purpose {
+	case userTokenModel.PurposePasswordReset:
+		return passwordResetExpiration, "/reset-password", "Reset your dev-journal password", "Someone asked to reset your dev-journal password. Open the link below within an hour to choose a new one, or ignore this mail if it was not you."
+	case userTokenModel.PurposeAccountUnlock:
+		return accountUnlockExpiration, "/unlock-account", "Your dev-journal account was locked", "Your dev-journal account was locked for 30 minutes after too many failed logins. If that was you, open the link below to unlock it now. If it was not, consider resetting your password."
+	}
+
+	return emailVerificationExpiration, "/verify-email", "Verify your dev-journal email", "Welcome to dev-journal! Confirm your email by opening the link below within 24 hours."
+}
@@ -0,0 +1,213 @@
+package userService
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	userIdentityModel "github.com/hwaengfan/dev-journal-backend/internal/models/userIdentity"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Handler function for starting a single sign-on login, the client redirects to the returned URL and keeps the login token for the callback
+func (handler *Handler) handleGetOIDCAuthorizationURL(writer http.ResponseWriter, request *http.Request) {
+	if !handler.oidcProvider.Enabled() {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("single sign-on is not configured"))
+		return
+	}
+
+	loginState := oidcServices.LoginState{}
+	var error error
+	if loginState.State, error = oidcServices.GenerateState(); error == nil {
+		if loginState.Nonce, error = oidcServices.GenerateState(); error == nil {
+			loginState.CodeVerifier, error = oidcServices.GenerateCodeVerifier()
+		}
+	}
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to generate login state: %v", error))
+		return
+	}
+
+	authorizationURL, error := handler.oidcProvider.AuthorizationURL(loginState.State, loginState.Nonce, loginState.CodeVerifier)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadGateway, error)
+		return
+	}
+
+	loginToken, error := authenticationServices.CreateOIDCLoginJWT(loginState)
+	if error != nil {
+		if error == authenticationServices.ErrSingleSignOnNotConfigured {
+			utils.WriteError(writer, http.StatusServiceUnavailable, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create login token: %v", error))
+		}
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"authorizationURL": authorizationURL, "loginToken": loginToken})
+}
+
+// Handler function for finishing a single sign-on login with the code the provider redirected back with
+func (handler *Handler) handleOIDCLogin(writer http.ResponseWriter, request *http.Request) {
+	if !handler.oidcProvider.Enabled() {
+		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("single sign-on is not configured"))
+		return
+	}
+
+	// get JSON payload
+	var payload userIdentityModel.OIDCLoginPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// the state must come back unchanged, otherwise the code may belong to a login someone else started
+	loginState, error := authenticationServices.ParseOIDCLoginJWT(payload.LoginToken)
+	if error != nil || loginState.State != payload.State {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid or expired login"))
+		return
+	}
+
+	// locked out addresses are turned away before the code is redeemed
+	ipAddress := utils.GetClientIP(request)
+	if handler.rejectLockedLogin(writer, "", uuid.NullUUID{}, ipAddress, authenticationServices.IPAttemptKey(ipAddress)) {
+		return
+	}
+
+	claims, error := handler.oidcProvider.Exchange(payload.Code, loginState.CodeVerifier, loginState.Nonce)
+	if error != nil {
+		log.Printf("failed to complete single sign-on: %v", error)
+		handler.recordFailedOIDCLogin("", ipAddress)
+		utils.WriteError(writer, http.StatusUnauthorized, fmt.Errorf("single sign-on failed"))
+		return
+	}
+
+	user, status, error := handler.findOrCreateOIDCUser(request, claims)
+	if error != nil {
+		if status == http.StatusForbidden {
+			handler.recordFailedOIDCLogin(claims.Email, ipAddress)
+		}
+		utils.WriteError(writer, status, error)
+		return
+	}
+
+	// an account locked by failed passwords stays locked for single sign-on, the unlock link is the way back in
+	if handler.rejectLockedLogin(writer, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, ipAddress, authenticationServices.AccountAttemptKey(user.Email)) {
+		return
+	}
+
+	handler.completeLogin(writer, request, user, ipAddress)
+}
+
+// recordFailedOIDCLogin counts a failed single sign-on against the address, the provider account is not known to belong to any user yet
+func (handler *Handler) recordFailedOIDCLogin(email string, ipAddress string) {
+	handler.recordLoginEvent(email, uuid.NullUUID{}, ipAddress, loginAttemptModel.EventLoginFailed)
+
+	if _, error := handler.loginGuard.RecordFailure(authenticationServices.IPAttemptKey(ipAddress), authenticationServices.IPLoginPolicy); error != nil {
+		log.Printf("failed to record failed login from %s: %v", ipAddress, error)
+	}
+}
+
+// findOrCreateOIDCUser returns the user linked to a provider account, linking an existing user by verified email or creating one the first time
+func (handler *Handler) findOrCreateOIDCUser(request *http.Request, claims *oidcServices.IDTokenClaims) (*userModel.User, int, error) {
+	issuer := handler.oidcProvider.Issuer()
+
+	identity, error := handler.identityStore.GetUserIdentity(issuer, claims.Subject)
+	if error != nil {
+		return nil, http.StatusInternalServerError, error
+	}
+
+	if identity != nil {
+		user, error := handler.store.GetUserByID(identity.UserID)
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		return user, http.StatusOK, nil
+	}
+
+	// an unverified email could be anyone's, it must not take over or claim an account
+	if claims.Email == "" || !claims.IsEmailVerified() {
+		return nil, http.StatusForbidden, fmt.Errorf("the identity provider did not verify your email")
+	}
+
+	user, error := handler.store.GetUserByEmail(claims.Email)
+	if error != nil {
+		firstName, lastName := oidcUserName(claims)
+
+		// the account has no usable password until the user resets it
+		password, _, error := authenticationServices.GenerateToken()
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		hashedPassword, error := authenticationServices.HashPassword(password)
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		error = handler.store.CreateUser(userModel.User{
+			FirstName: firstName,
+			LastName:  lastName,
+			Email:     claims.Email,
+			Password:  hashedPassword,
+		})
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		user, error = handler.store.GetUserByEmail(claims.Email)
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		handler.recordAccountEvent(request, user.ID, auditModel.ActionCreate, nil, user)
+	}
+
+	if user.EmailVerified != "True" {
+		if error := handler.store.SetEmailVerifiedByID(user.ID); error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		handler.recordUserUpdate(request, user)
+	}
+
+	error = handler.identityStore.CreateUserIdentity(userIdentityModel.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+	})
+	if error != nil {
+		return nil, http.StatusInternalServerError, error
+	}
+
+	return user, http.StatusOK, nil
+}
+
+// oidcUserName picks the name of a new user from the ID token, falling back to the email
+func oidcUserName(claims *oidcServices.IDTokenClaims) (string, string) {
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && claims.Name != "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	return firstName, lastName
+}
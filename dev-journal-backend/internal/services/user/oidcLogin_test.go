@@ -0,0 +1,425 @@
+package userService
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+	twoFactorModel "github.com/hwaengfan/dev-journal-backend/internal/models/twoFactor"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	userIdentityModel "github.com/hwaengfan/dev-journal-backend/internal/models/userIdentity"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
+	oidcMockServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc/oidcMock"
+	queueService "github.com/hwaengfan/dev-journal-backend/internal/services/queue"
+)
+
+// Address the test requests come from
+const remoteAddress = "192.0.2.1"
+
+// The fakes embed the store interfaces, methods the login flow does not need panic when called
+
+type fakeUserStore struct {
+	userModel.UserStore
+	users map[uuid.UUID]*userModel.User
+}
+
+func (store *fakeUserStore) GetUserByEmail(email string) (*userModel.User, error) {
+	for _, user := range store.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user not found")
+}
+
+func (store *fakeUserStore) GetUserByID(id uuid.UUID) (*userModel.User, error) {
+	user, exists := store.users[id]
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (store *fakeUserStore) CreateUser(user userModel.User) error {
+	user.ID = uuid.New()
+	user.EmailVerified = "False"
+	store.users[user.ID] = &user
+	return nil
+}
+
+func (store *fakeUserStore) SetEmailVerifiedByID(id uuid.UUID) error {
+	store.users[id].EmailVerified = "True"
+	return nil
+}
+
+type fakeIdentityStore struct {
+	identities []userIdentityModel.UserIdentity
+}
+
+func (store *fakeIdentityStore) GetUserIdentity(issuer string, subject string) (*userIdentityModel.UserIdentity, error) {
+	for _, identity := range store.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (store *fakeIdentityStore) CreateUserIdentity(identity userIdentityModel.UserIdentity) error {
+	store.identities = append(store.identities, identity)
+	return nil
+}
+
+type fakeTwoFactorStore struct {
+	twoFactorModel.TwoFactorStore
+}
+
+func (store *fakeTwoFactorStore) GetTwoFactorByUserID(userID uuid.UUID) (*twoFactorModel.TwoFactor, error) {
+	return nil, nil
+}
+
+type fakeLoginAuditStore struct {
+	loginAttemptModel.LoginAuditStore
+	events []loginAttemptModel.LoginAuditEvent
+}
+
+func (store *fakeLoginAuditStore) CreateLoginAuditEvent(event loginAttemptModel.LoginAuditEvent) error {
+	store.events = append(store.events, event)
+	return nil
+}
+
+type fakeAuditStore struct {
+	auditModel.AuditEventStore
+}
+
+func (store *fakeAuditStore) CreateAuditEvent(event auditModel.AuditEvent) error {
+	return nil
+}
+
+type oidcTest struct {
+	handler       *Handler
+	mockProvider  *oidcMockServices.Provider
+	userStore     *fakeUserStore
+	identityStore *fakeIdentityStore
+	auditStore    *fakeLoginAuditStore
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+
+	mockProvider, error := oidcMockServices.NewProvider()
+	if error != nil {
+		t.Fatal(error)
+	}
+	t.Cleanup(mockProvider.Close)
+
+	key := configs.OIDCEnvironmentVariables.StateEncryptionKey
+	configs.OIDCEnvironmentVariables.StateEncryptionKey = "test-state-key"
+	t.Cleanup(func() { configs.OIDCEnvironmentVariables.StateEncryptionKey = key })
+
+	test := &oidcTest{
+		mockProvider:  mockProvider,
+		userStore:     &fakeUserStore{users: make(map[uuid.UUID]*userModel.User)},
+		identityStore: &fakeIdentityStore{},
+		auditStore:    &fakeLoginAuditStore{},
+	}
+	loginGuard := authenticationServices.NewLoginGuard(authenticationServices.NewMemoryAttemptTracker())
+	auditStore := &fakeAuditStore{}
+	mailer := queueService.NewQueuedMailer(queueService.NewQueue(nil, time.Minute, time.Minute, time.Minute), nil)
+	test.handler = NewHandler(test.userStore, nil, &fakeTwoFactorStore{}, test.identityStore, test.auditStore, auditStore, mailer,
+		oidcServices.NewProvider(mockProvider.Configs(), nil), loginGuard, auditServices.NewRecorder(auditStore))
+
+	return test
+}
+
+// login runs the whole flow: get the authorization URL, log in at the provider and send the code back, change edits the payload before it is sent
+func (test *oidcTest) login(t *testing.T, identity oidcMockServices.Identity, change func(payload *userIdentityModel.OIDCLoginPayload)) *httptest.ResponseRecorder {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	test.handler.handleGetOIDCAuthorizationURL(recorder, newRequest(http.MethodGet, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("authorization URL status is %d: %s", recorder.Code, recorder.Body)
+	}
+
+	var response map[string]string
+	if error := json.Unmarshal(recorder.Body.Bytes(), &response); error != nil {
+		t.Fatal(error)
+	}
+
+	code, state, error := test.mockProvider.Authorize(response["authorizationURL"], identity)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	payload := userIdentityModel.OIDCLoginPayload{Code: code, State: state, LoginToken: response["loginToken"]}
+	if change != nil {
+		change(&payload)
+	}
+
+	recorder = httptest.NewRecorder()
+	test.handler.handleOIDCLogin(recorder, newRequest(http.MethodPost, payload))
+	return recorder
+}
+
+// lastEvent returns the last event of the login audit
+func (test *oidcTest) lastEvent(t *testing.T) loginAttemptModel.LoginAuditEvent {
+	t.Helper()
+
+	if len(test.auditStore.events) == 0 {
+		t.Fatal("no login event recorded")
+	}
+
+	return test.auditStore.events[len(test.auditStore.events)-1]
+}
+
+func newRequest(method string, payload any) *http.Request {
+	body, _ := json.Marshal(payload)
+	request := httptest.NewRequest(method, "/", bytes.NewReader(body))
+	request.RemoteAddr = remoteAddress + ":12345"
+	return request
+}
+
+// tokenUserID returns the user a session token in a response was issued to
+func tokenUserID(t *testing.T, recorder *httptest.ResponseRecorder) uuid.UUID {
+	t.Helper()
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("login status is %d: %s", recorder.Code, recorder.Body)
+	}
+
+	var response map[string]string
+	if error := json.Unmarshal(recorder.Body.Bytes(), &response); error != nil {
+		t.Fatal(error)
+	}
+
+	// without a key manager session tokens are signed with the JWT secret
+	claims := jwt.MapClaims{}
+	if _, error := jwt.ParseWithClaims(response["token"], claims, func(token *jwt.Token) (any, error) {
+		return []byte(configs.GlobalEnvironmentVariables.JWTSecret), nil
+	}); error != nil {
+		t.Fatal(error)
+	}
+
+	subject, _ := claims["sub"].(string)
+	userID, error := uuid.Parse(subject)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	return userID
+}
+
+func TestOIDCLoginCreatesAndLinksUser(t *testing.T) {
+	test := newOIDCTest(t)
+	identity := oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace"}
+
+	userID := tokenUserID(t, test.login(t, identity, nil))
+
+	user := test.userStore.users[userID]
+	if user == nil || user.Email != identity.Email || user.FirstName != "Ada" || user.LastName != "Lovelace" || user.EmailVerified != "True" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	if len(test.identityStore.identities) != 1 || test.identityStore.identities[0].UserID != userID || test.identityStore.identities[0].Issuer != test.mockProvider.Issuer() {
+		t.Fatalf("unexpected identities %+v", test.identityStore.identities)
+	}
+
+	event := test.lastEvent(t)
+	if event.Event != loginAttemptModel.EventLoginSucceeded || event.UserID.UUID != userID || event.IPAddress != remoteAddress {
+		t.Fatalf("unexpected login event %+v", event)
+	}
+
+	// the linked identity logs in again even after the email at the provider changed
+	identity.Email = "ada@other.example.com"
+	if secondUserID := tokenUserID(t, test.login(t, identity, nil)); secondUserID != userID {
+		t.Fatalf("second login is user %s, expected %s", secondUserID, userID)
+	}
+	if len(test.userStore.users) != 1 || len(test.identityStore.identities) != 1 {
+		t.Fatalf("second login created %d users and %d identities", len(test.userStore.users), len(test.identityStore.identities))
+	}
+}
+
+func TestOIDCLoginLinksExistingUserByVerifiedEmail(t *testing.T) {
+	test := newOIDCTest(t)
+	test.userStore.CreateUser(userModel.User{FirstName: "Ada", Email: "ada@example.com"})
+	existing, _ := test.userStore.GetUserByEmail("ada@example.com")
+
+	userID := tokenUserID(t, test.login(t, oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true}, nil))
+
+	if userID != existing.ID || len(test.userStore.users) != 1 {
+		t.Fatalf("login is user %s, expected the existing user %s", userID, existing.ID)
+	}
+	if test.userStore.users[userID].EmailVerified != "True" {
+		t.Fatal("email of the linked user is not verified")
+	}
+	if len(test.identityStore.identities) != 1 || test.identityStore.identities[0].UserID != existing.ID {
+		t.Fatalf("unexpected identities %+v", test.identityStore.identities)
+	}
+}
+
+func TestOIDCLoginFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		identity       oidcMockServices.Identity
+		setup          func(test *oidcTest)
+		change         func(payload *userIdentityModel.OIDCLoginPayload)
+		expectedStatus int
+		expectedEvent  string
+	}{
+		{
+			name:           "unverified email",
+			identity:       oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com"},
+			expectedStatus: http.StatusForbidden,
+			expectedEvent:  loginAttemptModel.EventLoginFailed,
+		},
+		{
+			name:           "state does not match",
+			identity:       oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+			change:         func(payload *userIdentityModel.OIDCLoginPayload) { payload.State = "other-state" },
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "code rejected by the provider",
+			identity:       oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+			change:         func(payload *userIdentityModel.OIDCLoginPayload) { payload.Code = "made-up-code" },
+			expectedStatus: http.StatusUnauthorized,
+			expectedEvent:  loginAttemptModel.EventLoginFailed,
+		},
+		{
+			name:     "address locked out",
+			identity: oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+			setup: func(test *oidcTest) {
+				for range authenticationServices.IPLoginPolicy.LockoutAttempts {
+					test.handler.loginGuard.RecordFailure(authenticationServices.IPAttemptKey(remoteAddress), authenticationServices.IPLoginPolicy)
+				}
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedEvent:  loginAttemptModel.EventLoginBlocked,
+		},
+		{
+			name:     "account locked out by failed passwords",
+			identity: oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+			setup: func(test *oidcTest) {
+				test.userStore.CreateUser(userModel.User{FirstName: "Ada", Email: "ada@example.com"})
+				for range authenticationServices.AccountLoginPolicy.LockoutAttempts {
+					test.handler.loginGuard.RecordFailure(authenticationServices.AccountAttemptKey("ada@example.com"), authenticationServices.AccountLoginPolicy)
+				}
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedEvent:  loginAttemptModel.EventLoginBlocked,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			test := newOIDCTest(t)
+			if testCase.setup != nil {
+				testCase.setup(test)
+			}
+
+			recorder := test.login(t, testCase.identity, testCase.change)
+			if recorder.Code != testCase.expectedStatus {
+				t.Fatalf("status is %d, expected %d: %s", recorder.Code, testCase.expectedStatus, recorder.Body)
+			}
+
+			if testCase.expectedStatus == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") == "" {
+				t.Error("locked out login has no Retry-After header")
+			}
+
+			if testCase.expectedEvent == "" {
+				if len(test.auditStore.events) != 0 {
+					t.Fatalf("unexpected login events %+v", test.auditStore.events)
+				}
+				return
+			}
+
+			if event := test.lastEvent(t); event.Event != testCase.expectedEvent || event.IPAddress != remoteAddress {
+				t.Fatalf("login event is %+v, expected %s", event, testCase.expectedEvent)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginFailuresCountAgainstTheAddress(t *testing.T) {
+	test := newOIDCTest(t)
+	identity := oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true}
+	invalidCode := func(payload *userIdentityModel.OIDCLoginPayload) { payload.Code = "made-up-code" }
+
+	for range authenticationServices.IPLoginPolicy.LockoutAttempts {
+		test.login(t, identity, invalidCode)
+	}
+
+	if recorder := test.login(t, identity, nil); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after %d failed logins is %d, expected %d", authenticationServices.IPLoginPolicy.LockoutAttempts, recorder.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestOIDCLoginTokenHidesTheNonceAndCodeVerifier(t *testing.T) {
+	test := newOIDCTest(t)
+
+	recorder := httptest.NewRecorder()
+	test.handler.handleGetOIDCAuthorizationURL(recorder, newRequest(http.MethodGet, nil))
+	var response map[string]string
+	if error := json.Unmarshal(recorder.Body.Bytes(), &response); error != nil {
+		t.Fatal(error)
+	}
+
+	authorizationURL, error := url.Parse(response["authorizationURL"])
+	if error != nil {
+		t.Fatal(error)
+	}
+	nonce := authorizationURL.Query().Get("nonce")
+	if nonce == "" {
+		t.Fatal("authorization URL has no nonce")
+	}
+
+	// anyone holding the token can read its claims without the signing key
+	claims := jwt.MapClaims{}
+	if _, _, error := jwt.NewParser().ParseUnverified(response["loginToken"], claims); error != nil {
+		t.Fatal(error)
+	}
+	for name, value := range claims {
+		if text, _ := value.(string); strings.Contains(text, nonce) || name == "nonce" || name == "codeVerifier" {
+			t.Fatalf("login token gives away the nonce in claim %s", name)
+		}
+	}
+
+	// a token issued for something else does not start a login
+	challengeToken, error := authenticationServices.CreateTwoFactorChallengeJWT(uuid.New())
+	if error != nil {
+		t.Fatal(error)
+	}
+	identity := oidcMockServices.Identity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true}
+	if recorder := test.login(t, identity, func(payload *userIdentityModel.OIDCLoginPayload) { payload.LoginToken = challengeToken }); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status with a challenge token is %d, expected %d", recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCLoginNeedsTheStateEncryptionKey(t *testing.T) {
+	test := newOIDCTest(t)
+	configs.OIDCEnvironmentVariables.StateEncryptionKey = ""
+
+	recorder := httptest.NewRecorder()
+	test.handler.handleGetOIDCAuthorizationURL(recorder, newRequest(http.MethodGet, nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without a key is %d, expected %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+}
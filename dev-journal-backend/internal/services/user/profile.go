@@ -0,0 +1,236 @@
+package userService
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	userTokenModel "github.com/hwaengfan/dev-journal-backend/internal/models/userToken"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Handler function for getting the profile of the logged in user
+func (handler *Handler) handleGetMe(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, user)
+}
+
+// Handler function for updating the name and email of the logged in user, a new email gets a verification mail
+func (handler *Handler) handleUpdateProfile(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload userModel.UpdateProfilePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// an unchanged email is not a change and keeps its verification
+	if payload.Email == user.Email {
+		payload.Email = ""
+	}
+
+	if payload.Email != "" {
+		if _, error := handler.store.GetUserByEmail(payload.Email); error == nil {
+			utils.WriteError(writer, http.StatusConflict, fmt.Errorf("user with email %s already exists", payload.Email))
+			return
+		}
+	}
+
+	error = handler.store.UpdateUserByID(userModel.User{
+		FirstName: payload.FirstName,
+		LastName:  payload.LastName,
+		Email:     payload.Email,
+	}, user.ID)
+	if error != nil {
+		if error.Error() == "no fields to update" {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	previousUser := user
+	user, error = handler.store.GetUserByID(user.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, user.ID, auditModel.ActionUpdate, previousUser, user)
+
+	// links sent to the old email must not verify the new one
+	if payload.Email != "" {
+		if error := handler.tokenStore.InvalidateUserTokens(user.ID, userTokenModel.PurposeEmailVerification); error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+
+		if error := handler.sendUserToken(request, user, userTokenModel.PurposeEmailVerification); error != nil {
+			log.Printf("failed to send verification mail to user %s: %v", user.ID, error)
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, user)
+}
+
+// Handler function for changing the password of the logged in user, the current password is required
+func (handler *Handler) handleChangePassword(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload userModel.ChangePasswordPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("password:" + user.ID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	if !authenticationServices.ComparePassword(user.Password, []byte(payload.CurrentPassword)) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("current password is incorrect"))
+		return
+	}
+
+	hashedPassword, error := authenticationServices.HashPassword(payload.NewPassword)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.UpdatePasswordByID(user.ID, hashedPassword); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, user.ID, auditModel.ActionPasswordChanged, nil, nil)
+
+	// pending reset mails were meant for the old password
+	if error := handler.tokenStore.InvalidateUserTokens(user.ID, userTokenModel.PurposePasswordReset); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// every session issued before the change is revoked, this one continues with a fresh token in the same workspace
+	token, error := authenticationServices.CreateJWT(user.ID, authenticationServices.GetWorkspaceIDFromContext(request.Context()))
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create JWT token: %v", error))
+		return
+	}
+
+	handler.recorder.RecordTokenCreated(request, user.ID, auditModel.TokenSession)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"token": token})
+}
+
+// Handler function for deleting the logged in user along with all their projects, notes and tasks
+func (handler *Handler) handleDeleteAccount(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload userModel.DeleteAccountPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !handler.tokenLimiter.Allow("password:" + user.ID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	if !authenticationServices.ComparePassword(user.Password, []byte(payload.Password)) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("password is incorrect"))
+		return
+	}
+
+	if error := handler.store.DeleteUserByID(user.ID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// audit events outlive the account, the deletion is the last one about it
+	handler.recordAccountEvent(request, user.ID, auditModel.ActionDelete, user, nil)
+
+	// the lockout counters are keyed by email and would otherwise outlive the account
+	if error := handler.loginGuard.Reset(authenticationServices.AccountAttemptKey(user.Email)); error != nil {
+		log.Printf("failed to reset login attempts of deleted user %s: %v", user.ID, error)
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
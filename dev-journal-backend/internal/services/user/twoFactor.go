@@ -0,0 +1,430 @@
+package userService
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	twoFactorModel "github.com/hwaengfan/dev-journal-backend/internal/models/twoFactor"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Issuer shown next to the account in authenticator apps
+const twoFactorIssuer = "dev-journal"
+
+// Recovery codes issued when two-factor is enabled or the codes are regenerated
+const recoveryCodeCount = 10
+
+// Handler function for getting whether two-factor is enabled for the logged in user
+func (handler *Handler) handleGetTwoFactorStatus(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	status := twoFactorModel.TwoFactorStatus{Enabled: "False"}
+	if twoFactor != nil && twoFactor.Enabled == "True" {
+		status.Enabled = "True"
+		status.RemainingRecoveryCodes, error = handler.twoFactorStore.CountUnusedRecoveryCodes(userID.UUID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, status)
+}
+
+// Handler function for starting the two-factor enrollment, it returns a new secret and the provisioning URI to show as a QR code
+func (handler *Handler) handleSetupTwoFactor(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(user.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if twoFactor != nil && twoFactor.Enabled == "True" {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("two-factor is already enabled"))
+		return
+	}
+
+	secret, error := authenticationServices.GenerateTOTPSecret()
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to generate secret: %v", error))
+		return
+	}
+
+	encryptedSecret, error := authenticationServices.EncryptSecret(secret)
+	if error != nil {
+		if error == authenticationServices.ErrTwoFactorNotConfigured {
+			utils.WriteError(writer, http.StatusServiceUnavailable, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	if error := handler.twoFactorStore.SaveTwoFactorSecret(user.ID, encryptedSecret); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{
+		"secret":          secret,
+		"provisioningURI": authenticationServices.TOTPProvisioningURI(twoFactorIssuer, user.Email, secret),
+	})
+}
+
+// Handler function for enabling two-factor with a first code of the new secret, the recovery codes are only shown in this response
+func (handler *Handler) handleEnableTwoFactor(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload twoFactorModel.EnableTwoFactorPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if twoFactor == nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("two-factor has not been set up"))
+		return
+	}
+
+	if twoFactor.Enabled == "True" {
+		utils.WriteError(writer, http.StatusConflict, fmt.Errorf("two-factor is already enabled"))
+		return
+	}
+
+	if !handler.twoFactorLimiter.Allow(userID.UUID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	secret, error := authenticationServices.DecryptSecret(twoFactor.Secret)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	step, valid := authenticationServices.ValidateTOTP(secret, payload.Code, time.Now(), twoFactor.LastUsedStep)
+	if !valid {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	recoveryCodes, recoveryCodeHashes, error := generateRecoveryCodes()
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.twoFactorStore.EnableTwoFactor(userID.UUID, step, recoveryCodeHashes); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, userID.UUID, auditModel.ActionTwoFactorEnabled, nil, nil)
+	handler.recorder.RecordTokenCreated(request, userID.UUID, auditModel.TokenRecoveryCodes)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string][]string{"recoveryCodes": recoveryCodes})
+}
+
+// Handler function for disabling two-factor, it asks for the password and a code or recovery code
+func (handler *Handler) handleDisableTwoFactor(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload twoFactorModel.DisableTwoFactorPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(user.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if twoFactor == nil || twoFactor.Enabled != "True" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("two-factor is not enabled"))
+		return
+	}
+
+	if !handler.twoFactorLimiter.Allow(user.ID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	if !authenticationServices.ComparePassword(user.Password, []byte(payload.Password)) {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid password or code"))
+		return
+	}
+
+	valid, error := handler.verifyTwoFactorCode(twoFactor, payload.Code, true)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !valid {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid password or code"))
+		return
+	}
+
+	if error := handler.twoFactorStore.DeleteTwoFactor(user.ID); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, user.ID, auditModel.ActionTwoFactorDisabled, nil, nil)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for replacing the recovery codes of the logged in user, the old codes stop working
+func (handler *Handler) handleRegenerateRecoveryCodes(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload twoFactorModel.RegenerateRecoveryCodesPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if twoFactor == nil || twoFactor.Enabled != "True" {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("two-factor is not enabled"))
+		return
+	}
+
+	if !handler.twoFactorLimiter.Allow(userID.UUID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	valid, error := handler.verifyTwoFactorCode(twoFactor, payload.Code, false)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !valid {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	recoveryCodes, recoveryCodeHashes, error := generateRecoveryCodes()
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.twoFactorStore.ReplaceRecoveryCodes(userID.UUID, recoveryCodeHashes); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recorder.RecordTokenCreated(request, userID.UUID, auditModel.TokenRecoveryCodes)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string][]string{"recoveryCodes": recoveryCodes})
+}
+
+// Handler function for the second step of a login, it exchanges the challenge token and a code or recovery code for the JWT token
+func (handler *Handler) handleTwoFactorLogin(writer http.ResponseWriter, request *http.Request) {
+	// get JSON payload
+	var payload twoFactorModel.TwoFactorLoginPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	userID, error := authenticationServices.ParseTwoFactorChallengeJWT(payload.ChallengeToken)
+	if error != nil {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	if !handler.twoFactorLimiter.Allow(userID.String()) {
+		utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many attempts, try again later"))
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID)
+	if error != nil {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// wrong codes count like wrong passwords, so the account may have been locked since the challenge was issued
+	ipAddress := utils.GetClientIP(request)
+	if handler.rejectLockedLogin(writer, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, ipAddress, authenticationServices.AccountAttemptKey(user.Email), authenticationServices.IPAttemptKey(ipAddress)) {
+		return
+	}
+
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(userID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// two-factor may have been disabled since the challenge was issued, the password alone was checked then
+	if twoFactor == nil || twoFactor.Enabled != "True" {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	valid, error := handler.verifyTwoFactorCode(twoFactor, payload.Code, true)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !valid {
+		handler.recordFailedLogin(request, user.Email, user, ipAddress)
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	handler.succeedLogin(writer, request, user, ipAddress)
+}
+
+// verifyTwoFactorCode checks a TOTP code, or a recovery code when allowed, and uses it up so it cannot be entered again
+func (handler *Handler) verifyTwoFactorCode(twoFactor *twoFactorModel.TwoFactor, code string, allowRecoveryCode bool) (bool, error) {
+	if utils.Validate.Var(code, "len=6,numeric") == nil {
+		secret, error := authenticationServices.DecryptSecret(twoFactor.Secret)
+		if error != nil {
+			return false, error
+		}
+
+		step, valid := authenticationServices.ValidateTOTP(secret, code, time.Now(), twoFactor.LastUsedStep)
+		if !valid {
+			return false, nil
+		}
+
+		// a concurrent request may have used the same step in the meantime
+		return handler.twoFactorStore.UseTwoFactorStep(twoFactor.UserID, step)
+	}
+
+	if !allowRecoveryCode {
+		return false, nil
+	}
+
+	return handler.twoFactorStore.UseRecoveryCode(twoFactor.UserID, authenticationServices.HashRecoveryCode(code))
+}
+
+// writeSessionToken creates the JWT token of a logged in user and writes it as the response
+func (handler *Handler) writeSessionToken(writer http.ResponseWriter, request *http.Request, userID uuid.UUID) {
+	// the session starts in the default workspace, see the workspace routes to switch
+	token, error := authenticationServices.CreateJWT(userID, uuid.Nil)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create JWT token: %v", error))
+		return
+	}
+
+	handler.recorder.RecordTokenCreated(request, userID, auditModel.TokenSession)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"token": token})
+}
+
+// generateRecoveryCodes creates the recovery codes to show the user and their hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	recoveryCodes, error := authenticationServices.GenerateRecoveryCodes(recoveryCodeCount)
+	if error != nil {
+		return nil, nil, fmt.Errorf("failed to generate recovery codes: %v", error)
+	}
+
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, authenticationServices.HashRecoveryCode(recoveryCode))
+	}
+
+	return recoveryCodes, recoveryCodeHashes, nil
+}
@@ -2,27 +2,87 @@ package userService
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"github.com/hwaengfan/dev-journal-backend/configs"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	loginAttemptModel "github.com/hwaengfan/dev-journal-backend/internal/models/loginAttempt"
+	twoFactorModel "github.com/hwaengfan/dev-journal-backend/internal/models/twoFactor"
 	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	userIdentityModel "github.com/hwaengfan/dev-journal-backend/internal/models/userIdentity"
+	userTokenModel "github.com/hwaengfan/dev-journal-backend/internal/models/userToken"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
 	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	oidcServices "github.com/hwaengfan/dev-journal-backend/internal/services/oidc"
+	queueService "github.com/hwaengfan/dev-journal-backend/internal/services/queue"
 	"github.com/hwaengfan/dev-journal-backend/internal/utils"
 )
 
 type Handler struct {
-	store userModel.UserStore
+	store            userModel.UserStore
+	tokenStore       userTokenModel.UserTokenStore
+	twoFactorStore   twoFactorModel.TwoFactorStore
+	identityStore    userIdentityModel.UserIdentityStore
+	loginAuditStore  loginAttemptModel.LoginAuditStore
+	auditStore       auditModel.AuditEventStore
+	mailer           *queueService.QueuedMailer
+	oidcProvider     *oidcServices.Provider
+	loginGuard       *authenticationServices.LoginGuard
+	recorder         *auditServices.Recorder
+	tokenLimiter     *authenticationServices.RateLimiter
+	twoFactorLimiter *authenticationServices.RateLimiter
 }
 
-func NewHandler(store userModel.UserStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store userModel.UserStore, tokenStore userTokenModel.UserTokenStore, twoFactorStore twoFactorModel.TwoFactorStore, identityStore userIdentityModel.UserIdentityStore, loginAuditStore loginAttemptModel.LoginAuditStore, auditStore auditModel.AuditEventStore, mailer *queueService.QueuedMailer, oidcProvider *oidcServices.Provider, loginGuard *authenticationServices.LoginGuard, recorder *auditServices.Recorder) *Handler {
+	handler := &Handler{
+		store:            store,
+		tokenStore:       tokenStore,
+		twoFactorStore:   twoFactorStore,
+		identityStore:    identityStore,
+		loginAuditStore:  loginAuditStore,
+		auditStore:       auditStore,
+		mailer:           mailer,
+		oidcProvider:     oidcProvider,
+		loginGuard:       loginGuard,
+		recorder:         recorder,
+		tokenLimiter:     authenticationServices.NewRateLimiter(10, 15*time.Minute),
+		twoFactorLimiter: authenticationServices.NewRateLimiter(5, 5*time.Minute),
+	}
+	mailer.Compose(userTokenMail, handler.composeUserTokenMail)
+
+	return handler
 }
 
 func (handler *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/login", handler.handleLogin).Methods(http.MethodPost)
+	router.HandleFunc("/login/two-factor", handler.handleTwoFactorLogin).Methods(http.MethodPost)
+	router.HandleFunc("/oidc/get-authorization-url", handler.handleGetOIDCAuthorizationURL).Methods(http.MethodGet)
+	router.HandleFunc("/oidc/login", handler.handleOIDCLogin).Methods(http.MethodPost)
 	router.HandleFunc("/register", handler.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/verify-email", handler.handleVerifyEmail).Methods(http.MethodPost)
+	router.HandleFunc("/resend-verification-email", authenticationServices.JWTAuthentication(handler.handleResendVerificationEmail, handler.store)).Methods(http.MethodPost)
+	router.HandleFunc("/forgot-password", handler.handleForgotPassword).Methods(http.MethodPost)
+	router.HandleFunc("/reset-password", handler.handleResetPassword).Methods(http.MethodPost)
+	router.HandleFunc("/unlock-account", handler.handleUnlockAccount).Methods(http.MethodPost)
+	router.HandleFunc("/me", authenticationServices.JWTAuthentication(handler.handleGetMe, handler.store)).Methods(http.MethodGet)
+	router.HandleFunc("/me/update-profile", authenticationServices.JWTAuthentication(handler.handleUpdateProfile, handler.store)).Methods(http.MethodPut)
+	router.HandleFunc("/me/change-password", authenticationServices.JWTAuthentication(handler.handleChangePassword, handler.store)).Methods(http.MethodPut)
+	router.HandleFunc("/me/delete-account", authenticationServices.JWTAuthentication(handler.handleDeleteAccount, handler.store)).Methods(http.MethodDelete)
+	router.HandleFunc("/users/update-timezone", authenticationServices.JWTAuthentication(handler.handleUpdateTimezone, handler.store)).Methods(http.MethodPut)
+	router.HandleFunc("/users/get-login-history", authenticationServices.JWTAuthentication(handler.handleGetLoginHistory, handler.store)).Methods(http.MethodGet)
+	router.HandleFunc("/users/get-security-log", authenticationServices.JWTAuthentication(handler.handleGetSecurityLog, handler.store)).Methods(http.MethodGet)
+	router.HandleFunc("/two-factor/get-status", authenticationServices.JWTAuthentication(handler.handleGetTwoFactorStatus, handler.store)).Methods(http.MethodGet)
+	router.HandleFunc("/two-factor/setup", authenticationServices.JWTAuthentication(handler.handleSetupTwoFactor, handler.store)).Methods(http.MethodPost)
+	router.HandleFunc("/two-factor/enable", authenticationServices.JWTAuthentication(handler.handleEnableTwoFactor, handler.store)).Methods(http.MethodPost)
+	router.HandleFunc("/two-factor/disable", authenticationServices.JWTAuthentication(handler.handleDisableTwoFactor, handler.store)).Methods(http.MethodPost)
+	router.HandleFunc("/two-factor/regenerate-recovery-codes", authenticationServices.JWTAuthentication(handler.handleRegenerateRecoveryCodes, handler.store)).Methods(http.MethodPost)
 }
 
 // Handler function for user login
@@ -41,27 +101,133 @@ func (handler *Handler) handleLogin(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
+	// locked out accounts and addresses are turned away before the password is checked
+	ipAddress := utils.GetClientIP(request)
+	if handler.rejectLockedLogin(writer, payload.Email, uuid.NullUUID{}, ipAddress, authenticationServices.AccountAttemptKey(payload.Email), authenticationServices.IPAttemptKey(ipAddress)) {
+		return
+	}
+
 	// validate user authentication
 	user, error := handler.store.GetUserByEmail(payload.Email)
-	if error != nil {
+	if error != nil || !authenticationServices.ComparePassword(user.Password, []byte(payload.Password)) {
+		handler.recordFailedLogin(request, payload.Email, user, ipAddress)
 		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("not found, invalid email or password"))
 		return
 	}
 
-	if !authenticationServices.ComparePassword(user.Password, []byte(payload.Password)) {
-		utils.WriteError(writer, http.StatusNotFound, fmt.Errorf("not found, invalid email or password"))
+	handler.completeLogin(writer, request, user, ipAddress)
+}
+
+// rejectLockedLogin turns a login away with 429 while one of the attempt keys is locked out, it reports whether the login was rejected
+func (handler *Handler) rejectLockedLogin(writer http.ResponseWriter, email string, userID uuid.NullUUID, ipAddress string, keys ...string) bool {
+	retryAfter, error := handler.loginGuard.RetryAfter(keys...)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return true
+	}
+
+	if retryAfter <= 0 {
+		return false
+	}
+
+	handler.recordLoginEvent(email, userID, ipAddress, loginAttemptModel.EventLoginBlocked)
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	writer.Header().Set("Retry-After", strconv.Itoa(seconds))
+	utils.WriteError(writer, http.StatusTooManyRequests, fmt.Errorf("too many failed logins, try again in %d seconds", seconds))
+	return true
+}
+
+// succeedLogin clears the failed logins of the account and records the login before the session token is written
+func (handler *Handler) succeedLogin(writer http.ResponseWriter, request *http.Request, user *userModel.User, ipAddress string) {
+	if error := handler.loginGuard.Reset(authenticationServices.AccountAttemptKey(user.Email)); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
 	}
 
-	// create JWT token
-	secret := []byte(configs.GlobalEnvironmentVariables.JWTSecret)
-	token, error := authenticationServices.CreateJWT(secret, user.ID)
+	handler.recordLoginEvent(user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, ipAddress, loginAttemptModel.EventLoginSucceeded)
+	handler.writeSessionToken(writer, request, user.ID)
+}
+
+// recordFailedLogin counts a failed login against the account and the address, and mails an unlock link when the account gets locked
+func (handler *Handler) recordFailedLogin(request *http.Request, email string, user *userModel.User, ipAddress string) {
+	userID := uuid.NullUUID{}
+	if user != nil {
+		userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+	handler.recordLoginEvent(email, userID, ipAddress, loginAttemptModel.EventLoginFailed)
+
+	// unknown emails are counted too, so lockouts do not reveal which emails are registered
+	locked, error := handler.loginGuard.RecordFailure(authenticationServices.AccountAttemptKey(email), authenticationServices.AccountLoginPolicy)
 	if error != nil {
-		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create JWT token: %v", error))
+		log.Printf("failed to record failed login for %s: %v", email, error)
+	}
+
+	if _, error := handler.loginGuard.RecordFailure(authenticationServices.IPAttemptKey(ipAddress), authenticationServices.IPLoginPolicy); error != nil {
+		log.Printf("failed to record failed login from %s: %v", ipAddress, error)
+	}
+
+	if locked && user != nil {
+		handler.recordLoginEvent(email, userID, ipAddress, loginAttemptModel.EventAccountLocked)
+		if error := handler.sendUserToken(request, user, userTokenModel.PurposeAccountUnlock); error != nil {
+			log.Printf("failed to send unlock mail to user %s: %v", user.ID, error)
+		}
+	}
+}
+
+// recordLoginEvent adds an event to the login audit, a failure to record it is only logged
+func (handler *Handler) recordLoginEvent(email string, userID uuid.NullUUID, ipAddress string, event string) {
+	error := handler.loginAuditStore.CreateLoginAuditEvent(loginAttemptModel.LoginAuditEvent{
+		UserID:    userID,
+		Email:     email,
+		IPAddress: ipAddress,
+		Event:     event,
+	})
+	if error != nil {
+		log.Printf("failed to record login event %s for %s: %v", event, email, error)
+	}
+}
+
+// recordAccountEvent adds an event about the account of a user to the audit, it shows up in their security log
+func (handler *Handler) recordAccountEvent(request *http.Request, userID uuid.UUID, action string, before any, after any) {
+	handler.recorder.Record(request, auditModel.AuditEvent{
+		EntityType: auditModel.EntityUser,
+		EntityID:   userID,
+		Action:     action,
+	}, before, after)
+}
+
+// recordUserUpdate records the changes made to a user since before was read
+func (handler *Handler) recordUserUpdate(request *http.Request, before *userModel.User) {
+	after, error := handler.store.GetUserByID(before.ID)
+	if error != nil {
+		log.Printf("failed to record update of user %s: %v", before.ID, error)
 		return
 	}
 
-	utils.WriteJSON(writer, http.StatusOK, map[string]string{"token": token})
+	handler.recordAccountEvent(request, before.ID, auditModel.ActionUpdate, before, after)
+}
+
+// completeLogin logs in a user whose password or single sign-on was checked, users with two-factor get a challenge token to exchange with a code instead
+// the failed logins are only cleared once the second factor was checked too
+func (handler *Handler) completeLogin(writer http.ResponseWriter, request *http.Request, user *userModel.User, ipAddress string) {
+	twoFactor, error := handler.twoFactorStore.GetTwoFactorByUserID(user.ID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if twoFactor != nil && twoFactor.Enabled == "True" {
+		challengeToken, error := authenticationServices.CreateTwoFactorChallengeJWT(user.ID)
+		if error != nil {
+			utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create challenge token: %v", error))
+			return
+		}
+
+		utils.WriteJSON(writer, http.StatusOK, map[string]any{"twoFactorRequired": true, "challengeToken": challengeToken})
+		return
+	}
+
+	handler.succeedLogin(writer, request, user, ipAddress)
 }
 
 // Handler function for user registration
@@ -100,11 +266,130 @@ func (handler *Handler) handleRegister(writer http.ResponseWriter, request *http
 		LastName:  payload.LastName,
 		Email:     payload.Email,
 		Password:  hashedPassword,
+		Timezone:  payload.Timezone,
 	})
 	if error != nil {
 		utils.WriteError(writer, http.StatusInternalServerError, error)
 		return
 	}
 
+	// send the verification mail, the account works without it so a failed mail only gets logged
+	user, error := handler.store.GetUserByEmail(payload.Email)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordAccountEvent(request, user.ID, auditModel.ActionCreate, nil, user)
+
+	if error := handler.sendUserToken(request, user, userTokenModel.PurposeEmailVerification); error != nil {
+		log.Printf("failed to send verification mail to user %s: %v", user.ID, error)
+	}
+
 	utils.WriteJSON(writer, http.StatusCreated, nil)
 }
+
+// Handler function for getting the latest login events of the logged in user
+func (handler *Handler) handleGetLoginHistory(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	events, error := handler.loginAuditStore.GetLoginAuditEventsByUserID(userID.UUID, 50)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, events)
+}
+
+// Handler function for getting the security log of the logged in user, their logins merged with the changes and tokens of their account
+func (handler *Handler) handleGetSecurityLog(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	limit := 50
+	if value, error := strconv.Atoi(request.URL.Query().Get("limit")); error == nil {
+		limit = min(max(value, 1), 200)
+	}
+
+	events, error := handler.auditStore.GetSecurityEventsByUserID(userID.UUID, limit)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	loginEvents, error := handler.loginAuditStore.GetLoginAuditEventsByUserID(userID.UUID, limit)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// login events are listed like audit events of the account
+	for _, loginEvent := range loginEvents {
+		events = append(events, &auditModel.AuditEvent{
+			ID:          loginEvent.ID,
+			EntityType:  auditModel.EntityUser,
+			EntityID:    userID.UUID,
+			Action:      loginEvent.Event,
+			IPAddress:   loginEvent.IPAddress,
+			DateCreated: loginEvent.DateCreated,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DateCreated.After(events[j].DateCreated)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, events)
+}
+
+// Handler function for updating the timezone of the logged in user
+func (handler *Handler) handleUpdateTimezone(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload userModel.UpdateTimezonePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	user, error := handler.store.GetUserByID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := handler.store.UpdateTimezoneByID(userID.UUID, payload.Timezone); error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	handler.recordUserUpdate(request, user)
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
@@ -0,0 +1,446 @@
+package workspaceService
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	auditModel "github.com/hwaengfan/dev-journal-backend/internal/models/audit"
+	userModel "github.com/hwaengfan/dev-journal-backend/internal/models/user"
+	workspaceModel "github.com/hwaengfan/dev-journal-backend/internal/models/workspace"
+	auditServices "github.com/hwaengfan/dev-journal-backend/internal/services/audit"
+	authenticationServices "github.com/hwaengfan/dev-journal-backend/internal/services/authentication"
+	"github.com/hwaengfan/dev-journal-backend/internal/utils"
+)
+
+// Workspaces a user is not a member of are reported like missing ones, so their IDs cannot be probed
+var errNotAMember = fmt.Errorf("workspace ID does not exist")
+
+var errNotAnAdmin = fmt.Errorf("requires the ADMIN role in the workspace")
+
+// Returned by authorize when a guest asks for more than the projects they were invited to
+var errGuest = fmt.Errorf("guests can only reach the projects they were invited to")
+
+type Handler struct {
+	store     workspaceModel.WorkspaceStore
+	userStore userModel.UserStore
+	recorder  *auditServices.Recorder
+}
+
+func NewHandler(store workspaceModel.WorkspaceStore, userStore userModel.UserStore, recorder *auditServices.Recorder) *Handler {
+	return &Handler{store: store, userStore: userStore, recorder: recorder}
+}
+
+func (handler *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/workspaces/create-new-workspace", authenticationServices.JWTAuthentication(handler.handleCreateNewWorkspace, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/workspaces/get-workspaces", authenticationServices.JWTAuthentication(handler.handleGetWorkspaces, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/workspaces/update-workspace-by-ID/{workspaceID}", authenticationServices.JWTAuthentication(handler.handleUpdateWorkspaceByID, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/workspaces/switch-workspace/{workspaceID}", authenticationServices.JWTAuthentication(handler.handleSwitchWorkspace, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/workspaces/get-workspace-members/{workspaceID}", authenticationServices.JWTAuthentication(handler.handleGetWorkspaceMembers, handler.userStore)).Methods(http.MethodGet)
+
+	router.HandleFunc("/workspaces/add-workspace-member/{workspaceID}", authenticationServices.JWTAuthentication(handler.handleAddWorkspaceMember, handler.userStore)).Methods(http.MethodPost)
+
+	router.HandleFunc("/workspaces/update-workspace-member-role/{workspaceID}/{userID}", authenticationServices.JWTAuthentication(handler.handleUpdateWorkspaceMemberRole, handler.userStore)).Methods(http.MethodPut)
+
+	router.HandleFunc("/workspaces/remove-workspace-member/{workspaceID}/{userID}", authenticationServices.JWTAuthentication(handler.handleRemoveWorkspaceMember, handler.userStore)).Methods(http.MethodDelete)
+}
+
+// Handler function for creating a new workspace
+func (handler *Handler) handleCreateNewWorkspace(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get JSON payload
+	var payload workspaceModel.CreateWorkspacePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// insert the new workspace into the database with the user as admin
+	workspaceID, error := handler.store.CreateWorkspace(workspaceModel.Workspace{Name: payload.Name}, userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"workspaceID": workspaceID})
+}
+
+// Handler function for getting the workspaces of the user
+func (handler *Handler) handleGetWorkspaces(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get the user's workspaces
+	workspaces, error := handler.store.GetWorkspacesByUserID(userID.UUID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	// mark the workspace the request was made in
+	workspaceID := authenticationServices.GetWorkspaceIDFromContext(request.Context())
+	for _, workspace := range workspaces {
+		workspace.Active = workspace.ID == workspaceID
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, workspaces)
+}
+
+// Handler function for updating the name and settings of a workspace
+func (handler *Handler) handleUpdateWorkspaceByID(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload workspaceModel.UpdateWorkspacePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user is an admin of the workspace
+	if error := handler.authorize(workspaceID, userID.UUID, workspaceModel.RoleAdmin); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// update the workspace by ID
+	error = handler.store.UpdateWorkspaceByID(workspaceModel.Workspace{
+		Name:                       payload.Name,
+		AllowMemberProjectCreation: payload.AllowMemberProjectCreation,
+		AllowExternalInvitations:   payload.AllowExternalInvitations,
+	}, workspaceID)
+	if error != nil {
+		if error.Error() == "no fields to update" {
+			utils.WriteError(writer, http.StatusBadRequest, error)
+		} else {
+			utils.WriteError(writer, http.StatusInternalServerError, error)
+		}
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for switching the active workspace, the returned token replaces the current one
+func (handler *Handler) handleSwitchWorkspace(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user is a member or a guest of the workspace
+	if error := handler.authorize(workspaceID, userID.UUID, workspaceModel.RoleGuest); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// create a token for the same user in the workspace
+	token, error := authenticationServices.CreateJWT(userID.UUID, workspaceID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, fmt.Errorf("failed to create JWT token: %v", error))
+		return
+	}
+
+	handler.recorder.RecordTokenCreated(request, userID.UUID, auditModel.TokenSession)
+
+	utils.WriteJSON(writer, http.StatusOK, map[string]string{"token": token})
+}
+
+// Handler function for getting the members of a workspace
+func (handler *Handler) handleGetWorkspaceMembers(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// check if the user is a member of the workspace
+	if error := handler.authorize(workspaceID, userID.UUID, workspaceModel.RoleMember); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// get the members of the workspace
+	members, error := handler.store.GetWorkspaceMembers(workspaceID)
+	if error != nil {
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, members)
+}
+
+// Handler function for adding a user to a workspace by email
+func (handler *Handler) handleAddWorkspaceMember(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload workspaceModel.AddWorkspaceMemberPayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user is an admin of the workspace
+	if error := handler.authorize(workspaceID, userID.UUID, workspaceModel.RoleAdmin); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// only people with an account can be added, others are invited to projects
+	member, error := handler.userStore.GetUserByEmail(payload.Email)
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, fmt.Errorf("no user with email %s", payload.Email))
+		return
+	}
+
+	// add the member to the workspace
+	if error := handler.store.AddWorkspaceMember(workspaceID, member.ID, payload.Role); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusCreated, map[string]uuid.UUID{"userID": member.ID})
+}
+
+// Handler function for changing the role of a workspace member
+func (handler *Handler) handleUpdateWorkspaceMemberRole(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID and member ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	memberID, error := utils.ParseIDFromURL(request, "userID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// get JSON payload
+	var payload workspaceModel.UpdateWorkspaceMemberRolePayload
+	if error := utils.ParseJSON(request, &payload); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// validate payload
+	if error := utils.Validate.Struct(payload); error != nil {
+		errors := error.(validator.ValidationErrors)
+		utils.WriteInvalidPayload(writer, errors)
+		return
+	}
+
+	// check if the user is an admin of the workspace
+	if error := handler.authorize(workspaceID, userID.UUID, workspaceModel.RoleAdmin); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// check if the member exists
+	if error := handler.validateMember(workspaceID, memberID); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// update the role of the member
+	if error := handler.store.UpdateWorkspaceMemberRole(workspaceID, memberID, payload.Role); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// Handler function for removing a member from a workspace, members can also remove themselves to leave it
+func (handler *Handler) handleRemoveWorkspaceMember(writer http.ResponseWriter, request *http.Request) {
+	// validate if the user is logged in
+	userID := authenticationServices.GetUserIDFromContext(request.Context())
+	if !userID.Valid {
+		utils.WritePermissionDenied(writer)
+		return
+	}
+
+	// get workspace ID and member ID from URL
+	workspaceID, error := utils.ParseIDFromURL(request, "workspaceID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	memberID, error := utils.ParseIDFromURL(request, "userID")
+	if error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// only admins can remove other members
+	minimumRole := workspaceModel.RoleAdmin
+	if memberID == userID.UUID {
+		minimumRole = workspaceModel.RoleMember
+	}
+
+	if error := handler.authorize(workspaceID, userID.UUID, minimumRole); error != nil {
+		writeAuthorizationError(writer, error)
+		return
+	}
+
+	// check if the member exists
+	if error := handler.validateMember(workspaceID, memberID); error != nil {
+		utils.WriteError(writer, http.StatusBadRequest, error)
+		return
+	}
+
+	// remove the member from the workspace and its projects
+	if error := handler.store.RemoveWorkspaceMember(workspaceID, memberID); error != nil {
+		writeMembershipError(writer, error)
+		return
+	}
+
+	utils.WriteJSON(writer, http.StatusOK, nil)
+}
+
+// authorize check if the user is a member of the workspace, and an admin when minimumRole is RoleAdmin, guests only pass RoleGuest
+func (handler *Handler) authorize(workspaceID uuid.UUID, userID uuid.UUID, minimumRole string) error {
+	role, error := handler.store.GetWorkspaceRole(workspaceID, userID)
+	if error != nil {
+		return error
+	}
+
+	if role == "" {
+		return errNotAMember
+	}
+
+	if minimumRole != workspaceModel.RoleGuest && role == workspaceModel.RoleGuest {
+		return errGuest
+	}
+
+	if minimumRole == workspaceModel.RoleAdmin && role != workspaceModel.RoleAdmin {
+		return errNotAnAdmin
+	}
+
+	return nil
+}
+
+// validateMember check if a user is a member of the workspace, guests are not
+func (handler *Handler) validateMember(workspaceID uuid.UUID, memberID uuid.UUID) error {
+	role, error := handler.store.GetWorkspaceRole(workspaceID, memberID)
+	if error != nil {
+		return error
+	}
+
+	if role == "" || role == workspaceModel.RoleGuest {
+		return fmt.Errorf("user is not a member of the workspace")
+	}
+
+	return nil
+}
+
+// writeAuthorizationError writes the response for an error returned by authorize
+func writeAuthorizationError(writer http.ResponseWriter, error error) {
+	switch error {
+	case errNotAMember:
+		utils.WriteError(writer, http.StatusBadRequest, error)
+	case errNotAnAdmin, errGuest:
+		utils.WriteError(writer, http.StatusForbidden, error)
+	default:
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+	}
+}
+
+// writeMembershipError writes the response for an error of the workspace store, conflicts with the current state are 409
+func writeMembershipError(writer http.ResponseWriter, error error) {
+	switch {
+	case errors.Is(error, workspaceModel.ErrLastAdmin), errors.Is(error, workspaceModel.ErrOnlyProjectOwner), errors.Is(error, workspaceModel.ErrAlreadyWorkspaceMember):
+		utils.WriteError(writer, http.StatusConflict, error)
+	default:
+		utils.WriteError(writer, http.StatusInternalServerError, error)
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ParseIDFromURL parses a UUID path parameter of the request
+func ParseIDFromURL(request *http.Request, key string) (uuid.UUID, error) {
+	idString, exists := mux.Vars(request)[key]
+	if !exists {
+		return uuid.Nil, fmt.Errorf("missing %s in parameters", key)
+	}
+
+	id, error := uuid.Parse(idString)
+	if error != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s", key)
+	}
+
+	return id, nil
+}
+
+// trustedProxies are the reverse proxies whose X-Forwarded-For is trusted, none until set
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies parses a comma separated list of IPs or CIDRs of the reverse proxies in front of the server
+func SetTrustedProxies(value string) error {
+	proxies := make([]netip.Prefix, 0)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if !strings.Contains(field, "/") {
+			address, error := netip.ParseAddr(field)
+			if error != nil {
+				return fmt.Errorf("invalid trusted proxy %q", field)
+			}
+			proxies = append(proxies, netip.PrefixFrom(address.Unmap(), address.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, error := netip.ParsePrefix(field)
+		if error != nil {
+			return fmt.Errorf("invalid trusted proxy %q", field)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	trustedProxies = proxies
+	return nil
+}
+
+// GetClientIP returns the IP address the request came from, behind trusted proxies it is the last address in X-Forwarded-For they did not add
+func GetClientIP(request *http.Request) string {
+	host, _, error := net.SplitHostPort(request.RemoteAddr)
+	if error != nil {
+		host = request.RemoteAddr
+	}
+
+	address, error := netip.ParseAddr(host)
+	if error != nil || !isTrustedProxy(address) {
+		return host
+	}
+
+	// walk the forwarded addresses from the closest hop, anything before the first untrusted one may be made up by the client
+	forwarded := strings.Split(strings.Join(request.Header.Values("X-Forwarded-For"), ","), ",")
+	for index := len(forwarded) - 1; index >= 0; index-- {
+		hop, error := netip.ParseAddr(strings.TrimSpace(forwarded[index]))
+		if error != nil {
+			break
+		}
+
+		host = hop.Unmap().String()
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+
+	return host
+}
+
+// isTrustedProxy checks if an address belongs to a trusted proxy
+func isTrustedProxy(address netip.Addr) bool {
+	address = address.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseLocationFromQuery loads the timezone query parameter of the request, defaulting to UTC
+func ParseLocationFromQuery(request *http.Request) (*time.Location, error) {
+	timezone := request.URL.Query().Get("timezone")
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	location, error := time.LoadLocation(timezone)
+	if error != nil {
+		return nil, fmt.Errorf("invalid timezone")
+	}
+
+	return location, nil
+}
+
+// ParseTimeRangeFromQuery parses the from and to query parameters of the request as RFC3339 timestamps or dates in the given location, a date given as to covers the whole day
+func ParseTimeRangeFromQuery(request *http.Request, location *time.Location) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	query := request.URL.Query()
+
+	if value := query.Get("from"); value != "" {
+		parsed, _, error := parseTimeBoundary(value, location)
+		if error != nil {
+			return nil, nil, fmt.Errorf("invalid from")
+		}
+		from = &parsed
+	}
+
+	if value := query.Get("to"); value != "" {
+		parsed, dateOnly, error := parseTimeBoundary(value, location)
+		if error != nil {
+			return nil, nil, fmt.Errorf("invalid to")
+		}
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = &parsed
+	}
+
+	if from != nil && to != nil && !to.After(*from) {
+		return nil, nil, fmt.Errorf("to must be after from")
+	}
+
+	return from, to, nil
+}
+
+// parseTimeBoundary parses an RFC3339 timestamp or a date in the given location
+func parseTimeBoundary(value string, location *time.Location) (time.Time, bool, error) {
+	if parsed, error := time.Parse(time.RFC3339, value); error == nil {
+		return parsed, false, nil
+	}
+
+	parsed, error := time.ParseInLocation(time.DateOnly, value, location)
+	return parsed, true, error
+}